@@ -0,0 +1,28 @@
+// Package apikey has the API keys as they are persisted
+// on the Volumes, so they are not lost when the whole
+// cluster is restarted
+package apikey
+
+import "time"
+
+// Key is an API key persisted on a Volume
+type Key struct {
+	ID          string
+	Secret      string
+	Permissions []string
+	Prefix      string
+
+	// UpdatedAt is the last time it was changed and it's
+	// used to resolve the conflicts between Nodes, the
+	// last one wins
+	UpdatedAt time.Time
+
+	// Deleted means that the Key has been deleted, it's
+	// kept so the deletion is also propagated to the cluster
+	Deleted bool
+}
+
+// IsNewer checks if k has been updated after ok
+func (k Key) IsNewer(ok *Key) bool {
+	return ok == nil || k.UpdatedAt.After(ok.UpdatedAt)
+}
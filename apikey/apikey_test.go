@@ -0,0 +1,19 @@
+package apikey_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/apikey"
+)
+
+func TestIsNewer(t *testing.T) {
+	now := time.Now()
+	k := apikey.Key{ID: "logs", UpdatedAt: now}
+
+	assert.True(t, k.IsNewer(nil))
+	assert.True(t, k.IsNewer(&apikey.Key{UpdatedAt: now.Add(-time.Second)}))
+	assert.False(t, k.IsNewer(&apikey.Key{UpdatedAt: now}))
+	assert.False(t, k.IsNewer(&apikey.Key{UpdatedAt: now.Add(time.Second)}))
+}
@@ -0,0 +1,14 @@
+package apikey
+
+import "context"
+
+//go:generate mockgen -destination=../mock/apikey_repository.go -mock_names=Repository=APIKeyRepository -package=mock github.com/xescugc/rebost/apikey Repository
+
+// Repository is the interface that has to be fulfilled to interact with the Keys
+type Repository interface {
+	CreateOrReplace(ctx context.Context, k *Key) error
+	FindByID(ctx context.Context, id string) (*Key, error)
+
+	// All returns all the Keys, including the deleted ones
+	All(ctx context.Context) ([]*Key, error)
+}
@@ -0,0 +1,105 @@
+// Package auth has the logic to authenticate and authorize the
+// requests to the API. The requests are authenticated with HTTP
+// Basic auth using the ID and the Secret of a Key, the Nodes of
+// the cluster use the ClusterID with the cluster secret
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClusterID is the ID used by the Nodes of the cluster to
+// authenticate between them with the cluster secret, it
+// can not be used as the ID of a Key
+const ClusterID = "cluster"
+
+// ErrForbidden is returned when the Key
+// does not have the required Permission
+var ErrForbidden = errors.New("forbidden")
+
+// Permission is an action that a Key can do
+type Permission string
+
+// List of all the Permissions
+const (
+	Read   Permission = "read"
+	Write  Permission = "write"
+	Delete Permission = "delete"
+	Admin  Permission = "admin"
+)
+
+// Permissions is the list of all the Permissions
+var Permissions = []Permission{Read, Write, Delete, Admin}
+
+// ParsePermission returns the Permission with the name s
+func ParsePermission(s string) (Permission, error) {
+	for _, p := range Permissions {
+		if string(p) == strings.ToLower(s) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid permission %q, the supported ones are %v", s, Permissions)
+}
+
+// Key is an API key used to access the API
+type Key struct {
+	ID     string
+	Secret string
+
+	// Permissions are the actions the Key can do
+	Permissions []Permission
+
+	// Prefix limits the Read, Write and Delete
+	// to the files with keys starting with it
+	Prefix string
+
+	// UpdatedAt is the last time it was changed and it's
+	// used to resolve the conflicts between Nodes, the
+	// last one wins. The Keys of the configuration
+	// do not have it so any change replaces them
+	UpdatedAt time.Time
+
+	// Deleted means that the Key has been deleted, it's
+	// kept so the deletion is also propagated to the cluster
+	Deleted bool
+}
+
+// Validate checks if the Key is valid
+func (k Key) Validate() error {
+	if k.ID == "" {
+		return errors.New("the ID of the key is required")
+	}
+	if k.ID == ClusterID {
+		return fmt.Errorf("the ID %q is reserved for the cluster", ClusterID)
+	}
+	if strings.Contains(k.ID, ":") {
+		return fmt.Errorf("the ID %q can not contain ':'", k.ID)
+	}
+	if k.Secret == "" {
+		return fmt.Errorf("the secret of the key %q is required", k.ID)
+	}
+	for _, p := range k.Permissions {
+		if _, err := ParsePermission(string(p)); err != nil {
+			return fmt.Errorf("invalid key %q: %w", k.ID, err)
+		}
+	}
+	return nil
+}
+
+// Can checks if the Key has the Permission p, for the
+// file permissions the key also has to be on the Prefix
+func (k Key) Can(p Permission, key string) bool {
+	for _, kp := range k.Permissions {
+		if kp != p {
+			continue
+		}
+		if p == Admin {
+			return true
+		}
+		return strings.HasPrefix(key, k.Prefix)
+	}
+	return false
+}
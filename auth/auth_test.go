@@ -0,0 +1,71 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/auth"
+)
+
+func TestParsePermission(t *testing.T) {
+	p, err := auth.ParsePermission("READ")
+	require.NoError(t, err)
+	assert.Equal(t, auth.Read, p)
+
+	_, err = auth.ParsePermission("list")
+	assert.EqualError(t, err, `invalid permission "list", the supported ones are [read write delete admin]`)
+}
+
+func TestKeyValidate(t *testing.T) {
+	tests := []struct {
+		Name string
+		Key  auth.Key
+		Err  string
+	}{
+		{
+			Name: "Success",
+			Key:  auth.Key{ID: "id", Secret: "secret", Permissions: []auth.Permission{auth.Read}},
+		},
+		{
+			Name: "NoID",
+			Key:  auth.Key{Secret: "secret"},
+			Err:  "the ID of the key is required",
+		},
+		{
+			Name: "ClusterID",
+			Key:  auth.Key{ID: auth.ClusterID, Secret: "secret"},
+			Err:  `the ID "cluster" is reserved for the cluster`,
+		},
+		{
+			Name: "NoSecret",
+			Key:  auth.Key{ID: "id"},
+			Err:  `the secret of the key "id" is required`,
+		},
+		{
+			Name: "InvalidPermission",
+			Key:  auth.Key{ID: "id", Secret: "secret", Permissions: []auth.Permission{"list"}},
+			Err:  `invalid key "id": invalid permission "list", the supported ones are [read write delete admin]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.Key.Validate()
+			if tt.Err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.Err)
+			}
+		})
+	}
+}
+
+func TestKeyCan(t *testing.T) {
+	k := auth.Key{ID: "id", Secret: "secret", Permissions: []auth.Permission{auth.Read, auth.Write}, Prefix: "logs/"}
+
+	assert.True(t, k.Can(auth.Read, "logs/a"))
+	assert.True(t, k.Can(auth.Write, "logs/a"))
+	assert.False(t, k.Can(auth.Read, "images/a"))
+	assert.False(t, k.Can(auth.Delete, "logs/a"))
+	assert.False(t, k.Can(auth.Admin, ""))
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/xescugc/rebost/presign"
+	"github.com/xescugc/rebost/storing/model"
+)
+
+type response struct {
+	Data interface{} `json:"data,omitempty"`
+	Err  error       `json:"error,omitempty"`
+}
+
+func (r response) error() error { return r.Err }
+
+func makeKeysEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		keys, err := s.Keys(ctx)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		mks := make([]model.Key, 0, len(keys))
+		for _, k := range keys {
+			mks = append(mks, keyToModel(k))
+		}
+		return response{Data: mks}, nil
+	}
+}
+
+type createKeyRequest struct {
+	Key Key
+}
+
+type createKeyResponse struct {
+	Err error
+}
+
+func (r createKeyResponse) error() error { return r.Err }
+
+func makeCreateKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createKeyRequest)
+		err := s.CreateKey(ctx, req.Key)
+		return createKeyResponse{Err: err}, nil
+	}
+}
+
+type deleteKeyRequest struct {
+	ID string
+}
+
+type deleteKeyResponse struct {
+	Err error
+}
+
+func (r deleteKeyResponse) error() error { return r.Err }
+
+func makeDeleteKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteKeyRequest)
+		err := s.DeleteKey(ctx, req.ID)
+		return deleteKeyResponse{Err: err}, nil
+	}
+}
+
+type presignRequest struct {
+	URL presign.URL
+}
+
+func makePresignEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(presignRequest)
+		u, err := s.Presign(ctx, req.URL)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		return response{Data: model.PresignedURL{URL: u}}, nil
+	}
+}
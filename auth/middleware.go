@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/presign"
+	"github.com/xescugc/rebost/storing/model"
+	"github.com/xescugc/rebost/version"
+)
+
+type contextKey int
+
+const (
+	clusterContextKey contextKey = iota
+	keyContextKey
+)
+
+// IsCluster checks if the request of the ctx was
+// made by a Node of the cluster
+func IsCluster(ctx context.Context) bool {
+	ok, _ := ctx.Value(clusterContextKey).(bool)
+	return ok
+}
+
+// KeyFromContext returns the Key that made
+// the request of the ctx
+func KeyFromContext(ctx context.Context) (Key, bool) {
+	k, ok := ctx.Value(keyContextKey).(Key)
+	return k, ok
+}
+
+// Buckets is the interface used to know the
+// access Policy of the Buckets
+type Buckets interface {
+	GetBucket(ctx context.Context, name string) (*bucket.Bucket, error)
+}
+
+// Middleware returns a http.Handler that only lets the requests
+// authenticated with a Key of the st, or the clusterSecret, with
+// the required Permission get to the next:
+// * /files/{key}: Read for GET and HEAD, Write for PUT, Delete for DELETE and Write and Delete for PATCH (the TTL)
+// * Copies: Read of the source and Write of the destination, and Delete of the source on a MOVE
+// * /buckets/{bucket}/files/{key}: the same as /files/ with the key '@{bucket}/{key}'
+// * /versions/{key} and /buckets/{bucket}/versions/{key}: Read for GET and HEAD and Write for POST
+// * /buckets/* and /quotas: any Key for GET and Admin for the rest
+// * /trash: Admin and /trash/{key}: Write
+// * /batch: the ones of each Operation as if it was a single request
+// * /config, /settings and /admin/*: Admin
+// * /presign: the one of the URL to presign
+// * /replicas/*: only the cluster
+// The cluster can do all of them, and if the Bucket of the bs has
+// the bucket.PublicRead Policy anyone can GET and HEAD its Files
+func Middleware(st *Store, clusterSecret string, bs Buckets, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The presigned URLs are verified by the storing.MakeHandler
+		// and can only be used for the methods that can be presigned
+		if strings.HasPrefix(r.URL.Path, "/files/") && presign.IsPresigned(r) && isPresignable(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bn, bkey, isBucketFile := parseBucketFile(r.URL.Path)
+
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			if isBucketFile && (r.Method == http.MethodGet || r.Method == http.MethodHead) && isPublicRead(r.Context(), bs, bn) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			unauthorized(w)
+			return
+		}
+
+		if id == ClusterID {
+			if subtle.ConstantTimeCompare([]byte(secret), []byte(clusterSecret)) != 1 {
+				unauthorized(w)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clusterContextKey, true)))
+			return
+		}
+
+		k, ok := st.Authenticate(id, secret)
+		if !ok {
+			unauthorized(w)
+			return
+		}
+
+		var allowed bool
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/files/"), strings.HasPrefix(r.URL.Path, "/versions/"), isBucketFile:
+			key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/files/"), "/versions/")
+			if isBucketFile {
+				key = bucket.Key(bn, bkey)
+			}
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				allowed = k.Can(Read, key)
+			case http.MethodPut, http.MethodPost:
+				allowed = k.Can(Write, key)
+			case http.MethodDelete:
+				allowed = k.Can(Delete, key)
+			case http.MethodPatch:
+				// Setting a TTL deletes the
+				// File when it expires
+				allowed = k.Can(Write, key) && k.Can(Delete, key)
+			case "COPY":
+				allowed = k.Can(Read, key)
+			case "MOVE":
+				allowed = k.Can(Read, key) && k.Can(Delete, key)
+			}
+			if allowed && isCopy(r) {
+				allowed = canCopy(k, r)
+			}
+		case r.URL.Path == "/batch":
+			allowed = canBatch(k, r)
+		case r.URL.Path == "/trash":
+			allowed = k.Can(Admin, "")
+		case strings.HasPrefix(r.URL.Path, "/trash/"):
+			allowed = k.Can(Write, strings.TrimPrefix(r.URL.Path, "/trash/"))
+		case r.URL.Path == "/buckets", strings.HasPrefix(r.URL.Path, "/buckets/"), r.URL.Path == "/quotas":
+			allowed = r.Method == http.MethodGet || k.Can(Admin, "")
+		case strings.HasPrefix(r.URL.Path, "/replicas/"):
+			allowed = false
+		case r.URL.Path == "/config", r.URL.Path == "/settings", strings.HasPrefix(r.URL.Path, "/admin/"):
+			allowed = k.Can(Admin, "")
+		case r.URL.Path == "/presign":
+			// The Permission depends on the
+			// URL to sign so it's checked later
+			allowed = true
+		default:
+			// Any other path does not exist
+			allowed = true
+		}
+
+		if !allowed {
+			writeError(w, http.StatusForbidden, ErrForbidden.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyContextKey, k)))
+	})
+}
+
+// parseBucketFile returns the name of the Bucket and the key
+// if the p is the path of a File, or the Versions of it, of a Bucket
+func parseBucketFile(p string) (string, string, bool) {
+	if !strings.HasPrefix(p, "/buckets/") {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(p, "/buckets/"), "/", 3)
+	if len(parts) != 3 || (parts[1] != "files" && parts[1] != "versions") {
+		return "", "", false
+	}
+	return parts[0], parts[2], true
+}
+
+// isPresignable checks if the r can be made with a presigned
+// URL, a GET (or HEAD) or a PUT that is not a copy
+func isPresignable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut:
+		return !isCopy(r)
+	}
+	return false
+}
+
+// isCopy checks if the r is a copy of a File, a COPY
+// or a MOVE or a PUT with the model.CopySourceHeader
+func isCopy(r *http.Request) bool {
+	return r.Method == "COPY" || r.Method == "MOVE" || r.Header.Get(model.CopySourceHeader) != ""
+}
+
+// canCopy checks if the k has the Permission needed on the other
+// key of the copy r, the source for a PUT and the destination
+// for the rest. If it's invalid the r fails later on
+func canCopy(k Key, r *http.Request) bool {
+	if r.Method == http.MethodPut {
+		src, _, err := model.ParseFilePath(r.Header.Get(model.CopySourceHeader))
+		if err != nil {
+			return true
+		}
+		// The Versions have the same
+		// Permissions as the File
+		if vk, _, ok := version.Split(src); ok {
+			src = vk
+		}
+		return k.Can(Read, src)
+	}
+	dst, _, err := model.ParseFilePath(r.Header.Get(model.DestinationHeader))
+	return err != nil || k.Can(Write, dst)
+}
+
+// canBatch checks if the k has the Permissions needed for all the
+// Operations of the batch r, the body is read and set again
+// to the r. If it's invalid the r fails later on
+func canBatch(k Key, r *http.Request) bool {
+	b, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return true
+	}
+
+	mops, err := model.DecodeBatch(bytes.NewReader(b), r.Header.Get("Content-Type"))
+	if err != nil {
+		return true
+	}
+
+	for _, mop := range mops {
+		op, err := model.ToBatchOperation(mop)
+		if err != nil {
+			// It fails later on when converting it
+			continue
+		}
+		var ok bool
+		switch op.Op {
+		case batch.Head:
+			ok = k.Can(Read, op.Key)
+		case batch.Delete:
+			ok = k.Can(Delete, op.Key)
+		case batch.Copy:
+			ok = k.Can(Read, op.Key) && k.Can(Write, op.Destination)
+		case batch.Move:
+			ok = k.Can(Read, op.Key) && k.Can(Delete, op.Key) && k.Can(Write, op.Destination)
+		case batch.Update:
+			ok = k.Can(Write, op.Key) && k.Can(Delete, op.Key)
+		default:
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPublicRead checks if the Bucket with the name of
+// the bs has the bucket.PublicRead Policy
+func isPublicRead(ctx context.Context, bs Buckets, name string) bool {
+	if bs == nil {
+		return false
+	}
+	b, err := bs.GetBucket(ctx, name)
+	if err != nil {
+		return false
+	}
+	return b.Policy == bucket.PublicRead
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="rebost"`)
+	writeError(w, http.StatusUnauthorized, "unauthorized")
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": msg,
+	})
+}
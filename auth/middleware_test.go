@@ -0,0 +1,146 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/auth"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/mock"
+)
+
+func TestMiddleware(t *testing.T) {
+	st, err := auth.NewStore(context.Background(), []auth.Key{
+		{ID: "logs", Secret: "logs", Permissions: []auth.Permission{auth.Read, auth.Write}, Prefix: "logs/"},
+		{ID: "admin", Secret: "admin", Permissions: []auth.Permission{auth.Admin}},
+		{ID: "private", Secret: "private", Permissions: []auth.Permission{auth.Read, auth.Write}, Prefix: "@private/"},
+		{ID: "move", Secret: "move", Permissions: []auth.Permission{auth.Read, auth.Write, auth.Delete}, Prefix: "logs/"},
+	}, nil)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	bs := mock.NewStoring(ctrl)
+	bs.EXPECT().GetBucket(gomock.Any(), "public").Return(&bucket.Bucket{Name: "public", Policy: bucket.PublicRead}, nil).AnyTimes()
+	bs.EXPECT().GetBucket(gomock.Any(), "private").Return(&bucket.Bucket{Name: "private", Policy: bucket.Private}, nil).AnyTimes()
+	bs.EXPECT().GetBucket(gomock.Any(), "potato").Return(nil, errors.New("not found")).AnyTimes()
+
+	var (
+		cluster bool
+		body    []byte
+	)
+	h := auth.Middleware(st, "cluster-secret", bs, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cluster = auth.IsCluster(r.Context())
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		Name    string
+		Method  string
+		Path    string
+		ID      string
+		Secret  string
+		Headers map[string]string
+		Body    string
+		Code    int
+		Cluster bool
+	}{
+		{Name: "NoCredentials", Method: http.MethodGet, Path: "/files/logs/a", Code: http.StatusUnauthorized},
+		{Name: "InvalidSecret", Method: http.MethodGet, Path: "/files/logs/a", ID: "logs", Secret: "admin", Code: http.StatusUnauthorized},
+		{Name: "UnknownKey", Method: http.MethodGet, Path: "/files/logs/a", ID: "potato", Secret: "logs", Code: http.StatusUnauthorized},
+		{Name: "Read", Method: http.MethodGet, Path: "/files/logs/a", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "Head", Method: http.MethodHead, Path: "/files/logs/a", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "Write", Method: http.MethodPut, Path: "/files/logs/a", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "DeleteForbidden", Method: http.MethodDelete, Path: "/files/logs/a", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "PrefixForbidden", Method: http.MethodGet, Path: "/files/images/a", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "ConfigForbidden", Method: http.MethodGet, Path: "/config", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "Config", Method: http.MethodGet, Path: "/config", ID: "admin", Secret: "admin", Code: http.StatusOK},
+		{Name: "SettingsForbidden", Method: http.MethodPut, Path: "/settings", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "Settings", Method: http.MethodPut, Path: "/settings", ID: "admin", Secret: "admin", Code: http.StatusOK},
+		{Name: "Admin", Method: http.MethodGet, Path: "/admin/keys", ID: "admin", Secret: "admin", Code: http.StatusOK},
+		{Name: "AdminFilesForbidden", Method: http.MethodGet, Path: "/files/logs/a", ID: "admin", Secret: "admin", Code: http.StatusForbidden},
+		{Name: "ReplicasForbidden", Method: http.MethodPut, Path: "/replicas/logs/a", ID: "admin", Secret: "admin", Code: http.StatusForbidden},
+		{Name: "ClusterInvalidSecret", Method: http.MethodPut, Path: "/replicas/logs/a", ID: auth.ClusterID, Secret: "admin", Code: http.StatusUnauthorized},
+		{Name: "ClusterReplicas", Method: http.MethodPut, Path: "/replicas/logs/a", ID: auth.ClusterID, Secret: "cluster-secret", Code: http.StatusOK, Cluster: true},
+		{Name: "Presign", Method: http.MethodPost, Path: "/presign", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "PresignedURL", Method: http.MethodGet, Path: "/files/logs/a?X-Rebost-Signature=sig", Code: http.StatusOK},
+		{Name: "PresignedURLPatch", Method: http.MethodPatch, Path: "/files/logs/a?ttl=1s&X-Rebost-Signature=sig", Code: http.StatusUnauthorized},
+		{Name: "PresignedURLDelete", Method: http.MethodDelete, Path: "/files/logs/a?X-Rebost-Signature=sig", Code: http.StatusUnauthorized},
+		{Name: "PresignedURLOnReplicas", Method: http.MethodPut, Path: "/replicas/logs/a?X-Rebost-Signature=sig", Code: http.StatusUnauthorized},
+		{Name: "BucketFile", Method: http.MethodGet, Path: "/buckets/private/files/a", ID: "private", Secret: "private", Code: http.StatusOK},
+		{Name: "BucketFileWrite", Method: http.MethodPut, Path: "/buckets/private/files/a", ID: "private", Secret: "private", Code: http.StatusOK},
+		{Name: "BucketFileForbidden", Method: http.MethodGet, Path: "/buckets/public/files/a", ID: "private", Secret: "private", Code: http.StatusForbidden},
+		{Name: "BucketFileNoCredentials", Method: http.MethodGet, Path: "/buckets/private/files/a", Code: http.StatusUnauthorized},
+		{Name: "BucketFileUnknownNoCredentials", Method: http.MethodGet, Path: "/buckets/potato/files/a", Code: http.StatusUnauthorized},
+		{Name: "PublicBucketFile", Method: http.MethodGet, Path: "/buckets/public/files/a", Code: http.StatusOK},
+		{Name: "PublicBucketFileHead", Method: http.MethodHead, Path: "/buckets/public/files/a", Code: http.StatusOK},
+		{Name: "PublicBucketFileWrite", Method: http.MethodPut, Path: "/buckets/public/files/a", Code: http.StatusUnauthorized},
+		{Name: "Versions", Method: http.MethodGet, Path: "/versions/logs/a", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "RestoreVersion", Method: http.MethodPost, Path: "/versions/logs/a", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "VersionsForbidden", Method: http.MethodGet, Path: "/versions/images/a", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "BucketVersions", Method: http.MethodGet, Path: "/buckets/private/versions/a", ID: "private", Secret: "private", Code: http.StatusOK},
+		{Name: "BucketVersionsForbidden", Method: http.MethodPost, Path: "/buckets/public/versions/a", ID: "private", Secret: "private", Code: http.StatusForbidden},
+		{Name: "Trash", Method: http.MethodGet, Path: "/trash", ID: "admin", Secret: "admin", Code: http.StatusOK},
+		{Name: "TrashForbidden", Method: http.MethodGet, Path: "/trash", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "RestoreTrash", Method: http.MethodPost, Path: "/trash/logs/a", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "RestoreTrashForbidden", Method: http.MethodPost, Path: "/trash/images/a", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "Buckets", Method: http.MethodGet, Path: "/buckets", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "CreateBucketForbidden", Method: http.MethodPut, Path: "/buckets/logs", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "Quotas", Method: http.MethodGet, Path: "/quotas", ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "QuotasNoCredentials", Method: http.MethodGet, Path: "/quotas", Code: http.StatusUnauthorized},
+		{Name: "CreateBucket", Method: http.MethodPut, Path: "/buckets/logs", ID: "admin", Secret: "admin", Code: http.StatusOK},
+		{Name: "CopySource", Method: http.MethodPut, Path: "/files/logs/b", Headers: map[string]string{"X-Rebost-Copy-Source": "/files/logs/a"}, ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "CopySourceForbidden", Method: http.MethodPut, Path: "/files/logs/b", Headers: map[string]string{"X-Rebost-Copy-Source": "/files/images/a"}, ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "CopySourceVersion", Method: http.MethodPut, Path: "/files/logs/b", Headers: map[string]string{"X-Rebost-Copy-Source": "/files/logs/a?version=1"}, ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "CopySourcePresignedURL", Method: http.MethodPut, Path: "/files/logs/b?X-Rebost-Signature=sig", Headers: map[string]string{"X-Rebost-Copy-Source": "/files/logs/a"}, Code: http.StatusUnauthorized},
+		{Name: "Copy", Method: "COPY", Path: "/files/logs/a", Headers: map[string]string{"Destination": "/files/logs/b"}, ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "CopyForbidden", Method: "COPY", Path: "/files/logs/a", Headers: map[string]string{"Destination": "/buckets/private/files/b"}, ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "BucketCopy", Method: "COPY", Path: "/buckets/private/files/a", Headers: map[string]string{"Destination": "http://example.com/buckets/private/files/b"}, ID: "private", Secret: "private", Code: http.StatusOK},
+		{Name: "Move", Method: "MOVE", Path: "/files/logs/a", Headers: map[string]string{"Destination": "/files/logs/b"}, ID: "move", Secret: "move", Code: http.StatusOK},
+		{Name: "MoveForbidden", Method: "MOVE", Path: "/files/logs/a", Headers: map[string]string{"Destination": "/files/logs/b"}, ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "TTL", Method: http.MethodPatch, Path: "/files/logs/a?ttl=24h", ID: "move", Secret: "move", Code: http.StatusOK},
+		{Name: "TTLForbidden", Method: http.MethodPatch, Path: "/files/logs/a?ttl=24h", ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "Batch", Method: http.MethodPost, Path: "/batch", Body: `{"operations":[{"op":"head","key":"logs/a"},{"op":"copy","key":"logs/a","destination":"logs/b"}]}`, ID: "logs", Secret: "logs", Code: http.StatusOK},
+		{Name: "BatchForbidden", Method: http.MethodPost, Path: "/batch", Body: `{"operations":[{"op":"head","key":"logs/a"},{"op":"delete","key":"logs/a"}]}`, ID: "logs", Secret: "logs", Code: http.StatusForbidden},
+		{Name: "BatchBucket", Method: http.MethodPost, Path: "/batch", Body: `{"operations":[{"op":"move","key":"a","bucket":"private","destination":"b"}]}`, ID: "private", Secret: "private", Code: http.StatusForbidden},
+		{Name: "BatchNDJSON", Method: http.MethodPost, Path: "/batch", Headers: map[string]string{"Content-Type": "application/x-ndjson"}, Body: "{\"op\":\"delete\",\"key\":\"logs/a\"}\n{\"op\":\"move\",\"key\":\"logs/a\",\"destination\":\"logs/b\"}\n", ID: "move", Secret: "move", Code: http.StatusOK},
+		{Name: "BatchNDJSONForbidden", Method: http.MethodPost, Path: "/batch", Headers: map[string]string{"Content-Type": "application/x-ndjson"}, Body: "{\"op\":\"delete\",\"key\":\"images/a\"}\n", ID: "move", Secret: "move", Code: http.StatusForbidden},
+		{Name: "ClusterFiles", Method: http.MethodDelete, Path: "/files/images/a", ID: auth.ClusterID, Secret: "cluster-secret", Code: http.StatusOK, Cluster: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			cluster = false
+
+			r := httptest.NewRequest(tt.Method, tt.Path, strings.NewReader(tt.Body))
+			if tt.ID != "" {
+				r.SetBasicAuth(tt.ID, tt.Secret)
+			}
+			for k, v := range tt.Headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.Code, w.Code)
+			assert.Equal(t, tt.Cluster, cluster)
+			if tt.Code == http.StatusOK {
+				// The body is still there for the next
+				assert.Equal(t, tt.Body, string(body))
+			}
+			if tt.Code == http.StatusUnauthorized {
+				assert.Equal(t, `Basic realm="rebost"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"github.com/xescugc/rebost/apikey"
+	"github.com/xescugc/rebost/storing/model"
+)
+
+// keyToModel converts a Key to a model.Key
+func keyToModel(k Key) model.Key {
+	mk := model.Key{
+		ID:          k.ID,
+		Secret:      k.Secret,
+		Permissions: make([]string, 0, len(k.Permissions)),
+		Prefix:      k.Prefix,
+		UpdatedAt:   k.UpdatedAt,
+		Deleted:     k.Deleted,
+	}
+	for _, p := range k.Permissions {
+		mk.Permissions = append(mk.Permissions, string(p))
+	}
+	return mk
+}
+
+// ModelToKey converts a model.Key to a Key
+func ModelToKey(mk model.Key) Key {
+	k := Key{
+		ID:          mk.ID,
+		Secret:      mk.Secret,
+		Permissions: make([]Permission, 0, len(mk.Permissions)),
+		Prefix:      mk.Prefix,
+		UpdatedAt:   mk.UpdatedAt,
+		Deleted:     mk.Deleted,
+	}
+	for _, p := range mk.Permissions {
+		k.Permissions = append(k.Permissions, Permission(p))
+	}
+	return k
+}
+
+// keyToAPIKey converts a Key to the apikey.Key persisted on the Volumes
+func keyToAPIKey(k Key) apikey.Key {
+	ak := apikey.Key{
+		ID:          k.ID,
+		Secret:      k.Secret,
+		Permissions: make([]string, 0, len(k.Permissions)),
+		Prefix:      k.Prefix,
+		UpdatedAt:   k.UpdatedAt,
+		Deleted:     k.Deleted,
+	}
+	for _, p := range k.Permissions {
+		ak.Permissions = append(ak.Permissions, string(p))
+	}
+	return ak
+}
+
+// apiKeyToKey converts an apikey.Key persisted on the Volumes to a Key
+func apiKeyToKey(ak apikey.Key) Key {
+	k := Key{
+		ID:          ak.ID,
+		Secret:      ak.Secret,
+		Permissions: make([]Permission, 0, len(ak.Permissions)),
+		Prefix:      ak.Prefix,
+		UpdatedAt:   ak.UpdatedAt,
+		Deleted:     ak.Deleted,
+	}
+	for _, p := range ak.Permissions {
+		k.Permissions = append(k.Permissions, Permission(p))
+	}
+	return k
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/presign"
+)
+
+// DefaultPresignExpiration is the expiration of the
+// presigned URLs if none is requested
+const DefaultPresignExpiration = 15 * time.Minute
+
+//go:generate mockgen -destination=../mock/auth.go -mock_names=Service=Auth -package=mock github.com/xescugc/rebost/auth Service
+
+// Service is the interface used to manage the Keys
+// of the cluster
+type Service interface {
+	// Keys returns all the Keys, the secrets and
+	// the deleted Keys are only returned to the cluster
+	Keys(ctx context.Context) ([]Key, error)
+
+	// CreateKey creates or replaces the Key k, if it's not
+	// made by the cluster it's updated at the current time
+	CreateKey(ctx context.Context, k Key) error
+
+	// DeleteKey deletes the Key with the id
+	DeleteKey(ctx context.Context, id string) error
+
+	// Presign returns the path of the u signed with the cluster
+	// secret if the Key of the ctx has the Permission to do it
+	Presign(ctx context.Context, u presign.URL) (string, error)
+}
+
+// Membership is the interface used to
+// propagate the changes to the cluster
+type Membership interface {
+	// Nodes return all the Nodes of the cluster except the current one
+	Nodes() []*client.Client
+}
+
+type service struct {
+	store         *Store
+	clusterSecret string
+	members       Membership
+
+	logger kitlog.Logger
+}
+
+// New returns an implementation of the Service that manages
+// the Keys of the st. The changes not made by the cluster
+// are propagated to all the Nodes of the m.
+// The clusterSecret is used to sign the presigned URLs
+func New(st *Store, clusterSecret string, m Membership, logger kitlog.Logger) Service {
+	return &service{
+		store:         st,
+		clusterSecret: clusterSecret,
+		members:       m,
+		logger:        kitlog.With(logger, "src", "auth"),
+	}
+}
+
+func (s *service) Keys(ctx context.Context) ([]Key, error) {
+	if IsCluster(ctx) {
+		return s.store.All(), nil
+	}
+	keys := s.store.Keys()
+	for i := range keys {
+		keys[i].Secret = ""
+	}
+	return keys, nil
+}
+
+func (s *service) CreateKey(ctx context.Context, k Key) error {
+	if !IsCluster(ctx) {
+		k.UpdatedAt = time.Now().UTC()
+		k.Deleted = false
+	}
+
+	err := s.store.Put(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	if !IsCluster(ctx) {
+		for _, n := range s.members.Nodes() {
+			err = n.CreateKey(ctx, keyToModel(k))
+			if err != nil {
+				s.logger.Log("msg", err.Error(), "key", k.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) DeleteKey(ctx context.Context, id string) error {
+	err := s.store.Delete(ctx, id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if !IsCluster(ctx) {
+		for _, n := range s.members.Nodes() {
+			err = n.DeleteKey(ctx, id)
+			if err != nil {
+				s.logger.Log("msg", err.Error(), "key", id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) Presign(ctx context.Context, u presign.URL) (string, error) {
+	err := u.Validate(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	if !IsCluster(ctx) {
+		p := Read
+		if u.Method == http.MethodPut {
+			p = Write
+		}
+
+		k, ok := KeyFromContext(ctx)
+		if !ok || !k.Can(p, u.Key) {
+			return "", ErrForbidden
+		}
+	}
+
+	return presign.Sign([]byte(s.clusterSecret), u), nil
+}
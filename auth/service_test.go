@@ -0,0 +1,192 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/auth"
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/presign"
+)
+
+func TestKeys(t *testing.T) {
+	t.Run("SuccessWithoutSecrets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		st, err := auth.NewStore(context.Background(), []auth.Key{{ID: "id", Secret: "secret"}}, nil)
+		require.NoError(t, err)
+
+		s := auth.New(st, "secret", m, kitlog.NewNopLogger())
+
+		keys, err := s.Keys(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []auth.Key{{ID: "id"}}, keys)
+
+		// The Store is not changed
+		assert.Equal(t, []auth.Key{{ID: "id", Secret: "secret"}}, st.Keys())
+	})
+}
+
+func TestCreateKey(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			m    = mock.NewMembership(ctrl)
+			ras  = mock.NewAuth(ctrl)
+			k    = auth.Key{ID: "id", Secret: "secret", Permissions: []auth.Permission{auth.Read}}
+		)
+		defer ctrl.Finish()
+
+		server := httptest.NewServer(auth.MakeHandler(ras))
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		// The Key is propagated with the time it was
+		// updated so the Nodes can resolve the conflicts
+		var rk auth.Key
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		ras.EXPECT().CreateKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k auth.Key) error {
+			rk = k
+			return nil
+		})
+
+		st, err := auth.NewStore(context.Background(), nil, nil)
+		require.NoError(t, err)
+
+		s := auth.New(st, "secret", m, kitlog.NewNopLogger())
+
+		now := time.Now()
+		err = s.CreateKey(context.Background(), k)
+		require.NoError(t, err)
+
+		keys := st.Keys()
+		require.Len(t, keys, 1)
+		assert.False(t, keys[0].UpdatedAt.Before(now))
+		assert.True(t, keys[0].UpdatedAt.Equal(rk.UpdatedAt))
+
+		k.UpdatedAt = keys[0].UpdatedAt
+		assert.Equal(t, k, keys[0])
+	})
+	t.Run("FailsWithInvalidKey", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		st, err := auth.NewStore(context.Background(), nil, nil)
+		require.NoError(t, err)
+
+		s := auth.New(st, "secret", m, kitlog.NewNopLogger())
+
+		err = s.CreateKey(context.Background(), auth.Key{ID: "id"})
+		assert.EqualError(t, err, `the secret of the key "id" is required`)
+	})
+}
+
+func TestDeleteKey(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			m    = mock.NewMembership(ctrl)
+			ras  = mock.NewAuth(ctrl)
+		)
+		defer ctrl.Finish()
+
+		server := httptest.NewServer(auth.MakeHandler(ras))
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		ras.EXPECT().DeleteKey(gomock.Any(), "id").Return(nil)
+
+		st, err := auth.NewStore(context.Background(), []auth.Key{{ID: "id", Secret: "secret"}}, nil)
+		require.NoError(t, err)
+
+		s := auth.New(st, "secret", m, kitlog.NewNopLogger())
+
+		err = s.DeleteKey(context.Background(), "id")
+		require.NoError(t, err)
+		assert.Empty(t, st.Keys())
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		st, err := auth.NewStore(context.Background(), nil, nil)
+		require.NoError(t, err)
+
+		s := auth.New(st, "secret", m, kitlog.NewNopLogger())
+
+		err = s.DeleteKey(context.Background(), "id")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestPresign(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		m    = mock.NewMembership(ctrl)
+		k    = auth.Key{ID: "logs", Secret: "logs", Permissions: []auth.Permission{auth.Read}, Prefix: "logs/"}
+	)
+	defer ctrl.Finish()
+
+	st, err := auth.NewStore(context.Background(), []auth.Key{k}, nil)
+	require.NoError(t, err)
+
+	s := auth.New(st, "secret", m, kitlog.NewNopLogger())
+
+	// The Key is set on the context by the
+	// Middleware so we use it to call the Service
+	var (
+		p    string
+		perr error
+		u    presign.URL
+	)
+	h := auth.Middleware(st, "secret", nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, perr = s.Presign(r.Context(), u)
+	}))
+
+	presignAs := func(id, secret string, pu presign.URL) (string, error) {
+		u = pu
+		r := httptest.NewRequest(http.MethodPost, "/presign", nil)
+		r.SetBasicAuth(id, secret)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		return p, perr
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		pu := presign.URL{Method: http.MethodGet, Key: "logs/a", Expires: time.Now().Add(time.Minute)}
+		p, err := presignAs("logs", "logs", pu)
+		require.NoError(t, err)
+
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+		err = presign.Verify([]byte("secret"), r, "logs/a", time.Now())
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithCluster", func(t *testing.T) {
+		_, err := presignAs(auth.ClusterID, "secret", presign.URL{Method: http.MethodPut, Key: "images/a", Expires: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+	})
+	t.Run("FailsWithoutPermission", func(t *testing.T) {
+		_, err := presignAs("logs", "logs", presign.URL{Method: http.MethodPut, Key: "logs/a", Expires: time.Now().Add(time.Minute)})
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+	t.Run("FailsWithOtherPrefix", func(t *testing.T) {
+		_, err := presignAs("logs", "logs", presign.URL{Method: http.MethodGet, Key: "images/a", Expires: time.Now().Add(time.Minute)})
+		assert.Equal(t, auth.ErrForbidden, err)
+	})
+	t.Run("FailsWithInvalidURL", func(t *testing.T) {
+		_, err := presignAs("logs", "logs", presign.URL{Method: http.MethodDelete, Key: "logs/a", Expires: time.Now().Add(time.Minute)})
+		assert.EqualError(t, err, `invalid method "DELETE", only GET and PUT can be presigned`)
+	})
+}
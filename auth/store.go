@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xescugc/rebost/apikey"
+)
+
+// Volume is where the Keys are persisted so
+// they are not lost when the Node is restarted
+type Volume interface {
+	// APIKeys returns all the Keys, including the deleted ones
+	APIKeys(ctx context.Context) ([]*apikey.Key, error)
+
+	// PutAPIKey stores the k only if it's newer than
+	// the one already stored with the same ID
+	PutAPIKey(ctx context.Context, k *apikey.Key) error
+}
+
+// Store holds all the Keys that can access the API
+type Store struct {
+	mxKeys sync.RWMutex
+	keys   map[string]Key
+
+	volumes []Volume
+}
+
+// NewStore returns a new Store with the keys, which are not
+// persisted, and the ones persisted on the vs. The Keys changed
+// after are persisted on all the vs and, as with the ones of the
+// cluster, the last updated one wins
+func NewStore(ctx context.Context, keys []Key, vs []Volume) (*Store, error) {
+	s := &Store{
+		keys:    make(map[string]Key, len(keys)),
+		volumes: vs,
+	}
+	for _, k := range keys {
+		err := k.Validate()
+		if err != nil {
+			return nil, err
+		}
+		s.keys[k.ID] = k
+	}
+
+	for _, v := range vs {
+		aks, err := v.APIKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ak := range aks {
+			k := apiKeyToKey(*ak)
+			if ok, exists := s.keys[k.ID]; exists && !k.UpdatedAt.After(ok.UpdatedAt) {
+				continue
+			}
+			s.keys[k.ID] = k
+		}
+	}
+
+	// The vs may be out of sync between them
+	// so all of them get the newest Keys
+	for _, k := range s.keys {
+		err := s.persist(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Authenticate returns the Key with the id
+// if the secret matches
+func (s *Store) Authenticate(id, secret string) (Key, bool) {
+	s.mxKeys.RLock()
+	defer s.mxKeys.RUnlock()
+
+	k, ok := s.keys[id]
+	if !ok || k.Deleted || subtle.ConstantTimeCompare([]byte(k.Secret), []byte(secret)) != 1 {
+		return Key{}, false
+	}
+
+	return k, true
+}
+
+// Keys returns all the Keys sorted by ID
+func (s *Store) Keys() []Key {
+	return s.keysSorted(false)
+}
+
+// All returns all the Keys sorted by ID including
+// the deleted ones, so the deletions can
+// be propagated to the cluster
+func (s *Store) All() []Key {
+	return s.keysSorted(true)
+}
+
+func (s *Store) keysSorted(deleted bool) []Key {
+	s.mxKeys.RLock()
+	defer s.mxKeys.RUnlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		if k.Deleted && !deleted {
+			continue
+		}
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+	return keys
+}
+
+// Put creates or replaces the Key if it's not
+// older than the one already on the Store
+func (s *Store) Put(ctx context.Context, k Key) error {
+	if k.Deleted {
+		if k.ID == "" {
+			return errors.New("the ID of the key is required")
+		}
+	} else {
+		err := k.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mxKeys.Lock()
+	defer s.mxKeys.Unlock()
+
+	if ok, exists := s.keys[k.ID]; exists && ok.UpdatedAt.After(k.UpdatedAt) {
+		return nil
+	}
+
+	err := s.persist(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	s.keys[k.ID] = k
+
+	return nil
+}
+
+// Delete removes the Key with the id as deleted at the da
+func (s *Store) Delete(ctx context.Context, id string, da time.Time) error {
+	s.mxKeys.Lock()
+	defer s.mxKeys.Unlock()
+
+	if k, ok := s.keys[id]; !ok || k.Deleted {
+		return errors.New("not found")
+	}
+
+	k := Key{ID: id, UpdatedAt: da, Deleted: true}
+
+	err := s.persist(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	s.keys[id] = k
+
+	return nil
+}
+
+// persist stores the k on all the volumes, the Keys
+// of the configuration (without UpdatedAt) are not
+func (s *Store) persist(ctx context.Context, k Key) error {
+	if k.UpdatedAt.IsZero() {
+		return nil
+	}
+	ak := keyToAPIKey(k)
+	for _, v := range s.volumes {
+		err := v.PutAPIKey(ctx, &ak)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
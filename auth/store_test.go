@@ -0,0 +1,115 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/apikey"
+	"github.com/xescugc/rebost/auth"
+	"github.com/xescugc/rebost/mock"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			a   = auth.Key{ID: "a", Secret: "sa", Permissions: []auth.Permission{auth.Read}}
+			b   = auth.Key{ID: "b", Secret: "sb", Permissions: []auth.Permission{auth.Admin}}
+		)
+
+		st, err := auth.NewStore(ctx, []auth.Key{b}, nil)
+		require.NoError(t, err)
+
+		err = st.Put(ctx, a)
+		require.NoError(t, err)
+		assert.Equal(t, []auth.Key{a, b}, st.Keys())
+
+		k, ok := st.Authenticate("a", "sa")
+		assert.True(t, ok)
+		assert.Equal(t, a, k)
+
+		_, ok = st.Authenticate("a", "sb")
+		assert.False(t, ok)
+
+		err = st.Delete(ctx, "a", time.Now())
+		require.NoError(t, err)
+
+		_, ok = st.Authenticate("a", "sa")
+		assert.False(t, ok)
+		assert.Equal(t, []auth.Key{b}, st.Keys())
+
+		err = st.Delete(ctx, "a", time.Now())
+		assert.EqualError(t, err, "not found")
+	})
+	t.Run("SuccessWithVolumes", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			v1   = mock.NewVolumeLocal(ctrl)
+			v2   = mock.NewVolumeLocal(ctrl)
+			ctx  = context.Background()
+			now  = time.Now().UTC()
+
+			// a is on the configuration but it was replaced on v1
+			a  = auth.Key{ID: "a", Secret: "sa", Permissions: []auth.Permission{auth.Read}}
+			pa = apikey.Key{ID: "a", Secret: "pa", Permissions: []string{"write"}, UpdatedAt: now.Add(-time.Minute)}
+
+			// b was created and then deleted when v1 was down
+			pb  = apikey.Key{ID: "b", Secret: "sb", Permissions: []string{"read"}, UpdatedAt: now.Add(-time.Hour)}
+			pdb = apikey.Key{ID: "b", UpdatedAt: now.Add(-time.Second), Deleted: true}
+		)
+		defer ctrl.Finish()
+
+		v1.EXPECT().APIKeys(ctx).Return([]*apikey.Key{&pa, &pb}, nil)
+		v2.EXPECT().APIKeys(ctx).Return([]*apikey.Key{&pdb}, nil)
+
+		// All the Volumes get the newest Keys
+		for _, v := range []*mock.VolumeLocal{v1, v2} {
+			v.EXPECT().PutAPIKey(ctx, &pa).Return(nil)
+			v.EXPECT().PutAPIKey(ctx, &apikey.Key{ID: "b", Permissions: []string{}, UpdatedAt: pdb.UpdatedAt, Deleted: true}).Return(nil)
+		}
+
+		st, err := auth.NewStore(ctx, []auth.Key{a}, []auth.Volume{v1, v2})
+		require.NoError(t, err)
+
+		k, ok := st.Authenticate("a", "pa")
+		assert.True(t, ok)
+		assert.Equal(t, []auth.Permission{auth.Write}, k.Permissions)
+
+		_, ok = st.Authenticate("b", "sb")
+		assert.False(t, ok)
+
+		// An older change is ignored
+		err = st.Put(ctx, auth.Key{ID: "a", Secret: "old", UpdatedAt: now.Add(-time.Hour)})
+		require.NoError(t, err)
+
+		_, ok = st.Authenticate("a", "old")
+		assert.False(t, ok)
+
+		// The newer ones are persisted
+		c := auth.Key{ID: "c", Secret: "sc", Permissions: []auth.Permission{auth.Read}, UpdatedAt: now}
+		for _, v := range []*mock.VolumeLocal{v1, v2} {
+			v.EXPECT().PutAPIKey(ctx, &apikey.Key{ID: "c", Secret: "sc", Permissions: []string{"read"}, UpdatedAt: now}).Return(nil)
+			v.EXPECT().PutAPIKey(ctx, &apikey.Key{ID: "c", Permissions: []string{}, UpdatedAt: now.Add(time.Second), Deleted: true}).Return(nil)
+		}
+
+		err = st.Put(ctx, c)
+		require.NoError(t, err)
+
+		err = st.Delete(ctx, "c", now.Add(time.Second))
+		require.NoError(t, err)
+
+		assert.Equal(t, []auth.Key{
+			{ID: "a", Secret: "pa", Permissions: []auth.Permission{auth.Write}, UpdatedAt: pa.UpdatedAt},
+			{ID: "b", Permissions: []auth.Permission{}, UpdatedAt: pdb.UpdatedAt, Deleted: true},
+			{ID: "c", UpdatedAt: now.Add(time.Second), Deleted: true},
+		}, st.All())
+	})
+	t.Run("FailsWithInvalidKey", func(t *testing.T) {
+		_, err := auth.NewStore(context.Background(), []auth.Key{{ID: "a"}}, nil)
+		assert.EqualError(t, err, `the secret of the key "a" is required`)
+	})
+}
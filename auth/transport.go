@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/xescugc/rebost/presign"
+	"github.com/xescugc/rebost/storing/model"
+)
+
+// MakeHandler returns a http.Handler with the admin API
+// to manage the Keys and the endpoint to presign URLs
+// with the auth.Service
+func MakeHandler(s Service) http.Handler {
+	keysHandler := kithttp.NewServer(
+		makeKeysEndpoint(s),
+		decodeKeysRequest,
+		encodeJSONResponse,
+	)
+
+	createKeyHandler := kithttp.NewServer(
+		makeCreateKeyEndpoint(s),
+		decodeCreateKeyRequest,
+		encodeNoContentResponse,
+	)
+
+	deleteKeyHandler := kithttp.NewServer(
+		makeDeleteKeyEndpoint(s),
+		decodeDeleteKeyRequest,
+		encodeNoContentResponse,
+	)
+
+	presignHandler := kithttp.NewServer(
+		makePresignEndpoint(s),
+		decodePresignRequest,
+		encodeJSONResponse,
+	)
+
+	r := mux.NewRouter()
+
+	r.Handle("/admin/keys", keysHandler).Methods("GET")
+	r.Handle("/admin/keys/{id}", createKeyHandler).Methods("PUT")
+	r.Handle("/admin/keys/{id}", deleteKeyHandler).Methods("DELETE")
+
+	r.Handle("/presign", presignHandler).Methods("POST")
+
+	return r
+}
+
+func decodeKeysRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeCreateKeyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var mk model.Key
+	err := json.NewDecoder(r.Body).Decode(&mk)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ID of the URL is the one used
+	mk.ID = mux.Vars(r)["id"]
+
+	return createKeyRequest{Key: ModelToKey(mk)}, nil
+}
+
+func decodePresignRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var mp model.Presign
+	err := json.NewDecoder(r.Body).Decode(&mp)
+	if err != nil {
+		return nil, err
+	}
+
+	ei := time.Duration(mp.ExpiresIn) * time.Second
+	if ei == 0 {
+		ei = DefaultPresignExpiration
+	}
+
+	return presignRequest{
+		URL: presign.URL{
+			Method:      strings.ToUpper(mp.Method),
+			Key:         mp.Key,
+			Expires:     time.Now().Add(ei),
+			ContentType: mp.ContentType,
+			MaxSize:     mp.MaxSize,
+		},
+	}, nil
+}
+
+func decodeDeleteKeyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return deleteKeyRequest{ID: mux.Vars(r)["id"]}, nil
+}
+
+func encodeNoContentResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func encodeJSONResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	b, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, string(b))
+
+	return err
+}
+
+type errorer interface {
+	error() error
+}
+
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	switch err.Error() {
+	case "not found":
+		writeError(w, http.StatusNotFound, err.Error())
+	case ErrForbidden.Error():
+		writeError(w, http.StatusForbidden, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Op is the type of an Operation
+type Op string
+
+const (
+	// Head checks if the key exists
+	Head Op = "head"
+
+	// Delete deletes the key
+	Delete Op = "delete"
+
+	// Copy copies the key to the Destination
+	Copy Op = "copy"
+
+	// Move moves the key to the Destination
+	Move Op = "move"
+
+	// Update updates the TTL of the key
+	Update Op = "update"
+)
+
+// ErrInvalid is the error returned when
+// an Operation is not valid
+var ErrInvalid = errors.New("invalid operation")
+
+// Operation is an action to do
+// on the File of the Key
+type Operation struct {
+	Op  Op
+	Key string
+
+	// Destination is the key of
+	// the Copy and the Move
+	Destination string
+
+	// TTL is the new TTL of the Update,
+	// 0 removes the one it has
+	TTL *time.Duration
+}
+
+// Validate checks that the Operation is valid
+func (o Operation) Validate() error {
+	if o.Key == "" {
+		return fmt.Errorf("%w: the key is required", ErrInvalid)
+	}
+
+	switch o.Op {
+	case Head, Delete:
+	case Copy, Move:
+		if o.Destination == "" {
+			return fmt.Errorf("%w: the destination is required to %s", ErrInvalid, o.Op)
+		}
+	case Update:
+		if o.TTL == nil {
+			return fmt.Errorf("%w: the ttl is required to %s", ErrInvalid, o.Op)
+		}
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalid, o.Op)
+	}
+
+	return nil
+}
+
+// Result is the result of an Operation,
+// if it failed it has the Err
+type Result struct {
+	Operation
+
+	// VolumeID is the ID of the volume
+	// that has the Key on a Head
+	VolumeID string
+
+	Err error
+}
@@ -0,0 +1,41 @@
+package batch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/batch"
+)
+
+func TestValidate(t *testing.T) {
+	ttl := time.Hour
+
+	tests := []struct {
+		Name string
+		Op   batch.Operation
+		Err  string
+	}{
+		{Name: "Head", Op: batch.Operation{Op: batch.Head, Key: "a"}},
+		{Name: "Delete", Op: batch.Operation{Op: batch.Delete, Key: "a"}},
+		{Name: "Copy", Op: batch.Operation{Op: batch.Copy, Key: "a", Destination: "b"}},
+		{Name: "Move", Op: batch.Operation{Op: batch.Move, Key: "a", Destination: "b"}},
+		{Name: "Update", Op: batch.Operation{Op: batch.Update, Key: "a", TTL: &ttl}},
+		{Name: "NoKey", Op: batch.Operation{Op: batch.Head}, Err: "invalid operation: the key is required"},
+		{Name: "NoDestination", Op: batch.Operation{Op: batch.Move, Key: "a"}, Err: "invalid operation: the destination is required to move"},
+		{Name: "NoTTL", Op: batch.Operation{Op: batch.Update, Key: "a"}, Err: "invalid operation: the ttl is required to update"},
+		{Name: "UnknownOp", Op: batch.Operation{Op: "potato", Key: "a"}, Err: `invalid operation: unknown op "potato"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.Op.Validate()
+			if tt.Err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.Err)
+				assert.ErrorIs(t, err, batch.ErrInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package bloom implements a Bloom filter, used to know if
+// a set may have a key without having to ask for it. It can
+// have false positives but never false negatives
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// Filter is a Bloom filter of M bits and K hashes
+type Filter struct {
+	M    uint64 `json:"m"`
+	K    uint64 `json:"k"`
+	Bits []byte `json:"bits"`
+}
+
+// New returns a Filter sized to have n keys
+// with a false positive probability of p
+func New(n int, p float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &Filter{
+		M:    m,
+		K:    k,
+		Bits: make([]byte, (m+7)/8),
+	}
+}
+
+// Add adds the key k to the Filter
+func (f *Filter) Add(k string) {
+	h1, h2 := hashes(k)
+	for i := uint64(0); i < f.K; i++ {
+		b := (h1 + i*h2) % f.M
+		f.Bits[b/8] |= 1 << (b % 8)
+	}
+}
+
+// Test checks if the key k may be on the Filter,
+// if it returns false it's sure it's not
+func (f *Filter) Test(k string) bool {
+	// An invalid Filter can not
+	// know so it may have it
+	if f.M == 0 || uint64(len(f.Bits)) < (f.M+7)/8 {
+		return true
+	}
+	h1, h2 := hashes(k)
+	for i := uint64(0); i < f.K; i++ {
+		b := (h1 + i*h2) % f.M
+		if f.Bits[b/8]&(1<<(b%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes returns the 2 hashes of the k used to calculate
+// all the K positions of it with the double hashing
+func hashes(k string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(k))
+	h1 := h.Sum64()
+
+	// The second one is derived from the first one and
+	// has to be odd so all the positions are different
+	h2 := h1
+	h2 ^= h2 >> 33
+	h2 *= 0xff51afd7ed558ccd
+	h2 ^= h2 >> 33
+
+	return h1, h2 | 1
+}
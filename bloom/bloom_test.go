@@ -0,0 +1,47 @@
+package bloom_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/bloom"
+)
+
+func TestFilter(t *testing.T) {
+	f := bloom.New(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(fmt.Sprintf("key-%d", i))
+	}
+
+	t.Run("NoFalseNegatives", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			assert.True(t, f.Test(fmt.Sprintf("key-%d", i)))
+		}
+	})
+	t.Run("FalsePositives", func(t *testing.T) {
+		var fp int
+		for i := 0; i < 10000; i++ {
+			if f.Test(fmt.Sprintf("other-%d", i)) {
+				fp++
+			}
+		}
+		assert.Less(t, fp, 300)
+	})
+	t.Run("JSON", func(t *testing.T) {
+		b, err := json.Marshal(f)
+		require.NoError(t, err)
+
+		var nf bloom.Filter
+		err = json.Unmarshal(b, &nf)
+		require.NoError(t, err)
+		assert.Equal(t, f, &nf)
+		assert.True(t, nf.Test("key-1"))
+	})
+	t.Run("Empty", func(t *testing.T) {
+		assert.False(t, bloom.New(0, 0.01).Test("key"))
+		assert.True(t, (&bloom.Filter{}).Test("key"))
+	})
+}
@@ -0,0 +1,66 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/xescugc/rebost/apikey"
+	bolt "go.etcd.io/bbolt"
+)
+
+type apiKeyRepository struct {
+	client     *bolt.DB
+	bucketName []byte
+	bucket     *bolt.Bucket
+}
+
+// NewAPIKeyRepository returns an implementation of the interface apikey.Repository
+func NewAPIKeyRepository(c *bolt.DB) (apikey.Repository, error) {
+	bn := []byte("api_keys")
+	if err := createBucket(c, bn); err != nil {
+		return nil, err
+	}
+	return &apiKeyRepository{
+		client:     c,
+		bucketName: bn,
+	}, nil
+}
+
+func (r *apiKeyRepository) CreateOrReplace(ctx context.Context, k *apikey.Key) error {
+	b, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return r.bucket.Put([]byte(k.ID), b)
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id string) (*apikey.Key, error) {
+	var k apikey.Key
+	b := r.bucket.Get([]byte(id))
+	if b == nil {
+		return nil, errors.New("not found")
+	}
+	err := json.Unmarshal(b, &k)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepository) All(ctx context.Context) ([]*apikey.Key, error) {
+	keys := make([]*apikey.Key, 0)
+	err := r.bucket.ForEach(func(_, v []byte) error {
+		var k apikey.Key
+		err := json.Unmarshal(v, &k)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, &k)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,66 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/xescugc/rebost/bucket"
+	bolt "go.etcd.io/bbolt"
+)
+
+type bucketRepository struct {
+	client     *bolt.DB
+	bucketName []byte
+	bucket     *bolt.Bucket
+}
+
+// NewBucketRepository returns an implementation of the interface bucket.Repository
+func NewBucketRepository(c *bolt.DB) (bucket.Repository, error) {
+	bn := []byte("buckets")
+	if err := createBucket(c, bn); err != nil {
+		return nil, err
+	}
+	return &bucketRepository{
+		client:     c,
+		bucketName: bn,
+	}, nil
+}
+
+func (r *bucketRepository) CreateOrReplace(ctx context.Context, bk *bucket.Bucket) error {
+	b, err := json.Marshal(bk)
+	if err != nil {
+		return err
+	}
+	return r.bucket.Put([]byte(bk.Name), b)
+}
+
+func (r *bucketRepository) FindByName(ctx context.Context, name string) (*bucket.Bucket, error) {
+	var bk bucket.Bucket
+	b := r.bucket.Get([]byte(name))
+	if b == nil {
+		return nil, errors.New("not found")
+	}
+	err := json.Unmarshal(b, &bk)
+	if err != nil {
+		return nil, err
+	}
+	return &bk, nil
+}
+
+func (r *bucketRepository) All(ctx context.Context) ([]*bucket.Bucket, error) {
+	bks := make([]*bucket.Bucket, 0)
+	err := r.bucket.ForEach(func(_, v []byte) error {
+		var bk bucket.Bucket
+		err := json.Unmarshal(v, &bk)
+		if err != nil {
+			return err
+		}
+		bks = append(bks, &bk)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bks, nil
+}
@@ -60,3 +60,43 @@ func (r *fileRepository) DeleteAll(ctx context.Context) error {
 	r.bucket = bk
 	return nil
 }
+
+func (r *fileRepository) All(ctx context.Context) ([]*file.File, error) {
+	files := make([]*file.File, 0)
+	err := r.bucket.ForEach(func(_, v []byte) error {
+		var f file.File
+		err := json.Unmarshal(v, &f)
+		if err != nil {
+			return err
+		}
+		files = append(files, &f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (r *fileRepository) After(ctx context.Context, sig string, n int) ([]*file.File, error) {
+	var (
+		files = make([]*file.File, 0, n)
+		c     = r.bucket.Cursor()
+		k, v  = c.First()
+	)
+	if sig != "" {
+		k, v = c.Seek([]byte(sig))
+		if k != nil && string(k) == sig {
+			k, v = c.Next()
+		}
+	}
+	for ; k != nil && len(files) < n; k, v = c.Next() {
+		var f file.File
+		err := json.Unmarshal(v, &f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &f)
+	}
+	return files, nil
+}
@@ -1,8 +1,11 @@
 package boltdb
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/xescugc/rebost/idxkey"
 	bolt "go.etcd.io/bbolt"
@@ -26,8 +29,24 @@ func NewIDXKeyRepository(c *bolt.DB) (idxkey.Repository, error) {
 	}, nil
 }
 
+// dbIDXKey is the stored value of the IDXKeys with their own
+// expiration or creation time, the rest (the ones stored
+// before them) only have the Signature of the File
+type dbIDXKey struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func (r *idxkeyRepository) CreateOrReplace(ctx context.Context, ik *idxkey.IDXKey) error {
-	return r.bucket.Put([]byte(ik.Key), []byte(ik.Value))
+	if ik.ExpiresAt.IsZero() && ik.CreatedAt.IsZero() {
+		return r.bucket.Put([]byte(ik.Key), []byte(ik.Value))
+	}
+	b, err := json.Marshal(dbIDXKey{Value: ik.Value, ExpiresAt: ik.ExpiresAt, CreatedAt: ik.CreatedAt})
+	if err != nil {
+		return err
+	}
+	return r.bucket.Put([]byte(ik.Key), b)
 }
 
 func (r *idxkeyRepository) FindByKey(ctx context.Context, k string) (*idxkey.IDXKey, error) {
@@ -35,7 +54,7 @@ func (r *idxkeyRepository) FindByKey(ctx context.Context, k string) (*idxkey.IDX
 	if v == nil {
 		return nil, errors.New("not found")
 	}
-	return idxkey.New(k, string(v)), nil
+	return newIDXKeyFromDB([]byte(k), v), nil
 }
 
 func (r *idxkeyRepository) DeleteByKey(ctx context.Context, k string) error {
@@ -50,3 +69,28 @@ func (r *idxkeyRepository) DeleteAll(ctx context.Context) error {
 	r.bucket = bk
 	return nil
 }
+
+func (r *idxkeyRepository) All(ctx context.Context) ([]*idxkey.IDXKey, error) {
+	iks := make([]*idxkey.IDXKey, 0)
+	err := r.bucket.ForEach(func(k, v []byte) error {
+		iks = append(iks, newIDXKeyFromDB(k, v))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return iks, nil
+}
+
+func newIDXKeyFromDB(k, v []byte) *idxkey.IDXKey {
+	// The Signatures never start with '{'
+	if !bytes.HasPrefix(v, []byte("{")) {
+		return idxkey.New(string(k), string(v))
+	}
+	var dik dbIDXKey
+	_ = json.Unmarshal(v, &dik)
+	ik := idxkey.New(string(k), dik.Value)
+	ik.ExpiresAt = dik.ExpiresAt
+	ik.CreatedAt = dik.CreatedAt
+	return ik
+}
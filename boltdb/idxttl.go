@@ -7,7 +7,6 @@ import (
 	"errors"
 	"time"
 
-	"github.com/xescugc/duration"
 	"github.com/xescugc/rebost/idxttl"
 	bolt "go.etcd.io/bbolt"
 )
@@ -24,30 +23,40 @@ func NewIDXTTLRepository(c *bolt.DB) (idxttl.Repository, error) {
 	if err := createBucket(c, bn); err != nil {
 		return nil, err
 	}
+	if err := migrateIDXTTLKeys(c, bn); err != nil {
+		return nil, err
+	}
 	return &idxttlRepository{
 		client:     c,
 		bucketName: bn,
 	}, nil
 }
 
+// dbIDXTTL is the stored value of the IDXTTL, the
+// old ones only have the list of Signatures
+type dbIDXTTL struct {
+	Signatures []string `json:"signatures"`
+	Keys       []string `json:"keys"`
+}
+
 func (r *idxttlRepository) CreateOrReplace(ctx context.Context, ittl *idxttl.IDXTTL) error {
-	b, err := json.Marshal(ittl.Signatures)
+	b, err := json.Marshal(dbIDXTTL{Signatures: ittl.Signatures, Keys: ittl.Keys})
 	if err != nil {
 		return err
 	}
 	return r.bucket.Put(formatTime(ittl.ExpiresAt), b)
 }
 
-// Filter will return all the IDXTTL older than ea
-func (r *idxttlRepository) Filter(ctx context.Context, ea time.Time) ([]*idxttl.IDXTTL, error) {
-	min := formatTime(time.Now().Add(-duration.Day))
+func (r *idxttlRepository) Filter(ctx context.Context, ea time.Time, limit int) ([]*idxttl.IDXTTL, error) {
 	max := formatTime(ea)
 
 	c := r.bucket.Cursor()
 	idxttls := make([]*idxttl.IDXTTL, 0, 0)
-	for k, v := c.Seek(min); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() {
-		ittl := newIDXTTLFromDB(k, v)
-		idxttls = append(idxttls, ittl)
+	for k, v := c.First(); k != nil && bytes.Compare(k, max) <= 0; k, v = c.Next() {
+		idxttls = append(idxttls, newIDXTTLFromDB(k, v))
+		if limit != 0 && len(idxttls) == limit {
+			break
+		}
 	}
 	return idxttls, nil
 }
@@ -66,19 +75,77 @@ func (r *idxttlRepository) Delete(ctx context.Context, ea time.Time) error {
 	return r.bucket.Delete(formatTime(ea))
 }
 
+// migrateIDXTTLKeys moves the IDXTTLs stored before the keys were
+// on UTC, with the local time zone, to their key on UTC so all
+// of them are sorted by the time they expire
+func migrateIDXTTLKeys(c *bolt.DB, bn []byte) error {
+	return c.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(bn)
+
+		olds := make(map[string][]byte)
+		err := bk.ForEach(func(k, v []byte) error {
+			t, err := time.Parse(time.RFC3339, string(k))
+			if err == nil && !bytes.Equal(k, formatTime(t)) {
+				olds[string(k)] = append([]byte(nil), v...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for k, v := range olds {
+			ittl := newIDXTTLFromDB([]byte(k), v)
+			// The UTC one may already exist
+			// so both of them are merged
+			if nv := bk.Get(formatTime(ittl.ExpiresAt)); nv != nil {
+				nittl := newIDXTTLFromDB(formatTime(ittl.ExpiresAt), nv)
+				ittl.AddSignatures(nittl.Signatures...)
+				ittl.AddKeys(nittl.Keys...)
+			}
+
+			b, err := json.Marshal(dbIDXTTL{Signatures: ittl.Signatures, Keys: ittl.Keys})
+			if err != nil {
+				return err
+			}
+
+			err = bk.Put(formatTime(ittl.ExpiresAt), b)
+			if err != nil {
+				return err
+			}
+
+			err = bk.Delete([]byte(k))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 func newIDXTTLFromDB(k, v []byte) *idxttl.IDXTTL {
 	ittl := &idxttl.IDXTTL{
 		ExpiresAt: parseTime(k),
 	}
-	_ = json.Unmarshal(v, &ittl.Signatures)
+	if bytes.HasPrefix(v, []byte("[")) {
+		_ = json.Unmarshal(v, &ittl.Signatures)
+		return ittl
+	}
+	var dittl dbIDXTTL
+	_ = json.Unmarshal(v, &dittl)
+	ittl.Signatures = dittl.Signatures
+	ittl.Keys = dittl.Keys
 	return ittl
 }
 
+// formatTime formats the t on UTC so the
+// keys are sorted by the time they expire
 func formatTime(t time.Time) []byte {
-	return []byte(t.Format(time.RFC3339))
+	return []byte(t.UTC().Format(time.RFC3339))
 }
 
 func parseTime(b []byte) time.Time {
-	t, _ := time.Parse(string(b), time.RFC3339)
+	t, _ := time.Parse(time.RFC3339, string(b))
 	return t
 }
@@ -62,3 +62,20 @@ func (r *idxvolumeRepository) DeleteAll(ctx context.Context) error {
 	r.bucket = bk
 	return nil
 }
+
+func (r *idxvolumeRepository) All(ctx context.Context) ([]*idxvolume.IDXVolume, error) {
+	idxvs := make([]*idxvolume.IDXVolume, 0)
+	err := r.bucket.ForEach(func(k, v []byte) error {
+		var sigs []string
+		err := json.Unmarshal(v, &sigs)
+		if err != nil {
+			return err
+		}
+		idxvs = append(idxvs, idxvolume.New(string(k), sigs))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idxvs, nil
+}
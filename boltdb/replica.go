@@ -61,3 +61,20 @@ func (r *replicaRepository) DeleteAll(ctx context.Context) error {
 	r.bucket = bk
 	return nil
 }
+
+func (r *replicaRepository) All(ctx context.Context) ([]*replica.Replica, error) {
+	rps := make([]*replica.Replica, 0)
+	err := r.bucket.ForEach(func(_, v []byte) error {
+		var rp replica.Replica
+		err := json.Unmarshal(v, &rp)
+		if err != nil {
+			return err
+		}
+		rps = append(rps, &rp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rps, nil
+}
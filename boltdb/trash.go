@@ -0,0 +1,79 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/xescugc/rebost/trash"
+	bolt "go.etcd.io/bbolt"
+)
+
+type trashRepository struct {
+	client     *bolt.DB
+	bucketName []byte
+	bucket     *bolt.Bucket
+}
+
+// NewTrashRepository returns an implementation of the interface trash.Repository
+func NewTrashRepository(c *bolt.DB) (trash.Repository, error) {
+	bn := []byte("trash")
+	if err := createBucket(c, bn); err != nil {
+		return nil, err
+	}
+	return &trashRepository{
+		client:     c,
+		bucketName: bn,
+	}, nil
+}
+
+func (r *trashRepository) CreateOrReplace(ctx context.Context, it *trash.Item) error {
+	b, err := json.Marshal(it)
+	if err != nil {
+		return err
+	}
+	return r.bucket.Put([]byte(trash.Key(it.Key, it.ID)), b)
+}
+
+func (r *trashRepository) Find(ctx context.Context, key, id string) (*trash.Item, error) {
+	var it trash.Item
+	b := r.bucket.Get([]byte(trash.Key(key, id)))
+	if b == nil {
+		return nil, errors.New("not found")
+	}
+	err := json.Unmarshal(b, &it)
+	if err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
+
+func (r *trashRepository) Delete(ctx context.Context, key, id string) error {
+	return r.bucket.Delete([]byte(trash.Key(key, id)))
+}
+
+func (r *trashRepository) All(ctx context.Context) ([]*trash.Item, error) {
+	its := make([]*trash.Item, 0)
+	err := r.bucket.ForEach(func(_, v []byte) error {
+		var it trash.Item
+		err := json.Unmarshal(v, &it)
+		if err != nil {
+			return err
+		}
+		its = append(its, &it)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return its, nil
+}
+
+func (r *trashRepository) DeleteAll(ctx context.Context) error {
+	bk, err := recreateBucket(r.bucket, r.bucketName)
+	if err != nil {
+		return err
+	}
+	r.bucket = bk
+	return nil
+}
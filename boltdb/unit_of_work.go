@@ -5,13 +5,17 @@ import (
 	"fmt"
 
 	"github.com/spf13/afero"
+	"github.com/xescugc/rebost/apikey"
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/file"
 	"github.com/xescugc/rebost/idxkey"
 	"github.com/xescugc/rebost/idxttl"
 	"github.com/xescugc/rebost/idxvolume"
 	"github.com/xescugc/rebost/replica"
 	"github.com/xescugc/rebost/state"
+	"github.com/xescugc/rebost/trash"
 	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -26,6 +30,10 @@ type unitOfWork struct {
 	fs                  afero.Fs
 	replicaRepository   replica.Repository
 	stateRepository     state.Repository
+	bucketRepository    bucket.Repository
+	versionRepository   version.Repository
+	trashRepository     trash.Repository
+	apiKeyRepository    apikey.Repository
 }
 
 type key int
@@ -111,6 +119,22 @@ func (uw *unitOfWork) State() state.Repository {
 	return uw.stateRepository
 }
 
+func (uw *unitOfWork) Buckets() bucket.Repository {
+	return uw.bucketRepository
+}
+
+func (uw *unitOfWork) Versions() version.Repository {
+	return uw.versionRepository
+}
+
+func (uw *unitOfWork) Trash() trash.Repository {
+	return uw.trashRepository
+}
+
+func (uw *unitOfWork) APIKeys() apikey.Repository {
+	return uw.apiKeyRepository
+}
+
 func newUnitOfWork(t uow.Type) *unitOfWork {
 	return &unitOfWork{
 		t: t,
@@ -221,6 +245,50 @@ func (uw *unitOfWork) add(r interface{}) error {
 			uw.stateRepository = &r
 		}
 		return nil
+	case *bucketRepository:
+		if uw.bucketRepository == nil {
+			r := *rep
+			b := uw.tx.Bucket(r.bucketName)
+			if b == nil {
+				return fmt.Errorf("bucker for %q not found", r.bucketName)
+			}
+			r.bucket = b
+			uw.bucketRepository = &r
+		}
+		return nil
+	case *versionRepository:
+		if uw.versionRepository == nil {
+			r := *rep
+			b := uw.tx.Bucket(r.bucketName)
+			if b == nil {
+				return fmt.Errorf("bucker for %q not found", r.bucketName)
+			}
+			r.bucket = b
+			uw.versionRepository = &r
+		}
+		return nil
+	case *trashRepository:
+		if uw.trashRepository == nil {
+			r := *rep
+			b := uw.tx.Bucket(r.bucketName)
+			if b == nil {
+				return fmt.Errorf("bucker for %q not found", r.bucketName)
+			}
+			r.bucket = b
+			uw.trashRepository = &r
+		}
+		return nil
+	case *apiKeyRepository:
+		if uw.apiKeyRepository == nil {
+			r := *rep
+			b := uw.tx.Bucket(r.bucketName)
+			if b == nil {
+				return fmt.Errorf("bucker for %q not found", r.bucketName)
+			}
+			r.bucket = b
+			uw.apiKeyRepository = &r
+		}
+		return nil
 	default:
 		if v, ok := r.(afero.Fs); ok {
 			uw.fs = v
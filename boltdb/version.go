@@ -0,0 +1,79 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/xescugc/rebost/version"
+	bolt "go.etcd.io/bbolt"
+)
+
+type versionRepository struct {
+	client     *bolt.DB
+	bucketName []byte
+	bucket     *bolt.Bucket
+}
+
+// NewVersionRepository returns an implementation of the interface version.Repository
+func NewVersionRepository(c *bolt.DB) (version.Repository, error) {
+	bn := []byte("versions")
+	if err := createBucket(c, bn); err != nil {
+		return nil, err
+	}
+	return &versionRepository{
+		client:     c,
+		bucketName: bn,
+	}, nil
+}
+
+func (r *versionRepository) CreateOrReplace(ctx context.Context, h *version.History) error {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return r.bucket.Put([]byte(h.Key), b)
+}
+
+func (r *versionRepository) FindByKey(ctx context.Context, k string) (*version.History, error) {
+	var h version.History
+	b := r.bucket.Get([]byte(k))
+	if b == nil {
+		return nil, errors.New("not found")
+	}
+	err := json.Unmarshal(b, &h)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *versionRepository) DeleteByKey(ctx context.Context, k string) error {
+	return r.bucket.Delete([]byte(k))
+}
+
+func (r *versionRepository) All(ctx context.Context) ([]*version.History, error) {
+	hs := make([]*version.History, 0)
+	err := r.bucket.ForEach(func(_, v []byte) error {
+		var h version.History
+		err := json.Unmarshal(v, &h)
+		if err != nil {
+			return err
+		}
+		hs = append(hs, &h)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hs, nil
+}
+
+func (r *versionRepository) DeleteAll(ctx context.Context) error {
+	bk, err := recreateBucket(r.bucket, r.bucketName)
+	if err != nil {
+		return err
+	}
+	r.bucket = bk
+	return nil
+}
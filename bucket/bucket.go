@@ -0,0 +1,151 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Policy is the access Policy of the Files of a Bucket
+type Policy string
+
+const (
+	// Private requires a Key with the Permission
+	// to access the Files of the Bucket
+	Private Policy = "private"
+
+	// PublicRead lets anyone read the Files of the Bucket
+	// but writing them still requires a Key
+	PublicRead Policy = "public-read"
+)
+
+// prefix is the prefix of all the keys that
+// belong to a Bucket, so they do not collide
+// with the keys of other Buckets
+const prefix = "@"
+
+var (
+	// ErrInvalid is the error returned when a Bucket
+	// has invalid settings
+	ErrInvalid = errors.New("invalid bucket")
+
+	nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,62}$`)
+)
+
+// Bucket is a namespace of keys with its own settings, which
+// are used as default for the Files of it
+type Bucket struct {
+	Name string
+
+	// Replica is the default number of replicas of the
+	// Files, if 0 the one of the Node is used
+	Replica int
+
+	// Class is the default storage class of the Files
+	Class string
+
+	// TTL is the default TTL of the Files
+	TTL time.Duration
+
+	// Quota is the limit of the Bucket
+	Quota Quota
+
+	// Policy is the access Policy of the Files
+	Policy Policy
+
+	// Versioning is the configuration of the
+	// Versions of the Files
+	Versioning Versioning
+
+	// UpdatedAt is the last time it was changed and it's
+	// used to resolve the conflicts between Nodes, the
+	// last one wins
+	UpdatedAt time.Time
+
+	// Deleted means that the Bucket has been deleted, it's
+	// kept so the deletion is also propagated to the cluster
+	Deleted bool
+}
+
+// Quota is the limit of Size (in bytes) and Count
+// of Files, 0 means no limit
+type Quota struct {
+	Size  int64
+	Count int
+}
+
+// Versioning is the configuration of the Versions of the Files,
+// if Enabled each write of a File creates a new Version. The
+// noncurrent Versions over MaxVersions or older than MaxAge are
+// pruned, 0 means no limit
+type Versioning struct {
+	Enabled     bool
+	MaxVersions int
+	MaxAge      time.Duration
+}
+
+// Validate checks that the Bucket is valid
+func (b Bucket) Validate() error {
+	if !nameRe.MatchString(b.Name) {
+		return fmt.Errorf("%w: the name %q must have from 2 to 63 lowercase letters, numbers, dots or dashes", ErrInvalid, b.Name)
+	}
+	if b.Replica < 0 {
+		return fmt.Errorf("%w: the replica can not be negative", ErrInvalid)
+	}
+	if b.TTL < 0 {
+		return fmt.Errorf("%w: the ttl can not be negative", ErrInvalid)
+	}
+	if b.Quota.Size < 0 || b.Quota.Count < 0 {
+		return fmt.Errorf("%w: the quota can not be negative", ErrInvalid)
+	}
+	if b.Versioning.MaxVersions < 0 || b.Versioning.MaxAge < 0 {
+		return fmt.Errorf("%w: the versioning limits can not be negative", ErrInvalid)
+	}
+	switch b.Policy {
+	case Private, PublicRead:
+	default:
+		return fmt.Errorf("%w: unknown policy %q", ErrInvalid, b.Policy)
+	}
+	return nil
+}
+
+// IsNewer checks if b has been updated after ob
+func (b Bucket) IsNewer(ob *Bucket) bool {
+	return ob == nil || b.UpdatedAt.After(ob.UpdatedAt)
+}
+
+// Key returns the key used to store the key k
+// of the Bucket with the name
+func Key(name, k string) string {
+	return prefix + name + "/" + k
+}
+
+// IsKey checks if the k is the key of a File of a Bucket
+func IsKey(k string) bool {
+	return strings.HasPrefix(k, prefix)
+}
+
+// Name returns the name of the Bucket the
+// key k belongs to, if it belongs to one
+func Name(k string) (string, bool) {
+	p, ok := Prefix(k)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/"), true
+}
+
+// Prefix returns the prefix of the keys of the Bucket
+// the key k belongs to, if it belongs to one
+func Prefix(k string) (string, bool) {
+	if !strings.HasPrefix(k, prefix) {
+		return "", false
+	}
+	i := strings.Index(k, "/")
+	if i == -1 {
+		return "", false
+	}
+	return k[:i+1], true
+}
@@ -0,0 +1,73 @@
+package bucket_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/bucket"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		b := bucket.Bucket{Name: "team-a.logs", Replica: 2, TTL: time.Hour, Policy: bucket.PublicRead}
+		assert.NoError(t, b.Validate())
+	})
+	t.Run("Fails", func(t *testing.T) {
+		tests := []struct {
+			Name   string
+			Bucket bucket.Bucket
+		}{
+			{Name: "EmptyName", Bucket: bucket.Bucket{Policy: bucket.Private}},
+			{Name: "UpperCaseName", Bucket: bucket.Bucket{Name: "Logs", Policy: bucket.Private}},
+			{Name: "SlashName", Bucket: bucket.Bucket{Name: "lo/gs", Policy: bucket.Private}},
+			{Name: "NegativeReplica", Bucket: bucket.Bucket{Name: "logs", Replica: -1, Policy: bucket.Private}},
+			{Name: "NegativeTTL", Bucket: bucket.Bucket{Name: "logs", TTL: -time.Hour, Policy: bucket.Private}},
+			{Name: "NegativeQuota", Bucket: bucket.Bucket{Name: "logs", Quota: bucket.Quota{Size: -1}, Policy: bucket.Private}},
+			{Name: "NegativeMaxVersions", Bucket: bucket.Bucket{Name: "logs", Versioning: bucket.Versioning{Enabled: true, MaxVersions: -1}, Policy: bucket.Private}},
+			{Name: "UnknownPolicy", Bucket: bucket.Bucket{Name: "logs", Policy: "public"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.Name, func(t *testing.T) {
+				err := tt.Bucket.Validate()
+				assert.True(t, errors.Is(err, bucket.ErrInvalid))
+			})
+		}
+	})
+}
+
+func TestIsNewer(t *testing.T) {
+	now := time.Now()
+	b := bucket.Bucket{Name: "logs", UpdatedAt: now}
+
+	assert.True(t, b.IsNewer(nil))
+	assert.True(t, b.IsNewer(&bucket.Bucket{UpdatedAt: now.Add(-time.Second)}))
+	assert.False(t, b.IsNewer(&bucket.Bucket{UpdatedAt: now}))
+	assert.False(t, b.IsNewer(&bucket.Bucket{UpdatedAt: now.Add(time.Second)}))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "@logs/a/b.txt", bucket.Key("logs", "a/b.txt"))
+}
+
+func TestPrefix(t *testing.T) {
+	p, ok := bucket.Prefix(bucket.Key("logs", "a/b.txt"))
+	assert.True(t, ok)
+	assert.Equal(t, bucket.Key("logs", ""), p)
+
+	_, ok = bucket.Prefix("logs/a/b.txt")
+	assert.False(t, ok)
+
+	_, ok = bucket.Prefix("@logs")
+	assert.False(t, ok)
+}
+
+func TestName(t *testing.T) {
+	n, ok := bucket.Name(bucket.Key("logs", "a/b.txt"))
+	assert.True(t, ok)
+	assert.Equal(t, "logs", n)
+
+	_, ok = bucket.Name("logs/a/b.txt")
+	assert.False(t, ok)
+}
@@ -0,0 +1,14 @@
+package bucket
+
+import "context"
+
+//go:generate mockgen -destination=../mock/bucket_repository.go -mock_names=Repository=BucketRepository -package=mock github.com/xescugc/rebost/bucket Repository
+
+// Repository is the interface that has to be fulfilled to interact with Buckets
+type Repository interface {
+	CreateOrReplace(ctx context.Context, b *Bucket) error
+	FindByName(ctx context.Context, name string) (*Bucket, error)
+
+	// All returns all the Buckets, including the deleted ones
+	All(ctx context.Context) ([]*Bucket, error)
+}
@@ -3,20 +3,38 @@ package client_test
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/auth"
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/client"
 	"github.com/xescugc/rebost/config"
 	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/presign"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/settings"
 	"github.com/xescugc/rebost/storing"
+	"github.com/xescugc/rebost/storing/model"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/version"
 )
 
 func TestNew(t *testing.T) {
@@ -74,6 +92,49 @@ func TestNew(t *testing.T) {
 	})
 }
 
+func TestNewWithTLS(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			key  = "fileName"
+			ctrl = gomock.NewController(t)
+			evid = "vid"
+		)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().HasFile(gomock.Any(), key).Return(evid, true, nil)
+
+		server := httptest.NewTLSServer(storing.MakeHandler(st))
+		defer server.Close()
+
+		cp := x509.NewCertPool()
+		cp.AddCert(server.Certificate())
+
+		// The host has no scheme so https is used
+		c, err := client.NewWithTLS(&tls.Config{RootCAs: cp}, strings.TrimPrefix(server.URL, "https://"))
+		require.NoError(t, err)
+
+		vid, ok, err := c.HasFile(context.Background(), key)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, evid, vid)
+	})
+	t.Run("FailsWithUnknownCA", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		server := httptest.NewTLSServer(storing.MakeHandler(st))
+		defer server.Close()
+
+		c, err := client.NewWithTLS(&tls.Config{}, server.URL)
+		require.NoError(t, err)
+
+		_, _, err = c.HasFile(context.Background(), "fileName")
+		assert.Error(t, err)
+	})
+}
+
 func TestCreateFile(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		var (
@@ -85,10 +146,11 @@ func TestCreateFile(t *testing.T) {
 			rep         = 10
 			ttl         = 10 * time.Minute
 			ca          = time.Now()
+			class       = "logs"
 		)
 		defer ctrl.Finish()
 
-		st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), rep, ttl, timeMatcher{ca}).Do(func(_ context.Context, _ string, b io.ReadCloser, _ int, _ time.Duration, _ time.Time) {
+		st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), rep, ttl, timeMatcher{ca}, class).Do(func(_ context.Context, _ string, b io.ReadCloser, _ int, _ time.Duration, _ time.Time, _ string) {
 			c, err := io.ReadAll(b)
 			require.NoError(t, err)
 			assert.Equal(t, content, c)
@@ -99,7 +161,31 @@ func TestCreateFile(t *testing.T) {
 		c, err := client.New(server.URL)
 		require.NoError(t, err)
 
-		err = c.CreateFile(context.Background(), key, iorcContent, rep, ttl, ca)
+		err = c.CreateFile(context.Background(), key, iorcContent, rep, ttl, ca, class)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessSendsDigests", func(t *testing.T) {
+		var (
+			content     = []byte("content of the file")
+			iorcContent = io.NopCloser(bytes.NewBuffer(content))
+			md5sum      = md5.Sum(content)
+			sha1sum     = sha1.Sum(content)
+		)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, content, b)
+			assert.Equal(t, base64.StdEncoding.EncodeToString(md5sum[:]), r.Trailer.Get(model.ContentMD5Header))
+			assert.Equal(t, hex.EncodeToString(sha1sum[:]), r.Trailer.Get(model.SHA1Header))
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CreateFile(context.Background(), "filename", iorcContent, 1, 0, time.Now(), "")
 		require.NoError(t, err)
 	})
 	t.Run("Error", func(t *testing.T) {
@@ -115,7 +201,7 @@ func TestCreateFile(t *testing.T) {
 		)
 		defer ctrl.Finish()
 
-		st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), rep, ttl, timeMatcher{ca}).Do(func(_ context.Context, _ string, b io.ReadCloser, _ int, _ time.Duration, _ time.Time) {
+		st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), rep, ttl, timeMatcher{ca}, "").Do(func(_ context.Context, _ string, b io.ReadCloser, _ int, _ time.Duration, _ time.Time, _ string) {
 			c, err := io.ReadAll(b)
 			require.NoError(t, err)
 			assert.Equal(t, content, c)
@@ -126,7 +212,7 @@ func TestCreateFile(t *testing.T) {
 		c, err := client.New(server.URL)
 		require.NoError(t, err)
 
-		err = c.CreateFile(context.Background(), key, iorcContent, rep, ttl, ca)
+		err = c.CreateFile(context.Background(), key, iorcContent, rep, ttl, ca, "")
 		assert.EqualError(t, err, "some error")
 	})
 }
@@ -325,10 +411,11 @@ func TestCreateReplica(t *testing.T) {
 			volID       = "volID"
 			ttl         = 2 * time.Minute
 			ca          = time.Now()
+			class       = "logs"
 		)
 		defer ctrl.Finish()
 
-		st.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), ttl, timeMatcher{ca}).Do(func(_ context.Context, _ string, b io.ReadCloser, _ time.Duration, _ time.Time) {
+		st.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), ttl, timeMatcher{ca}, class).Do(func(_ context.Context, _ string, b io.ReadCloser, _ time.Duration, _ time.Time, _ string) {
 			c, err := io.ReadAll(b)
 			require.NoError(t, err)
 			assert.Equal(t, content, c)
@@ -339,7 +426,7 @@ func TestCreateReplica(t *testing.T) {
 		c, err := client.New(server.URL)
 		require.NoError(t, err)
 
-		vID, err := c.CreateReplica(context.Background(), key, iorcContent, ttl, ca)
+		vID, err := c.CreateReplica(context.Background(), key, iorcContent, ttl, ca, class)
 		require.NoError(t, err)
 		assert.Equal(t, volID, vID)
 	})
@@ -355,7 +442,7 @@ func TestCreateReplica(t *testing.T) {
 		)
 		defer ctrl.Finish()
 
-		st.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), ttl, timeMatcher{ca}).Do(func(_ context.Context, _ string, b io.ReadCloser, _ time.Duration, _ time.Time) {
+		st.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), ttl, timeMatcher{ca}, "").Do(func(_ context.Context, _ string, b io.ReadCloser, _ time.Duration, _ time.Time, _ string) {
 			c, err := io.ReadAll(b)
 			require.NoError(t, err)
 			assert.Equal(t, content, c)
@@ -366,7 +453,7 @@ func TestCreateReplica(t *testing.T) {
 		c, err := client.New(server.URL)
 		require.NoError(t, err)
 
-		vID, err := c.CreateReplica(context.Background(), key, iorcContent, ttl, ca)
+		vID, err := c.CreateReplica(context.Background(), key, iorcContent, ttl, ca, "")
 		assert.EqualError(t, err, "some-error")
 		assert.Equal(t, "", vID)
 	})
@@ -431,3 +518,712 @@ func (tm timeMatcher) Matches(x interface{}) bool {
 func (tm timeMatcher) String() string {
 	return fmt.Sprintf("is equal to %s", tm.t.Format(time.RFC3339))
 }
+
+func TestKeys(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().Keys(gomock.Any()).Return([]auth.Key{{ID: "id", Secret: "secret", Permissions: []auth.Permission{auth.Read}, Prefix: "logs/"}}, nil)
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		keys, err := c.Keys(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []model.Key{{ID: "id", Secret: "secret", Permissions: []string{"read"}, Prefix: "logs/"}}, keys)
+	})
+	t.Run("SuccessWithCredentials", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().Keys(gomock.Any()).Return([]auth.Key{}, nil)
+
+		st, err := auth.NewStore(context.Background(), nil, nil)
+		require.NoError(t, err)
+
+		h := auth.Middleware(st, "secret", nil, auth.MakeHandler(as))
+		server := httptest.NewServer(h)
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		u.User = url.UserPassword("potato", "secret")
+		c, err := client.New(u.String())
+		require.NoError(t, err)
+
+		_, err = c.Keys(context.Background())
+		assert.EqualError(t, err, "unauthorized")
+
+		u.User = url.UserPassword(auth.ClusterID, "secret")
+		c, err = client.New(u.String())
+		require.NoError(t, err)
+
+		_, err = c.Keys(context.Background())
+		require.NoError(t, err)
+	})
+}
+
+func TestCreateKey(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().CreateKey(gomock.Any(), auth.Key{ID: "id", Secret: "secret", Permissions: []auth.Permission{auth.Read, auth.Write}}).Return(nil)
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CreateKey(context.Background(), model.Key{ID: "id", Secret: "secret", Permissions: []string{"read", "write"}})
+		require.NoError(t, err)
+	})
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().CreateKey(gomock.Any(), auth.Key{ID: "id", Permissions: []auth.Permission{}}).Return(errors.New("some error"))
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CreateKey(context.Background(), model.Key{ID: "id"})
+		assert.EqualError(t, err, "some error")
+	})
+}
+
+func TestDeleteKey(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().DeleteKey(gomock.Any(), "id").Return(nil)
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.DeleteKey(context.Background(), "id")
+		require.NoError(t, err)
+	})
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().DeleteKey(gomock.Any(), "id").Return(errors.New("not found"))
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.DeleteKey(context.Background(), "id")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestPresign(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().Presign(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u presign.URL) (string, error) {
+			assert.Equal(t, http.MethodPut, u.Method)
+			assert.Equal(t, "key", u.Key)
+			assert.Equal(t, "text/plain", u.ContentType)
+			assert.Equal(t, int64(10), u.MaxSize)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), u.Expires, time.Second)
+			return "/files/key?X-Rebost-Signature=sig", nil
+		})
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		p, err := c.Presign(context.Background(), model.Presign{Method: "put", Key: "key", ExpiresIn: 60, ContentType: "text/plain", MaxSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "/files/key?X-Rebost-Signature=sig", p)
+	})
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		as := mock.NewAuth(ctrl)
+		defer ctrl.Finish()
+
+		as.EXPECT().Presign(gomock.Any(), gomock.Any()).Return("", auth.ErrForbidden)
+
+		h := auth.MakeHandler(as)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		_, err = c.Presign(context.Background(), model.Presign{Method: "GET", Key: "key"})
+		assert.EqualError(t, err, "forbidden")
+	})
+}
+
+func TestCreateBucket(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		b := &bucket.Bucket{Name: "logs", Replica: 2, TTL: time.Hour, Class: "cold", Quota: bucket.Quota{Size: 10, Count: 1}, Policy: bucket.PublicRead}
+		defer ctrl.Finish()
+
+		st.EXPECT().CreateBucket(gomock.Any(), b).Return(nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CreateBucket(context.Background(), b)
+		require.NoError(t, err)
+	})
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		b := &bucket.Bucket{Name: "Logs"}
+		defer ctrl.Finish()
+
+		st.EXPECT().CreateBucket(gomock.Any(), b).Return(fmt.Errorf("%w: invalid name", bucket.ErrInvalid))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CreateBucket(context.Background(), b)
+		assert.EqualError(t, err, "invalid bucket: invalid name")
+	})
+}
+
+func TestGetBucket(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		b := &bucket.Bucket{Name: "logs", TTL: time.Hour, Policy: bucket.Private, UpdatedAt: time.Now().UTC().Truncate(time.Second)}
+		defer ctrl.Finish()
+
+		st.EXPECT().GetBucket(gomock.Any(), b.Name).Return(b, nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		rb, err := c.GetBucket(context.Background(), b.Name)
+		require.NoError(t, err)
+		assert.Equal(t, b, rb)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().GetBucket(gomock.Any(), "logs").Return(nil, errors.New("not found"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		_, err = c.GetBucket(context.Background(), "logs")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestBuckets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	bks := []*bucket.Bucket{
+		{Name: "logs", Policy: bucket.Private, UpdatedAt: time.Now().UTC().Truncate(time.Second)},
+		{Name: "images", Policy: bucket.PublicRead, UpdatedAt: time.Now().UTC().Truncate(time.Second)},
+	}
+	defer ctrl.Finish()
+
+	st.EXPECT().Buckets(gomock.Any()).Return(bks, nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	rbks, err := c.Buckets(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, bks, rbks)
+}
+
+func TestDeleteBucket(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().DeleteBucket(gomock.Any(), "logs").Return(nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.DeleteBucket(context.Background(), "logs")
+		require.NoError(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().DeleteBucket(gomock.Any(), "logs").Return(errors.New("not found"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.DeleteBucket(context.Background(), "logs")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestQuotas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	sts := []*quota.Status{
+		{Quota: quota.Quota{Prefix: "@logs/", Size: 100}, Usage: quota.Usage{Size: 10, Count: 1}},
+		{Quota: quota.Quota{Prefix: "tmp/", Count: 10}, Usage: quota.Usage{Size: 1, Count: 1}},
+	}
+	defer ctrl.Finish()
+
+	st.EXPECT().Quotas(gomock.Any()).Return(sts, nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	rsts, err := c.Quotas(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, sts, rsts)
+}
+
+func TestSettings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	stt := &settings.Settings{Replica: 2, Redirect: settings.RedirectAlways, MaxRepairs: 1, Rebalance: 10}
+	defer ctrl.Finish()
+
+	st.EXPECT().Settings(gomock.Any()).Return(stt, nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	rstt, err := c.Settings(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, stt, rstt)
+}
+
+func TestUpdateSettings(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		stt := &settings.Settings{Replica: 2, Redirect: settings.RedirectNever}
+		defer ctrl.Finish()
+
+		st.EXPECT().UpdateSettings(gomock.Any(), stt).Return(nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.UpdateSettings(context.Background(), stt)
+		require.NoError(t, err)
+	})
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		stt := &settings.Settings{Replica: 2}
+		defer ctrl.Finish()
+
+		st.EXPECT().UpdateSettings(gomock.Any(), stt).Return(errors.New("there is no leader on the cluster"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.UpdateSettings(context.Background(), stt)
+		assert.EqualError(t, err, "there is no leader on the cluster")
+	})
+}
+
+func TestVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	vs := []*version.Version{
+		{ID: "1-sig", Signature: "sig", Size: 7, Class: "cold", CreatedAt: time.Now().UTC().Truncate(time.Second)},
+		{ID: "2-delete", DeleteMarker: true, CreatedAt: time.Now().UTC().Truncate(time.Second)},
+	}
+	defer ctrl.Finish()
+
+	st.EXPECT().Versions(gomock.Any(), "fileName").Return(vs, nil)
+	st.EXPECT().HasVersions(gomock.Any(), "fileName").Return("vid", true, nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	rvs, err := c.Versions(context.Background(), "fileName")
+	require.NoError(t, err)
+	assert.Equal(t, vs, rvs)
+
+	vid, ok, err := c.HasVersions(context.Background(), "fileName")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "vid", vid)
+}
+
+func TestGetFileVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	st.EXPECT().GetFile(gomock.Any(), version.Key("fileName", "1-sig")).Return(io.NopCloser(bytes.NewBufferString("content")), nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	ior, err := c.GetFileVersion(context.Background(), "fileName", "1-sig")
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(ior)
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+}
+
+func TestRestoreVersion(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().RestoreVersion(gomock.Any(), "fileName", "1-sig").Return(nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.RestoreVersion(context.Background(), "fileName", "1-sig")
+		require.NoError(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().RestoreVersion(gomock.Any(), "fileName", "potato").Return(errors.New("not found"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.RestoreVersion(context.Background(), "fileName", "potato")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	now := time.Now().UTC().Truncate(time.Second)
+	its := []*trash.Item{
+		{ID: "1", Key: "fileName", Signature: "sig", Size: 7, DeletedAt: now, PurgeAt: now.Add(time.Hour)},
+	}
+	defer ctrl.Finish()
+
+	st.EXPECT().Trash(gomock.Any(), true).Return(its, nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	rits, err := c.Trash(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, its, rits)
+}
+
+func TestRestoreTrash(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().RestoreTrash(gomock.Any(), "fileName", "1", false).Return(nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.RestoreTrash(context.Background(), "fileName", "1", false)
+		require.NoError(t, err)
+	})
+	t.Run("AlreadyExists", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().RestoreTrash(gomock.Any(), "fileName", "1", true).Return(errors.New("already exists"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.RestoreTrash(context.Background(), "fileName", "1", true)
+		assert.EqualError(t, err, "already exists")
+	})
+}
+
+func TestTrashReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	da := time.Now()
+	pa := da.Add(time.Hour)
+	defer ctrl.Finish()
+
+	st.EXPECT().TrashReplica(gomock.Any(), "fileName", gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, rda, rpa time.Time) error {
+		assert.True(t, da.Equal(rda))
+		assert.True(t, pa.Equal(rpa))
+		return nil
+	})
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	err = c.TrashReplica(context.Background(), "fileName", da, pa)
+	require.NoError(t, err)
+}
+
+func TestGetFileSignatureReplica(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().GetFileSignatureReplica(gomock.Any(), "fileName").Return("sha1:123", nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		sig, err := c.GetFileSignatureReplica(context.Background(), "fileName")
+		require.NoError(t, err)
+		assert.Equal(t, "sha1:123", sig)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().GetFileSignatureReplica(gomock.Any(), "fileName").Return("", errors.New("not found"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		_, err = c.GetFileSignatureReplica(context.Background(), "fileName")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestGetFileNodes(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().GetFileNodes(gomock.Any(), "fileName").Return([]string{"http://n1:3805", "http://n2:3805"}, nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		urls, err := c.GetFileNodes(context.Background(), "fileName")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"http://n1:3805", "http://n2:3805"}, urls)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().GetFileNodes(gomock.Any(), "fileName").Return(nil, errors.New("not found"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		_, err = c.GetFileNodes(context.Background(), "fileName")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestCopyFile(t *testing.T) {
+	t.Run("Copy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().CopyFile(gomock.Any(), "a", "b", false, time.Time{}).Return(nil)
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CopyFile(context.Background(), "a", "b", false, time.Time{})
+		require.NoError(t, err)
+	})
+	t.Run("Move", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		ca := time.Now()
+		defer ctrl.Finish()
+
+		// The key of the Bucket is only accepted from the cluster
+		st.EXPECT().Config(gomock.Any()).Return(&config.Config{}, nil)
+		st.EXPECT().CopyFile(gomock.Any(), "a", "@logs/b", true, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, _ bool, rca time.Time) error {
+			assert.True(t, ca.Equal(rca))
+			return nil
+		})
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CopyFile(context.Background(), "a", "@logs/b", true, ca)
+		require.NoError(t, err)
+	})
+	t.Run("Error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		st := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		st.EXPECT().CopyFile(gomock.Any(), "a", "b", false, time.Time{}).Return(errors.New("not found"))
+
+		h := storing.MakeHandler(st)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		err = c.CopyFile(context.Background(), "a", "b", false, time.Time{})
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestCopyReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	ca := time.Now()
+	defer ctrl.Finish()
+
+	st.EXPECT().CopyReplica(gomock.Any(), "a", "b", true, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, _ bool, rca time.Time) error {
+		assert.True(t, ca.Equal(rca))
+		return nil
+	})
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	err = c.CopyReplica(context.Background(), "a", "b", true, ca)
+	require.NoError(t, err)
+}
+
+func TestUpdateFileTTL(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		ctx  = context.Background()
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	st.EXPECT().UpdateFileTTL(gomock.Any(), "logs/a", 24*time.Hour).Return(nil)
+	st.EXPECT().UpdateFileTTL(gomock.Any(), "logs/b", time.Duration(0)).Return(errors.New("not found"))
+	st.EXPECT().UpdateFileTTLReplica(gomock.Any(), "logs/a", 24*time.Hour).Return(nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	err = c.UpdateFileTTL(ctx, "logs/a", 24*time.Hour)
+	require.NoError(t, err)
+
+	err = c.UpdateFileTTL(ctx, "logs/b", 0)
+	assert.EqualError(t, err, "not found")
+
+	err = c.UpdateFileTTLReplica(ctx, "logs/a", 24*time.Hour)
+	require.NoError(t, err)
+}
+
+func TestBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	ops := []*batch.Operation{
+		{Op: batch.Head, Key: "a"},
+		{Op: batch.Move, Key: "@logs/a", Destination: "@logs/b"},
+	}
+
+	// The keys of the Bucket are only accepted from the cluster
+	st.EXPECT().Config(gomock.Any()).Return(&config.Config{}, nil)
+	st.EXPECT().Batch(gomock.Any(), ops, true).Return([]*batch.Result{
+		{Operation: *ops[0], VolumeID: "vid"},
+		{Operation: *ops[1], Err: errors.New("not found")},
+	}, nil)
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	rs, err := c.Batch(context.Background(), ops, true)
+	require.NoError(t, err)
+	assert.Equal(t, []*batch.Result{
+		{Operation: *ops[0], VolumeID: "vid"},
+		{Operation: *ops[1], Err: errors.New("not found")},
+	}, rs)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+
+	"github.com/xescugc/rebost/storing/model"
+)
+
+// digestBody wraps a body and calculates the MD5 and SHA1 of it while
+// it's read so when it reaches the EOF it sets them on the Trailer
+// so the server can validate the content
+type digestBody struct {
+	body    io.ReadCloser
+	trailer http.Header
+
+	md5  hash.Hash
+	sha1 hash.Hash
+}
+
+// setDigestBody sets the body of r to a digestBody of b and declares the
+// Trailers it'll send
+func setDigestBody(r *http.Request, b io.ReadCloser) {
+	r.Trailer = http.Header{
+		http.CanonicalHeaderKey(model.ContentMD5Header): nil,
+		http.CanonicalHeaderKey(model.SHA1Header):       nil,
+	}
+	r.Body = &digestBody{
+		body:    b,
+		trailer: r.Trailer,
+		md5:     md5.New(),
+		sha1:    sha1.New(),
+	}
+}
+
+func (db *digestBody) Read(p []byte) (int, error) {
+	n, err := db.body.Read(p)
+	if n > 0 {
+		db.md5.Write(p[:n])
+		db.sha1.Write(p[:n])
+	}
+	if err == io.EOF {
+		db.trailer.Set(model.ContentMD5Header, base64.StdEncoding.EncodeToString(db.md5.Sum(nil)))
+		db.trailer.Set(model.SHA1Header, hex.EncodeToString(db.sha1.Sum(nil)))
+	}
+	return n, err
+}
+
+func (db *digestBody) Close() error {
+	return db.body.Close()
+}
@@ -8,73 +8,367 @@ import (
 	kithttp "github.com/go-kit/kit/transport/http"
 )
 
-func makeCreatFileEndpoint(u url.URL) endpoint.Endpoint {
+func makeCreatFileEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/files"
 	return kithttp.NewClient(
 		http.MethodPut,
 		&u,
 		encodeCreateFileRequest,
 		decodeCreateFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
 	).Endpoint()
 }
 
-func makeGetFileEndpoint(u url.URL) endpoint.Endpoint {
+func makeGetFileEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/files"
 	return kithttp.NewClient(
 		http.MethodGet,
 		&u,
 		encodeGetFileRequest,
 		decodeGetFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
 		kithttp.BufferedStream(true),
 	).Endpoint()
 }
 
-func makeDeleteFileEndpoint(u url.URL) endpoint.Endpoint {
+func makeDeleteFileEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/files"
 	return kithttp.NewClient(
 		http.MethodDelete,
 		&u,
 		encodeDeleteFileRequest,
 		decodeDeleteFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
 	).Endpoint()
 }
 
-func makeHasFileEndpoint(u url.URL) endpoint.Endpoint {
+func makeHasFileEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/files"
 	return kithttp.NewClient(
 		http.MethodHead,
 		&u,
 		encodeHasFileRequest,
 		decodeHasFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
 	).Endpoint()
 }
 
-func makeGetConfigEndpoint(u url.URL) endpoint.Endpoint {
+func makeGetConfigEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/config"
 	return kithttp.NewClient(
 		http.MethodGet,
 		&u,
 		encodeGetConfigRequest,
 		decodeGetConfigResponse,
+		kithttp.SetClient(hc),
 	).Endpoint()
 }
 
-func makeCreatReplicaEndpoint(u url.URL) endpoint.Endpoint {
+func makeCreatReplicaEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/replicas"
 	return kithttp.NewClient(
 		http.MethodPut,
 		&u,
 		encodeCreateReplicaRequest,
 		decodeCreateReplicaResponse,
+		kithttp.SetClient(hc),
 	).Endpoint()
 }
 
-func makeUpdateFileReplica(u url.URL) endpoint.Endpoint {
+func makeUpdateFileReplica(u url.URL, hc *http.Client) endpoint.Endpoint {
 	u.Path = "/replicas"
 	return kithttp.NewClient(
 		http.MethodPatch,
 		&u,
 		encodeUpdateFileReplicaRequest,
 		decodeUpdateFileReplicaResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeKeysEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/admin/keys"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeKeysRequest,
+		decodeKeysResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeCreateKeyEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/admin/keys"
+	return kithttp.NewClient(
+		http.MethodPut,
+		&u,
+		encodeCreateKeyRequest,
+		decodeCreateKeyResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeDeleteKeyEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/admin/keys"
+	return kithttp.NewClient(
+		http.MethodDelete,
+		&u,
+		encodeDeleteKeyRequest,
+		decodeDeleteKeyResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makePresignEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/presign"
+	return kithttp.NewClient(
+		http.MethodPost,
+		&u,
+		encodePresignRequest,
+		decodePresignResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeCreateBucketEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/buckets"
+	return kithttp.NewClient(
+		http.MethodPut,
+		&u,
+		encodeCreateBucketRequest,
+		decodeCreateBucketResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeGetBucketEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/buckets"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeGetBucketRequest,
+		decodeGetBucketResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeBucketsEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/buckets"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeBucketsRequest,
+		decodeBucketsResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeDeleteBucketEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/buckets"
+	return kithttp.NewClient(
+		http.MethodDelete,
+		&u,
+		encodeDeleteBucketRequest,
+		decodeDeleteBucketResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeSettingsEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/settings"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeSettingsRequest,
+		decodeSettingsResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeUpdateSettingsEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/settings"
+	return kithttp.NewClient(
+		http.MethodPut,
+		&u,
+		encodeUpdateSettingsRequest,
+		decodeUpdateSettingsResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeQuotasEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/quotas"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeQuotasRequest,
+		decodeQuotasResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeVersionsEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/versions"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeVersionsRequest,
+		decodeVersionsResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeHasVersionsEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/versions"
+	return kithttp.NewClient(
+		http.MethodHead,
+		&u,
+		encodeVersionsRequest,
+		decodeHasFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeRestoreVersionEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/versions"
+	return kithttp.NewClient(
+		http.MethodPost,
+		&u,
+		encodeRestoreVersionRequest,
+		decodeRestoreVersionResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeTrashEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/trash"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeTrashRequest,
+		decodeTrashResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeRestoreTrashEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/trash"
+	return kithttp.NewClient(
+		http.MethodPost,
+		&u,
+		encodeRestoreTrashRequest,
+		decodeRestoreTrashResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeTrashReplicaEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/replicas"
+	return kithttp.NewClient(
+		http.MethodDelete,
+		&u,
+		encodeTrashReplicaRequest,
+		decodeTrashReplicaResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeGetFileSignatureReplicaEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/replicas"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeGetFileSignatureReplicaRequest,
+		decodeGetFileSignatureReplicaResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeGetFileNodesEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/files"
+	return kithttp.NewClient(
+		http.MethodGet,
+		&u,
+		encodeGetFileNodesRequest,
+		decodeGetFileNodesResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeCopyFileEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/files"
+	return kithttp.NewClient(
+		"COPY",
+		&u,
+		encodeCopyFileRequest,
+		decodeCopyFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeMoveFileEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/files"
+	return kithttp.NewClient(
+		"MOVE",
+		&u,
+		encodeCopyFileRequest,
+		decodeCopyFileResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeCopyReplicaEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/replicas"
+	return kithttp.NewClient(
+		"COPY",
+		&u,
+		encodeCopyReplicaRequest,
+		decodeCopyFileResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeUpdateFileTTLEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/files"
+	return kithttp.NewClient(
+		http.MethodPatch,
+		&u,
+		encodeUpdateFileTTLRequest,
+		decodeUpdateFileTTLResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
+	).Endpoint()
+}
+
+func makeUpdateFileTTLReplicaEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/replicas"
+	return kithttp.NewClient(
+		http.MethodPatch,
+		&u,
+		encodeUpdateFileTTLRequest,
+		decodeUpdateFileTTLResponse,
+		kithttp.SetClient(hc),
+	).Endpoint()
+}
+
+func makeBatchEndpoint(u url.URL, hc *http.Client) endpoint.Endpoint {
+	u.Path = "/batch"
+	return kithttp.NewClient(
+		http.MethodPost,
+		&u,
+		encodeBatchRequest,
+		decodeBatchResponse,
+		kithttp.SetClient(hc),
+		kithttp.ClientBefore(setInternalHeader),
 	).Endpoint()
 }
@@ -28,9 +28,24 @@ func decodeHasFileResponse(_ context.Context, r *http.Response) (interface{}, er
 	}, nil
 }
 
+// setInternalHeader sets the model.InternalHeader to the r as
+// the Client is used by the Nodes to make requests between them
+func setInternalHeader(ctx context.Context, r *http.Request) context.Context {
+	r.Header.Set(model.InternalHeader, "true")
+	return ctx
+}
+
 func encodeGetFileRequest(_ context.Context, r *http.Request, request interface{}) error {
 	gfr := request.(getFileRequest)
 	r.URL.Path += "/" + gfr.Key
+	if gfr.Version != "" {
+		q := r.URL.Query()
+		q.Set("version", gfr.Version)
+		r.URL.RawQuery = q.Encode()
+	}
+	// The Node has to send the content instead of redirecting
+	// to the one that has it as it's also used between Nodes
+	r.Header.Set(model.RedirectHeader, "false")
 	return nil
 }
 
@@ -84,8 +99,11 @@ func encodeCreateFileRequest(_ context.Context, r *http.Request, request interfa
 	q.Set("replica", strconv.Itoa(cfr.Replica))
 	q.Set("ttl", cfr.TTL.String())
 	q.Set("created_at", cfr.CreatedAt.Format(time.RFC3339))
+	if cfr.Class != "" {
+		q.Set("class", cfr.Class)
+	}
 	r.URL.RawQuery = q.Encode()
-	r.Body = cfr.IORC
+	setDigestBody(r, cfr.IORC)
 	return nil
 }
 
@@ -106,8 +124,11 @@ func encodeCreateReplicaRequest(_ context.Context, r *http.Request, request inte
 	q := r.URL.Query()
 	q.Set("ttl", crr.TTL.String())
 	q.Set("created_at", crr.CreatedAt.Format(time.RFC3339))
+	if crr.Class != "" {
+		q.Set("class", crr.Class)
+	}
 	r.URL.RawQuery = q.Encode()
-	r.Body = crr.IORC
+	setDigestBody(r, crr.IORC)
 	return nil
 }
 
@@ -140,3 +161,389 @@ func decodeUpdateFileReplicaResponse(_ context.Context, r *http.Response) (inter
 	}
 	return response, nil
 }
+
+func encodeKeysRequest(_ context.Context, r *http.Request, request interface{}) error {
+	return nil
+}
+
+func decodeKeysResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response keysResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeCreateKeyRequest(_ context.Context, r *http.Request, request interface{}) error {
+	ckr := request.(createKeyRequest)
+	r.URL.Path += "/" + ckr.Key.ID
+	b, err := json.Marshal(ckr.Key)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(b))
+	return nil
+}
+
+func decodeCreateKeyResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response createKeyResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeDeleteKeyRequest(_ context.Context, r *http.Request, request interface{}) error {
+	dkr := request.(deleteKeyRequest)
+	r.URL.Path += "/" + dkr.ID
+	return nil
+}
+
+func decodeDeleteKeyResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response deleteKeyResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodePresignRequest(_ context.Context, r *http.Request, request interface{}) error {
+	pr := request.(presignRequest)
+	b, err := json.Marshal(pr.Presign)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(b))
+	return nil
+}
+
+func decodePresignResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response presignResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeCreateBucketRequest(_ context.Context, r *http.Request, request interface{}) error {
+	cbr := request.(createBucketRequest)
+	r.URL.Path += "/" + cbr.Bucket.Name
+	b, err := json.Marshal(cbr.Bucket)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(b))
+	return nil
+}
+
+func decodeCreateBucketResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response createBucketResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeGetBucketRequest(_ context.Context, r *http.Request, request interface{}) error {
+	gbr := request.(getBucketRequest)
+	r.URL.Path += "/" + gbr.Name
+	return nil
+}
+
+func decodeGetBucketResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response getBucketResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeBucketsRequest(_ context.Context, r *http.Request, request interface{}) error {
+	return nil
+}
+
+func decodeBucketsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response bucketsResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeDeleteBucketRequest(_ context.Context, r *http.Request, request interface{}) error {
+	dbr := request.(deleteBucketRequest)
+	r.URL.Path += "/" + dbr.Name
+	return nil
+}
+
+func decodeDeleteBucketResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response deleteBucketResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeSettingsRequest(_ context.Context, r *http.Request, request interface{}) error {
+	return nil
+}
+
+func decodeSettingsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response settingsResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeUpdateSettingsRequest(_ context.Context, r *http.Request, request interface{}) error {
+	usr := request.(updateSettingsRequest)
+	b, err := json.Marshal(usr.Settings)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(b))
+	return nil
+}
+
+func decodeUpdateSettingsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response updateSettingsResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeQuotasRequest(_ context.Context, r *http.Request, request interface{}) error {
+	return nil
+}
+
+func decodeQuotasResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response quotasResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeVersionsRequest(_ context.Context, r *http.Request, request interface{}) error {
+	vr := request.(versionsRequest)
+	r.URL.Path += "/" + vr.Key
+	return nil
+}
+
+func decodeVersionsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response versionsResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeRestoreVersionRequest(_ context.Context, r *http.Request, request interface{}) error {
+	rvr := request.(restoreVersionRequest)
+	r.URL.Path += "/" + rvr.Key
+	q := r.URL.Query()
+	q.Set("version", rvr.Version)
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func decodeRestoreVersionResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response restoreVersionResponse
+	if r.StatusCode == http.StatusCreated {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeTrashRequest(_ context.Context, r *http.Request, request interface{}) error {
+	tr := request.(trashRequest)
+	if tr.Local {
+		q := r.URL.Query()
+		q.Set("local", "true")
+		r.URL.RawQuery = q.Encode()
+	}
+	return nil
+}
+
+func decodeTrashResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response trashResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeRestoreTrashRequest(_ context.Context, r *http.Request, request interface{}) error {
+	rtr := request.(restoreTrashRequest)
+	r.URL.Path += "/" + rtr.Key
+	q := r.URL.Query()
+	q.Set("id", rtr.ID)
+	if rtr.Local {
+		q.Set("local", "true")
+	}
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func decodeRestoreTrashResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response restoreTrashResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeTrashReplicaRequest(_ context.Context, r *http.Request, request interface{}) error {
+	trr := request.(trashReplicaRequest)
+	r.URL.Path += "/" + trr.Key
+	q := r.URL.Query()
+	q.Set("deleted_at", trr.DeletedAt.Format(time.RFC3339Nano))
+	q.Set("purge_at", trr.PurgeAt.Format(time.RFC3339Nano))
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func decodeTrashReplicaResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response trashReplicaResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeCopyFileRequest(_ context.Context, r *http.Request, request interface{}) error {
+	cfr := request.(copyFileRequest)
+	r.URL.Path += "/" + cfr.Source
+	r.Header.Set(model.DestinationHeader, "/files/"+cfr.Destination)
+	if !cfr.CopiedAt.IsZero() {
+		q := r.URL.Query()
+		q.Set("copied_at", cfr.CopiedAt.Format(time.RFC3339Nano))
+		r.URL.RawQuery = q.Encode()
+	}
+	return nil
+}
+
+func decodeCopyFileResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response copyFileResponse
+	if r.StatusCode == http.StatusCreated || r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeGetFileSignatureReplicaRequest(_ context.Context, r *http.Request, request interface{}) error {
+	gfsr := request.(getFileSignatureReplicaRequest)
+	r.URL.Path += "/" + gfsr.Key
+	return nil
+}
+
+func decodeGetFileSignatureReplicaResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response getFileSignatureReplicaResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeGetFileNodesRequest(_ context.Context, r *http.Request, request interface{}) error {
+	gfnr := request.(getFileNodesRequest)
+	r.URL.Path += "/" + gfnr.Key
+	q := r.URL.Query()
+	q.Set("nodes", "true")
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func decodeGetFileNodesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response getFileNodesResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeCopyReplicaRequest(_ context.Context, r *http.Request, request interface{}) error {
+	cfr := request.(copyFileRequest)
+	r.URL.Path += "/" + cfr.Source
+	q := r.URL.Query()
+	q.Set("destination", cfr.Destination)
+	q.Set("copied_at", cfr.CopiedAt.Format(time.RFC3339Nano))
+	if cfr.Move {
+		q.Set("move", "true")
+	}
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func encodeUpdateFileTTLRequest(_ context.Context, r *http.Request, request interface{}) error {
+	ufr := request.(updateFileTTLRequest)
+	r.URL.Path += "/" + ufr.Key
+	q := r.URL.Query()
+	q.Set("ttl", ufr.TTL.String())
+	r.URL.RawQuery = q.Encode()
+	return nil
+}
+
+func decodeUpdateFileTTLResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response updateFileTTLResponse
+	if r.StatusCode == http.StatusNoContent {
+		return response, nil
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func encodeBatchRequest(_ context.Context, r *http.Request, request interface{}) error {
+	br := request.(batchRequest)
+	if br.Local {
+		q := r.URL.Query()
+		q.Set("local", "true")
+		r.URL.RawQuery = q.Encode()
+	}
+	b, err := json.Marshal(br.Batch)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Body = io.NopCloser(bytes.NewBuffer(b))
+	return nil
+}
+
+func decodeBatchResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	var response batchResponse
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/xescugc/rebost/boltdb"
+	"github.com/xescugc/rebost/encryption"
+	"github.com/xescugc/rebost/fs"
+	"github.com/xescugc/rebost/signature"
+	"github.com/xescugc/rebost/volume"
+)
+
+var (
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrates the volumes to a hash algorithm",
+		Long:  "Migrates the Signatures of all the files of the volumes to the hash algorithm, the legacy volumes (with no hash algorithm) use SHA1 and are migrated to it when the Node starts. The Node must be stopped while migrating",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			logger := kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
+			logger = kitlog.With(logger, "ts", kitlog.DefaultTimestampUTC, "caller", kitlog.DefaultCaller)
+
+			// The flags are read directly as the viper keys
+			// are already bind to the serve flags
+			volumes, err := cmd.Flags().GetStringSlice("volumes")
+			if err != nil {
+				return err
+			}
+			if len(volumes) == 0 {
+				return errors.New("at least one volume is required")
+			}
+
+			h, err := cmd.Flags().GetString("hash")
+			if err != nil {
+				return err
+			}
+			alg, err := signature.Parse(h)
+			if err != nil {
+				return err
+			}
+
+			var kr *encryption.Keyring
+			kf, err := cmd.Flags().GetString("encryption.key-file")
+			if err != nil {
+				return err
+			}
+			if kf != "" {
+				kr, err = encryption.LoadKeyring(kf)
+				if err != nil {
+					return fmt.Errorf("error loading the encryption keys: %s", err)
+				}
+			}
+
+			osfs := afero.NewOsFs()
+
+			for _, vp := range volumes {
+				// We split the vp as it may contain the size of the volume as the second position like : /root:20G
+				bdb, err := createDB(strings.Split(vp, ":")[0])
+				if err != nil {
+					return fmt.Errorf("error creating the BoltDB: %s", err)
+				}
+				files, err := boltdb.NewFileRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating File Repository: %s", err)
+				}
+				idxkeys, err := boltdb.NewIDXKeyRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating IDXKeys Repository: %s", err)
+				}
+				idxttl, err := boltdb.NewIDXTTLRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating IDXTTL Repository: %s", err)
+				}
+				idxvolumes, err := boltdb.NewIDXVolumeRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating IDXVolumes Repository: %s", err)
+				}
+				replicas, err := boltdb.NewReplicaRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating Replica Repository: %s", err)
+				}
+				suow := fs.UOWWithFs(boltdb.NewUOW(bdb))
+
+				err = volume.Migrate(ctx, vp, files, idxkeys, idxttl, idxvolumes, replicas, osfs, logger, suow, alg, kr)
+				if err != nil {
+					return fmt.Errorf("error migrating the Volume %q: %s", vp, err)
+				}
+
+				err = bdb.Close()
+				if err != nil {
+					return err
+				}
+
+				logger.Log("msg", fmt.Sprintf("Migrated volume: %q", vp))
+			}
+
+			return nil
+		},
+	}
+)
+
+func init() {
+	migrateCmd.Flags().StringSliceP("volumes", "v", []string{}, "Volumes to migrate")
+	migrateCmd.Flags().String("encryption.key-file", "", "File with the master keys, required if the volumes have encrypted files")
+	migrateCmd.Flags().String("hash", string(signature.Default), fmt.Sprintf("The hash algorithm to migrate to. Supported ones are %v", signature.Algorithms))
+
+	RootCmd.AddCommand(migrateCmd)
+}
@@ -13,19 +13,23 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/gorilla/handlers"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/xescugc/rebost/auth"
 	"github.com/xescugc/rebost/boltdb"
 	"github.com/xescugc/rebost/config"
 	"github.com/xescugc/rebost/dashboard"
 	"github.com/xescugc/rebost/dashboard/assets"
 	dhttp "github.com/xescugc/rebost/dashboard/transport/http"
+	"github.com/xescugc/rebost/encryption"
 	"github.com/xescugc/rebost/fs"
 	"github.com/xescugc/rebost/membership"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/state"
 	"github.com/xescugc/rebost/storing"
 	"github.com/xescugc/rebost/uow"
@@ -47,8 +51,8 @@ var (
 			logger := kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(os.Stdout))
 			logger = kitlog.With(logger, "ts", kitlog.DefaultTimestampUTC, "caller", kitlog.DefaultCaller)
 
-			if len(cfg.Volumes) == 0 {
-				return errors.New("at last one volume is required")
+			if len(cfg.Volumes) == 0 && !cfg.Gateway {
+				return errors.New("at least one volume is required, or the node has to be a gateway")
 			}
 			if cfg.Name == "" {
 				return errors.New("the 'name' is required")
@@ -56,6 +60,36 @@ var (
 
 			osfs := afero.NewOsFs()
 
+			var kr *encryption.Keyring
+			if cfg.Encryption.KeyFile != "" {
+				kr, err = encryption.LoadKeyring(cfg.Encryption.KeyFile)
+				if err != nil {
+					return fmt.Errorf("error loading the encryption keys: %s", err)
+				}
+
+				// The key file is read again so the master key
+				// can be rotated without restarting the Node
+				go func() {
+					for range time.Tick(volume.TickerDuration) {
+						ok, err := kr.Reload()
+						if err != nil {
+							logger.Log("msg", "error reloading the encryption keys", "error", err.Error())
+							continue
+						}
+						if ok {
+							logger.Log("msg", "rotated the encryption master key", "key", kr.Current())
+						}
+					}
+				}()
+			}
+
+			// The Volumes only need the prefixes to
+			// calculate the usage of each one of them
+			prefixes := make([]string, 0, len(cfg.Quotas))
+			for _, q := range cfg.Quotas {
+				prefixes = append(prefixes, q.Prefix)
+			}
+
 			vs := make([]volume.Local, 0, len(cfg.Volumes))
 			for _, vp := range cfg.Volumes {
 				// We split the vp as it may contain the size of the volume as the second position like : /root:20G
@@ -87,6 +121,22 @@ var (
 				if err != nil {
 					return fmt.Errorf("error creating State Repository: %s", err)
 				}
+				buckets, err := boltdb.NewBucketRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating Bucket Repository: %s", err)
+				}
+				versions, err := boltdb.NewVersionRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating Version Repository: %s", err)
+				}
+				trs, err := boltdb.NewTrashRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating Trash Repository: %s", err)
+				}
+				apikeys, err := boltdb.NewAPIKeyRepository(bdb)
+				if err != nil {
+					return fmt.Errorf("error creating API Key Repository: %s", err)
+				}
 				suow := fs.UOWWithFs(boltdb.NewUOW(bdb))
 
 				var (
@@ -104,7 +154,24 @@ var (
 					return fmt.Errorf("error getting the state of Volume: %s", err)
 				}
 
-				v, err := volume.New(vp, files, idxkeys, idxttl, idxvolumes, replicas, stater, osfs, logger, suow)
+				v, err := volume.New(vp, volume.Repositories{
+					Files:      files,
+					IDXKeys:    idxkeys,
+					IDXTTLs:    idxttl,
+					IDXVolumes: idxvolumes,
+					Replicas:   replicas,
+					State:      stater,
+					Buckets:    buckets,
+					Versions:   versions,
+					Trash:      trs,
+					APIKeys:    apikeys,
+				}, osfs, suow, logger, volume.Options{
+					Hash:         signature.Algorithm(cfg.Hash),
+					Compressions: cfg.Compressions(),
+					Keyring:      kr,
+					Prefixes:     prefixes,
+					Rules:        cfg.LifecycleRules(),
+				})
 				if err != nil {
 					return fmt.Errorf("error creating Volume: %s", err)
 				}
@@ -135,7 +202,53 @@ var (
 
 			mux.Handle("/", storing.MakeHandler(s))
 
-			http.Handle("/", handlers.CustomLoggingHandler(os.Stdout, mux, func(writer io.Writer, params handlers.LogFormatterParams) {
+			var handler http.Handler = mux
+			if cfg.Auth.ClusterSecret != "" {
+				keys := make([]auth.Key, 0, len(cfg.Auth.Keys))
+				for _, k := range cfg.Auth.Keys {
+					ak := auth.Key{ID: k.ID, Secret: k.Secret, Prefix: k.Prefix}
+					for _, p := range k.Permissions {
+						ak.Permissions = append(ak.Permissions, auth.Permission(p))
+					}
+					keys = append(keys, ak)
+				}
+
+				avs := make([]auth.Volume, 0, len(vs))
+				for _, v := range vs {
+					avs = append(avs, v)
+				}
+
+				st, err := auth.NewStore(ctx, keys, avs)
+				if err != nil {
+					return fmt.Errorf("error loading the auth keys: %s", err)
+				}
+
+				// The keys created with the admin API are persisted
+				// on the volumes and the ones changed while this
+				// Node was down are fetched from the rest of the
+				// cluster, the last updated one wins
+				for _, n := range m.Nodes() {
+					mks, err := n.Keys(ctx)
+					if err != nil {
+						logger.Log("msg", fmt.Sprintf("error fetching the auth keys: %s", err))
+						continue
+					}
+					for _, mk := range mks {
+						err = st.Put(ctx, auth.ModelToKey(mk))
+						if err != nil {
+							logger.Log("msg", fmt.Sprintf("error loading the auth key %q: %s", mk.ID, err))
+						}
+					}
+				}
+
+				ah := auth.MakeHandler(auth.New(st, cfg.Auth.ClusterSecret, m, logger))
+				mux.Handle("/admin/", ah)
+				mux.Handle("/presign", ah)
+
+				handler = auth.Middleware(st, cfg.Auth.ClusterSecret, s, mux)
+			}
+
+			http.Handle("/", handlers.CustomLoggingHandler(os.Stdout, handler, func(writer io.Writer, params handlers.LogFormatterParams) {
 				username := "-"
 				if params.URL.User != nil {
 					if name := params.URL.User.Username(); name != "" {
@@ -174,7 +287,7 @@ var (
 
 			svr := &http.Server{
 				Addr:    fmt.Sprintf(":%d", cfg.Port),
-				Handler: handlers.LoggingHandler(os.Stdout, mux),
+				Handler: handlers.LoggingHandler(os.Stdout, handler),
 			}
 
 			go func() {
@@ -183,13 +296,24 @@ var (
 				errs <- fmt.Errorf("%s", <-c)
 			}()
 
+			if cfg.TLS.Enabled() {
+				svr.TLSConfig, err = cfg.TLS.ServerConfig()
+				if err != nil {
+					return fmt.Errorf("error loading the TLS configuration: %s", err)
+				}
+			}
+
 			go func() {
-				logger.Log("port", cfg.Port, "msg", "started storing server")
+				logger.Log("port", cfg.Port, "tls", cfg.TLS.Enabled(), "msg", "started storing server")
+				if svr.TLSConfig != nil {
+					errs <- svr.ListenAndServeTLS("", "")
+					return
+				}
 				errs <- svr.ListenAndServe()
 			}()
 
 			if cfg.Dashboard.Enabled {
-				d := dashboard.New(cfg, m, logger)
+				d := dashboard.New(cfg, m, logger, s)
 
 				dhandler := dhttp.MakeHandler(d, logger)
 
@@ -203,8 +327,19 @@ var (
 					Handler: handlers.LoggingHandler(os.Stdout, dmux),
 				}
 
+				if cfg.Dashboard.TLS.Enabled() {
+					dsvr.TLSConfig, err = cfg.Dashboard.TLS.ServerConfig()
+					if err != nil {
+						return fmt.Errorf("error loading the dashboard TLS configuration: %s", err)
+					}
+				}
+
 				go func() {
-					logger.Log("port", cfg.Dashboard.Port, "msg", "started dashboard server")
+					logger.Log("port", cfg.Dashboard.Port, "tls", cfg.Dashboard.TLS.Enabled(), "msg", "started dashboard server")
+					if dsvr.TLSConfig != nil {
+						errs <- dsvr.ListenAndServeTLS("", "")
+						return
+					}
 					errs <- dsvr.ListenAndServe()
 				}()
 			}
@@ -234,6 +369,12 @@ func init() {
 	serveCmd.PersistentFlags().StringSliceP("volumes", "v", []string{}, "Volumes to store the data, to specify a fixed size for the volume do it as so '/:20G'")
 	viper.BindPFlag("volumes", serveCmd.PersistentFlags().Lookup("volumes"))
 
+	serveCmd.PersistentFlags().Bool("gateway", false, "Join the cluster without volumes, forwarding the writes and reads to the nodes with them")
+	viper.BindPFlag("gateway", serveCmd.PersistentFlags().Lookup("gateway"))
+
+	serveCmd.PersistentFlags().Bool("redirect", false, "Redirect the requests for the files on other nodes to them instead of proxying them")
+	viper.BindPFlag("redirect", serveCmd.PersistentFlags().Lookup("redirect"))
+
 	serveCmd.PersistentFlags().StringP("remote", "r", "", "The URL of a remote Node to join on the cluster")
 	viper.BindPFlag("remote", serveCmd.PersistentFlags().Lookup("remote"))
 
@@ -255,5 +396,56 @@ func init() {
 	serveCmd.PersistentFlags().Int("cache.size", config.DefaultCacheSize, "Size of the cache used to store reference to object location on other nodes")
 	viper.BindPFlag("cache.size", serveCmd.PersistentFlags().Lookup("cache.size"))
 
+	serveCmd.PersistentFlags().Duration("cache.negative-ttl", config.DefaultCacheNegativeTTL, "The time the keys not found on the cluster are cached so the next requests for them do not ask all the nodes again, 0 disables it")
+	viper.BindPFlag("cache.negative-ttl", serveCmd.PersistentFlags().Lookup("cache.negative-ttl"))
+
+	serveCmd.PersistentFlags().String("cache.disk.dir", "", "Directory of the cache of the content of the files served from other nodes, so the most used ones are not requested to them every time. By default there is no cache")
+	viper.BindPFlag("cache.disk.dir", serveCmd.PersistentFlags().Lookup("cache.disk.dir"))
+
+	serveCmd.PersistentFlags().String("cache.disk.size", config.DefaultCacheDiskSize, "Maximum size of the cache on the disk, the least recently used files are removed to fit the new ones")
+	viper.BindPFlag("cache.disk.size", serveCmd.PersistentFlags().Lookup("cache.disk.size"))
+
+	serveCmd.PersistentFlags().Int("coordinator.port", 0, "Port used to elect the leader that coordinates the cluster, it decides the settings of the cluster, to synchronize the replicas of the volumes that left it, the turns of the nodes to create the pending replicas and to rebalance the volumes. All the nodes with it take part on the election. The node without --remote starts the election and the rest are added by the leader. The nodes are connected with mTLS so the tls.cert-file, tls.key-file and tls.ca-file are required. By default there is no election, each node synchronizes and creates the replicas on its own and the volumes are not rebalanced")
+	viper.BindPFlag("coordinator.port", serveCmd.PersistentFlags().Lookup("coordinator.port"))
+
+	serveCmd.PersistentFlags().String("coordinator.dir", "", "Directory where the decisions of the leader are stored so they are kept after a restart. By default they are only kept in memory")
+	viper.BindPFlag("coordinator.dir", serveCmd.PersistentFlags().Lookup("coordinator.dir"))
+
+	serveCmd.PersistentFlags().Duration("trash.retention", 0, "The time the deleted files are kept on the trash, where they can be restored, before purging them. By default they are deleted directly")
+	viper.BindPFlag("trash.retention", serveCmd.PersistentFlags().Lookup("trash.retention"))
+
+	serveCmd.PersistentFlags().String("hash", string(signature.Default), fmt.Sprintf("The hash algorithm used to calculate the Signatures of the files, all the Nodes of the cluster must use the same. Supported ones are %v", signature.Algorithms))
+	viper.BindPFlag("hash", serveCmd.PersistentFlags().Lookup("hash"))
+
+	serveCmd.PersistentFlags().String("encryption.key-file", "", "File with the master keys used to encrypt the files at rest, one '<id>:<base64 32 bytes key>' per line being the last one the current. To rotate the key append a new one, it's read again without restarting and the data keys are wrapped again in the background")
+	viper.BindPFlag("encryption.key-file", serveCmd.PersistentFlags().Lookup("encryption.key-file"))
+
+	serveCmd.PersistentFlags().String("auth.cluster-secret", "", "Secret used by the Nodes of the cluster to authenticate between them, if set all the requests have to be authenticated with it or with one of the auth.keys of the config")
+	viper.BindPFlag("auth.cluster-secret", serveCmd.PersistentFlags().Lookup("auth.cluster-secret"))
+
+	serveCmd.PersistentFlags().String("tls.cert-file", "", "Certificate used to serve the API with TLS and, with the tls.ca-file, to authenticate with the other Nodes")
+	viper.BindPFlag("tls.cert-file", serveCmd.PersistentFlags().Lookup("tls.cert-file"))
+
+	serveCmd.PersistentFlags().String("tls.key-file", "", "Key of the tls.cert-file")
+	viper.BindPFlag("tls.key-file", serveCmd.PersistentFlags().Lookup("tls.key-file"))
+
+	serveCmd.PersistentFlags().String("tls.ca-file", "", "CA used to verify the certificates of the Nodes and the clients (mTLS)")
+	viper.BindPFlag("tls.ca-file", serveCmd.PersistentFlags().Lookup("tls.ca-file"))
+
+	serveCmd.PersistentFlags().Bool("tls.require-client-cert", false, "Reject the clients without a certificate signed by the tls.ca-file")
+	viper.BindPFlag("tls.require-client-cert", serveCmd.PersistentFlags().Lookup("tls.require-client-cert"))
+
+	serveCmd.PersistentFlags().String("tls.server-name", "", "Name used to verify the certificates of the other Nodes, by default their IP")
+	viper.BindPFlag("tls.server-name", serveCmd.PersistentFlags().Lookup("tls.server-name"))
+
+	serveCmd.PersistentFlags().String("dashboard.tls.cert-file", "", "Certificate used to serve the dashboard with TLS")
+	viper.BindPFlag("dashboard.tls.cert-file", serveCmd.PersistentFlags().Lookup("dashboard.tls.cert-file"))
+
+	serveCmd.PersistentFlags().String("dashboard.tls.key-file", "", "Key of the dashboard.tls.cert-file")
+	viper.BindPFlag("dashboard.tls.key-file", serveCmd.PersistentFlags().Lookup("dashboard.tls.key-file"))
+
+	serveCmd.PersistentFlags().StringSlice("memberlist.keys", []string{}, "Base64 keys (16, 24 or 32 bytes) used to encrypt the gossip, the first one is used to encrypt and all of them to decrypt. They are required with the auth.cluster-secret")
+	viper.BindPFlag("memberlist.keys", serveCmd.PersistentFlags().Lookup("memberlist.keys"))
+
 	RootCmd.AddCommand(serveCmd)
 }
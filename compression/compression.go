@@ -0,0 +1,133 @@
+// Package compression has the logic to compress the files
+// when stored and decompress them when read with the
+// different supported algorithms
+package compression
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Algorithm is a compression algorithm, the value
+// is also the one used on the 'Content-Encoding'
+type Algorithm string
+
+// List of all the supported Algorithms
+const (
+	None Algorithm = ""
+	Gzip Algorithm = "gzip"
+	Zstd Algorithm = "zstd"
+)
+
+// Algorithms is the list of all the supported algorithms
+var Algorithms = []Algorithm{Gzip, Zstd}
+
+// Parse returns the Algorithm with the name s, if
+// s is empty or 'none' the None Algorithm is returned
+func Parse(s string) (Algorithm, error) {
+	if s == "" || strings.ToLower(s) == "none" {
+		return None, nil
+	}
+	for _, a := range Algorithms {
+		if string(a) == strings.ToLower(s) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("invalid compression algorithm %q, the supported ones are %v", s, Algorithms)
+}
+
+// IsAccepted checks if the Algorithm is accepted by the value of
+// an 'Accept-Encoding' Header, the ones with 'q=0' are not accepted
+func (a Algorithm) IsAccepted(ae string) bool {
+	if a == None {
+		return false
+	}
+	for _, e := range strings.Split(ae, ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(e), ";")
+		if strings.ToLower(strings.TrimSpace(enc)) != string(a) {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// NewWriter returns a io.WriteCloser that compresses with the Algorithm
+// all the content written to it and writes it to w. It has to be closed
+// to flush all the content to w, but w is not closed
+func (a Algorithm) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	switch a {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	default:
+		return nopWriteCloser{Writer: w}, nil
+	}
+}
+
+// NewReader returns a Reader that decompresses the content
+// of r with the Algorithm
+func (a Algorithm) NewReader(r io.ReadCloser) *Reader {
+	return &Reader{
+		alg: a,
+		raw: r,
+	}
+}
+
+// Reader decompresses the content while it's read but it
+// also allows to read the content without decompressing it
+// with Raw. Only one of the two can be used
+type Reader struct {
+	alg Algorithm
+	raw io.ReadCloser
+	dec io.ReadCloser
+}
+
+// Algorithm returns the Algorithm of the content
+func (r *Reader) Algorithm() Algorithm { return r.alg }
+
+// Raw returns the content without decompressing it
+func (r *Reader) Raw() io.ReadCloser { return r.raw }
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.dec == nil {
+		switch r.alg {
+		case Gzip:
+			gr, err := gzip.NewReader(r.raw)
+			if err != nil {
+				return 0, err
+			}
+			r.dec = gr
+		case Zstd:
+			zr, err := zstd.NewReader(r.raw)
+			if err != nil {
+				return 0, err
+			}
+			r.dec = zr.IOReadCloser()
+		default:
+			r.dec = io.NopCloser(r.raw)
+		}
+	}
+	return r.dec.Read(p)
+}
+
+// Close closes the decompressor (if used) and the
+// underlying content
+func (r *Reader) Close() error {
+	if r.dec != nil {
+		r.dec.Close()
+	}
+	return r.raw.Close()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
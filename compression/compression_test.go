@@ -0,0 +1,66 @@
+package compression_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/compression"
+)
+
+func TestParse(t *testing.T) {
+	a, err := compression.Parse("")
+	require.NoError(t, err)
+	assert.Equal(t, compression.None, a)
+
+	a, err = compression.Parse("none")
+	require.NoError(t, err)
+	assert.Equal(t, compression.None, a)
+
+	a, err = compression.Parse("ZSTD")
+	require.NoError(t, err)
+	assert.Equal(t, compression.Zstd, a)
+
+	_, err = compression.Parse("lz4")
+	assert.EqualError(t, err, fmt.Sprintf("invalid compression algorithm %q, the supported ones are %v", "lz4", compression.Algorithms))
+}
+
+func TestIsAccepted(t *testing.T) {
+	assert.True(t, compression.Gzip.IsAccepted("gzip"))
+	assert.True(t, compression.Gzip.IsAccepted("deflate, GZIP;q=0.5"))
+	assert.True(t, compression.Zstd.IsAccepted("gzip, zstd"))
+	assert.False(t, compression.Zstd.IsAccepted("gzip"))
+	assert.False(t, compression.Gzip.IsAccepted("gzip;q=0"))
+	assert.False(t, compression.None.IsAccepted("identity"))
+}
+
+func TestWriterReader(t *testing.T) {
+	content := strings.Repeat("content", 100)
+	for _, a := range append(compression.Algorithms, compression.None) {
+		t.Run(fmt.Sprintf("Algorithm(%s)", a), func(t *testing.T) {
+			var b bytes.Buffer
+			w, err := a.NewWriter(&b)
+			require.NoError(t, err)
+
+			_, err = io.WriteString(w, content)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+
+			if a != compression.None {
+				assert.Less(t, b.Len(), len(content))
+			}
+
+			r := a.NewReader(io.NopCloser(bytes.NewReader(b.Bytes())))
+			assert.Equal(t, a, r.Algorithm())
+
+			d, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(d))
+			require.NoError(t, r.Close())
+		})
+	}
+}
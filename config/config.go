@@ -1,11 +1,19 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"code.cloudfoundry.org/bytefmt"
 	"github.com/spf13/viper"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/lifecycle"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/util"
 	"github.com/xescugc/rebost/volume"
 	"github.com/xyproto/randomstring"
@@ -22,6 +30,14 @@ const (
 	// DefaultCacheSize is the default size of the cache
 	DefaultCacheSize = 200
 
+	// DefaultCacheNegativeTTL is the default time the
+	// keys not found on the cluster are cached
+	DefaultCacheNegativeTTL = 5 * time.Second
+
+	// DefaultCacheDiskSize is the default maximum size of
+	// the cache on the disk of the files of other Nodes
+	DefaultCacheDiskSize = "1GB"
+
 	// DefaultVolumeDowntime is the default time
 	// a Volume can be down before start replicating
 	DefaultVolumeDowntime = 2 * time.Minute
@@ -43,6 +59,17 @@ type Config struct {
 	// Remote is the URL of another Node
 	Remote string `mapstructure:"remote"`
 
+	// Gateway makes the Node join the cluster without any
+	// volumes, it forwards the writes to the Nodes with
+	// storage and serves the reads from them
+	Gateway bool `mapstructure:"gateway"`
+
+	// Redirect answers the requests for the files on other
+	// Nodes with a redirect to them instead of proxying
+	// them, it can be changed per request with the
+	// X-Rebost-Redirect Header
+	Redirect bool `mapstructure:"redirect"`
+
 	// Replica is the default number of replicas
 	// that each file will have if none specified
 	// If set to -1 it'll not try to replicate any
@@ -59,8 +86,34 @@ type Config struct {
 	// Name is the name the Node will have inside of the Memberlist
 	Name string `mapstructure:"name"`
 
+	// Hash is the hash algorithm used to calculate the Signatures
+	// of the files, all the Nodes of the cluster must use the same
+	Hash string `mapstructure:"hash"`
+
+	// Classes are the storage classes that can be used
+	// to store the files, the key is the name of the class
+	Classes map[string]Class `mapstructure:"classes"`
+
+	// Quotas are the limits of the files
+	// with a key prefix on all the cluster
+	Quotas []Quota `mapstructure:"quotas"`
+
+	// Lifecycle are the rules applied to the files
+	// with a key prefix by each volume
+	Lifecycle []Lifecycle `mapstructure:"lifecycle"`
+
 	Cache Cache
 
+	Coordinator Coordinator
+
+	Trash Trash
+
+	Encryption Encryption
+
+	Auth Auth
+
+	TLS TLS
+
 	Memberlist Memberlist
 
 	Dashboard Dashboard
@@ -70,17 +123,172 @@ type Config struct {
 // the name has  moved to the main part of the config as it's more clear there
 type Memberlist struct {
 	Port int `mapstructure:"port"`
+
+	// Keys are the base64 keys used to encrypt the gossip,
+	// the first one is used to encrypt and all of them
+	// to decrypt so they can be rotated
+	Keys []string `mapstructure:"keys"`
+}
+
+// SecretKeys returns the decoded Keys
+func (m Memberlist) SecretKeys() ([][]byte, error) {
+	keys := make([][]byte, 0, len(m.Keys))
+	for i, k := range m.Keys {
+		b, err := base64.StdEncoding.DecodeString(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memberlist key %d: %w", i, err)
+		}
+		if l := len(b); l != 16 && l != 24 && l != 32 {
+			return nil, fmt.Errorf("invalid memberlist key %d, it has to be 16, 24 or 32 bytes long", i)
+		}
+		keys = append(keys, b)
+	}
+	return keys, nil
 }
 
 // Dashboard is the configuration required for the dashboard
 type Dashboard struct {
 	Port    int  `mapstructure:"port"`
 	Enabled bool `mapstructure:"enabled"`
+
+	TLS TLS `mapstructure:"tls"`
+}
+
+// Class is the configuration of a storage class
+type Class struct {
+	// Compression is the algorithm used to compress
+	// the files of the class, by default none
+	Compression string `mapstructure:"compression"`
+}
+
+// Quota is the configuration of the limit of
+// the files with the Prefix
+type Quota struct {
+	Prefix string `mapstructure:"prefix"`
+
+	// Size is the maximum size, like 10GB,
+	// if empty there is no limit
+	Size string `mapstructure:"size"`
+
+	// Count is the maximum number of files,
+	// if 0 there is no limit
+	Count int `mapstructure:"count"`
+}
+
+// Lifecycle is the configuration of the lifecycle of the files with the
+// Prefix, of the Bucket if set. All the days are since the file was created
+type Lifecycle struct {
+	Prefix string `mapstructure:"prefix"`
+	Bucket string `mapstructure:"bucket"`
+
+	// ExpireDays is the number of days after which
+	// the files are deleted, if 0 they do not expire
+	ExpireDays int `mapstructure:"expire-days"`
+
+	// Replica is the number of replicas the files are reduced
+	// to after the ReplicaDays, if 0 they are not reduced
+	Replica     int `mapstructure:"replica"`
+	ReplicaDays int `mapstructure:"replica-days"`
+
+	// Class is the storage class the files are moved
+	// to after the ClassDays, if empty they are not moved
+	Class     string `mapstructure:"class"`
+	ClassDays int    `mapstructure:"class-days"`
+}
+
+// prefix returns the key prefix of the files of the Lifecycle
+func (l Lifecycle) prefix() string {
+	if l.Bucket != "" {
+		return bucket.Key(l.Bucket, l.Prefix)
+	}
+	return l.Prefix
+}
+
+// Encryption is the configuration required to encrypt the files at rest
+type Encryption struct {
+	// KeyFile is the path to the file with the master keys,
+	// if empty the files are not encrypted
+	KeyFile string `mapstructure:"key-file"`
+}
+
+// Auth is the configuration required to authenticate
+// and authorize the requests
+type Auth struct {
+	// ClusterSecret is the secret used by the Nodes to authenticate
+	// between them, if empty the authentication is disabled
+	ClusterSecret string `mapstructure:"cluster-secret"`
+
+	// Keys are the API keys that can access the API
+	Keys []Key `mapstructure:"keys"`
+}
+
+// Key is the configuration of an API key
+type Key struct {
+	ID          string   `mapstructure:"id"`
+	Secret      string   `mapstructure:"secret"`
+	Permissions []string `mapstructure:"permissions"`
+	Prefix      string   `mapstructure:"prefix"`
+}
+
+// Trash is the configuration of the deleted files
+type Trash struct {
+	// Retention is the time the deleted files are kept
+	// on the trash before purging them, if 0 the
+	// files are deleted directly
+	Retention time.Duration `mapstructure:"retention"`
 }
 
 // Cache is the configuration required for the cache
 type Cache struct {
 	Size int `mapstructure:"size"`
+
+	// NegativeTTL is the time the keys not found on the
+	// cluster are cached as missing, if 0 they are not
+	NegativeTTL time.Duration `mapstructure:"negative-ttl"`
+
+	Disk CacheDisk `mapstructure:"disk"`
+}
+
+// CacheDisk is the configuration of the cache on the
+// disk of the content of the files served from other Nodes
+type CacheDisk struct {
+	// Dir is where the content is stored,
+	// if empty there is no cache
+	Dir string `mapstructure:"dir"`
+
+	// Size is the maximum size, like 10GB
+	Size string `mapstructure:"size"`
+}
+
+// Enabled checks if the CacheDisk has to be used
+func (c CacheDisk) Enabled() bool {
+	return c.Dir != ""
+}
+
+// Bytes returns the Size in bytes
+func (c CacheDisk) Bytes() int64 {
+	// It's already validated on the New
+	s, _ := bytefmt.ToBytes(c.Size)
+	return int64(s)
+}
+
+// Coordinator is the configuration of the election of the
+// leader that coordinates the cluster
+type Coordinator struct {
+	// Port is the one used to elect the leader, with
+	// mTLS, if 0 there is no election. The Node without
+	// Remote is the one that starts the election
+	Port int `mapstructure:"port"`
+
+	// Dir is where the Decisions of the leader are stored,
+	// if empty they are only kept in memory
+	Dir string `mapstructure:"dir"`
+}
+
+// Enabled checks if the Node takes part
+// on the election of the leader
+func (c Coordinator) Enabled() bool {
+	return c.Port != 0
 }
 
 // New returns a new Config from the viper.Viper, the ENV variables
@@ -99,6 +307,9 @@ func New(v *viper.Viper) (*Config, error) {
 	v.SetDefault("replica", DefaultReplica)
 	v.SetDefault("volume-downtime", DefaultVolumeDowntime)
 	v.SetDefault("cache.size", DefaultCacheSize)
+	v.SetDefault("cache.negative-ttl", DefaultCacheNegativeTTL)
+	v.SetDefault("cache.disk.size", DefaultCacheDiskSize)
+	v.SetDefault("hash", string(signature.Default))
 
 	name := randomstring.HumanFriendlyEnglishString(defaultNameLen)
 	v.SetDefault("name", name)
@@ -121,5 +332,144 @@ func New(v *viper.Viper) (*Config, error) {
 		return nil, fmt.Errorf("the volume-downtime cannot be lower than %s", volume.TickerDuration)
 	}
 
+	alg, err := signature.Parse(cfg.Hash)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Hash = string(alg)
+
+	for n, c := range cfg.Classes {
+		_, err = compression.Parse(c.Compression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid class %q: %w", n, err)
+		}
+	}
+
+	for _, q := range cfg.Quotas {
+		if q.Size != "" {
+			_, err = bytefmt.ToBytes(q.Size)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quota %q: %w", q.Prefix, err)
+			}
+		}
+		if q.Count < 0 {
+			return nil, fmt.Errorf("invalid quota %q: the count can not be negative", q.Prefix)
+		}
+	}
+
+	for _, l := range cfg.Lifecycle {
+		if l.ExpireDays < 0 || l.ReplicaDays < 0 || l.ClassDays < 0 {
+			return nil, fmt.Errorf("invalid lifecycle %q: the days can not be negative", l.prefix())
+		}
+		if l.Replica < 0 {
+			return nil, fmt.Errorf("invalid lifecycle %q: the replica can not be negative", l.prefix())
+		}
+		if _, ok := cfg.Classes[l.Class]; !ok && l.Class != "" {
+			return nil, fmt.Errorf("invalid lifecycle %q: unknown class %q", l.prefix(), l.Class)
+		}
+		if l.ExpireDays == 0 && l.Replica == 0 && l.Class == "" {
+			return nil, fmt.Errorf("invalid lifecycle %q: it has no expiration, replica or class", l.prefix())
+		}
+	}
+
+	if cfg.Gateway && len(cfg.Volumes) != 0 {
+		return nil, errors.New("a gateway can not have volumes")
+	}
+
+	if cfg.Trash.Retention < 0 {
+		return nil, errors.New("the trash.retention can not be negative")
+	}
+
+	if cfg.Cache.NegativeTTL < 0 {
+		return nil, errors.New("the cache.negative-ttl can not be negative")
+	}
+
+	if cfg.Coordinator.Port < 0 {
+		return nil, errors.New("the coordinator.port can not be negative")
+	}
+
+	if cfg.Cache.Disk.Enabled() {
+		_, err = bytefmt.ToBytes(cfg.Cache.Disk.Size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache.disk.size: %w", err)
+		}
+	}
+
+	if err = cfg.TLS.validate(); err != nil {
+		return nil, fmt.Errorf("invalid tls: %w", err)
+	}
+
+	// The Decisions of the leader are replicated with
+	// the Raft log, so only the Nodes with a certificate
+	// signed by the CA can take part on the election
+	if cfg.Coordinator.Enabled() && (!cfg.TLS.Enabled() || cfg.TLS.CAFile == "") {
+		return nil, errors.New("the tls.cert-file, tls.key-file and tls.ca-file are required to use the coordinator.port")
+	}
+
+	if err = cfg.Dashboard.TLS.validate(); err != nil {
+		return nil, fmt.Errorf("invalid dashboard.tls: %w", err)
+	}
+
+	if _, err = cfg.Memberlist.SecretKeys(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Auth.Keys) != 0 && cfg.Auth.ClusterSecret == "" {
+		return nil, errors.New("the auth.cluster-secret is required to use the auth.keys")
+	}
+
+	// The Buckets (with their Policy) and the State of the Nodes
+	// are gossiped, so without the memberlist.keys anyone that
+	// can reach the memberlist.port could change them
+	if cfg.Auth.ClusterSecret != "" && len(cfg.Memberlist.Keys) == 0 {
+		return nil, errors.New("the memberlist.keys are required to use the auth.cluster-secret")
+	}
+
 	return &cfg, nil
 }
+
+// Compressions returns the compression Algorithm
+// of each one of the Classes
+func (c *Config) Compressions() map[string]compression.Algorithm {
+	cs := make(map[string]compression.Algorithm, len(c.Classes))
+	for n, cl := range c.Classes {
+		// It's already validated on the New
+		cs[n], _ = compression.Parse(cl.Compression)
+	}
+	return cs
+}
+
+// PrefixQuotas returns the quota.Quota
+// of each one of the Quotas
+func (c *Config) PrefixQuotas() []quota.Quota {
+	qs := make([]quota.Quota, 0, len(c.Quotas))
+	for _, q := range c.Quotas {
+		// It's already validated on the New
+		s, _ := bytefmt.ToBytes(q.Size)
+		qs = append(qs, quota.Quota{
+			Prefix: q.Prefix,
+			Size:   int64(s),
+			Count:  q.Count,
+		})
+	}
+	return qs
+}
+
+// LifecycleRules returns the lifecycle.Rule
+// of each one of the Lifecycle
+func (c *Config) LifecycleRules() []lifecycle.Rule {
+	const day = 24 * time.Hour
+
+	rs := make([]lifecycle.Rule, 0, len(c.Lifecycle))
+	for _, l := range c.Lifecycle {
+		rs = append(rs, lifecycle.Rule{
+			Prefix:       l.prefix(),
+			Expiration:   time.Duration(l.ExpireDays) * day,
+			Replica:      l.Replica,
+			ReplicaAfter: time.Duration(l.ReplicaDays) * day,
+			Class:        l.Class,
+			ClassAfter:   time.Duration(l.ClassDays) * day,
+		})
+	}
+	return rs
+}
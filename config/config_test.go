@@ -8,7 +8,11 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/compression"
 	"github.com/xescugc/rebost/config"
+	"github.com/xescugc/rebost/lifecycle"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/volume"
 )
 
@@ -20,7 +24,10 @@ func TestNew(t *testing.T) {
 		assert.NotEmpty(t, cfg.Memberlist.Port)
 		assert.Equal(t, config.DefaultReplica, cfg.Replica)
 		assert.Equal(t, config.DefaultCacheSize, cfg.Cache.Size)
+		assert.Equal(t, config.DefaultCacheNegativeTTL, cfg.Cache.NegativeTTL)
+		assert.False(t, cfg.Cache.Disk.Enabled())
 		assert.Equal(t, config.DefaultVolumeDowntime, cfg.VolumeDowntime)
+		assert.Equal(t, string(signature.Default), cfg.Hash)
 	})
 	t.Run("InvalidVolumeDowntime", func(t *testing.T) {
 		v := viper.New()
@@ -28,4 +35,198 @@ func TestNew(t *testing.T) {
 		_, err := config.New(v)
 		assert.EqualError(t, err, fmt.Sprintf("the volume-downtime cannot be lower than %s", volume.TickerDuration))
 	})
+	t.Run("Hash", func(t *testing.T) {
+		v := viper.New()
+		v.Set("hash", "SHA256")
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, string(signature.SHA256), cfg.Hash)
+	})
+	t.Run("Classes", func(t *testing.T) {
+		v := viper.New()
+		v.Set("classes", map[string]interface{}{
+			"logs": map[string]interface{}{"compression": "zstd"},
+			"raw":  map[string]interface{}{"compression": "none"},
+		})
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]config.Class{"logs": {Compression: "zstd"}, "raw": {Compression: "none"}}, cfg.Classes)
+		assert.Equal(t, map[string]compression.Algorithm{"logs": compression.Zstd, "raw": compression.None}, cfg.Compressions())
+	})
+	t.Run("InvalidClass", func(t *testing.T) {
+		v := viper.New()
+		v.Set("classes", map[string]interface{}{
+			"logs": map[string]interface{}{"compression": "lz4"},
+		})
+		_, err := config.New(v)
+		assert.EqualError(t, err, fmt.Sprintf("invalid class \"logs\": invalid compression algorithm %q, the supported ones are %v", "lz4", compression.Algorithms))
+	})
+	t.Run("Quotas", func(t *testing.T) {
+		v := viper.New()
+		v.Set("quotas", []map[string]interface{}{
+			{"prefix": "logs/", "size": "1KB", "count": 10},
+			{"prefix": "tmp/", "count": 2},
+		})
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, []config.Quota{{Prefix: "logs/", Size: "1KB", Count: 10}, {Prefix: "tmp/", Count: 2}}, cfg.Quotas)
+		assert.Equal(t, []quota.Quota{{Prefix: "logs/", Size: 1024, Count: 10}, {Prefix: "tmp/", Count: 2}}, cfg.PrefixQuotas())
+	})
+	t.Run("InvalidQuota", func(t *testing.T) {
+		v := viper.New()
+		v.Set("quotas", []map[string]interface{}{
+			{"prefix": "logs/", "size": "potato"},
+		})
+		_, err := config.New(v)
+		assert.Error(t, err)
+	})
+	t.Run("Lifecycle", func(t *testing.T) {
+		v := viper.New()
+		v.Set("classes", map[string]interface{}{
+			"cold": map[string]interface{}{"compression": "zstd"},
+		})
+		v.Set("lifecycle", []map[string]interface{}{
+			{"prefix": "logs/", "expire-days": 30, "replica": 1, "replica-days": 7, "class": "cold", "class-days": 14},
+			{"bucket": "tmp", "expire-days": 1},
+		})
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, []config.Lifecycle{
+			{Prefix: "logs/", ExpireDays: 30, Replica: 1, ReplicaDays: 7, Class: "cold", ClassDays: 14},
+			{Bucket: "tmp", ExpireDays: 1},
+		}, cfg.Lifecycle)
+		assert.Equal(t, []lifecycle.Rule{
+			{Prefix: "logs/", Expiration: 30 * 24 * time.Hour, Replica: 1, ReplicaAfter: 7 * 24 * time.Hour, Class: "cold", ClassAfter: 14 * 24 * time.Hour},
+			{Prefix: "@tmp/", Expiration: 24 * time.Hour},
+		}, cfg.LifecycleRules())
+	})
+	t.Run("InvalidLifecycle", func(t *testing.T) {
+		tests := []struct {
+			Name      string
+			Lifecycle map[string]interface{}
+			Err       string
+		}{
+			{Name: "NegativeDays", Lifecycle: map[string]interface{}{"prefix": "logs/", "expire-days": -1}, Err: `invalid lifecycle "logs/": the days can not be negative`},
+			{Name: "NegativeReplica", Lifecycle: map[string]interface{}{"prefix": "logs/", "replica": -1}, Err: `invalid lifecycle "logs/": the replica can not be negative`},
+			{Name: "UnknownClass", Lifecycle: map[string]interface{}{"prefix": "logs/", "class": "cold"}, Err: `invalid lifecycle "logs/": unknown class "cold"`},
+			{Name: "NoAction", Lifecycle: map[string]interface{}{"bucket": "logs"}, Err: `invalid lifecycle "@logs/": it has no expiration, replica or class`},
+		}
+		for _, tt := range tests {
+			t.Run(tt.Name, func(t *testing.T) {
+				v := viper.New()
+				v.Set("lifecycle", []map[string]interface{}{tt.Lifecycle})
+				_, err := config.New(v)
+				assert.EqualError(t, err, tt.Err)
+			})
+		}
+	})
+	t.Run("Trash", func(t *testing.T) {
+		v := viper.New()
+		v.Set("trash.retention", "24h")
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, cfg.Trash.Retention)
+	})
+	t.Run("InvalidTrash", func(t *testing.T) {
+		v := viper.New()
+		v.Set("trash.retention", "-1h")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "the trash.retention can not be negative")
+	})
+	t.Run("InvalidCacheNegativeTTL", func(t *testing.T) {
+		v := viper.New()
+		v.Set("cache.negative-ttl", "-1s")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "the cache.negative-ttl can not be negative")
+	})
+	t.Run("Gateway", func(t *testing.T) {
+		v := viper.New()
+		v.Set("gateway", true)
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.True(t, cfg.Gateway)
+		assert.Empty(t, cfg.Volumes)
+	})
+	t.Run("InvalidGateway", func(t *testing.T) {
+		v := viper.New()
+		v.Set("gateway", true)
+		v.Set("volumes", []string{"/tmp/v1"})
+		_, err := config.New(v)
+		assert.EqualError(t, err, "a gateway can not have volumes")
+	})
+	t.Run("CacheDisk", func(t *testing.T) {
+		v := viper.New()
+		v.Set("cache.disk.dir", "/tmp/cache")
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.True(t, cfg.Cache.Disk.Enabled())
+		assert.Equal(t, int64(1024*1024*1024), cfg.Cache.Disk.Bytes())
+	})
+	t.Run("InvalidCacheDisk", func(t *testing.T) {
+		v := viper.New()
+		v.Set("cache.disk.dir", "/tmp/cache")
+		v.Set("cache.disk.size", "potato")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "invalid cache.disk.size: byte quantity must be a positive integer with a unit of measurement like M, MB, MiB, G, GiB, or GB")
+	})
+	t.Run("Coordinator", func(t *testing.T) {
+		v := viper.New()
+		v.Set("coordinator.port", 4000)
+		v.Set("coordinator.dir", "/tmp/raft")
+		v.Set("tls.cert-file", "/tmp/cert.pem")
+		v.Set("tls.key-file", "/tmp/key.pem")
+		v.Set("tls.ca-file", "/tmp/ca.pem")
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.True(t, cfg.Coordinator.Enabled())
+		assert.Equal(t, config.Coordinator{Port: 4000, Dir: "/tmp/raft"}, cfg.Coordinator)
+	})
+	t.Run("InvalidCoordinator", func(t *testing.T) {
+		v := viper.New()
+		v.Set("coordinator.port", -1)
+		_, err := config.New(v)
+		assert.EqualError(t, err, "the coordinator.port can not be negative")
+	})
+	t.Run("CoordinatorWithoutTLS", func(t *testing.T) {
+		v := viper.New()
+		v.Set("coordinator.port", 4000)
+		v.Set("tls.cert-file", "/tmp/cert.pem")
+		v.Set("tls.key-file", "/tmp/key.pem")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "the tls.cert-file, tls.key-file and tls.ca-file are required to use the coordinator.port")
+	})
+	t.Run("Auth", func(t *testing.T) {
+		v := viper.New()
+		v.Set("auth.cluster-secret", "secret")
+		v.Set("auth.keys", []interface{}{
+			map[string]interface{}{"id": "logs", "secret": "pass", "permissions": []string{"read", "write"}, "prefix": "logs/"},
+		})
+		v.Set("memberlist.keys", []string{"MDEyMzQ1Njc4OWFiY2RlZg=="})
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, config.Auth{
+			ClusterSecret: "secret",
+			Keys:          []config.Key{{ID: "logs", Secret: "pass", Permissions: []string{"read", "write"}, Prefix: "logs/"}},
+		}, cfg.Auth)
+	})
+	t.Run("AuthWithoutClusterSecret", func(t *testing.T) {
+		v := viper.New()
+		v.Set("auth.keys", []interface{}{
+			map[string]interface{}{"id": "logs", "secret": "pass", "permissions": []string{"read"}},
+		})
+		_, err := config.New(v)
+		assert.EqualError(t, err, "the auth.cluster-secret is required to use the auth.keys")
+	})
+	t.Run("AuthWithoutMemberlistKeys", func(t *testing.T) {
+		v := viper.New()
+		v.Set("auth.cluster-secret", "secret")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "the memberlist.keys are required to use the auth.cluster-secret")
+	})
+	t.Run("InvalidHash", func(t *testing.T) {
+		v := viper.New()
+		v.Set("hash", "md5")
+		_, err := config.New(v)
+		assert.EqualError(t, err, fmt.Sprintf("invalid hash algorithm %q, the supported ones are %v", "md5", signature.Algorithms))
+	})
 }
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLS is the configuration required to use TLS
+type TLS struct {
+	// CertFile and KeyFile are the certificate and the
+	// key used by the server and, if there is a CAFile,
+	// also by the client to authenticate
+	CertFile string `mapstructure:"cert-file"`
+	KeyFile  string `mapstructure:"key-file"`
+
+	// CAFile is the CA used to verify the certificates of the
+	// servers and the certificates of the clients
+	CAFile string `mapstructure:"ca-file"`
+
+	// RequireClientCert makes the server reject the clients
+	// without a certificate signed by the CAFile, by default
+	// the certificates are only verified if sent
+	RequireClientCert bool `mapstructure:"require-client-cert"`
+
+	// ServerName is the name used to verify the certificates
+	// of the servers, by default the host is used
+	ServerName string `mapstructure:"server-name"`
+}
+
+// Enabled checks if TLS is configured
+func (t TLS) Enabled() bool { return t.CertFile != "" }
+
+func (t TLS) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("the cert-file and the key-file are both required")
+	}
+	if !t.Enabled() && (t.CAFile != "" || t.RequireClientCert) {
+		return errors.New("the cert-file and the key-file are required to use the ca-file")
+	}
+	if t.RequireClientCert && t.CAFile == "" {
+		return errors.New("the ca-file is required to require client certificates")
+	}
+	return nil
+}
+
+// ServerConfig returns the tls.Config for the server
+func (t TLS) ServerConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if t.CAFile != "" {
+		cp, err := loadCertPool(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = cp
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if t.RequireClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return cfg, nil
+}
+
+// ClientConfig returns the tls.Config for the clients
+// used to connect to the other Nodes
+func (t TLS) ClientConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName: t.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if t.CAFile != "" {
+		cp, err := loadCertPool(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = cp
+
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func loadCertPool(p string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := x509.NewCertPool()
+	if !cp.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificates found on %q", p)
+	}
+
+	return cp, nil
+}
@@ -0,0 +1,235 @@
+package config_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/config"
+)
+
+// certs has the paths to the generated certificates
+type certs struct {
+	CA         string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+}
+
+// generateCerts generates on dir a CA and a server
+// and client certificates signed by it
+func generateCerts(t *testing.T, dir string) certs {
+	t.Helper()
+
+	cak, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "rebost-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	cab, err := x509.CreateCertificate(rand.Reader, ca, ca, &cak.PublicKey, cak)
+	require.NoError(t, err)
+
+	ca, err = x509.ParseCertificate(cab)
+	require.NoError(t, err)
+
+	c := certs{
+		CA:         filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	writePEM(t, c.CA, "CERTIFICATE", cab)
+
+	sign := func(sn int64, eku x509.ExtKeyUsage, certPath, keyPath string) {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(sn),
+			Subject:      pkix.Name{CommonName: "rebost"},
+			DNSNames:     []string{"rebost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{eku},
+		}
+		b, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &k.PublicKey, cak)
+		require.NoError(t, err)
+
+		kb, err := x509.MarshalECPrivateKey(k)
+		require.NoError(t, err)
+
+		writePEM(t, certPath, "CERTIFICATE", b)
+		writePEM(t, keyPath, "EC PRIVATE KEY", kb)
+	}
+
+	sign(2, x509.ExtKeyUsageServerAuth, c.ServerCert, c.ServerKey)
+	sign(3, x509.ExtKeyUsageClientAuth, c.ClientCert, c.ClientKey)
+
+	return c
+}
+
+func writePEM(t *testing.T, p, typ string, b []byte) {
+	t.Helper()
+	err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600)
+	require.NoError(t, err)
+}
+
+func TestTLS(t *testing.T) {
+	c := generateCerts(t, t.TempDir())
+
+	newServer := func(t *testing.T, tc config.TLS) *httptest.Server {
+		scfg, err := tc.ServerConfig()
+		require.NoError(t, err)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		server.TLS = scfg
+		server.StartTLS()
+		t.Cleanup(server.Close)
+
+		return server
+	}
+
+	get := func(tc config.TLS, u string) error {
+		ccfg, err := tc.ClientConfig()
+		if err != nil {
+			return err
+		}
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: ccfg}}
+		resp, err := hc.Get(u)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	t.Run("SuccessMutual", func(t *testing.T) {
+		server := newServer(t, config.TLS{CertFile: c.ServerCert, KeyFile: c.ServerKey, CAFile: c.CA, RequireClientCert: true})
+
+		err := get(config.TLS{CertFile: c.ClientCert, KeyFile: c.ClientKey, CAFile: c.CA}, server.URL)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithServerName", func(t *testing.T) {
+		server := newServer(t, config.TLS{CertFile: c.ServerCert, KeyFile: c.ServerKey, CAFile: c.CA, RequireClientCert: true})
+
+		// The server is requested with 'localhost' which is
+		// not on the certificate so the ServerName is used
+		_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+		u := "https://" + net.JoinHostPort("localhost", port)
+
+		err := get(config.TLS{CertFile: c.ClientCert, KeyFile: c.ClientKey, CAFile: c.CA}, u)
+		assert.Error(t, err)
+
+		err = get(config.TLS{CertFile: c.ClientCert, KeyFile: c.ClientKey, CAFile: c.CA, ServerName: "rebost"}, u)
+		require.NoError(t, err)
+	})
+	t.Run("FailsWithoutClientCert", func(t *testing.T) {
+		server := newServer(t, config.TLS{CertFile: c.ServerCert, KeyFile: c.ServerKey, CAFile: c.CA, RequireClientCert: true})
+
+		ccfg, err := config.TLS{CertFile: c.ClientCert, KeyFile: c.ClientKey, CAFile: c.CA}.ClientConfig()
+		require.NoError(t, err)
+
+		ccfg.Certificates = nil
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: ccfg}}
+		_, err = hc.Get(server.URL)
+		assert.Error(t, err)
+	})
+	t.Run("SuccessWithoutClientCertIfNotRequired", func(t *testing.T) {
+		server := newServer(t, config.TLS{CertFile: c.ServerCert, KeyFile: c.ServerKey, CAFile: c.CA})
+
+		ccfg, err := config.TLS{CertFile: c.ClientCert, KeyFile: c.ClientKey, CAFile: c.CA}.ClientConfig()
+		require.NoError(t, err)
+
+		ccfg.Certificates = nil
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: ccfg}}
+		resp, err := hc.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	})
+	t.Run("FailsWithUnknownCA", func(t *testing.T) {
+		server := newServer(t, config.TLS{CertFile: c.ServerCert, KeyFile: c.ServerKey})
+
+		oc := generateCerts(t, t.TempDir())
+
+		err := get(config.TLS{CertFile: oc.ClientCert, KeyFile: oc.ClientKey, CAFile: oc.CA}, server.URL)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewTLS(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		v := viper.New()
+		v.Set("tls.cert-file", "cert.pem")
+		v.Set("tls.key-file", "key.pem")
+		v.Set("tls.ca-file", "ca.pem")
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+		assert.Equal(t, config.TLS{CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem"}, cfg.TLS)
+		assert.True(t, cfg.TLS.Enabled())
+		assert.False(t, cfg.Dashboard.TLS.Enabled())
+	})
+	t.Run("InvalidTLS", func(t *testing.T) {
+		v := viper.New()
+		v.Set("tls.cert-file", "cert.pem")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "invalid tls: the cert-file and the key-file are both required")
+	})
+	t.Run("InvalidDashboardTLS", func(t *testing.T) {
+		v := viper.New()
+		v.Set("dashboard.tls.ca-file", "ca.pem")
+		_, err := config.New(v)
+		assert.EqualError(t, err, "invalid dashboard.tls: the cert-file and the key-file are required to use the ca-file")
+	})
+	t.Run("InvalidRequireClientCert", func(t *testing.T) {
+		v := viper.New()
+		v.Set("tls.cert-file", "cert.pem")
+		v.Set("tls.key-file", "key.pem")
+		v.Set("tls.require-client-cert", true)
+		_, err := config.New(v)
+		assert.EqualError(t, err, "invalid tls: the ca-file is required to require client certificates")
+	})
+	t.Run("MemberlistKeys", func(t *testing.T) {
+		v := viper.New()
+		v.Set("memberlist.keys", []string{"MDEyMzQ1Njc4OWFiY2RlZg=="})
+		cfg, err := config.New(v)
+		require.NoError(t, err)
+
+		keys, err := cfg.Memberlist.SecretKeys()
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("0123456789abcdef")}, keys)
+	})
+	t.Run("InvalidMemberlistKeys", func(t *testing.T) {
+		v := viper.New()
+		v.Set("memberlist.keys", []string{"cG90YXRv"})
+		_, err := config.New(v)
+		assert.EqualError(t, err, "invalid memberlist key 0, it has to be 16, 24 or 32 bytes long")
+	})
+}
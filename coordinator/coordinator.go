@@ -0,0 +1,331 @@
+// Package coordinator elects with Raft one of the Nodes of the cluster as
+// the leader that takes the Decisions to coordinate it, which are logged
+// and replicated to all the Nodes so a new leader can resume from them
+package coordinator
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+)
+
+const (
+	// timeout is the maximum time to wait for a
+	// Decision or a change of the Nodes to be applied
+	timeout = 5 * time.Second
+
+	// retainSnapshots is the number of
+	// snapshots kept on the dir
+	retainSnapshots = 2
+
+	// maxPool is the number of connections
+	// kept open to each one of the Nodes
+	maxPool = 3
+)
+
+var (
+	// ErrNotLeader is returned when a Decision is
+	// taken by a Node that it's not the leader
+	ErrNotLeader = errors.New("not the leader")
+)
+
+// Coordinator is the Node on the election of the leader
+type Coordinator struct {
+	name string
+
+	raft      *raft.Raft
+	fsm       *fsm
+	stream    *streamLayer
+	transport *raft.NetworkTransport
+
+	// store is the one of the dir,
+	// nil if it's only in memory
+	store *raftboltdb.BoltStore
+
+	// onDecision is called with the
+	// Decisions applied on the Node
+	onDecision func(d Decision)
+
+	done chan struct{}
+
+	logger kitlog.Logger
+}
+
+// New returns a Coordinator for the Node with the name that listens on
+// the port and that it's reachable by the others on the addr. The Nodes
+// are connected with mTLS, the server and client have to have the
+// certificate of the Node and the CA used to verify the others. The
+// Decisions are stored on the dir, or only in memory if empty.
+// If bootstrap and there is nothing on the dir it starts a new
+// cluster with only itself, if not it waits for the leader to add it.
+// The onDecision is called with each one of the Decisions once they are
+// applied on the Node, in the same order on all the Nodes
+func New(name string, port int, addr, dir string, bootstrap bool, server, client *tls.Config, onDecision func(d Decision), logger kitlog.Logger) (*Coordinator, error) {
+	logger = kitlog.With(logger, "src", "coordinator", "name", name)
+
+	c := &Coordinator{
+		name:       name,
+		fsm:        newFSM(),
+		onDecision: onDecision,
+		done:       make(chan struct{}),
+		logger:     logger,
+	}
+
+	w := logWriter{logger: logger}
+
+	var (
+		logs   raft.LogStore
+		stable raft.StableStore
+		snaps  raft.SnapshotStore
+		err    error
+	)
+	if dir == "" {
+		st := raft.NewInmemStore()
+		logs, stable = st, st
+		snaps = raft.NewInmemSnapshotStore()
+	} else {
+		err = os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+		c.store, err = raftboltdb.NewBoltStore(filepath.Join(dir, "raft.db"))
+		if err != nil {
+			return nil, fmt.Errorf("error creating the log store: %w", err)
+		}
+		logs, stable = c.store, c.store
+		snaps, err = raft.NewFileSnapshotStore(dir, retainSnapshots, w)
+		if err != nil {
+			c.close()
+			return nil, fmt.Errorf("error creating the snapshot store: %w", err)
+		}
+	}
+
+	c.stream, err = newStreamLayer(port, addr, server, client)
+	if err != nil {
+		c.close()
+		return nil, fmt.Errorf("error creating the transport: %w", err)
+	}
+	c.transport = raft.NewNetworkTransport(c.stream, maxPool, timeout, w)
+
+	rcfg := raft.DefaultConfig()
+	rcfg.LocalID = raft.ServerID(name)
+	rcfg.Logger = hclog.New(&hclog.LoggerOptions{
+		Name:   "raft",
+		Level:  hclog.Warn,
+		Output: w,
+		// The logger already has the time
+		DisableTime: true,
+	})
+
+	if bootstrap {
+		ok, err := raft.HasExistingState(logs, stable, snaps)
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		if !ok {
+			err = raft.BootstrapCluster(rcfg, logs, stable, snaps, c.transport, raft.Configuration{
+				Servers: []raft.Server{
+					{ID: rcfg.LocalID, Address: c.transport.LocalAddr()},
+				},
+			})
+			if err != nil {
+				c.close()
+				return nil, fmt.Errorf("error bootstrapping the cluster: %w", err)
+			}
+		}
+	}
+
+	c.raft, err = raft.NewRaft(rcfg, c.fsm, logs, stable, snaps, c.transport)
+	if err != nil {
+		c.close()
+		return nil, fmt.Errorf("error creating the raft: %w", err)
+	}
+
+	go c.loopApplied()
+	go c.loopLeader()
+
+	return c, nil
+}
+
+// IsLeader checks if the Node is the leader
+func (c *Coordinator) IsLeader() bool {
+	return c.raft.State() == raft.Leader
+}
+
+// Leader returns the name of the leader,
+// empty if there is none
+func (c *Coordinator) Leader() string {
+	_, id := c.raft.LeaderWithID()
+	return string(id)
+}
+
+// Decide logs the d and replicates it to all the Nodes,
+// it can only be done by the leader
+func (c *Coordinator) Decide(d Decision) error {
+	if !c.IsLeader() {
+		return ErrNotLeader
+	}
+
+	d.Leader = c.name
+	d.DecidedAt = time.Now().UTC()
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	f := c.raft.Apply(b, timeout)
+	if err = f.Error(); err != nil {
+		if errors.Is(err, raft.ErrNotLeader) {
+			return ErrNotLeader
+		}
+		return err
+	}
+
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+// Decisions returns the last Decisions applied on the Node
+func (c *Coordinator) Decisions() []Decision {
+	return c.fsm.Decisions()
+}
+
+// Last returns the last Decision of the t applied on the Node, it's
+// only kept for the ones which last is the state of the cluster,
+// like the UpdateSettings or the ScheduleRepairs
+func (c *Coordinator) Last(t DecisionType) (Decision, bool) {
+	return c.fsm.Last(t)
+}
+
+// Reconcile makes the nodes, the address of each Node by its name,
+// the ones that take part on the election of the leader. It's
+// only done by the leader, for the rest it does nothing
+func (c *Coordinator) Reconcile(nodes map[string]string) error {
+	if !c.IsLeader() {
+		return nil
+	}
+
+	f := c.raft.GetConfiguration()
+	if err := f.Error(); err != nil {
+		return err
+	}
+
+	servers := make(map[string]string)
+	for _, s := range f.Configuration().Servers {
+		id := string(s.ID)
+		servers[id] = string(s.Address)
+		if _, ok := nodes[id]; ok || id == c.name {
+			continue
+		}
+		err := c.raft.RemoveServer(s.ID, 0, timeout).Error()
+		if err != nil {
+			return err
+		}
+		c.logger.Log("action", "remove", "node", id)
+	}
+
+	for n, addr := range nodes {
+		if n == c.name || servers[n] == addr {
+			continue
+		}
+		// A Node that can not be reached would make the rest
+		// lose the quorum, so it's added once it's up
+		conn, err := c.stream.Dial(raft.ServerAddress(addr), timeout)
+		if err != nil {
+			c.logger.Log("msg", "node not reachable", "node", n, "error", err.Error())
+			continue
+		}
+		conn.Close()
+
+		err = c.raft.AddVoter(raft.ServerID(n), raft.ServerAddress(addr), 0, timeout).Error()
+		if err != nil {
+			return err
+		}
+		c.logger.Log("action", "add", "node", n, "addr", addr)
+	}
+
+	return nil
+}
+
+// Shutdown stops the Node, if it's the leader
+// it tries to transfer the leadership first
+func (c *Coordinator) Shutdown() error {
+	if c.IsLeader() {
+		// The error is ignored as with only
+		// one Node it can not be transferred
+		c.raft.LeadershipTransfer().Error()
+	}
+
+	err := c.raft.Shutdown().Error()
+	close(c.done)
+	c.close()
+
+	return err
+}
+
+// close closes the transport and the store
+func (c *Coordinator) close() {
+	if c.transport != nil {
+		c.transport.Close()
+	}
+	if c.store != nil {
+		c.store.Close()
+	}
+}
+
+// logWriter writes each line of the
+// logs of raft as a msg of the logger
+type logWriter struct {
+	logger kitlog.Logger
+}
+
+func (l logWriter) Write(p []byte) (int, error) {
+	l.logger.Log("msg", strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+// loopApplied calls the onDecision with the Decisions
+// applied on the Node, in the same order
+func (c *Coordinator) loopApplied() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.fsm.queued:
+			for _, d := range c.fsm.dequeue() {
+				c.onDecision(d)
+			}
+		}
+	}
+}
+
+// loopLeader logs the changes of leadership of the Node
+func (c *Coordinator) loopLeader() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case leader := <-c.raft.LeaderCh():
+			if leader {
+				c.logger.Log("action", "leader")
+			} else {
+				c.logger.Log("action", "follower")
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package coordinator_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/coordinator"
+	"github.com/xescugc/rebost/settings"
+	"github.com/xescugc/rebost/util"
+)
+
+// authority is the CA that signs the certificates of the Nodes
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+// newCA generates a new CA
+func newCA(t *testing.T) *authority {
+	t.Helper()
+
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "rebost-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	b, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &k.PublicKey, k)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(b)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &authority{cert: cert, key: k, pool: pool}
+}
+
+// tlsConfigs returns the server and client tls.Config of
+// a Node with a certificate for 127.0.0.1 signed by the ca
+func (c *authority) tlsConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "rebost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	b, err := x509.CreateCertificate(rand.Reader, tmpl, c.cert, &k.PublicKey, c.key)
+	require.NoError(t, err)
+
+	cert := tls.Certificate{Certificate: [][]byte{b}, PrivateKey: k}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}, ClientCAs: c.pool, MinVersion: tls.VersionTLS12},
+		&tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: c.pool, MinVersion: tls.VersionTLS12}
+}
+
+// newCoordinator returns a Coordinator with the name on a free port,
+// with a certificate signed by the ca, and the channel with the
+// Decisions it applies
+func newCoordinator(t *testing.T, c *authority, name, dir string, bootstrap bool) (*coordinator.Coordinator, string, chan coordinator.Decision) {
+	p, err := util.FreePort()
+	require.NoError(t, err)
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(p))
+	dC := make(chan coordinator.Decision, 10)
+	stc, ctc := c.tlsConfigs(t)
+	co, err := coordinator.New(name, p, addr, dir, bootstrap, stc, ctc, func(d coordinator.Decision) { dC <- d }, kitlog.NewNopLogger())
+	require.NoError(t, err)
+
+	return co, addr, dC
+}
+
+// waitDecision waits for the Decision on the dC
+func waitDecision(t *testing.T, dC chan coordinator.Decision) coordinator.Decision {
+	select {
+	case d := <-dC:
+		return d
+	case <-time.After(10 * time.Second):
+		t.Fatal("the decision was not applied")
+	}
+	return coordinator.Decision{}
+}
+
+func TestCoordinator(t *testing.T) {
+	ca := newCA(t)
+
+	t.Run("Bootstrap", func(t *testing.T) {
+		c, _, dC := newCoordinator(t, ca, "n1", "", true)
+		defer c.Shutdown()
+
+		require.Eventually(t, c.IsLeader, 10*time.Second, 50*time.Millisecond)
+		assert.Equal(t, "n1", c.Leader())
+
+		err := c.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: "vid"})
+		require.NoError(t, err)
+
+		d := waitDecision(t, dC)
+		assert.Equal(t, coordinator.SynchronizeReplicas, d.Type)
+		assert.Equal(t, "vid", d.VolumeID)
+		assert.Equal(t, "n1", d.Leader)
+		assert.Equal(t, []coordinator.Decision{d}, c.Decisions())
+	})
+	t.Run("SlowDecisions", func(t *testing.T) {
+		c, _, dC := newCoordinator(t, ca, "n1", "", true)
+		defer c.Shutdown()
+
+		require.Eventually(t, c.IsLeader, 10*time.Second, 50*time.Millisecond)
+
+		// The Decisions are not read so the onDecision is blocked,
+		// which does not block the ones taken after
+		vids := make([]string, 0, 200)
+		for i := 0; i < 200; i++ {
+			vid := strconv.Itoa(i)
+			err := c.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: vid})
+			require.NoError(t, err)
+			vids = append(vids, vid)
+		}
+
+		for _, vid := range vids {
+			assert.Equal(t, vid, waitDecision(t, dC).VolumeID)
+		}
+	})
+	t.Run("Last", func(t *testing.T) {
+		c, _, dC := newCoordinator(t, ca, "n1", "", true)
+		defer c.Shutdown()
+
+		require.Eventually(t, c.IsLeader, 10*time.Second, 50*time.Millisecond)
+
+		_, ok := c.Last(coordinator.UpdateSettings)
+		assert.False(t, ok)
+
+		for _, d := range []coordinator.Decision{
+			{Type: coordinator.UpdateSettings, Settings: &settings.Settings{Replica: 2}},
+			{Type: coordinator.UpdateSettings, Settings: &settings.Settings{Replica: 3}},
+			{Type: coordinator.SynchronizeReplicas, VolumeID: "vid"},
+		} {
+			require.NoError(t, c.Decide(d))
+			waitDecision(t, dC)
+		}
+
+		d, ok := c.Last(coordinator.UpdateSettings)
+		require.True(t, ok)
+		assert.Equal(t, &settings.Settings{Replica: 3}, d.Settings)
+
+		// Only the ones that are the state
+		// of the cluster are kept
+		_, ok = c.Last(coordinator.SynchronizeReplicas)
+		assert.False(t, ok)
+	})
+	t.Run("NotLeader", func(t *testing.T) {
+		c, _, _ := newCoordinator(t, ca, "n1", "", false)
+		defer c.Shutdown()
+
+		assert.False(t, c.IsLeader())
+		assert.Equal(t, "", c.Leader())
+
+		err := c.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: "vid"})
+		assert.Equal(t, coordinator.ErrNotLeader, err)
+	})
+	t.Run("Reconcile", func(t *testing.T) {
+		c1, _, dC1 := newCoordinator(t, ca, "n1", "", true)
+		defer c1.Shutdown()
+		c2, addr2, dC2 := newCoordinator(t, ca, "n2", "", false)
+		defer c2.Shutdown()
+
+		require.Eventually(t, c1.IsLeader, 10*time.Second, 50*time.Millisecond)
+
+		// Only the leader adds the Nodes
+		require.NoError(t, c2.Reconcile(map[string]string{"n1": "127.0.0.1:1"}))
+		require.NoError(t, c1.Reconcile(map[string]string{"n2": addr2}))
+
+		err := c1.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: "vid"})
+		require.NoError(t, err)
+
+		d1 := waitDecision(t, dC1)
+		d2 := waitDecision(t, dC2)
+		assert.Equal(t, d1, d2)
+		require.Eventually(t, func() bool { return c2.Leader() == "n1" }, 10*time.Second, 50*time.Millisecond)
+
+		// Once removed it no longer
+		// gets the Decisions
+		require.NoError(t, c1.Reconcile(map[string]string{}))
+
+		err = c1.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: "vid2"})
+		require.NoError(t, err)
+
+		waitDecision(t, dC1)
+		assert.Len(t, c1.Decisions(), 2)
+		assert.Len(t, c2.Decisions(), 1)
+	})
+	t.Run("OtherCA", func(t *testing.T) {
+		c1, _, _ := newCoordinator(t, ca, "n1", "", true)
+		defer c1.Shutdown()
+		c2, addr2, _ := newCoordinator(t, newCA(t), "n2", "", false)
+		defer c2.Shutdown()
+
+		require.Eventually(t, c1.IsLeader, 10*time.Second, 50*time.Millisecond)
+
+		// The Node can not be reached without a valid
+		// certificate so it's not added, if not the
+		// Decision would not have the quorum
+		require.NoError(t, c1.Reconcile(map[string]string{"n2": addr2}))
+
+		err := c1.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: "vid"})
+		require.NoError(t, err)
+		assert.Empty(t, c2.Decisions())
+	})
+	t.Run("WithoutTLS", func(t *testing.T) {
+		p, err := util.FreePort()
+		require.NoError(t, err)
+
+		_, err = coordinator.New("n1", p, net.JoinHostPort("127.0.0.1", strconv.Itoa(p)), "", true, nil, nil, func(coordinator.Decision) {}, kitlog.NewNopLogger())
+		assert.EqualError(t, err, "error creating the transport: the TLS is required")
+	})
+	t.Run("Resume", func(t *testing.T) {
+		dir, err := os.MkdirTemp("", "coordinator")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		c, _, dC := newCoordinator(t, ca, "n1", dir, true)
+		require.Eventually(t, c.IsLeader, 10*time.Second, 50*time.Millisecond)
+
+		err = c.Decide(coordinator.Decision{Type: coordinator.SynchronizeReplicas, VolumeID: "vid"})
+		require.NoError(t, err)
+		d := waitDecision(t, dC)
+		require.NoError(t, c.Shutdown())
+
+		// The Decisions on the dir are applied again
+		// and it's not bootstrapped from scratch
+		c, _, dC = newCoordinator(t, ca, "n1", dir, true)
+		defer c.Shutdown()
+
+		assert.Equal(t, d, waitDecision(t, dC))
+		require.Eventually(t, c.IsLeader, 10*time.Second, 50*time.Millisecond)
+		assert.Equal(t, []coordinator.Decision{d}, c.Decisions())
+	})
+}
@@ -0,0 +1,59 @@
+package coordinator
+
+import (
+	"time"
+
+	"github.com/xescugc/rebost/settings"
+)
+
+// DecisionType is the type of the Decisions
+// the leader takes for the cluster
+type DecisionType string
+
+const (
+	// SynchronizeReplicas is taken when a volume left the cluster
+	// for longer than the volume-downtime, all the Nodes have
+	// to synchronize the replicas they shared with it
+	SynchronizeReplicas DecisionType = "synchronize-replicas"
+
+	// UpdateSettings is taken when the Settings of the cluster
+	// are changed, the last one has the ones used by all the Nodes
+	UpdateSettings DecisionType = "update-settings"
+
+	// ScheduleRepairs is taken when the Settings limit the Nodes
+	// that create the pending replicas at the same time, the
+	// last one has the Nodes which turn it is
+	ScheduleRepairs DecisionType = "schedule-repairs"
+
+	// RebalanceVolume is taken when the used space of the volumes
+	// differs more than the Settings allow, the Node of the volume
+	// moves Files of up to the Size to the one of the ToVolumeID
+	RebalanceVolume DecisionType = "rebalance-volume"
+)
+
+// Decision is what the leader decided to do to coordinate the
+// cluster, it's logged and replicated to all the Nodes that
+// apply it in the same order
+type Decision struct {
+	Type DecisionType `json:"type"`
+
+	// VolumeID is the volume it's about
+	VolumeID string `json:"volume_id,omitempty"`
+
+	// ToVolumeID is the volume the Files are moved to
+	ToVolumeID string `json:"to_volume_id,omitempty"`
+
+	// Size is the size of the Files to move
+	Size int `json:"size,omitempty"`
+
+	// Settings are the ones of the cluster
+	Settings *settings.Settings `json:"settings,omitempty"`
+
+	// Nodes are the names of the Nodes it's about
+	Nodes []string `json:"nodes,omitempty"`
+
+	// Leader is the Node that took it
+	Leader string `json:"leader"`
+
+	DecidedAt time.Time `json:"decided_at"`
+}
@@ -0,0 +1,165 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/hashicorp/raft"
+)
+
+// maxDecisions is the number of the last
+// Decisions kept after being applied
+const maxDecisions = 1000
+
+// lastTypes are the DecisionTypes which last Decision is
+// the current state of the cluster, so it's kept even
+// when it's no longer one of the last Decisions
+var lastTypes = map[DecisionType]struct{}{
+	UpdateSettings:  {},
+	ScheduleRepairs: {},
+	RebalanceVolume: {},
+}
+
+// fsm is the raft.FSM with the log of the Decisions
+type fsm struct {
+	mx        sync.RWMutex
+	decisions []Decision
+
+	// last has the last Decision of each one of the lastTypes
+	last map[DecisionType]Decision
+
+	// queue has the Decisions applied, not the restored ones,
+	// that are not notified yet and queued is signaled when
+	// one is added. It's a queue so Apply never blocks
+	queue  []Decision
+	queued chan struct{}
+}
+
+// newFSM returns an empty fsm
+func newFSM() *fsm {
+	return &fsm{
+		last:   make(map[DecisionType]Decision),
+		queued: make(chan struct{}, 1),
+	}
+}
+
+func (f *fsm) Apply(l *raft.Log) interface{} {
+	var d Decision
+	err := json.Unmarshal(l.Data, &d)
+	if err != nil {
+		return err
+	}
+
+	f.mx.Lock()
+	f.decisions = append(f.decisions, d)
+	if len(f.decisions) > maxDecisions {
+		f.decisions = f.decisions[len(f.decisions)-maxDecisions:]
+	}
+	if _, ok := lastTypes[d.Type]; ok {
+		f.last[d.Type] = d
+	}
+	f.queue = append(f.queue, d)
+	f.mx.Unlock()
+
+	select {
+	case f.queued <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// dequeue returns the Decisions applied since the last call
+func (f *fsm) dequeue() []Decision {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	ds := f.queue
+	f.queue = nil
+
+	return ds
+}
+
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+
+	ds := make([]Decision, len(f.decisions))
+	copy(ds, f.decisions)
+
+	last := make([]Decision, 0, len(f.last))
+	for _, d := range f.last {
+		last = append(last, d)
+	}
+
+	return &snapshot{decisions: ds, last: last}, nil
+}
+
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var sd snapshotData
+	err := json.NewDecoder(rc).Decode(&sd)
+	if err != nil {
+		return err
+	}
+
+	last := make(map[DecisionType]Decision)
+	for _, d := range sd.Last {
+		last[d.Type] = d
+	}
+
+	f.mx.Lock()
+	f.decisions = sd.Decisions
+	f.last = last
+	f.mx.Unlock()
+
+	return nil
+}
+
+// Decisions returns the last Decisions applied
+func (f *fsm) Decisions() []Decision {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+
+	ds := make([]Decision, len(f.decisions))
+	copy(ds, f.decisions)
+
+	return ds
+}
+
+// Last returns the last Decision applied of the t,
+// it's only kept for the ones on the lastTypes
+func (f *fsm) Last(t DecisionType) (Decision, bool) {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+
+	d, ok := f.last[t]
+
+	return d, ok
+}
+
+// snapshot is the raft.FSMSnapshot of the Decisions
+type snapshot struct {
+	decisions []Decision
+	last      []Decision
+}
+
+// snapshotData is how the snapshot is persisted
+type snapshotData struct {
+	Decisions []Decision `json:"decisions"`
+	Last      []Decision `json:"last"`
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	err := json.NewEncoder(sink).Encode(snapshotData{Decisions: s.decisions, Last: s.last})
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {}
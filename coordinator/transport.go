@@ -0,0 +1,73 @@
+package coordinator
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// streamLayer is the raft.StreamLayer that connects the
+// Nodes with mTLS so only the ones with a certificate
+// signed by the CA take part on the election
+type streamLayer struct {
+	net.Listener
+
+	// advertise is the address the
+	// other Nodes use to reach it
+	advertise net.Addr
+
+	client *tls.Config
+}
+
+// newStreamLayer listens with the server on the port and dials with the
+// client, both have to have a certificate and the CA to verify the others
+func newStreamLayer(port int, addr string, server, client *tls.Config) (*streamLayer, error) {
+	if server == nil || client == nil {
+		return nil, errors.New("the TLS is required")
+	}
+	if server.ClientCAs == nil || client.RootCAs == nil || len(client.Certificates) == 0 {
+		return nil, errors.New("the certificates of the Nodes have to be verified with a CA")
+	}
+
+	adv, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Nodes always have to send
+	// their certificate
+	server = server.Clone()
+	server.ClientAuth = tls.RequireAndVerifyClientCert
+
+	l, err := tls.Listen("tcp", net.JoinHostPort("", strconv.Itoa(port)), server)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamLayer{
+		Listener:  l,
+		advertise: adv,
+		client:    client,
+	}, nil
+}
+
+// Dial connects to the address and checks
+// the certificates before returning
+func (s *streamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	d := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    s.client,
+	}
+
+	return d.Dial("tcp", string(address))
+}
+
+// Addr returns the advertise address
+// instead of the one it listens on
+func (s *streamLayer) Addr() net.Addr {
+	return s.advertise
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/xescugc/rebost/config"
 	"github.com/xescugc/rebost/membership"
+	"github.com/xescugc/rebost/quota"
 	"github.com/xescugc/rebost/state"
 	"github.com/xescugc/rebost/storing"
 
@@ -17,11 +18,21 @@ import (
 type Service interface {
 	// ListNodes returns the list of all the nodes configuration
 	ListNodes(context.Context) ([]*Node, error)
+
+	// ListQuotas returns the list of all the Quotas with their usage
+	ListQuotas(context.Context) ([]*quota.Status, error)
+}
+
+// Quotas is the interface used to get the Status
+// of the Quotas of the cluster
+type Quotas interface {
+	Quotas(ctx context.Context) ([]*quota.Status, error)
 }
 
 type service struct {
 	members storing.Membership
 	cfg     *config.Config
+	quotas  Quotas
 
 	logger kitlog.Logger
 }
@@ -35,10 +46,11 @@ type Node struct {
 
 // New returns an implementation of the Dashboard with
 // the given parameters
-func New(cfg *config.Config, m storing.Membership, logger kitlog.Logger) Service {
+func New(cfg *config.Config, m storing.Membership, logger kitlog.Logger, qs Quotas) Service {
 	return &service{
 		members: m,
 		cfg:     cfg,
+		quotas:  qs,
 
 		logger: kitlog.With(logger, "src", "dashboard", "name", cfg.Name),
 	}
@@ -85,3 +97,7 @@ func (s *service) ListNodes(ctx context.Context) ([]*Node, error) {
 
 	return nodes, nil
 }
+
+func (s *service) ListQuotas(ctx context.Context) ([]*quota.Status, error) {
+	return s.quotas.Quotas(ctx)
+}
@@ -7,9 +7,11 @@ import (
 	"math"
 	"path/filepath"
 	"regexp"
+	"time"
 
 	"code.cloudfoundry.org/bytefmt"
 	"github.com/xescugc/rebost/dashboard"
+	"github.com/xescugc/rebost/quota"
 	"github.com/xescugc/rebost/state"
 )
 
@@ -112,6 +114,23 @@ func loadTemplates(path string) error {
 			"humanizeTotalSize": func(s state.State) string {
 				return bytefmt.ByteSize(uint64(s.TotalSize()))
 			},
+			"humanizeBytes": func(b int64) string {
+				return bytefmt.ByteSize(uint64(b))
+			},
+			"humanizeDuration": func(d time.Duration) string {
+				return d.Round(time.Second).String()
+			},
+			"percentageQuota": func(s *quota.Status) float64 {
+				// The percentage is of the limit closer to be reached
+				var p float64
+				if s.Size != 0 {
+					p = float64(s.Usage.Size) / float64(s.Size)
+				}
+				if s.Count != 0 {
+					p = math.Max(p, float64(s.Usage.Count)/float64(s.Count))
+				}
+				return math.Min(math.Round(p*100), 100)
+			},
 		})
 
 		pt, err := pt.ParseFS(files, newpath, filepath.Join(layoutsDir, extension))
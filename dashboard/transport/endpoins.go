@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/xescugc/rebost/dashboard"
+	"github.com/xescugc/rebost/quota"
 )
 
 // Endpoints is the list of all the endpoints of the Dashboard
@@ -25,8 +26,9 @@ type HomeRequest struct {
 
 // HomeResponse defines the response of the Home page
 type HomeResponse struct {
-	Nodes []*dashboard.Node
-	Err   error
+	Nodes  []*dashboard.Node
+	Quotas []*quota.Status
+	Err    error
 }
 
 // MakeHomeEndpoint has the logic to get the needed information for the Home Page
@@ -34,6 +36,10 @@ func MakeHomeEndpoint(s dashboard.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		_ = request.(HomeRequest)
 		ns, err := s.ListNodes(ctx)
-		return HomeResponse{Nodes: ns, Err: err}, nil
+		if err != nil {
+			return HomeResponse{Err: err}, nil
+		}
+		qs, err := s.ListQuotas(ctx)
+		return HomeResponse{Nodes: ns, Quotas: qs, Err: err}, nil
 	}
 }
@@ -0,0 +1,215 @@
+// Package diskcache implements an LRU cache of the content of the
+// files on the disk, identified by their Signatures, so the ones
+// served from other Nodes do not have to be requested every time
+package diskcache
+
+import (
+	"container/list"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/afero"
+	"github.com/xescugc/rebost/signature"
+)
+
+// Cache is an LRU cache of the content of the files
+// by their Signatures limited to a size in bytes
+type Cache struct {
+	fs   afero.Fs
+	dir  string
+	size int64
+
+	mx   sync.Mutex
+	used int64
+
+	// lru has the *entry from the most
+	// recently used to the least one
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	sig  string
+	size int64
+}
+
+// New returns a Cache on the dir of the fs of up to size bytes,
+// the content already on the dir is removed
+func New(fs afero.Fs, dir string, size int64) (*Cache, error) {
+	err := fs.RemoveAll(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		fs:   fs,
+		dir:  dir,
+		size: size,
+
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}, nil
+}
+
+// Get returns the content with the sig if it's on the Cache
+func (c *Cache) Get(sig string) (io.ReadCloser, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	e, ok := c.entries[sig]
+	if !ok {
+		return nil, false
+	}
+
+	fh, err := c.fs.Open(c.path(sig))
+	if err != nil {
+		c.remove(e)
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+
+	return fh, true
+}
+
+// Add returns a reader of the r that adds its content to the Cache
+// with the sig once it's read completely, if the Signature of
+// the content is the sig and it fits on the Cache
+func (c *Cache) Add(sig string, r io.ReadCloser) io.ReadCloser {
+	// Only the valid Signatures are used as they
+	// are also the name of the files on the dir
+	alg, d := signature.Split(sig)
+	if _, err := signature.Parse(string(alg)); err != nil {
+		return r
+	}
+	if _, err := hex.DecodeString(d); err != nil || d == "" {
+		return r
+	}
+
+	tmp := path.Join(c.dir, uuid.NewV4().String()+".tmp")
+	fh, err := c.fs.Create(tmp)
+	if err != nil {
+		return r
+	}
+
+	return &reader{
+		ReadCloser: r,
+		c:          c,
+		sig:        sig,
+		alg:        alg,
+		h:          alg.New(),
+		fh:         fh,
+		tmp:        tmp,
+	}
+}
+
+// path returns the path of the content with the sig
+func (c *Cache) path(sig string) string {
+	alg, d := signature.Split(sig)
+	return path.Join(c.dir, string(alg)+"-"+d)
+}
+
+// put adds the content with the sig and the size, already
+// on the tmp, evicting the least recently used ones
+func (c *Cache) put(sig, tmp string, size int64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if _, ok := c.entries[sig]; ok {
+		c.fs.Remove(tmp)
+		return
+	}
+
+	for c.used+size > c.size && c.lru.Len() != 0 {
+		c.remove(c.lru.Back())
+	}
+
+	err := c.fs.Rename(tmp, c.path(sig))
+	if err != nil {
+		c.fs.Remove(tmp)
+		return
+	}
+
+	c.entries[sig] = c.lru.PushFront(&entry{sig: sig, size: size})
+	c.used += size
+}
+
+// remove removes the e from the Cache
+func (c *Cache) remove(e *list.Element) {
+	en := c.lru.Remove(e).(*entry)
+	delete(c.entries, en.sig)
+	c.used -= en.size
+	c.fs.Remove(c.path(en.sig))
+}
+
+// reader writes the content it reads to the tmp
+// and adds it to the Cache when it's all read
+type reader struct {
+	io.ReadCloser
+
+	c   *Cache
+	sig string
+	alg signature.Algorithm
+	h   hash.Hash
+
+	// fh is the tmp file with the content
+	// read, nil if it's not going to be added
+	fh   afero.File
+	tmp  string
+	size int64
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && r.fh != nil {
+		r.size += int64(n)
+		if r.size > r.c.size {
+			r.discard()
+		} else if _, werr := r.fh.Write(p[:n]); werr != nil {
+			r.discard()
+		} else {
+			r.h.Write(p[:n])
+		}
+	}
+	if err == io.EOF && r.fh != nil {
+		r.commit()
+	}
+	return n, err
+}
+
+func (r *reader) Close() error {
+	// If it was not read completely it's not added
+	if r.fh != nil {
+		r.discard()
+	}
+	return r.ReadCloser.Close()
+}
+
+// commit adds the content to the Cache if it has the sig
+func (r *reader) commit() {
+	err := r.fh.Close()
+	r.fh = nil
+	_, d := signature.Split(r.sig)
+	_, hd := signature.Split(r.alg.Sum(r.h))
+	if err != nil || d != hd {
+		r.c.fs.Remove(r.tmp)
+		return
+	}
+	r.c.put(r.sig, r.tmp, r.size)
+}
+
+// discard removes the content read
+func (r *reader) discard() {
+	r.fh.Close()
+	r.fh = nil
+	r.c.fs.Remove(r.tmp)
+}
@@ -0,0 +1,110 @@
+package diskcache_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/diskcache"
+	"github.com/xescugc/rebost/signature"
+)
+
+// sig returns the Signature of the content
+func sig(content string) string {
+	h := signature.SHA1.New()
+	h.Write([]byte(content))
+	return signature.SHA1.Sum(h)
+}
+
+// read reads all the r and closes it
+func read(t *testing.T, r io.ReadCloser) string {
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return string(b)
+}
+
+func TestCache(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		c, err := diskcache.New(afero.NewMemMapFs(), "/cache", 1024)
+		require.NoError(t, err)
+
+		_, ok := c.Get(sig("content"))
+		assert.False(t, ok)
+
+		r := c.Add(sig("content"), io.NopCloser(strings.NewReader("content")))
+		assert.Equal(t, "content", read(t, r))
+
+		r, ok = c.Get(sig("content"))
+		require.True(t, ok)
+		assert.Equal(t, "content", read(t, r))
+	})
+	t.Run("InvalidSignature", func(t *testing.T) {
+		c, err := diskcache.New(afero.NewMemMapFs(), "/cache", 1024)
+		require.NoError(t, err)
+
+		r := c.Add(sig("other"), io.NopCloser(strings.NewReader("content")))
+		assert.Equal(t, "content", read(t, r))
+
+		_, ok := c.Get(sig("other"))
+		assert.False(t, ok)
+
+		r = c.Add("md5:../../etc", io.NopCloser(strings.NewReader("content")))
+		assert.Equal(t, "content", read(t, r))
+
+		_, ok = c.Get("md5:../../etc")
+		assert.False(t, ok)
+	})
+	t.Run("NotReadCompletely", func(t *testing.T) {
+		c, err := diskcache.New(afero.NewMemMapFs(), "/cache", 1024)
+		require.NoError(t, err)
+
+		r := c.Add(sig("content"), io.NopCloser(strings.NewReader("content")))
+		b := make([]byte, 3)
+		_, err = r.Read(b)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		_, ok := c.Get(sig("content"))
+		assert.False(t, ok)
+	})
+	t.Run("TooLarge", func(t *testing.T) {
+		c, err := diskcache.New(afero.NewMemMapFs(), "/cache", 4)
+		require.NoError(t, err)
+
+		r := c.Add(sig("content"), io.NopCloser(strings.NewReader("content")))
+		assert.Equal(t, "content", read(t, r))
+
+		_, ok := c.Get(sig("content"))
+		assert.False(t, ok)
+	})
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		c, err := diskcache.New(afero.NewMemMapFs(), "/cache", 10)
+		require.NoError(t, err)
+
+		for _, content := range []string{"aaaa", "bbbb"} {
+			read(t, c.Add(sig(content), io.NopCloser(bytes.NewBufferString(content))))
+		}
+
+		// The aaaa is used so the bbbb is the
+		// one evicted to fit the cccc
+		r, ok := c.Get(sig("aaaa"))
+		require.True(t, ok)
+		read(t, r)
+
+		read(t, c.Add(sig("cccc"), io.NopCloser(bytes.NewBufferString("cccc"))))
+
+		_, ok = c.Get(sig("bbbb"))
+		assert.False(t, ok)
+
+		for _, content := range []string{"aaaa", "cccc"} {
+			r, ok := c.Get(sig(content))
+			require.True(t, ok)
+			assert.Equal(t, content, read(t, r))
+		}
+	})
+}
@@ -0,0 +1,197 @@
+// Package encryption has the logic to encrypt the files at rest
+// with envelope encryption, each file is encrypted with its own
+// data key which is stored wrapped (encrypted) with a master key
+// of the Keyring.
+//
+// The content is encrypted with AES-256-GCM in chunks of ChunkSize
+// so it can be streamed, each chunk has as nonce the number of the
+// chunk and a flag for the last one so the chunks can not be
+// reordered nor the content truncated
+package encryption
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	// KeySize is the size in bytes of the keys
+	KeySize = 32
+
+	// ChunkSize is the size of the plain content
+	// of each one of the encrypted chunks
+	ChunkSize = 64 * 1024
+)
+
+// errFinished is returned when a chunk is tried
+// to be sealed or opened after the last one
+var errFinished = errors.New("the content has already been finished")
+
+// chunker has the shared logic of the Writer and Reader
+// to seal and open the chunks
+type chunker struct {
+	aead    cipher.AEAD
+	counter uint64
+	done    bool
+}
+
+func newChunker(key []byte) (*chunker, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &chunker{aead: aead}, nil
+}
+
+// nonce returns the nonce of the current chunk
+func (c *chunker) nonce(last bool) []byte {
+	n := make([]byte, c.aead.NonceSize())
+	binary.BigEndian.PutUint64(n, c.counter)
+	if last {
+		n[len(n)-1] = 1
+	}
+	return n
+}
+
+func (c *chunker) seal(dst, p []byte, last bool) ([]byte, error) {
+	if c.done {
+		return nil, errFinished
+	}
+	dst = c.aead.Seal(dst, c.nonce(last), p, nil)
+	c.counter++
+	c.done = last
+	return dst, nil
+}
+
+func (c *chunker) open(dst, p []byte, last bool) ([]byte, error) {
+	if c.done {
+		return nil, errFinished
+	}
+	dst, err := c.aead.Open(dst, c.nonce(last), p, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.counter++
+	c.done = last
+	return dst, nil
+}
+
+type writer struct {
+	w   io.Writer
+	c   *chunker
+	buf []byte
+	out []byte
+}
+
+// NewWriter returns a io.WriteCloser that encrypts with the key
+// all the content written to it and writes it to w. It has to be
+// closed to write the last chunk, but w is not closed
+func NewWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	c, err := newChunker(key)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{
+		w:   w,
+		c:   c,
+		buf: make([]byte, 0, ChunkSize),
+	}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		// The chunk is only written when we know there is more content
+		// after it, so the last one is always written on the Close
+		if len(w.buf) == ChunkSize {
+			err := w.flush(false)
+			if err != nil {
+				return n, err
+			}
+		}
+		l := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+l]
+		p = p[l:]
+		n += l
+	}
+	return n, nil
+}
+
+func (w *writer) flush(last bool) error {
+	var err error
+	w.out, err = w.c.seal(w.out[:0], w.buf, last)
+	if err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	_, err = w.w.Write(w.out)
+	return err
+}
+
+func (w *writer) Close() error {
+	return w.flush(true)
+}
+
+type reader struct {
+	r   io.ReadCloser
+	br  *bufio.Reader
+	c   *chunker
+	in  []byte
+	buf []byte
+}
+
+// NewReader returns a io.ReadCloser that decrypts
+// the content of r with the key
+func NewReader(r io.ReadCloser, key []byte) (io.ReadCloser, error) {
+	c, err := newChunker(key)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{
+		r:  r,
+		br: bufio.NewReader(r),
+		c:  c,
+		in: make([]byte, ChunkSize+c.aead.Overhead()),
+	}, nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.c.done {
+			return 0, io.EOF
+		}
+		err := r.next()
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next reads and opens the next chunk
+func (r *reader) next() error {
+	n, err := io.ReadFull(r.br, r.in)
+	last := false
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		last = true
+	} else if err != nil {
+		return err
+	} else if _, err := r.br.Peek(1); err == io.EOF {
+		last = true
+	}
+
+	r.buf, err = r.c.open(r.buf[:0], r.in[:n], last)
+	if err != nil {
+		return errors.New("the encrypted content is not valid")
+	}
+	return nil
+}
+
+func (r *reader) Close() error {
+	return r.r.Close()
+}
@@ -0,0 +1,76 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/encryption"
+)
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+	k := make([]byte, encryption.KeySize)
+	_, err := rand.Read(k)
+	require.NoError(t, err)
+	return k
+}
+
+func encrypt(t *testing.T, key, content []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w, err := encryption.NewWriter(&b, key)
+	require.NoError(t, err)
+	_, err = w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return b.Bytes()
+}
+
+func decrypt(key, content []byte) ([]byte, error) {
+	r, err := encryption.NewReader(io.NopCloser(bytes.NewReader(content)), key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func TestWriterReader(t *testing.T) {
+	key := newKey(t)
+	for _, s := range []int{0, 1, encryption.ChunkSize - 1, encryption.ChunkSize, encryption.ChunkSize + 1, 3 * encryption.ChunkSize} {
+		t.Run(fmt.Sprintf("Size(%d)", s), func(t *testing.T) {
+			content := make([]byte, s)
+			_, err := rand.Read(content)
+			require.NoError(t, err)
+
+			ec := encrypt(t, key, content)
+			assert.NotEqual(t, content, ec)
+
+			dc, err := decrypt(key, ec)
+			require.NoError(t, err)
+			assert.Equal(t, content, dc)
+		})
+	}
+	t.Run("FailsWithOtherKey", func(t *testing.T) {
+		ec := encrypt(t, key, []byte("content"))
+		_, err := decrypt(newKey(t), ec)
+		assert.EqualError(t, err, "the encrypted content is not valid")
+	})
+	t.Run("FailsWhenTruncated", func(t *testing.T) {
+		ec := encrypt(t, key, make([]byte, 2*encryption.ChunkSize))
+		// We remove the last chunk
+		_, err := decrypt(key, ec[:len(ec)-16])
+		assert.EqualError(t, err, "the encrypted content is not valid")
+	})
+	t.Run("FailsWhenModified", func(t *testing.T) {
+		ec := encrypt(t, key, []byte("content"))
+		ec[0] ^= 1
+		_, err := decrypt(key, ec)
+		assert.EqualError(t, err, "the encrypted content is not valid")
+	})
+}
@@ -0,0 +1,233 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Keyring holds the master keys used to wrap the
+// data keys of the files. The current one is used
+// to wrap the new data keys and the rest are only
+// used to unwrap the old ones
+type Keyring struct {
+	mx      sync.RWMutex
+	keys    map[string][]byte
+	current string
+
+	// path is the key file it was loaded
+	// from, empty if it was not
+	path string
+}
+
+// LoadKeyring loads the Keyring from the key file on p.
+// Each line of the file is a master key with the format
+// '<id>:<base64 of 32 bytes>' and the last one is the current.
+// To rotate the master key a new one has to be appended to the
+// file, the old ones have to be kept until all the data keys
+// are wrapped again with the new one.
+// Empty lines and lines starting with '#' are ignored
+func LoadKeyring(p string) (*Keyring, error) {
+	keys, current, err := readKeys(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keyring{keys: keys, current: current, path: p}, nil
+}
+
+// Reload loads again the keys from the key file, so a new master key
+// appended to it is used without restarting, and returns if the
+// current one has changed. The Keyring is not changed on error
+func (kr *Keyring) Reload() (bool, error) {
+	if kr.path == "" {
+		return false, nil
+	}
+
+	keys, current, err := readKeys(kr.path)
+	if err != nil {
+		return false, err
+	}
+
+	kr.mx.Lock()
+	defer kr.mx.Unlock()
+
+	changed := kr.current != current
+	kr.keys, kr.current = keys, current
+
+	return changed, nil
+}
+
+// readKeys reads the keys of the key file on p
+// and the current one
+func readKeys(p string) (map[string][]byte, string, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var (
+		keys    = make(map[string][]byte)
+		current string
+	)
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+
+		id, bk, ok := strings.Cut(l, ":")
+		if !ok || id == "" {
+			return nil, "", fmt.Errorf("invalid key on line %d, the format is '<id>:<base64 key>'", n)
+		}
+
+		k, err := base64.StdEncoding.DecodeString(bk)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid key on line %d: %w", n, err)
+		}
+		if len(k) != KeySize {
+			return nil, "", fmt.Errorf("invalid key on line %d, it has to be %d bytes long", n, KeySize)
+		}
+
+		if _, ok := keys[id]; ok {
+			return nil, "", fmt.Errorf("duplicated key %q on line %d", id, n)
+		}
+
+		keys[id] = k
+		current = id
+	}
+	if err := s.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if current == "" {
+		return nil, "", fmt.Errorf("no keys found on %q", p)
+	}
+
+	return keys, current, nil
+}
+
+// NewKeyring returns a new Keyring with the keys
+// and the current as the one to use
+func NewKeyring(keys map[string][]byte, current string) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("the current key %q is not on the keys", current)
+	}
+	for id, k := range keys {
+		if len(k) != KeySize {
+			return nil, fmt.Errorf("invalid key %q, it has to be %d bytes long", id, KeySize)
+		}
+	}
+	return &Keyring{keys: keys, current: current}, nil
+}
+
+// Current returns the ID of the current master key
+func (kr *Keyring) Current() string {
+	kr.mx.RLock()
+	defer kr.mx.RUnlock()
+
+	return kr.current
+}
+
+// GenerateDataKey generates a new data key and returns it and also
+// wrapped with the current master key, which ID is returned with it
+func (kr *Keyring) GenerateDataKey() (id string, key []byte, wrapped []byte, err error) {
+	key = make([]byte, KeySize)
+	_, err = io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	kr.mx.RLock()
+	id, mk := kr.current, kr.keys[kr.current]
+	kr.mx.RUnlock()
+
+	wrapped, err = seal(mk, key)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return id, key, wrapped, nil
+}
+
+// Unwrap returns the data key wrapped with the master key with the id
+func (kr *Keyring) Unwrap(id string, wrapped []byte) ([]byte, error) {
+	kr.mx.RLock()
+	mk, ok := kr.keys[id]
+	kr.mx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("master key %q not found", id)
+	}
+
+	return open(mk, wrapped)
+}
+
+// Rewrap unwraps the data key with the master key with the id and wraps
+// it again with the current one, which ID is returned with it
+func (kr *Keyring) Rewrap(id string, wrapped []byte) (string, []byte, error) {
+	key, err := kr.Unwrap(id, wrapped)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kr.mx.RLock()
+	cid, mk := kr.current, kr.keys[kr.current]
+	kr.mx.RUnlock()
+
+	wrapped, err = seal(mk, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return cid, wrapped, nil
+}
+
+// seal encrypts the data with the key and returns
+// it with the random nonce as prefix
+func seal(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts the data sealed with seal
+func open(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("invalid wrapped key")
+	}
+
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
@@ -0,0 +1,113 @@
+package encryption_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/encryption"
+)
+
+func TestLoadKeyring(t *testing.T) {
+	var (
+		k1 = newKey(t)
+		k2 = newKey(t)
+	)
+
+	writeFile := func(t *testing.T, c string) string {
+		p := path.Join(t.TempDir(), "keys")
+		require.NoError(t, os.WriteFile(p, []byte(c), 0600))
+		return p
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		p := writeFile(t, fmt.Sprintf("# Old key\nk1:%s\n\nk2:%s\n", base64.StdEncoding.EncodeToString(k1), base64.StdEncoding.EncodeToString(k2)))
+		kr, err := encryption.LoadKeyring(p)
+		require.NoError(t, err)
+		assert.Equal(t, "k2", kr.Current())
+	})
+	t.Run("SuccessReload", func(t *testing.T) {
+		p := writeFile(t, fmt.Sprintf("k1:%s\n", base64.StdEncoding.EncodeToString(k1)))
+		kr, err := encryption.LoadKeyring(p)
+		require.NoError(t, err)
+
+		ok, err := kr.Reload()
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		// The new key appended is the current one
+		require.NoError(t, os.WriteFile(p, []byte(fmt.Sprintf("k1:%s\nk2:%s\n", base64.StdEncoding.EncodeToString(k1), base64.StdEncoding.EncodeToString(k2))), 0600))
+		ok, err = kr.Reload()
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "k2", kr.Current())
+
+		// An invalid file does not change it
+		require.NoError(t, os.WriteFile(p, []byte("# No keys\n"), 0600))
+		_, err = kr.Reload()
+		assert.EqualError(t, err, fmt.Sprintf("no keys found on %q", p))
+		assert.Equal(t, "k2", kr.Current())
+	})
+	t.Run("FailsWithNoKeys", func(t *testing.T) {
+		p := writeFile(t, "# No keys\n")
+		_, err := encryption.LoadKeyring(p)
+		assert.EqualError(t, err, fmt.Sprintf("no keys found on %q", p))
+	})
+	t.Run("FailsWithInvalidFormat", func(t *testing.T) {
+		p := writeFile(t, base64.StdEncoding.EncodeToString(k1))
+		_, err := encryption.LoadKeyring(p)
+		assert.EqualError(t, err, "invalid key on line 1, the format is '<id>:<base64 key>'")
+	})
+	t.Run("FailsWithInvalidSize", func(t *testing.T) {
+		p := writeFile(t, "k1:"+base64.StdEncoding.EncodeToString([]byte("short")))
+		_, err := encryption.LoadKeyring(p)
+		assert.EqualError(t, err, "invalid key on line 1, it has to be 32 bytes long")
+	})
+	t.Run("FailsWithDuplicatedKey", func(t *testing.T) {
+		p := writeFile(t, fmt.Sprintf("k1:%s\nk1:%s\n", base64.StdEncoding.EncodeToString(k1), base64.StdEncoding.EncodeToString(k2)))
+		_, err := encryption.LoadKeyring(p)
+		assert.EqualError(t, err, `duplicated key "k1" on line 2`)
+	})
+}
+
+func TestKeyring(t *testing.T) {
+	var (
+		k1 = newKey(t)
+		k2 = newKey(t)
+	)
+
+	kr1, err := encryption.NewKeyring(map[string][]byte{"k1": k1}, "k1")
+	require.NoError(t, err)
+
+	id, dk, wdk, err := kr1.GenerateDataKey()
+	require.NoError(t, err)
+	assert.Equal(t, "k1", id)
+	assert.Len(t, dk, encryption.KeySize)
+	assert.NotEqual(t, dk, wdk)
+
+	udk, err := kr1.Unwrap("k1", wdk)
+	require.NoError(t, err)
+	assert.Equal(t, dk, udk)
+
+	_, err = kr1.Unwrap("k2", wdk)
+	assert.EqualError(t, err, `master key "k2" not found`)
+
+	// Rotation of the master key
+	kr2, err := encryption.NewKeyring(map[string][]byte{"k1": k1, "k2": k2}, "k2")
+	require.NoError(t, err)
+
+	id, rwdk, err := kr2.Rewrap("k1", wdk)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", id)
+
+	udk, err = kr2.Unwrap("k2", rwdk)
+	require.NoError(t, err)
+	assert.Equal(t, dk, udk)
+
+	_, err = kr2.Unwrap("k1", rwdk)
+	assert.Error(t, err)
+}
@@ -3,15 +3,19 @@ package file
 import (
 	"path"
 	"time"
+
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/signature"
 )
 
-// File represents the structure of a stored File with the Signature (SHA1 of the content of the File)
+// File represents the structure of a stored File with the Signature (hash of the content of the File)
 // and the key which is the name of the file
 type File struct {
 	// Keys has all the keys that point to this file
 	Keys []string
 
-	// Signature is the SHA1 of the file
+	// Signature is the hash of the file prefixed with the
+	// algorithm used to calculate it, like 'sha1:<hex>'
 	Signature string
 
 	// Replica number of replicas for that file
@@ -23,6 +27,26 @@ type File struct {
 	// Is the size of the object in bytes
 	Size int
 
+	// StoredSize is the size of the object in bytes on the
+	// disk, it's different from the Size if it's compressed
+	StoredSize int
+
+	// Class is the storage class of the file
+	Class string
+
+	// Compression is the algorithm used to compress
+	// the file when stored
+	Compression compression.Algorithm
+
+	// EncryptionKeyID is the ID of the master key used
+	// to wrap the EncryptedKey
+	EncryptionKeyID string
+
+	// EncryptedKey is the data key used to encrypt the file
+	// wrapped with the master key, if empty the file
+	// is not encrypted
+	EncryptedKey []byte
+
 	// TTL is the duration the file has before it'll be automatically deleted
 	// if 0 it means it has no expiration
 	TTL time.Duration
@@ -36,10 +60,17 @@ func (f File) Path(p string) string {
 	return Path(p, f.Signature)
 }
 
-// Path calculates the storage path for the File with the Signature
+// Path calculates the storage path for the File with the Signature.
+// The SHA1 Signatures (prefixed or legacy) are stored directly on the base
+// to keep the layout of the already stored files and the other algorithms
+// are stored on a subdirectory with the name of the algorithm
 func Path(base, sig string) string {
+	alg, d := signature.Split(sig)
+	if alg != signature.SHA1 {
+		base = path.Join(base, string(alg))
+	}
 	currentDir := []byte{}
-	for _, b := range []byte(sig) {
+	for _, b := range []byte(d) {
 		currentDir = append(currentDir, b)
 		if len(currentDir) == 2 {
 			base = path.Join(base, string(currentDir))
@@ -64,5 +95,23 @@ func (f *File) DeleteVolumeID(vid string) {
 	f.VolumeIDs = vids
 }
 
+// IsEncrypted checks if the File is stored encrypted
+func (f *File) IsEncrypted() bool { return len(f.EncryptedKey) != 0 }
+
+// DiskSize returns the size the File uses on the disk, the
+// Files stored before the compression have no StoredSize
+// so the Size is used
+func (f *File) DiskSize() int {
+	if f.StoredSize == 0 {
+		return f.Size
+	}
+	return f.StoredSize
+}
+
 // ExpiresAt returns the expiration date of the File based on the CreatedAt and the TTL
 func (f *File) ExpiresAt() time.Time { return f.CreatedAt.Add(f.TTL) }
+
+// IsExpired checks if the File has a TTL and it has expired at the now
+func (f *File) IsExpired(now time.Time) bool {
+	return f.TTL != 0 && !now.Before(f.ExpiresAt())
+}
@@ -2,6 +2,7 @@ package file_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/xescugc/rebost/file"
@@ -14,6 +15,20 @@ func TestFilePath(t *testing.T) {
 
 func TestPath(t *testing.T) {
 	assert.Equal(t, "root/12/31/23/12/32", file.Path("root", "1231231232"))
+	assert.Equal(t, "root/12/31/23/12/32", file.Path("root", "sha1:1231231232"))
+	assert.Equal(t, "root/sha256/12/31/23/12/32", file.Path("root", "sha256:1231231232"))
+}
+
+func TestFileIsExpired(t *testing.T) {
+	ca := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	f := file.File{CreatedAt: ca, TTL: time.Hour}
+	assert.False(t, f.IsExpired(ca.Add(time.Minute)))
+	assert.True(t, f.IsExpired(ca.Add(time.Hour)))
+
+	// Without TTL it never expires
+	f.TTL = 0
+	assert.False(t, f.IsExpired(ca.Add(time.Hour)))
 }
 
 func TestFileDeleteVolumeID(t *testing.T) {
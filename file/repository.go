@@ -10,4 +10,11 @@ type Repository interface {
 	FindBySignature(ctx context.Context, sig string) (*File, error)
 	DeleteBySignature(ctx context.Context, sig string) error
 	DeleteAll(ctx context.Context) error
+
+	// All returns all the Files
+	All(ctx context.Context) ([]*File, error)
+
+	// After returns up to n Files sorted by the Signature that
+	// are after the sig, or from the first one if it's empty
+	After(ctx context.Context, sig string, n int) ([]*File, error)
 }
@@ -100,7 +100,7 @@ func (uowt *uowTracker) OpenFile(name string, flag int, perm os.FileMode) (afero
 
 func (uowt *uowTracker) Remove(name string) error {
 	tmp := fmt.Sprintf("%s.tmp", name)
-	uowt.commitActions = append(uowt.rollbackActions, func(fs afero.Fs) error {
+	uowt.commitActions = append(uowt.commitActions, func(fs afero.Fs) error {
 		return fs.Remove(tmp)
 	})
 	uowt.rollbackActions = append(uowt.rollbackActions, func(fs afero.Fs) error {
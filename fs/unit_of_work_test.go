@@ -53,6 +53,22 @@ func TestRemove(t *testing.T) {
 			return nil
 		}, mfs)
 	})
+	t.Run("SuccessMultiple", func(t *testing.T) {
+		mfs, suow, finishFn := newSuow(t)
+		defer finishFn()
+		ctx := context.Background()
+
+		fsre := mfs.EXPECT().Rename("test/path", "test/path.tmp").Return(nil)
+		fsre2 := mfs.EXPECT().Rename("test/path2", "test/path2.tmp").Return(nil).After(fsre)
+		fsrm := mfs.EXPECT().Remove("test/path.tmp").Return(nil).After(fsre2)
+		mfs.EXPECT().Remove("test/path2.tmp").Return(nil).After(fsrm)
+
+		fs.UOWWithFs(suow)(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+			uw.Fs().Remove("test/path")
+			uw.Fs().Remove("test/path2")
+			return nil
+		}, mfs)
+	})
 	t.Run("Error", func(t *testing.T) {
 		mfs, suow, finishFn := newSuow(t)
 		defer finishFn()
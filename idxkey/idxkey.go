@@ -1,9 +1,20 @@
 package idxkey
 
+import "time"
+
 // IDXKey represents a KV of Key => File.Key and Value => File.Signature
 type IDXKey struct {
 	Key   string
 	Value string
+
+	// ExpiresAt is when the Key expires on its own, without
+	// the rest of keys of the File, if it's not zero
+	ExpiresAt time.Time
+
+	// CreatedAt is when the Key was created, which could be
+	// after the File if it's shared with other keys. If
+	// it's zero it's the one of the File
+	CreatedAt time.Time
 }
 
 // New returns a new IDXKey with te Key and Value provided
@@ -13,3 +24,9 @@ func New(k, v string) *IDXKey {
 		Value: v,
 	}
 }
+
+// IsExpired checks if the IDXKey has its own
+// expiration and it has expired at the now
+func (ik *IDXKey) IsExpired(now time.Time) bool {
+	return !ik.ExpiresAt.IsZero() && !now.Before(ik.ExpiresAt)
+}
@@ -10,4 +10,7 @@ type Repository interface {
 	FindByKey(ctx context.Context, key string) (*IDXKey, error)
 	DeleteByKey(ctx context.Context, key string) error
 	DeleteAll(ctx context.Context) error
+
+	// All returns all the IDXKeys
+	All(ctx context.Context) ([]*IDXKey, error)
 }
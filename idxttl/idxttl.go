@@ -6,6 +6,11 @@ import "time"
 type IDXTTL struct {
 	ExpiresAt  time.Time
 	Signatures []string
+
+	// Keys are the keys that need to expire
+	// at the ExpiresAt without the rest of
+	// keys of the File of them
+	Keys []string
 }
 
 // New initializes a new IDXTTL to expire all the ss at ea
@@ -34,3 +39,56 @@ func (i *IDXTTL) AddSignatures(ss ...string) {
 		}
 	}
 }
+
+// DeleteSignatures will remove all the ss from
+// the list of signatures to expire on the ExpiresAt
+func (i *IDXTTL) DeleteSignatures(ss ...string) {
+	mapSig := make(map[string]struct{})
+	for _, s := range ss {
+		mapSig[s] = struct{}{}
+	}
+	sigs := make([]string, 0, len(i.Signatures))
+	for _, s := range i.Signatures {
+		if _, ok := mapSig[s]; !ok {
+			sigs = append(sigs, s)
+		}
+	}
+	i.Signatures = sigs
+}
+
+// IsEmpty checks if the IDXTTL has nothing to expire
+func (i *IDXTTL) IsEmpty() bool {
+	return len(i.Signatures) == 0 && len(i.Keys) == 0
+}
+
+// AddKeys will add all the ks to the list of
+// keys to expire on the ExpiresAt if they do
+// not exists already
+func (i *IDXTTL) AddKeys(ks ...string) {
+	mapKey := make(map[string]struct{})
+	for _, k := range i.Keys {
+		mapKey[k] = struct{}{}
+	}
+	for _, k := range ks {
+		if _, ok := mapKey[k]; !ok {
+			i.Keys = append(i.Keys, k)
+			mapKey[k] = struct{}{}
+		}
+	}
+}
+
+// DeleteKeys will remove all the ks from the
+// list of keys to expire on the ExpiresAt
+func (i *IDXTTL) DeleteKeys(ks ...string) {
+	mapKey := make(map[string]struct{})
+	for _, k := range ks {
+		mapKey[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(i.Keys))
+	for _, k := range i.Keys {
+		if _, ok := mapKey[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	i.Keys = keys
+}
@@ -32,3 +32,56 @@ func TestAddSignature(t *testing.T) {
 
 	assert.Equal(t, eittl, ittl)
 }
+
+func TestAddKeys(t *testing.T) {
+	ittl := &idxttl.IDXTTL{
+		ExpiresAt: time.Now(),
+		Keys:      []string{"a"},
+	}
+	eittl := &idxttl.IDXTTL{
+		ExpiresAt: ittl.ExpiresAt,
+		Keys:      []string{"a", "b"},
+	}
+
+	ittl.AddKeys("a", "b")
+
+	assert.Equal(t, eittl, ittl)
+}
+
+func TestDeleteSignatures(t *testing.T) {
+	ittl := &idxttl.IDXTTL{
+		ExpiresAt:  time.Now(),
+		Signatures: []string{"1", "2", "3"},
+	}
+	eittl := &idxttl.IDXTTL{
+		ExpiresAt:  ittl.ExpiresAt,
+		Signatures: []string{"2"},
+	}
+
+	ittl.DeleteSignatures("1", "3", "4")
+
+	assert.Equal(t, eittl, ittl)
+	assert.False(t, ittl.IsEmpty())
+
+	ittl.DeleteSignatures("2")
+	assert.True(t, ittl.IsEmpty())
+}
+
+func TestDeleteKeys(t *testing.T) {
+	ittl := &idxttl.IDXTTL{
+		ExpiresAt: time.Now(),
+		Keys:      []string{"a", "b", "c"},
+	}
+	eittl := &idxttl.IDXTTL{
+		ExpiresAt: ittl.ExpiresAt,
+		Keys:      []string{"b"},
+	}
+
+	ittl.DeleteKeys("a", "c", "d")
+
+	assert.Equal(t, eittl, ittl)
+	assert.False(t, ittl.IsEmpty())
+
+	ittl.DeleteKeys("b")
+	assert.True(t, ittl.IsEmpty())
+}
@@ -8,11 +8,12 @@ import (
 //go:generate mockgen -destination=../mock/idxttl_repository.go -mock_names=Repository=IDXTTLRepository -package=mock github.com/xescugc/rebost/idxttl Repository
 
 // Repository is the interface that has to be fulfilled to interact with IDXTTL.
-// All the 'ea' used as keys will be  converted to RFC3339
+// All the 'ea' used as keys will be  converted to RFC3339 on UTC
 type Repository interface {
 	CreateOrReplace(ctx context.Context, ik *IDXTTL) error
-	// Filter will return all the IDXTTL older than ea
-	Filter(ctx context.Context, ea time.Time) ([]*IDXTTL, error)
+	// Filter will return the IDXTTL that expire at or before the ea
+	// ordered from the oldest one, up to limit (0 means no limit)
+	Filter(ctx context.Context, ea time.Time, limit int) ([]*IDXTTL, error)
 	Find(ctx context.Context, ea time.Time) (*IDXTTL, error)
 	Delete(ctx context.Context, ea time.Time) error
 }
@@ -26,3 +26,14 @@ func (idxv *IDXVolume) AddSignature(sig string) {
 	}
 	idxv.Signatures = append(idxv.Signatures, sig)
 }
+
+// DeleteSignature removes the sig from the list of Signatures
+// if the sig is not present it'll do nothing
+func (idxv *IDXVolume) DeleteSignature(sig string) {
+	for i, s := range idxv.Signatures {
+		if s == sig {
+			idxv.Signatures = append(idxv.Signatures[:i], idxv.Signatures[i+1:]...)
+			return
+		}
+	}
+}
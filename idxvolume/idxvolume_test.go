@@ -21,3 +21,15 @@ func TestAddSignature(t *testing.T) {
 		assert.Equal(t, []string{"value", "value2"}, idxv.Signatures)
 	})
 }
+
+func TestDeleteSignature(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		idxv := idxvolume.New("key", []string{"value", "value2"})
+
+		idxv.DeleteSignature("value")
+		assert.Equal(t, []string{"value2"}, idxv.Signatures)
+
+		idxv.DeleteSignature("value")
+		assert.Equal(t, []string{"value2"}, idxv.Signatures)
+	})
+}
@@ -10,4 +10,7 @@ type Repository interface {
 	FindByVolumeID(ctx context.Context, volumeID string) (*IDXVolume, error)
 	DeleteByKey(ctx context.Context, volumeID string) error
 	DeleteAll(ctx context.Context) error
+
+	// All returns all the IDXVolumes
+	All(ctx context.Context) ([]*IDXVolume, error)
 }
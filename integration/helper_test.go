@@ -21,6 +21,7 @@ import (
 	"github.com/xescugc/rebost/config"
 	"github.com/xescugc/rebost/fs"
 	"github.com/xescugc/rebost/membership"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/storing"
 	"github.com/xescugc/rebost/util"
 	"github.com/xescugc/rebost/volume"
@@ -87,9 +88,35 @@ func newClient(t *testing.T, name string, remote string) (*client.Client, string
 	state, err := boltdb.NewStateRepository(bdb)
 	require.NoError(t, err)
 
+	buckets, err := boltdb.NewBucketRepository(bdb)
+	require.NoError(t, err)
+
+	versions, err := boltdb.NewVersionRepository(bdb)
+	require.NoError(t, err)
+
+	trs, err := boltdb.NewTrashRepository(bdb)
+	require.NoError(t, err)
+
+	apikeys, err := boltdb.NewAPIKeyRepository(bdb)
+	require.NoError(t, err)
+
 	suow := fs.UOWWithFs(boltdb.NewUOW(bdb))
 
-	v, err := volume.New(tmpDir, files, idxkeys, idxttl, idxvolumes, replicas, state, osfs, logger, suow)
+	v, err := volume.New(tmpDir, volume.Repositories{
+		Files:      files,
+		IDXKeys:    idxkeys,
+		IDXTTLs:    idxttl,
+		IDXVolumes: idxvolumes,
+		Replicas:   replicas,
+		State:      state,
+		Buckets:    buckets,
+		Versions:   versions,
+		Trash:      trs,
+		APIKeys:    apikeys,
+	}, osfs, suow, logger, volume.Options{
+		Hash:         signature.Algorithm(cfg.Hash),
+		Compressions: cfg.Compressions(),
+	})
 	require.NoError(t, err)
 
 	m, err := membership.New(cfg, []volume.Local{v}, cfg.Remote, logger)
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/rendezvous"
 )
 
 const (
@@ -49,7 +50,7 @@ func TestCRUD(t *testing.T) {
 
 	cl1, u1, vid1, ca1 := newClient(t, "n1", firstNode)
 	defer ca1()
-	cl2, _, _, ca2 := newClient(t, "n2", u1)
+	cl2, _, vid2, ca2 := newClient(t, "n2", u1)
 	defer ca2()
 	cl3, _, vid3, ca3 := newClient(t, "n3", u1)
 	defer ca3()
@@ -62,44 +63,32 @@ func TestCRUD(t *testing.T) {
 
 	t.Run("CreateFile", func(t *testing.T) {
 
-		err = cl1.CreateFile(ctx, keytxt, iorctxt, noReplica, noTTL, noCA)
+		err = cl1.CreateFile(ctx, keytxt, iorctxt, noReplica, noTTL, noCA, "")
 		require.NoError(t, err)
 
-		err = cl3.CreateFile(ctx, keyimg, iorcimg, noReplica, noTTL, noCA)
+		err = cl3.CreateFile(ctx, keyimg, iorcimg, noReplica, noTTL, noCA, "")
 		require.NoError(t, err)
 
 	})
 
 	t.Run("HasFile", func(t *testing.T) {
-		vid, ok, err := cl1.HasFile(ctx, keytxt)
-		require.NoError(t, err)
-		assert.True(t, ok)
-		assert.Equal(t, vid1, vid)
-
-		vid, ok, err = cl1.HasFile(ctx, keyimg)
-		require.NoError(t, err)
-		assert.False(t, ok)
-		assert.Equal(t, "", vid)
-
-		vid, ok, err = cl2.HasFile(ctx, keytxt)
-		require.NoError(t, err)
-		assert.False(t, ok)
-		assert.Equal(t, "", vid)
-
-		vid, ok, err = cl2.HasFile(ctx, keyimg)
-		require.NoError(t, err)
-		assert.False(t, ok)
-		assert.Equal(t, "", vid)
-
-		vid, ok, err = cl3.HasFile(ctx, keytxt)
-		require.NoError(t, err)
-		assert.False(t, ok)
-		assert.Equal(t, "", vid)
-
-		vid, ok, err = cl3.HasFile(ctx, keyimg)
-		require.NoError(t, err)
-		assert.True(t, ok)
-		assert.Equal(t, vid3, vid)
+		// The files are created on the preferred volume of
+		// the cluster for the key, no matter the Node
+		vids := []string{vid1, vid2, vid3}
+		for _, k := range []string{keytxt, keyimg} {
+			owner := rendezvous.Rank(vids, k)[0]
+			for i, c := range clients {
+				vid, ok, err := c.HasFile(ctx, k)
+				require.NoError(t, err)
+				if vids[i] == owner {
+					assert.True(t, ok, k)
+					assert.Equal(t, owner, vid, k)
+				} else {
+					assert.False(t, ok, k)
+					assert.Equal(t, "", vid, k)
+				}
+			}
+		}
 	})
 
 	t.Run("GetFile", func(t *testing.T) {
@@ -177,12 +166,23 @@ func TestReplica(t *testing.T) {
 	vids := []string{vid1, vid2, vid3, vid4, vid5}
 	cancels := []cancelFn{ca1, ca2, ca3, ca4, ca5}
 
+	// The owner of the file is the Node with the preferred
+	// volume for the key so it's moved to be the first one
+	for i, vid := range vids {
+		if vid == rendezvous.Rank(vids, keytxt)[0] {
+			clients[0], clients[i] = clients[i], clients[0]
+			vids[0], vids[i] = vids[i], vids[0]
+			cancels[0], cancels[i] = cancels[i], cancels[0]
+			break
+		}
+	}
+
 	// Sleep one second to let the nodes communicate between each other
 	// and have the cluster stable
 	time.Sleep(time.Second)
 
 	t.Run("HasFileReplicatedOn3/5", func(t *testing.T) {
-		err := cl1.CreateFile(ctx, keytxt, iorctxt, 3, noTTL, noCA)
+		err := cl1.CreateFile(ctx, keytxt, iorctxt, 3, noTTL, noCA, "")
 		require.NoError(t, err)
 
 		// As the goroutine has a delay of 1s we may have to
@@ -316,7 +316,7 @@ func TestTTL(t *testing.T) {
 	time.Sleep(time.Second)
 
 	t.Run("HasFileReplicatedOn3/5", func(t *testing.T) {
-		err := cl1.CreateFile(ctx, keytxt, iorctxt, 3, ttl, noCA)
+		err := cl1.CreateFile(ctx, keytxt, iorctxt, 3, ttl, noCA, "")
 		require.NoError(t, err)
 
 		// As the goroutine has a delay of 1s we may have to
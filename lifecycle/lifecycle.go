@@ -0,0 +1,138 @@
+package lifecycle
+
+import (
+	"strings"
+	"time"
+)
+
+// Rule is the lifecycle of the Files which key has the Prefix,
+// all the durations are the age of the key since it was created
+type Rule struct {
+	Prefix string
+
+	// Expiration is the age at which the Files are
+	// deleted, 0 means they do not expire
+	Expiration time.Duration
+
+	// Replica is the number of replicas the Files are reduced to
+	// once they are ReplicaAfter old, 0 means they are not reduced
+	Replica      int
+	ReplicaAfter time.Duration
+
+	// Class is the storage class the Files are moved to once
+	// they are ClassAfter old, empty means they are not moved
+	Class      string
+	ClassAfter time.Duration
+}
+
+// Matches checks if the key k is managed by the Rule
+func (r Rule) Matches(k string) bool {
+	return strings.HasPrefix(k, r.Prefix)
+}
+
+// ExpiresAt returns when a File created at ca expires,
+// if it does not expire it returns the zero time
+func (r Rule) ExpiresAt(ca time.Time) time.Time {
+	if r.Expiration == 0 {
+		return time.Time{}
+	}
+	return ca.Add(r.Expiration)
+}
+
+// ReplicaAt returns the number of replicas a File created at ca
+// has to have at the now, 0 means it has not to be changed
+func (r Rule) ReplicaAt(ca, now time.Time) int {
+	if r.Replica == 0 || now.Before(ca.Add(r.ReplicaAfter)) {
+		return 0
+	}
+	return r.Replica
+}
+
+// ClassAt returns the storage class a File created at ca has
+// to have at the now, empty means it has not to be changed
+func (r Rule) ClassAt(ca, now time.Time) string {
+	if r.Class == "" || now.Before(ca.Add(r.ClassAfter)) {
+		return ""
+	}
+	return r.Class
+}
+
+// Find returns the Rule of the rs with the
+// longest Prefix that matches the key k
+func Find(rs []Rule, k string) (Rule, bool) {
+	var (
+		rule  Rule
+		found bool
+	)
+	for _, r := range rs {
+		if r.Matches(k) && (!found || len(r.Prefix) > len(rule.Prefix)) {
+			rule, found = r, true
+		}
+	}
+	return rule, found
+}
+
+// Plan is what has to be done to a File at
+// a point in time to follow the Rules
+type Plan struct {
+	// Expired are the keys of the File that have
+	// expired with the time in which they did
+	Expired map[string]time.Time
+
+	// Replica is the number of replicas the File has
+	// to be reduced to, 0 means it has not to be changed
+	Replica int
+
+	// Class is the storage class the File has to be
+	// moved to, empty means it has not to be changed
+	Class string
+}
+
+// Evaluate returns the Plan of a File with the keys, with when each one
+// was created, at the now with the rs. As the content of the File is
+// shared by all the keys that have not expired, the Replica is the
+// highest of them and the Class is only set if all of them agree on it
+func Evaluate(rs []Rule, keys map[string]time.Time, now time.Time) Plan {
+	var (
+		p = Plan{Expired: make(map[string]time.Time)}
+
+		reduce  = true
+		classes = make(map[string]struct{})
+		active  int
+	)
+	for k, ca := range keys {
+		r, ok := Find(rs, k)
+		if !ok {
+			reduce = false
+			classes[""] = struct{}{}
+			continue
+		}
+
+		if ea := r.ExpiresAt(ca); !ea.IsZero() && !now.Before(ea) {
+			p.Expired[k] = ea
+			continue
+		}
+		active++
+
+		rep := r.ReplicaAt(ca, now)
+		if rep == 0 {
+			reduce = false
+		} else if rep > p.Replica {
+			p.Replica = rep
+		}
+
+		classes[r.ClassAt(ca, now)] = struct{}{}
+	}
+
+	if !reduce || active == 0 {
+		p.Replica = 0
+	}
+
+	if len(classes) == 1 {
+		for c := range classes {
+			p.Class = c
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,127 @@
+package lifecycle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/lifecycle"
+)
+
+func TestFind(t *testing.T) {
+	rs := []lifecycle.Rule{
+		{Prefix: "logs/", Expiration: time.Hour},
+		{Prefix: "logs/debug/", Expiration: time.Minute},
+		{Prefix: "", Replica: 1},
+	}
+
+	tests := []struct {
+		Name  string
+		Key   string
+		ERule lifecycle.Rule
+	}{
+		{Name: "Prefix", Key: "logs/a", ERule: rs[0]},
+		{Name: "LongestPrefix", Key: "logs/debug/a", ERule: rs[1]},
+		{Name: "Empty", Key: "potato", ERule: rs[2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r, ok := lifecycle.Find(rs, tt.Key)
+			assert.True(t, ok)
+			assert.Equal(t, tt.ERule, r)
+		})
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, ok := lifecycle.Find(rs[:2], "potato")
+		assert.False(t, ok)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	var (
+		ca  = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		day = 24 * time.Hour
+		rs  = []lifecycle.Rule{
+			{Prefix: "logs/", Expiration: 30 * day, Replica: 1, ReplicaAfter: 7 * day, Class: "cold", ClassAfter: 14 * day},
+			{Prefix: "tmp/", Expiration: day},
+			{Prefix: "backups/", Replica: 2, ReplicaAfter: day, Class: "archive"},
+		}
+	)
+
+	tests := []struct {
+		Name  string
+		Keys  map[string]time.Time
+		Now   time.Time
+		EPlan lifecycle.Plan
+	}{
+		{
+			Name:  "Nothing",
+			Keys:  map[string]time.Time{"logs/a": ca},
+			Now:   ca.Add(day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}},
+		},
+		{
+			Name:  "Replica",
+			Keys:  map[string]time.Time{"logs/a": ca},
+			Now:   ca.Add(7 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}, Replica: 1},
+		},
+		{
+			Name:  "ReplicaAndClass",
+			Keys:  map[string]time.Time{"logs/a": ca},
+			Now:   ca.Add(20 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}, Replica: 1, Class: "cold"},
+		},
+		{
+			Name:  "Expired",
+			Keys:  map[string]time.Time{"logs/a": ca, "tmp/a": ca},
+			Now:   ca.Add(30 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{"logs/a": ca.Add(30 * day), "tmp/a": ca.Add(day)}},
+		},
+		{
+			Name:  "ExpiredOneKey",
+			Keys:  map[string]time.Time{"logs/a": ca, "tmp/a": ca},
+			Now:   ca.Add(20 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{"tmp/a": ca.Add(day)}, Replica: 1, Class: "cold"},
+		},
+		{
+			Name:  "HighestReplica",
+			Keys:  map[string]time.Time{"logs/a": ca, "backups/a": ca},
+			Now:   ca.Add(7 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}, Replica: 2},
+		},
+		{
+			Name:  "KeyWithoutRule",
+			Keys:  map[string]time.Time{"logs/a": ca, "potato": ca},
+			Now:   ca.Add(20 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}},
+		},
+		{
+			Name:  "DifferentClasses",
+			Keys:  map[string]time.Time{"logs/a": ca, "backups/a": ca},
+			Now:   ca.Add(20 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}, Replica: 2},
+		},
+		{
+			Name:  "KeyCreatedLater",
+			Keys:  map[string]time.Time{"logs/a": ca, "logs/b": ca.Add(10 * day)},
+			Now:   ca.Add(30 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{"logs/a": ca.Add(30 * day)}, Replica: 1, Class: "cold"},
+		},
+		{
+			Name:  "KeyCreatedLaterNotOldEnough",
+			Keys:  map[string]time.Time{"logs/a": ca, "logs/b": ca.Add(10 * day)},
+			Now:   ca.Add(15 * day),
+			EPlan: lifecycle.Plan{Expired: map[string]time.Time{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			p := lifecycle.Evaluate(rs, tt.Keys, tt.Now)
+			assert.Equal(t, tt.EPlan, p)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package membership
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/xescugc/rebost/bucket"
+)
+
+// messageType is the type of the messages
+// broadcasted to the cluster
+type messageType uint8
+
+const (
+	bucketMessage messageType = iota
+	keyMessage
+	invalidationMessage
+	keysFiltersMessage
+)
+
+// broadcast is a memberlist.Broadcast with the name
+// of what it's about so the older ones with the
+// same name are invalidated
+type broadcast struct {
+	name string
+	msg  []byte
+}
+
+func (b *broadcast) Invalidates(other memberlist.Broadcast) bool {
+	ob, ok := other.(*broadcast)
+	return ok && ob.name == b.name
+}
+
+func (b *broadcast) Message() []byte { return b.msg }
+
+func (b *broadcast) Finished() {}
+
+// BroadcastBucket notifies the b to all the Nodes of the cluster
+func (m *Membership) BroadcastBucket(b *bucket.Bucket) {
+	msg, err := json.Marshal(b)
+	if err != nil {
+		m.logger.Log("msg", "failed to encode the bucket", "bucket", b.Name, "error", err.Error())
+		return
+	}
+	m.broadcasts.QueueBroadcast(&broadcast{
+		name: "bucket/" + b.Name,
+		msg:  append([]byte{byte(bucketMessage)}, msg...),
+	})
+}
+
+// mergeBucket stores the b on the local volumes
+// if it's newer than the one they have
+func (m *Membership) mergeBucket(b *bucket.Bucket) {
+	for _, v := range m.localVolumes {
+		err := v.PutBucket(context.Background(), b)
+		if err != nil {
+			m.logger.Log("msg", "failed to store the bucket", "bucket", b.Name, "volume", v.ID(), "error", err.Error())
+		}
+	}
+}
+
+// localBuckets returns the Buckets of the Node, as all the local
+// volumes have the same ones it only uses the first one
+func (m *Membership) localBuckets() []*bucket.Bucket {
+	if len(m.localVolumes) == 0 {
+		return nil
+	}
+	bks, err := m.localVolumes[0].Buckets(context.Background())
+	if err != nil {
+		m.logger.Log("msg", "failed to read the buckets", "error", err.Error())
+		return nil
+	}
+	return bks
+}
+
+// maxKeyMessage is the maximum size of the message of a key,
+// the bigger ones do not fit on the gossip packets
+const maxKeyMessage = 1024
+
+// key is a key created on a Node out of its preferred volumes
+// that is not on the keysFilters of the Node yet
+type key struct {
+	Node string    `json:"node"`
+	Key  string    `json:"key"`
+	At   time.Time `json:"at"`
+}
+
+// BroadcastKey notifies the k, created on the Node, to all the Nodes of the
+// cluster so they know it has it until the filters of its keys include it
+func (m *Membership) BroadcastKey(k string) {
+	msg, err := json.Marshal(key{Node: m.cfg.Name, Key: k, At: time.Now()})
+	if err != nil {
+		m.logger.Log("msg", "failed to encode the key", "key", k, "error", err.Error())
+		return
+	}
+	if len(msg) > maxKeyMessage {
+		return
+	}
+	m.broadcasts.QueueBroadcast(&broadcast{
+		name: "key/" + k,
+		msg:  append([]byte{byte(keyMessage)}, msg...),
+	})
+}
+
+// BroadcastInvalidation notifies the cluster that the locations of the
+// k they know are no longer valid, as it was deleted or overwritten
+func (m *Membership) BroadcastInvalidation(k string) {
+	if len(k)+1 > maxKeyMessage {
+		return
+	}
+	m.broadcasts.QueueBroadcast(&broadcast{
+		name: "invalidation/" + k,
+		msg:  append([]byte{byte(invalidationMessage)}, k...),
+	})
+}
+
+// OnKeyInvalidated sets the fn to be called with the keys
+// which locations are invalidated by the other Nodes
+func (m *Membership) OnKeyInvalidated(fn func(k string)) {
+	m.keyInvalidatedLock.Lock()
+	m.keyInvalidated = fn
+	m.keyInvalidatedLock.Unlock()
+}
+
+// invalidateKey notifies the k to the fn set with OnKeyInvalidated
+func (m *Membership) invalidateKey(k string) {
+	m.keyInvalidatedLock.RLock()
+	fn := m.keyInvalidated
+	m.keyInvalidatedLock.RUnlock()
+
+	if fn != nil {
+		fn(k)
+	}
+}
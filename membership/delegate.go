@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/state"
 )
 
@@ -13,8 +14,9 @@ type delegate struct {
 
 func (d *delegate) NodeMeta(limit int) []byte {
 	m := Metadata{
-		Port:    d.members.cfg.Port,
-		Volumes: make(map[string]struct{}),
+		Port:            d.members.cfg.Port,
+		Volumes:         make(map[string]struct{}),
+		CoordinatorPort: d.members.cfg.Coordinator.Port,
 	}
 	for _, v := range d.members.localVolumes {
 		m.Volumes[v.ID()] = struct{}{}
@@ -27,10 +29,40 @@ func (d *delegate) NodeMeta(limit int) []byte {
 	return b
 }
 
-func (d *delegate) NotifyMsg([]byte) {}
+func (d *delegate) NotifyMsg(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	switch messageType(b[0]) {
+	case bucketMessage:
+		var bk bucket.Bucket
+		err := json.Unmarshal(b[1:], &bk)
+		if err != nil {
+			return
+		}
+		d.members.mergeBucket(&bk)
+	case keyMessage:
+		var k key
+		err := json.Unmarshal(b[1:], &k)
+		if err != nil {
+			return
+		}
+		d.members.addNodeKey(k)
+	case invalidationMessage:
+		d.members.invalidateKey(string(b[1:]))
+	case keysFiltersMessage:
+		var kf keysFilters
+		err := json.Unmarshal(b[1:], &kf)
+		if err != nil {
+			return
+		}
+		d.members.updateNodeKeysFilters(kf)
+	}
+}
 
 func (d *delegate) GetBroadcasts(overhead, limit int) [][]byte {
-	return nil
+	return d.members.broadcasts.GetBroadcasts(overhead, limit)
 }
 
 func (d *delegate) LocalState(join bool) []byte {
@@ -45,6 +77,7 @@ func (d *delegate) LocalState(join bool) []byte {
 		}
 		s.Volumes[v.ID()] = *vs
 	}
+	s.Buckets = d.members.localBuckets()
 	b, err := json.Marshal(s)
 	if err != nil {
 		panic(err)
@@ -55,5 +88,14 @@ func (d *delegate) LocalState(join bool) []byte {
 func (d *delegate) MergeRemoteState(buf []byte, join bool) {
 	var s State
 	_ = json.Unmarshal(buf, &s)
+
+	// The Buckets are also gossiped with the State so
+	// the Nodes that missed a broadcast, or just joined,
+	// end up having the same ones
+	for _, b := range s.Buckets {
+		d.members.mergeBucket(b)
+	}
+	s.Buckets = nil
+
 	_ = d.members.updateNodeState(s)
 }
@@ -31,7 +31,7 @@ func (e *eventDelegate) NotifyJoin(n *memberlist.Node) {
 	}
 
 	url := net.JoinHostPort(n.Addr.String(), strconv.Itoa(meta.Port))
-	c, err := client.New(url)
+	c, err := client.NewWithTLS(e.members.tls, e.members.withCredentials(url))
 	if err != nil {
 		panic(err)
 	}
@@ -39,16 +39,30 @@ func (e *eventDelegate) NotifyJoin(n *memberlist.Node) {
 	nn := node{
 		conn: c,
 		meta: meta,
+		url:  e.members.publicURL(url),
 		state: State{
 			Volumes: make(map[string]state.State),
 		},
 	}
+	if meta.CoordinatorPort != 0 {
+		nn.coordinator = net.JoinHostPort(n.Addr.String(), strconv.Itoa(meta.CoordinatorPort))
+	}
 
 	e.members.nodesLock.Lock()
+	// The keys are kept when the Node is updated
+	if on, ok := e.members.nodes[n.Name]; ok {
+		nn.keys = on.keys
+	}
 	e.members.nodes[n.Name] = nn
 	e.members.logger.Log("action", "join", "name", n.Name, "url", url)
 	e.members.nodesLock.Unlock()
 
+	// The new Node does not have the filters of
+	// the keys of the local volumes yet
+	if len(e.members.localVolumes) != 0 {
+		go e.members.sendKeysFilters(e.members.localKeysFilters(), n)
+	}
+
 	// We remove any vid that was marked as to be deleted
 	e.members.removedVolumeIDsLock.Lock()
 	for vid := range meta.Volumes {
@@ -66,6 +80,7 @@ func (e *eventDelegate) NotifyLeave(n *memberlist.Node) {
 		e.members.removedVolumeIDs[vid] = time.Now()
 	}
 	delete(e.members.nodes, n.Name)
+	delete(e.members.keysFilters, n.Name)
 	e.members.logger.Log("action", "leave", "name", n.Name)
 
 	e.members.nodesLock.Unlock()
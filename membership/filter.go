@@ -0,0 +1,184 @@
+package membership
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/xescugc/rebost/bloom"
+	"github.com/xescugc/rebost/client"
+)
+
+const (
+	// keysFiltersInterval is the interval in which the filters
+	// of the keys of the local volumes are sent to the
+	// other Nodes if they changed
+	keysFiltersInterval = time.Minute
+
+	// keysFiltersRefresh is the time after which the filters are
+	// sent again even if they did not change, so the Nodes that
+	// missed them end up having them
+	keysFiltersRefresh = 10 * time.Minute
+)
+
+// keysFilters are the filters of the keys of the volumes of a Node
+// and when it read them. They are too big to be gossiped with the
+// State of the Node so they are sent on their own, with TCP, only
+// when they change and to the Nodes that join
+type keysFilters struct {
+	Node    string                   `json:"node"`
+	Filters map[string]*bloom.Filter `json:"filters"`
+	At      time.Time                `json:"at"`
+}
+
+// NodesWithKey returns the Nodes of the cluster, except the current one,
+// that may have the key k by the filters of the keys of their volumes
+func (m *Membership) NodesWithKey(k string) (res []*client.Client) {
+	m.nodesLock.RLock()
+	for nn, n := range m.nodes {
+		if n.mayHaveKey(k, m.keysFilters[nn].Filters) {
+			res = append(res, n.conn)
+		}
+	}
+	m.nodesLock.RUnlock()
+
+	return
+}
+
+// mayHaveKey checks if any of the volumes of the n may have the key k by
+// the fs, if the filter of one of the volumes is not known it may have it
+func (n node) mayHaveKey(k string, fs map[string]*bloom.Filter) bool {
+	if _, ok := n.keys[k]; ok {
+		return true
+	}
+	for vid := range n.meta.Volumes {
+		f, ok := fs[vid]
+		if !ok || f.Test(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// localKeysFilters returns the filters of the keys of the local volumes,
+// the keys added after the At are not on them
+func (m *Membership) localKeysFilters() keysFilters {
+	kf := keysFilters{
+		Node:    m.cfg.Name,
+		Filters: make(map[string]*bloom.Filter, len(m.localVolumes)),
+		At:      time.Now(),
+	}
+	for _, v := range m.localVolumes {
+		f, err := v.KeysFilter(context.Background())
+		if err != nil {
+			// Without the filter the other Nodes
+			// will consider it may have any key
+			m.logger.Log("msg", "failed to calculate the keys filter", "volume", v.ID(), "error", err.Error())
+			continue
+		}
+		kf.Filters[v.ID()] = f
+	}
+
+	return kf
+}
+
+// loopKeysFilters sends the filters of the keys of the local volumes
+// to all the Nodes every keysFiltersInterval if they changed, or after
+// the keysFiltersRefresh if not, until the Node leaves the cluster
+func (m *Membership) loopKeysFilters() {
+	// The Nodes without volumes
+	// have no keys to send
+	if len(m.localVolumes) == 0 {
+		return
+	}
+
+	var (
+		ticker = time.NewTicker(keysFiltersInterval)
+		last   []byte
+		lastAt time.Time
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			kf := m.localKeysFilters()
+			fs, err := json.Marshal(kf.Filters)
+			if err != nil {
+				continue
+			}
+			if bytes.Equal(fs, last) && time.Since(lastAt) < keysFiltersRefresh {
+				continue
+			}
+			last, lastAt = fs, time.Now()
+
+			var ns []*memberlist.Node
+			for _, n := range m.members.Members() {
+				if n.Name != m.cfg.Name {
+					ns = append(ns, n)
+				}
+			}
+			m.sendKeysFilters(kf, ns...)
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// sendKeysFilters sends the kf to the ns
+func (m *Membership) sendKeysFilters(kf keysFilters, ns ...*memberlist.Node) {
+	msg, err := json.Marshal(kf)
+	if err != nil {
+		m.logger.Log("msg", "failed to encode the keys filters", "error", err.Error())
+		return
+	}
+	msg = append([]byte{byte(keysFiltersMessage)}, msg...)
+
+	for _, n := range ns {
+		err = m.members.SendReliable(n, msg)
+		if err != nil {
+			m.logger.Log("msg", "failed to send the keys filters", "node", n.Name, "error", err.Error())
+		}
+	}
+}
+
+// updateNodeKeysFilters sets the kf to the Node that sent
+// them, if they are newer than the ones it already has
+func (m *Membership) updateNodeKeysFilters(kf keysFilters) {
+	m.nodesLock.Lock()
+	defer m.nodesLock.Unlock()
+
+	if kf.At.Before(m.keysFilters[kf.Node].At) {
+		return
+	}
+	m.keysFilters[kf.Node] = kf
+
+	// The keys broadcasted before the
+	// keysFilters are already on them
+	if n, ok := m.nodes[kf.Node]; ok {
+		for k, at := range n.keys {
+			if at.Before(kf.At) {
+				delete(n.keys, k)
+			}
+		}
+	}
+}
+
+// addNodeKey adds the k to the keys of the Node that broadcasted
+// it, if the keysFilters of it are older than the key
+func (m *Membership) addNodeKey(k key) {
+	m.nodesLock.Lock()
+	defer m.nodesLock.Unlock()
+
+	n, ok := m.nodes[k.Node]
+	if !ok || k.At.Before(m.keysFilters[k.Node].At) {
+		return
+	}
+	if n.keys == nil {
+		n.keys = make(map[string]time.Time)
+	}
+	n.keys[k.Key] = k.At
+	m.nodes[k.Node] = n
+}
@@ -2,6 +2,7 @@ package membership
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -12,8 +13,10 @@ import (
 
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/hashicorp/memberlist"
+	"github.com/xescugc/rebost/auth"
 	"github.com/xescugc/rebost/client"
 	"github.com/xescugc/rebost/config"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/volume"
 )
 
@@ -23,20 +26,42 @@ type Membership struct {
 	members *memberlist.Memberlist
 	events  *memberlist.EventDelegate
 
+	// broadcasts are the messages that have
+	// to be gossiped to the cluster
+	broadcasts *memberlist.TransmitLimitedQueue
+
 	localVolumes []volume.Local
 	cfg          *config.Config
 
+	// tls is the configuration used by the
+	// clients of the Nodes, nil if no TLS
+	tls *tls.Config
+
 	// Somehow improve this to make it easy
 	// to search for Nodes by Volume
 	nodesLock sync.RWMutex
 	nodes     map[string]node
 
+	// keysFilters are the filters of the keys of the Nodes by
+	// their name, they are not on the nodes as they can be
+	// received before the Node joins. Locked with the nodesLock
+	keysFilters map[string]keysFilters
+
 	// removedVolumeIDs list of all the volumeIDs removed by
 	// nodes leaving the cluster and the time in which
 	// this was detected
 	removedVolumeIDs     map[string]time.Time
 	removedVolumeIDsLock sync.Mutex
 
+	// done is closed when the Node leaves the cluster
+	done      chan struct{}
+	leaveOnce sync.Once
+
+	// keyInvalidated is called with the keys which
+	// locations are invalidated by the cluster
+	keyInvalidated     func(k string)
+	keyInvalidatedLock sync.RWMutex
+
 	logger kitlog.Logger
 }
 
@@ -46,6 +71,18 @@ type node struct {
 	conn  *client.Client
 	meta  Metadata
 	state State
+
+	// url is the public URL of the Node, without
+	// the credentials used by the conn
+	url string
+
+	// coordinator is the host:port used to elect the leader,
+	// empty if the Node does not take part on the election
+	coordinator string
+
+	// keys are the keys broadcasted by the Node with
+	// when, that are not on the keysFilters yet
+	keys map[string]time.Time
 }
 
 // New returns an implementation of the Membership interface
@@ -53,12 +90,27 @@ func New(cfg *config.Config, lv []volume.Local, remote string, logger kitlog.Log
 	m := &Membership{
 		localVolumes:     lv,
 		nodes:            make(map[string]node),
+		keysFilters:      make(map[string]keysFilters),
 		cfg:              cfg,
 		removedVolumeIDs: make(map[string]time.Time),
+		done:             make(chan struct{}),
 		logger:           kitlog.With(logger, "src", "membership", "name", cfg.Name),
 	}
 
-	list, err := memberlist.Create(m.buildConfig(cfg))
+	if cfg.TLS.Enabled() {
+		tc, err := cfg.TLS.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the TLS configuration: %s", err.Error())
+		}
+		m.tls = tc
+	}
+
+	mcfg, err := m.buildConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := memberlist.Create(mcfg)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create memberlist: %s", err.Error())
 	}
@@ -73,9 +125,16 @@ func New(cfg *config.Config, lv []volume.Local, remote string, logger kitlog.Log
 
 		if u.Scheme == "" {
 			u.Scheme = "http"
+			if m.tls != nil {
+				u.Scheme = "https"
+			}
 		}
 
-		c, err := client.New(u.String())
+		if m.cfg.Auth.ClusterSecret != "" {
+			u.User = url.UserPassword(auth.ClusterID, m.cfg.Auth.ClusterSecret)
+		}
+
+		c, err := client.NewWithTLS(m.tls, u.String())
 		if err != nil {
 			return nil, err
 		}
@@ -85,6 +144,15 @@ func New(cfg *config.Config, lv []volume.Local, remote string, logger kitlog.Log
 			return nil, err
 		}
 
+		// The legacy Nodes do not have the Hash so
+		// the Parse returns the Default (SHA1) for them
+		rh, _ := signature.Parse(cfg.Hash)
+		lh, _ := signature.Parse(m.cfg.Hash)
+		if rh != lh {
+			list.Shutdown()
+			return nil, fmt.Errorf("the remote cluster uses the hash %q and this Node %q, all the Nodes must use the same", cfg.Hash, lh)
+		}
+
 		host, _, err := net.SplitHostPort(u.Host)
 		if err != nil {
 			return nil, err
@@ -98,6 +166,8 @@ func New(cfg *config.Config, lv []volume.Local, remote string, logger kitlog.Log
 		m.logger.Log("msg", fmt.Sprintf("Joined remote cluster %q", hostPort))
 	}
 
+	go m.loopKeysFilters()
+
 	return m, nil
 }
 
@@ -120,6 +190,67 @@ func (m *Membership) GetNodeWithVolumeByID(vid string) (*client.Client, error) {
 	return nil, errors.New("not found")
 }
 
+// GetNodeByName returns the Node with the name nn
+func (m *Membership) GetNodeByName(nn string) (*client.Client, error) {
+	m.nodesLock.RLock()
+	defer m.nodesLock.RUnlock()
+	if n, ok := m.nodes[nn]; ok {
+		return n.conn, nil
+	}
+
+	return nil, errors.New("not found")
+}
+
+// NodeURL returns the public URL of the Node with the conn n
+func (m *Membership) NodeURL(n *client.Client) (string, error) {
+	m.nodesLock.RLock()
+	defer m.nodesLock.RUnlock()
+	for _, nd := range m.nodes {
+		if nd.conn == n {
+			return nd.url, nil
+		}
+	}
+
+	return "", errors.New("not found")
+}
+
+// LocalURL returns the public URL of the current Node
+func (m *Membership) LocalURL() string {
+	ln := m.members.LocalNode()
+	return m.publicURL(net.JoinHostPort(ln.Addr.String(), strconv.Itoa(m.cfg.Port)))
+}
+
+// publicURL returns the URL of the hp (host:port) used
+// to access it from outside of the cluster
+func (m *Membership) publicURL(hp string) string {
+	scheme := "http"
+	if m.tls != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + hp
+}
+
+// CoordinatorNodes returns the host:port used to elect the leader by
+// the name of the Nodes that take part on the election, the current
+// one included if it does
+func (m *Membership) CoordinatorNodes() map[string]string {
+	res := make(map[string]string)
+	if m.cfg.Coordinator.Enabled() {
+		ln := m.members.LocalNode()
+		res[ln.Name] = net.JoinHostPort(ln.Addr.String(), strconv.Itoa(m.cfg.Coordinator.Port))
+	}
+
+	m.nodesLock.RLock()
+	defer m.nodesLock.RUnlock()
+	for nn, n := range m.nodes {
+		if n.coordinator != "" {
+			res[nn] = n.coordinator
+		}
+	}
+
+	return res
+}
+
 // GetNodeState returns the volume State
 func (m *Membership) GetNodeState(nn string) (*State, error) {
 	m.nodesLock.RLock()
@@ -133,9 +264,23 @@ func (m *Membership) GetNodeState(nn string) (*State, error) {
 	return nil, errors.New("not found")
 }
 
-func (m *Membership) updateNodeState(s State) error {
+// NodeStates returns the State of all the Nodes
+// of the cluster except the current one
+func (m *Membership) NodeStates() []*State {
 	m.nodesLock.RLock()
 	defer m.nodesLock.RUnlock()
+	res := make([]*State, 0, len(m.nodes))
+	for _, n := range m.nodes {
+		s := n.state
+		res = append(res, &s)
+	}
+
+	return res
+}
+
+func (m *Membership) updateNodeState(s State) error {
+	m.nodesLock.Lock()
+	defer m.nodesLock.Unlock()
 	if n, ok := m.nodes[s.Node]; ok {
 		n.state = s
 		m.nodes[s.Node] = n
@@ -156,10 +301,29 @@ func (m *Membership) Nodes() (res []*client.Client) {
 	return
 }
 
-// NodesWithoutVolumeIDs return all the nodes of the Cluster
+// VolumeIDs returns the IDs of the volumes of all
+// the Nodes of the Cluster except the current one
+func (m *Membership) VolumeIDs() (res []string) {
+	m.nodesLock.RLock()
+	for _, n := range m.nodes {
+		for vid := range n.meta.Volumes {
+			res = append(res, vid)
+		}
+	}
+	m.nodesLock.RUnlock()
+
+	return
+}
+
+// NodesWithoutVolumeIDs return all the nodes of the Cluster that do not
+// have any of the vids, the ones without volumes (gateways) are ignored
+// as they can not store anything
 func (m *Membership) NodesWithoutVolumeIDs(vids []string) (res []*client.Client) {
 	m.nodesLock.RLock()
 	for _, r := range m.nodes {
+		if len(r.meta.Volumes) == 0 {
+			continue
+		}
 		var found bool
 		for _, vid := range vids {
 			if _, ok := r.meta.Volumes[vid]; ok {
@@ -194,12 +358,31 @@ func (m *Membership) RemovedVolumeIDs() []string {
 	return rvids
 }
 
+// leaveTimeout is the maximum time to wait for the leave to be
+// broadcasted, without it the Node could wait forever if the rest
+// of the Nodes leave at the same time
+const leaveTimeout = 5 * time.Second
+
 // Leave makes the node leave the cluster
 func (m *Membership) Leave() {
-	m.members.Leave(0)
+	m.leaveOnce.Do(func() { close(m.done) })
+
+	err := m.members.Leave(leaveTimeout)
+	if err != nil {
+		m.logger.Log("msg", "failed to leave the cluster", "error", err.Error())
+	}
+}
+
+// withCredentials adds the cluster credentials
+// to the hp (host:port) if there are
+func (m *Membership) withCredentials(hp string) string {
+	if m.cfg.Auth.ClusterSecret == "" {
+		return hp
+	}
+	return url.UserPassword(auth.ClusterID, m.cfg.Auth.ClusterSecret).String() + "@" + hp
 }
 
-func (m *Membership) buildConfig(cfg *config.Config) *memberlist.Config {
+func (m *Membership) buildConfig(cfg *config.Config) (*memberlist.Config, error) {
 	mcfg := memberlist.DefaultLocalConfig()
 	if cfg.Memberlist.Port != 0 {
 		mcfg.BindPort = cfg.Memberlist.Port
@@ -207,5 +390,28 @@ func (m *Membership) buildConfig(cfg *config.Config) *memberlist.Config {
 	mcfg.Name = cfg.Name
 	mcfg.Events = &eventDelegate{members: m}
 	mcfg.Delegate = &delegate{members: m}
-	return mcfg
+
+	m.broadcasts = &memberlist.TransmitLimitedQueue{
+		NumNodes: func() int {
+			if m.members == nil {
+				return 1
+			}
+			return m.members.NumMembers()
+		},
+		RetransmitMult: mcfg.RetransmitMult,
+	}
+
+	keys, err := cfg.Memberlist.SecretKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != 0 {
+		kr, err := memberlist.NewKeyring(keys, keys[0])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create the memberlist keyring: %s", err.Error())
+		}
+		mcfg.Keyring = kr
+	}
+
+	return mcfg, nil
 }
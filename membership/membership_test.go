@@ -2,6 +2,7 @@ package membership_test
 
 import (
 	"context"
+	"net"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/bloom"
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/config"
 	"github.com/xescugc/rebost/membership"
 	"github.com/xescugc/rebost/mock"
@@ -40,12 +43,16 @@ func TestVolumes(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			v := mock.NewVolumeLocal(ctrl)
-			v.EXPECT().ID().Return("id").Times(2)
+			v.EXPECT().ID().Return("id").AnyTimes()
 			v.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
 
 			v2 := mock.NewVolumeLocal(ctrl)
-			v2.EXPECT().ID().Return("id").Times(2)
+			v2.EXPECT().ID().Return("id").AnyTimes()
 			v2.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v2.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
 			p2, err := util.FreePort()
 			require.NoError(t, err)
 			cfg2 := &config.Config{Name: "am2", Replica: -1, Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
@@ -66,16 +73,302 @@ func TestVolumes(t *testing.T) {
 			assert.Equal(t, []volume.Local{v}, m.LocalVolumes())
 			assert.Equal(t, []string{}, m.RemovedVolumeIDs())
 		})
+		t.Run("AddGateway", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			v2 := mock.NewVolumeLocal(ctrl)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
+			v2.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v2.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+			p2, err := util.FreePort()
+			require.NoError(t, err)
+			cfg2 := &config.Config{Name: "gm2", Replica: -1, Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m2, err := membership.New(cfg2, []volume.Local{v2}, "", kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m2.Leave()
+
+			s, err := storing.New(cfg2, m2, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			server := httptest.NewServer(storing.MakeHandler(s))
+			defer server.Close()
+
+			p3, err := util.FreePort()
+			require.NoError(t, err)
+			cfg := &config.Config{Name: "gm", Gateway: true, Memberlist: config.Memberlist{Port: p3}, Cache: config.Cache{Size: config.DefaultCacheSize}, Coordinator: config.Coordinator{Port: 4000}}
+			m, err := membership.New(cfg, nil, server.URL, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m.Leave()
+
+			assert.Len(t, m.Nodes(), 1)
+			assert.Len(t, m.LocalVolumes(), 0)
+			assert.Equal(t, []string{"id2"}, m.VolumeIDs())
+			assert.Len(t, m.NodesWithoutVolumeIDs(nil), 1)
+
+			u, err := m.NodeURL(m.Nodes()[0])
+			require.NoError(t, err)
+			assert.Equal(t, m2.LocalURL(), u)
+
+			// The gateway can not store replicas
+			assert.Len(t, m2.Nodes(), 1)
+			assert.Len(t, m2.NodesWithoutVolumeIDs(nil), 0)
+
+			// Only the gateway takes part on the election
+			cns := m.CoordinatorNodes()
+			assert.Len(t, cns, 1)
+			assert.Equal(t, cns, m2.CoordinatorNodes())
+			_, port, err := net.SplitHostPort(cns["gm"])
+			require.NoError(t, err)
+			assert.Equal(t, "4000", port)
+		})
+		t.Run("FailsWithDifferentHash", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			v := mock.NewVolumeLocal(ctrl)
+			v.EXPECT().ID().Return("id").AnyTimes()
+
+			v2 := mock.NewVolumeLocal(ctrl)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
+			p2, err := util.FreePort()
+			require.NoError(t, err)
+			cfg2 := &config.Config{Name: "hm2", Replica: -1, Hash: "sha256", Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m2, err := membership.New(cfg2, []volume.Local{v2}, "", kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m2.Leave()
+
+			s, err := storing.New(cfg2, m2, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			server := httptest.NewServer(storing.MakeHandler(s))
+			defer server.Close()
+
+			p3, err := util.FreePort()
+			require.NoError(t, err)
+			cfg := &config.Config{Name: "hm", Memberlist: config.Memberlist{Port: p3}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			_, err = membership.New(cfg, []volume.Local{v}, server.URL, kitlog.NewNopLogger())
+			assert.EqualError(t, err, `the remote cluster uses the hash "sha256" and this Node "sha1", all the Nodes must use the same`)
+		})
+		t.Run("AddWithGossipKeys", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			v := mock.NewVolumeLocal(ctrl)
+			v.EXPECT().ID().Return("id").AnyTimes()
+			v.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+
+			v2 := mock.NewVolumeLocal(ctrl)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
+			v2.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v2.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+			p2, err := util.FreePort()
+			require.NoError(t, err)
+			cfg2 := &config.Config{Name: "gm2", Replica: -1, Memberlist: config.Memberlist{Port: p2, Keys: []string{"MDEyMzQ1Njc4OWFiY2RlZg=="}}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m2, err := membership.New(cfg2, []volume.Local{v2}, "", kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m2.Leave()
+
+			s, err := storing.New(cfg2, m2, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			server := httptest.NewServer(storing.MakeHandler(s))
+			defer server.Close()
+
+			// Without the key it can not join
+			p3, err := util.FreePort()
+			require.NoError(t, err)
+			cfg := &config.Config{Name: "gm", Memberlist: config.Memberlist{Port: p3}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			_, err = membership.New(cfg, []volume.Local{v}, server.URL, kitlog.NewNopLogger())
+			assert.Error(t, err)
+
+			// With the same primary key and an extra one, which
+			// is how a new key is added before rotating to it
+			p4, err := util.FreePort()
+			require.NoError(t, err)
+			cfg = &config.Config{Name: "gm3", Memberlist: config.Memberlist{Port: p4, Keys: []string{"MDEyMzQ1Njc4OWFiY2RlZg==", "ZmVkY2JhOTg3NjU0MzIxMA=="}}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m, err := membership.New(cfg, []volume.Local{v}, server.URL, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m.Leave()
+			assert.Len(t, m.Nodes(), 1)
+		})
+		t.Run("NodesWithKey", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			f := bloom.New(1, 0.01)
+			f.Add("a")
+
+			v := mock.NewVolumeLocal(ctrl)
+			v.EXPECT().ID().Return("id").AnyTimes()
+			v.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+
+			v2 := mock.NewVolumeLocal(ctrl)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
+			v2.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v2.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(f, nil).AnyTimes()
+
+			p2, err := util.FreePort()
+			require.NoError(t, err)
+			cfg2 := &config.Config{Name: "km2", Replica: -1, Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m2, err := membership.New(cfg2, []volume.Local{v2}, "", kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m2.Leave()
+
+			s, err := storing.New(cfg2, m2, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			server := httptest.NewServer(storing.MakeHandler(s))
+			defer server.Close()
+
+			p3, err := util.FreePort()
+			require.NoError(t, err)
+			cfg := &config.Config{Name: "km", Memberlist: config.Memberlist{Port: p3}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m, err := membership.New(cfg, []volume.Local{v}, server.URL, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m.Leave()
+
+			// The filters are sent once it joins
+			assert.Eventually(t, func() bool {
+				return len(m.NodesWithKey("b")) == 0
+			}, time.Second, 10*time.Millisecond)
+			assert.Len(t, m.NodesWithKey("a"), 1)
+		})
+		t.Run("SyncBuckets", func(t *testing.T) {
+			var (
+				b  = &bucket.Bucket{Name: "logs", Policy: bucket.Private, UpdatedAt: time.Now().UTC()}
+				b2 = &bucket.Bucket{Name: "images", Policy: bucket.PublicRead, UpdatedAt: time.Now().UTC()}
+			)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The broadcasts are retransmitted so the same
+			// Bucket can be received more than once
+			putC := make(chan *bucket.Bucket, 10)
+
+			v := mock.NewVolumeLocal(ctrl)
+			v.EXPECT().ID().Return("id").AnyTimes()
+			v.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+			v.EXPECT().PutBucket(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, b *bucket.Bucket) error {
+				select {
+				case putC <- b:
+				default:
+				}
+				return nil
+			}).MinTimes(2)
+
+			v2 := mock.NewVolumeLocal(ctrl)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
+			v2.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v2.EXPECT().Buckets(gomock.Any()).Return([]*bucket.Bucket{b}, nil).AnyTimes()
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+
+			p2, err := util.FreePort()
+			require.NoError(t, err)
+			cfg2 := &config.Config{Name: "bm2", Replica: -1, Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m2, err := membership.New(cfg2, []volume.Local{v2}, "", kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m2.Leave()
+
+			s, err := storing.New(cfg2, m2, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			server := httptest.NewServer(storing.MakeHandler(s))
+			defer server.Close()
+
+			p3, err := util.FreePort()
+			require.NoError(t, err)
+			cfg := &config.Config{Name: "bm", Memberlist: config.Memberlist{Port: p3}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m, err := membership.New(cfg, []volume.Local{v}, server.URL, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m.Leave()
+
+			// The ones the Node had before joining
+			// are received with the State
+			select {
+			case rb := <-putC:
+				assert.Equal(t, b, rb)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the bucket was not synchronized on join")
+			}
+
+			// The new ones are broadcasted
+			m2.BroadcastBucket(b2)
+			select {
+			case rb := <-putC:
+				assert.Equal(t, b2, rb)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the bucket was not broadcasted")
+			}
+		})
+		t.Run("InvalidateKeys", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			v := mock.NewVolumeLocal(ctrl)
+			v.EXPECT().ID().Return("id").AnyTimes()
+			v.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+
+			v2 := mock.NewVolumeLocal(ctrl)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
+			v2.EXPECT().GetState(gomock.Any()).Return(&state.State{}, nil).AnyTimes()
+			v2.EXPECT().Buckets(gomock.Any()).Return(nil, nil).AnyTimes()
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
+
+			p2, err := util.FreePort()
+			require.NoError(t, err)
+			cfg2 := &config.Config{Name: "im2", Replica: -1, Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m2, err := membership.New(cfg2, []volume.Local{v2}, "", kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m2.Leave()
+
+			s, err := storing.New(cfg2, m2, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			server := httptest.NewServer(storing.MakeHandler(s))
+			defer server.Close()
+
+			p3, err := util.FreePort()
+			require.NoError(t, err)
+			cfg := &config.Config{Name: "im", Memberlist: config.Memberlist{Port: p3}, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			m, err := membership.New(cfg, []volume.Local{v}, server.URL, kitlog.NewNopLogger())
+			require.NoError(t, err)
+			defer m.Leave()
+
+			// The broadcasts are retransmitted so the same
+			// key can be received more than once
+			keyC := make(chan string, 10)
+			m.OnKeyInvalidated(func(k string) {
+				select {
+				case keyC <- k:
+				default:
+				}
+			})
+
+			m2.BroadcastInvalidation("a")
+			select {
+			case k := <-keyC:
+				assert.Equal(t, "a", k)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the invalidation was not broadcasted")
+			}
+		})
 		t.Run("Remove", func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			v := mock.NewVolumeLocal(ctrl)
-			v.EXPECT().ID().Return("id").Times(2)
+			v.EXPECT().ID().Return("id").AnyTimes()
 			v.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
 
 			v2 := mock.NewVolumeLocal(ctrl)
-			v2.EXPECT().ID().Return("id2").Times(2)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
 			v2.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v2.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
 			p2, err := util.FreePort()
 			require.NoError(t, err)
 			cfg2 := &config.Config{Name: "rm2", Replica: -1, Memberlist: config.Memberlist{Port: p2}, Cache: config.Cache{Size: config.DefaultCacheSize}}
@@ -104,12 +397,16 @@ func TestVolumes(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			v := mock.NewVolumeLocal(ctrl)
-			v.EXPECT().ID().Return("id").Times(2)
+			v.EXPECT().ID().Return("id").AnyTimes()
 			v.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
 
 			v2 := mock.NewVolumeLocal(ctrl)
-			v2.EXPECT().ID().Return("id2").Times(2)
+			v2.EXPECT().ID().Return("id2").AnyTimes()
 			v2.EXPECT().GetState(context.Background()).Return(&state.State{}, nil)
+			v2.EXPECT().Buckets(context.Background()).Return(nil, nil)
+			v2.EXPECT().KeysFilter(gomock.Any()).Return(bloom.New(0, 0.01), nil).AnyTimes()
 
 			p2, err := util.FreePort()
 			require.NoError(t, err)
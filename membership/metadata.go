@@ -8,4 +8,8 @@ type Metadata struct {
 
 	// Volumes list of all VolumeIDs of the Node
 	Volumes map[string]struct{} `json:"volumes"`
+
+	// CoordinatorPort is the port used to elect the leader,
+	// 0 if the Node does not take part on the election
+	CoordinatorPort int `json:"coordinator_port,omitempty"`
 }
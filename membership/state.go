@@ -1,6 +1,9 @@
 package membership
 
-import "github.com/xescugc/rebost/state"
+import (
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/state"
+)
 
 // State holds the node state which will be notified to the other Nodes
 type State struct {
@@ -10,4 +13,8 @@ type State struct {
 	// Volumes is the list of volumes of the Node with the State
 	// each one have
 	Volumes map[string]state.State `json:"volume_ids"`
+
+	// Buckets is the list of Buckets the Node
+	// knows, including the deleted ones
+	Buckets []*bucket.Bucket `json:"buckets,omitempty"`
 }
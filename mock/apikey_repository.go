@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/rebost/apikey (interfaces: Repository)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	apikey "github.com/xescugc/rebost/apikey"
+)
+
+// APIKeyRepository is a mock of Repository interface.
+type APIKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *APIKeyRepositoryMockRecorder
+}
+
+// APIKeyRepositoryMockRecorder is the mock recorder for APIKeyRepository.
+type APIKeyRepositoryMockRecorder struct {
+	mock *APIKeyRepository
+}
+
+// NewAPIKeyRepository creates a new mock instance.
+func NewAPIKeyRepository(ctrl *gomock.Controller) *APIKeyRepository {
+	mock := &APIKeyRepository{ctrl: ctrl}
+	mock.recorder = &APIKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *APIKeyRepository) EXPECT() *APIKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *APIKeyRepository) All(arg0 context.Context) ([]*apikey.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*apikey.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *APIKeyRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*APIKeyRepository)(nil).All), arg0)
+}
+
+// CreateOrReplace mocks base method.
+func (m *APIKeyRepository) CreateOrReplace(arg0 context.Context, arg1 *apikey.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrReplace", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrReplace indicates an expected call of CreateOrReplace.
+func (mr *APIKeyRepositoryMockRecorder) CreateOrReplace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrReplace", reflect.TypeOf((*APIKeyRepository)(nil).CreateOrReplace), arg0, arg1)
+}
+
+// FindByID mocks base method.
+func (m *APIKeyRepository) FindByID(arg0 context.Context, arg1 string) (*apikey.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", arg0, arg1)
+	ret0, _ := ret[0].(*apikey.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *APIKeyRepositoryMockRecorder) FindByID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*APIKeyRepository)(nil).FindByID), arg0, arg1)
+}
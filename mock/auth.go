@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/rebost/auth (interfaces: Service)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	auth "github.com/xescugc/rebost/auth"
+	presign "github.com/xescugc/rebost/presign"
+)
+
+// Auth is a mock of Service interface.
+type Auth struct {
+	ctrl     *gomock.Controller
+	recorder *AuthMockRecorder
+}
+
+// AuthMockRecorder is the mock recorder for Auth.
+type AuthMockRecorder struct {
+	mock *Auth
+}
+
+// NewAuth creates a new mock instance.
+func NewAuth(ctrl *gomock.Controller) *Auth {
+	mock := &Auth{ctrl: ctrl}
+	mock.recorder = &AuthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Auth) EXPECT() *AuthMockRecorder {
+	return m.recorder
+}
+
+// CreateKey mocks base method.
+func (m *Auth) CreateKey(arg0 context.Context, arg1 auth.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateKey indicates an expected call of CreateKey.
+func (mr *AuthMockRecorder) CreateKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*Auth)(nil).CreateKey), arg0, arg1)
+}
+
+// DeleteKey mocks base method.
+func (m *Auth) DeleteKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteKey indicates an expected call of DeleteKey.
+func (mr *AuthMockRecorder) DeleteKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKey", reflect.TypeOf((*Auth)(nil).DeleteKey), arg0, arg1)
+}
+
+// Keys mocks base method.
+func (m *Auth) Keys(arg0 context.Context) ([]auth.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", arg0)
+	ret0, _ := ret[0].([]auth.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *AuthMockRecorder) Keys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*Auth)(nil).Keys), arg0)
+}
+
+// Presign mocks base method.
+func (m *Auth) Presign(arg0 context.Context, arg1 presign.URL) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Presign", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Presign indicates an expected call of Presign.
+func (mr *AuthMockRecorder) Presign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Presign", reflect.TypeOf((*Auth)(nil).Presign), arg0, arg1)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/rebost/bucket (interfaces: Repository)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	bucket "github.com/xescugc/rebost/bucket"
+)
+
+// BucketRepository is a mock of Repository interface.
+type BucketRepository struct {
+	ctrl     *gomock.Controller
+	recorder *BucketRepositoryMockRecorder
+}
+
+// BucketRepositoryMockRecorder is the mock recorder for BucketRepository.
+type BucketRepositoryMockRecorder struct {
+	mock *BucketRepository
+}
+
+// NewBucketRepository creates a new mock instance.
+func NewBucketRepository(ctrl *gomock.Controller) *BucketRepository {
+	mock := &BucketRepository{ctrl: ctrl}
+	mock.recorder = &BucketRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *BucketRepository) EXPECT() *BucketRepositoryMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *BucketRepository) All(arg0 context.Context) ([]*bucket.Bucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*bucket.Bucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *BucketRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*BucketRepository)(nil).All), arg0)
+}
+
+// CreateOrReplace mocks base method.
+func (m *BucketRepository) CreateOrReplace(arg0 context.Context, arg1 *bucket.Bucket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrReplace", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrReplace indicates an expected call of CreateOrReplace.
+func (mr *BucketRepositoryMockRecorder) CreateOrReplace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrReplace", reflect.TypeOf((*BucketRepository)(nil).CreateOrReplace), arg0, arg1)
+}
+
+// FindByName mocks base method.
+func (m *BucketRepository) FindByName(arg0 context.Context, arg1 string) (*bucket.Bucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByName", arg0, arg1)
+	ret0, _ := ret[0].(*bucket.Bucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByName indicates an expected call of FindByName.
+func (mr *BucketRepositoryMockRecorder) FindByName(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByName", reflect.TypeOf((*BucketRepository)(nil).FindByName), arg0, arg1)
+}
@@ -35,6 +35,21 @@ func (m *IDXKeyRepository) EXPECT() *IDXKeyRepositoryMockRecorder {
 	return m.recorder
 }
 
+// All mocks base method.
+func (m *IDXKeyRepository) All(arg0 context.Context) ([]*idxkey.IDXKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*idxkey.IDXKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *IDXKeyRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*IDXKeyRepository)(nil).All), arg0)
+}
+
 // CreateOrReplace mocks base method.
 func (m *IDXKeyRepository) CreateOrReplace(arg0 context.Context, arg1 *idxkey.IDXKey) error {
 	m.ctrl.T.Helper()
@@ -65,18 +65,18 @@ func (mr *IDXTTLRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.C
 }
 
 // Filter mocks base method.
-func (m *IDXTTLRepository) Filter(arg0 context.Context, arg1 time.Time) ([]*idxttl.IDXTTL, error) {
+func (m *IDXTTLRepository) Filter(arg0 context.Context, arg1 time.Time, arg2 int) ([]*idxttl.IDXTTL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Filter", arg0, arg1)
+	ret := m.ctrl.Call(m, "Filter", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]*idxttl.IDXTTL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Filter indicates an expected call of Filter.
-func (mr *IDXTTLRepositoryMockRecorder) Filter(arg0, arg1 interface{}) *gomock.Call {
+func (mr *IDXTTLRepositoryMockRecorder) Filter(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*IDXTTLRepository)(nil).Filter), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*IDXTTLRepository)(nil).Filter), arg0, arg1, arg2)
 }
 
 // Find mocks base method.
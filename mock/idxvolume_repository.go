@@ -35,6 +35,21 @@ func (m *IDXVolumeRepository) EXPECT() *IDXVolumeRepositoryMockRecorder {
 	return m.recorder
 }
 
+// All mocks base method.
+func (m *IDXVolumeRepository) All(arg0 context.Context) ([]*idxvolume.IDXVolume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*idxvolume.IDXVolume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *IDXVolumeRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*IDXVolumeRepository)(nil).All), arg0)
+}
+
 // CreateOrReplace mocks base method.
 func (m *IDXVolumeRepository) CreateOrReplace(arg0 context.Context, arg1 *idxvolume.IDXVolume) error {
 	m.ctrl.T.Helper()
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	bucket "github.com/xescugc/rebost/bucket"
 	client "github.com/xescugc/rebost/client"
 	membership "github.com/xescugc/rebost/membership"
 	volume "github.com/xescugc/rebost/volume"
@@ -36,6 +37,71 @@ func (m *Membership) EXPECT() *MembershipMockRecorder {
 	return m.recorder
 }
 
+// BroadcastBucket mocks base method.
+func (m *Membership) BroadcastBucket(arg0 *bucket.Bucket) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BroadcastBucket", arg0)
+}
+
+// BroadcastBucket indicates an expected call of BroadcastBucket.
+func (mr *MembershipMockRecorder) BroadcastBucket(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastBucket", reflect.TypeOf((*Membership)(nil).BroadcastBucket), arg0)
+}
+
+// BroadcastInvalidation mocks base method.
+func (m *Membership) BroadcastInvalidation(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BroadcastInvalidation", arg0)
+}
+
+// BroadcastInvalidation indicates an expected call of BroadcastInvalidation.
+func (mr *MembershipMockRecorder) BroadcastInvalidation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastInvalidation", reflect.TypeOf((*Membership)(nil).BroadcastInvalidation), arg0)
+}
+
+// BroadcastKey mocks base method.
+func (m *Membership) BroadcastKey(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BroadcastKey", arg0)
+}
+
+// BroadcastKey indicates an expected call of BroadcastKey.
+func (mr *MembershipMockRecorder) BroadcastKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastKey", reflect.TypeOf((*Membership)(nil).BroadcastKey), arg0)
+}
+
+// CoordinatorNodes mocks base method.
+func (m *Membership) CoordinatorNodes() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CoordinatorNodes")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// CoordinatorNodes indicates an expected call of CoordinatorNodes.
+func (mr *MembershipMockRecorder) CoordinatorNodes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CoordinatorNodes", reflect.TypeOf((*Membership)(nil).CoordinatorNodes))
+}
+
+// GetNodeByName mocks base method.
+func (m *Membership) GetNodeByName(arg0 string) (*client.Client, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNodeByName", arg0)
+	ret0, _ := ret[0].(*client.Client)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNodeByName indicates an expected call of GetNodeByName.
+func (mr *MembershipMockRecorder) GetNodeByName(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNodeByName", reflect.TypeOf((*Membership)(nil).GetNodeByName), arg0)
+}
+
 // GetNodeState mocks base method.
 func (m *Membership) GetNodeState(arg0 string) (*membership.State, error) {
 	m.ctrl.T.Helper()
@@ -78,6 +144,20 @@ func (mr *MembershipMockRecorder) Leave() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Leave", reflect.TypeOf((*Membership)(nil).Leave))
 }
 
+// LocalURL mocks base method.
+func (m *Membership) LocalURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LocalURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LocalURL indicates an expected call of LocalURL.
+func (mr *MembershipMockRecorder) LocalURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalURL", reflect.TypeOf((*Membership)(nil).LocalURL))
+}
+
 // LocalVolumes mocks base method.
 func (m *Membership) LocalVolumes() []volume.Local {
 	m.ctrl.T.Helper()
@@ -92,6 +172,35 @@ func (mr *MembershipMockRecorder) LocalVolumes() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalVolumes", reflect.TypeOf((*Membership)(nil).LocalVolumes))
 }
 
+// NodeStates mocks base method.
+func (m *Membership) NodeStates() []*membership.State {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NodeStates")
+	ret0, _ := ret[0].([]*membership.State)
+	return ret0
+}
+
+// NodeStates indicates an expected call of NodeStates.
+func (mr *MembershipMockRecorder) NodeStates() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeStates", reflect.TypeOf((*Membership)(nil).NodeStates))
+}
+
+// NodeURL mocks base method.
+func (m *Membership) NodeURL(arg0 *client.Client) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NodeURL", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NodeURL indicates an expected call of NodeURL.
+func (mr *MembershipMockRecorder) NodeURL(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeURL", reflect.TypeOf((*Membership)(nil).NodeURL), arg0)
+}
+
 // Nodes mocks base method.
 func (m *Membership) Nodes() []*client.Client {
 	m.ctrl.T.Helper()
@@ -106,6 +215,20 @@ func (mr *MembershipMockRecorder) Nodes() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nodes", reflect.TypeOf((*Membership)(nil).Nodes))
 }
 
+// NodesWithKey mocks base method.
+func (m *Membership) NodesWithKey(arg0 string) []*client.Client {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NodesWithKey", arg0)
+	ret0, _ := ret[0].([]*client.Client)
+	return ret0
+}
+
+// NodesWithKey indicates an expected call of NodesWithKey.
+func (mr *MembershipMockRecorder) NodesWithKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodesWithKey", reflect.TypeOf((*Membership)(nil).NodesWithKey), arg0)
+}
+
 // NodesWithoutVolumeIDs mocks base method.
 func (m *Membership) NodesWithoutVolumeIDs(arg0 []string) []*client.Client {
 	m.ctrl.T.Helper()
@@ -133,3 +256,17 @@ func (mr *MembershipMockRecorder) RemovedVolumeIDs() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovedVolumeIDs", reflect.TypeOf((*Membership)(nil).RemovedVolumeIDs))
 }
+
+// VolumeIDs mocks base method.
+func (m *Membership) VolumeIDs() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VolumeIDs")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// VolumeIDs indicates an expected call of VolumeIDs.
+func (mr *MembershipMockRecorder) VolumeIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VolumeIDs", reflect.TypeOf((*Membership)(nil).VolumeIDs))
+}
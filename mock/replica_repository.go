@@ -35,6 +35,21 @@ func (m *ReplicaRepository) EXPECT() *ReplicaRepositoryMockRecorder {
 	return m.recorder
 }
 
+// All mocks base method.
+func (m *ReplicaRepository) All(arg0 context.Context) ([]*replica.Replica, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*replica.Replica)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *ReplicaRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*ReplicaRepository)(nil).All), arg0)
+}
+
 // Create mocks base method.
 func (m *ReplicaRepository) Create(arg0 context.Context, arg1 *replica.Replica) error {
 	m.ctrl.T.Helper()
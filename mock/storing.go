@@ -11,7 +11,13 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	batch "github.com/xescugc/rebost/batch"
+	bucket "github.com/xescugc/rebost/bucket"
 	config "github.com/xescugc/rebost/config"
+	quota "github.com/xescugc/rebost/quota"
+	settings "github.com/xescugc/rebost/settings"
+	trash "github.com/xescugc/rebost/trash"
+	version "github.com/xescugc/rebost/version"
 )
 
 // Storing is a mock of Service interface.
@@ -37,6 +43,36 @@ func (m *Storing) EXPECT() *StoringMockRecorder {
 	return m.recorder
 }
 
+// Batch mocks base method.
+func (m *Storing) Batch(arg0 context.Context, arg1 []*batch.Operation, arg2 bool) ([]*batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *StoringMockRecorder) Batch(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*Storing)(nil).Batch), arg0, arg1, arg2)
+}
+
+// Buckets mocks base method.
+func (m *Storing) Buckets(arg0 context.Context) ([]*bucket.Bucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Buckets", arg0)
+	ret0, _ := ret[0].([]*bucket.Bucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Buckets indicates an expected call of Buckets.
+func (mr *StoringMockRecorder) Buckets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Buckets", reflect.TypeOf((*Storing)(nil).Buckets), arg0)
+}
+
 // Config mocks base method.
 func (m *Storing) Config(arg0 context.Context) (*config.Config, error) {
 	m.ctrl.T.Helper()
@@ -52,33 +88,89 @@ func (mr *StoringMockRecorder) Config(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*Storing)(nil).Config), arg0)
 }
 
+// CopyFile mocks base method.
+func (m *Storing) CopyFile(arg0 context.Context, arg1, arg2 string, arg3 bool, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFile", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyFile indicates an expected call of CopyFile.
+func (mr *StoringMockRecorder) CopyFile(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFile", reflect.TypeOf((*Storing)(nil).CopyFile), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CopyReplica mocks base method.
+func (m *Storing) CopyReplica(arg0 context.Context, arg1, arg2 string, arg3 bool, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyReplica", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyReplica indicates an expected call of CopyReplica.
+func (mr *StoringMockRecorder) CopyReplica(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyReplica", reflect.TypeOf((*Storing)(nil).CopyReplica), arg0, arg1, arg2, arg3, arg4)
+}
+
+// CreateBucket mocks base method.
+func (m *Storing) CreateBucket(arg0 context.Context, arg1 *bucket.Bucket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBucket", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBucket indicates an expected call of CreateBucket.
+func (mr *StoringMockRecorder) CreateBucket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBucket", reflect.TypeOf((*Storing)(nil).CreateBucket), arg0, arg1)
+}
+
 // CreateFile mocks base method.
-func (m *Storing) CreateFile(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 int, arg4 time.Duration, arg5 time.Time) error {
+func (m *Storing) CreateFile(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 int, arg4 time.Duration, arg5 time.Time, arg6 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateFile indicates an expected call of CreateFile.
-func (mr *StoringMockRecorder) CreateFile(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+func (mr *StoringMockRecorder) CreateFile(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*Storing)(nil).CreateFile), arg0, arg1, arg2, arg3, arg4, arg5)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*Storing)(nil).CreateFile), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 }
 
 // CreateReplica mocks base method.
-func (m *Storing) CreateReplica(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 time.Duration, arg4 time.Time) (string, error) {
+func (m *Storing) CreateReplica(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 time.Duration, arg4 time.Time, arg5 string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateReplica", arg0, arg1, arg2, arg3, arg4)
+	ret := m.ctrl.Call(m, "CreateReplica", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateReplica indicates an expected call of CreateReplica.
-func (mr *StoringMockRecorder) CreateReplica(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+func (mr *StoringMockRecorder) CreateReplica(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReplica", reflect.TypeOf((*Storing)(nil).CreateReplica), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// DeleteBucket mocks base method.
+func (m *Storing) DeleteBucket(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBucket", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBucket indicates an expected call of DeleteBucket.
+func (mr *StoringMockRecorder) DeleteBucket(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReplica", reflect.TypeOf((*Storing)(nil).CreateReplica), arg0, arg1, arg2, arg3, arg4)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBucket", reflect.TypeOf((*Storing)(nil).DeleteBucket), arg0, arg1)
 }
 
 // DeleteFile mocks base method.
@@ -95,6 +187,21 @@ func (mr *StoringMockRecorder) DeleteFile(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*Storing)(nil).DeleteFile), arg0, arg1)
 }
 
+// GetBucket mocks base method.
+func (m *Storing) GetBucket(arg0 context.Context, arg1 string) (*bucket.Bucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBucket", arg0, arg1)
+	ret0, _ := ret[0].(*bucket.Bucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBucket indicates an expected call of GetBucket.
+func (mr *StoringMockRecorder) GetBucket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBucket", reflect.TypeOf((*Storing)(nil).GetBucket), arg0, arg1)
+}
+
 // GetFile mocks base method.
 func (m *Storing) GetFile(arg0 context.Context, arg1 string) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +217,36 @@ func (mr *StoringMockRecorder) GetFile(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*Storing)(nil).GetFile), arg0, arg1)
 }
 
+// GetFileNodes mocks base method.
+func (m *Storing) GetFileNodes(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileNodes", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileNodes indicates an expected call of GetFileNodes.
+func (mr *StoringMockRecorder) GetFileNodes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileNodes", reflect.TypeOf((*Storing)(nil).GetFileNodes), arg0, arg1)
+}
+
+// GetFileSignatureReplica mocks base method.
+func (m *Storing) GetFileSignatureReplica(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileSignatureReplica", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileSignatureReplica indicates an expected call of GetFileSignatureReplica.
+func (mr *StoringMockRecorder) GetFileSignatureReplica(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileSignatureReplica", reflect.TypeOf((*Storing)(nil).GetFileSignatureReplica), arg0, arg1)
+}
+
 // HasFile mocks base method.
 func (m *Storing) HasFile(arg0 context.Context, arg1 string) (string, bool, error) {
 	m.ctrl.T.Helper()
@@ -126,6 +263,109 @@ func (mr *StoringMockRecorder) HasFile(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFile", reflect.TypeOf((*Storing)(nil).HasFile), arg0, arg1)
 }
 
+// HasVersions mocks base method.
+func (m *Storing) HasVersions(arg0 context.Context, arg1 string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasVersions", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HasVersions indicates an expected call of HasVersions.
+func (mr *StoringMockRecorder) HasVersions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasVersions", reflect.TypeOf((*Storing)(nil).HasVersions), arg0, arg1)
+}
+
+// Quotas mocks base method.
+func (m *Storing) Quotas(arg0 context.Context) ([]*quota.Status, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quotas", arg0)
+	ret0, _ := ret[0].([]*quota.Status)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quotas indicates an expected call of Quotas.
+func (mr *StoringMockRecorder) Quotas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quotas", reflect.TypeOf((*Storing)(nil).Quotas), arg0)
+}
+
+// RestoreTrash mocks base method.
+func (m *Storing) RestoreTrash(arg0 context.Context, arg1, arg2 string, arg3 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTrash", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreTrash indicates an expected call of RestoreTrash.
+func (mr *StoringMockRecorder) RestoreTrash(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTrash", reflect.TypeOf((*Storing)(nil).RestoreTrash), arg0, arg1, arg2, arg3)
+}
+
+// RestoreVersion mocks base method.
+func (m *Storing) RestoreVersion(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreVersion", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreVersion indicates an expected call of RestoreVersion.
+func (mr *StoringMockRecorder) RestoreVersion(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreVersion", reflect.TypeOf((*Storing)(nil).RestoreVersion), arg0, arg1, arg2)
+}
+
+// Settings mocks base method.
+func (m *Storing) Settings(arg0 context.Context) (*settings.Settings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Settings", arg0)
+	ret0, _ := ret[0].(*settings.Settings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Settings indicates an expected call of Settings.
+func (mr *StoringMockRecorder) Settings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Settings", reflect.TypeOf((*Storing)(nil).Settings), arg0)
+}
+
+// Trash mocks base method.
+func (m *Storing) Trash(arg0 context.Context, arg1 bool) ([]*trash.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash", arg0, arg1)
+	ret0, _ := ret[0].([]*trash.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *StoringMockRecorder) Trash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*Storing)(nil).Trash), arg0, arg1)
+}
+
+// TrashReplica mocks base method.
+func (m *Storing) TrashReplica(arg0 context.Context, arg1 string, arg2, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashReplica", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrashReplica indicates an expected call of TrashReplica.
+func (mr *StoringMockRecorder) TrashReplica(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashReplica", reflect.TypeOf((*Storing)(nil).TrashReplica), arg0, arg1, arg2, arg3)
+}
+
 // UpdateFileReplica mocks base method.
 func (m *Storing) UpdateFileReplica(arg0 context.Context, arg1 string, arg2 []string, arg3 int) error {
 	m.ctrl.T.Helper()
@@ -139,3 +379,60 @@ func (mr *StoringMockRecorder) UpdateFileReplica(arg0, arg1, arg2, arg3 interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileReplica", reflect.TypeOf((*Storing)(nil).UpdateFileReplica), arg0, arg1, arg2, arg3)
 }
+
+// UpdateFileTTL mocks base method.
+func (m *Storing) UpdateFileTTL(arg0 context.Context, arg1 string, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileTTL", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFileTTL indicates an expected call of UpdateFileTTL.
+func (mr *StoringMockRecorder) UpdateFileTTL(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileTTL", reflect.TypeOf((*Storing)(nil).UpdateFileTTL), arg0, arg1, arg2)
+}
+
+// UpdateFileTTLReplica mocks base method.
+func (m *Storing) UpdateFileTTLReplica(arg0 context.Context, arg1 string, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileTTLReplica", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFileTTLReplica indicates an expected call of UpdateFileTTLReplica.
+func (mr *StoringMockRecorder) UpdateFileTTLReplica(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileTTLReplica", reflect.TypeOf((*Storing)(nil).UpdateFileTTLReplica), arg0, arg1, arg2)
+}
+
+// UpdateSettings mocks base method.
+func (m *Storing) UpdateSettings(arg0 context.Context, arg1 *settings.Settings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *StoringMockRecorder) UpdateSettings(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*Storing)(nil).UpdateSettings), arg0, arg1)
+}
+
+// Versions mocks base method.
+func (m *Storing) Versions(arg0 context.Context, arg1 string) ([]*version.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions", arg0, arg1)
+	ret0, _ := ret[0].([]*version.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *StoringMockRecorder) Versions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*Storing)(nil).Versions), arg0, arg1)
+}
@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/rebost/trash (interfaces: Repository)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	trash "github.com/xescugc/rebost/trash"
+)
+
+// TrashRepository is a mock of Repository interface.
+type TrashRepository struct {
+	ctrl     *gomock.Controller
+	recorder *TrashRepositoryMockRecorder
+}
+
+// TrashRepositoryMockRecorder is the mock recorder for TrashRepository.
+type TrashRepositoryMockRecorder struct {
+	mock *TrashRepository
+}
+
+// NewTrashRepository creates a new mock instance.
+func NewTrashRepository(ctrl *gomock.Controller) *TrashRepository {
+	mock := &TrashRepository{ctrl: ctrl}
+	mock.recorder = &TrashRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *TrashRepository) EXPECT() *TrashRepositoryMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *TrashRepository) All(arg0 context.Context) ([]*trash.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*trash.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *TrashRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*TrashRepository)(nil).All), arg0)
+}
+
+// CreateOrReplace mocks base method.
+func (m *TrashRepository) CreateOrReplace(arg0 context.Context, arg1 *trash.Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrReplace", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrReplace indicates an expected call of CreateOrReplace.
+func (mr *TrashRepositoryMockRecorder) CreateOrReplace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrReplace", reflect.TypeOf((*TrashRepository)(nil).CreateOrReplace), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *TrashRepository) Delete(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *TrashRepositoryMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*TrashRepository)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteAll mocks base method.
+func (m *TrashRepository) DeleteAll(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAll", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAll indicates an expected call of DeleteAll.
+func (mr *TrashRepositoryMockRecorder) DeleteAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*TrashRepository)(nil).DeleteAll), arg0)
+}
+
+// Find mocks base method.
+func (m *TrashRepository) Find(arg0 context.Context, arg1, arg2 string) (*trash.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*trash.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *TrashRepositoryMockRecorder) Find(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*TrashRepository)(nil).Find), arg0, arg1, arg2)
+}
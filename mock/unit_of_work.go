@@ -9,12 +9,16 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	afero "github.com/spf13/afero"
+	apikey "github.com/xescugc/rebost/apikey"
+	bucket "github.com/xescugc/rebost/bucket"
 	file "github.com/xescugc/rebost/file"
 	idxkey "github.com/xescugc/rebost/idxkey"
 	idxttl "github.com/xescugc/rebost/idxttl"
 	idxvolume "github.com/xescugc/rebost/idxvolume"
 	replica "github.com/xescugc/rebost/replica"
 	state "github.com/xescugc/rebost/state"
+	trash "github.com/xescugc/rebost/trash"
+	version "github.com/xescugc/rebost/version"
 )
 
 // UnitOfWork is a mock of UnitOfWork interface.
@@ -40,6 +44,34 @@ func (m *UnitOfWork) EXPECT() *UnitOfWorkMockRecorder {
 	return m.recorder
 }
 
+// APIKeys mocks base method.
+func (m *UnitOfWork) APIKeys() apikey.Repository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKeys")
+	ret0, _ := ret[0].(apikey.Repository)
+	return ret0
+}
+
+// APIKeys indicates an expected call of APIKeys.
+func (mr *UnitOfWorkMockRecorder) APIKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKeys", reflect.TypeOf((*UnitOfWork)(nil).APIKeys))
+}
+
+// Buckets mocks base method.
+func (m *UnitOfWork) Buckets() bucket.Repository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Buckets")
+	ret0, _ := ret[0].(bucket.Repository)
+	return ret0
+}
+
+// Buckets indicates an expected call of Buckets.
+func (mr *UnitOfWorkMockRecorder) Buckets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Buckets", reflect.TypeOf((*UnitOfWork)(nil).Buckets))
+}
+
 // Files mocks base method.
 func (m *UnitOfWork) Files() file.Repository {
 	m.ctrl.T.Helper()
@@ -137,3 +169,31 @@ func (mr *UnitOfWorkMockRecorder) State() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*UnitOfWork)(nil).State))
 }
+
+// Trash mocks base method.
+func (m *UnitOfWork) Trash() trash.Repository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash")
+	ret0, _ := ret[0].(trash.Repository)
+	return ret0
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *UnitOfWorkMockRecorder) Trash() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*UnitOfWork)(nil).Trash))
+}
+
+// Versions mocks base method.
+func (m *UnitOfWork) Versions() version.Repository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions")
+	ret0, _ := ret[0].(version.Repository)
+	return ret0
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *UnitOfWorkMockRecorder) Versions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*UnitOfWork)(nil).Versions))
+}
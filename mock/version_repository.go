@@ -0,0 +1,108 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/rebost/version (interfaces: Repository)
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	version "github.com/xescugc/rebost/version"
+)
+
+// VersionRepository is a mock of Repository interface.
+type VersionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *VersionRepositoryMockRecorder
+}
+
+// VersionRepositoryMockRecorder is the mock recorder for VersionRepository.
+type VersionRepositoryMockRecorder struct {
+	mock *VersionRepository
+}
+
+// NewVersionRepository creates a new mock instance.
+func NewVersionRepository(ctrl *gomock.Controller) *VersionRepository {
+	mock := &VersionRepository{ctrl: ctrl}
+	mock.recorder = &VersionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *VersionRepository) EXPECT() *VersionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *VersionRepository) All(arg0 context.Context) ([]*version.History, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", arg0)
+	ret0, _ := ret[0].([]*version.History)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *VersionRepositoryMockRecorder) All(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*VersionRepository)(nil).All), arg0)
+}
+
+// CreateOrReplace mocks base method.
+func (m *VersionRepository) CreateOrReplace(arg0 context.Context, arg1 *version.History) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrReplace", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrReplace indicates an expected call of CreateOrReplace.
+func (mr *VersionRepositoryMockRecorder) CreateOrReplace(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrReplace", reflect.TypeOf((*VersionRepository)(nil).CreateOrReplace), arg0, arg1)
+}
+
+// DeleteAll mocks base method.
+func (m *VersionRepository) DeleteAll(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAll", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAll indicates an expected call of DeleteAll.
+func (mr *VersionRepositoryMockRecorder) DeleteAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*VersionRepository)(nil).DeleteAll), arg0)
+}
+
+// DeleteByKey mocks base method.
+func (m *VersionRepository) DeleteByKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByKey indicates an expected call of DeleteByKey.
+func (mr *VersionRepositoryMockRecorder) DeleteByKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKey", reflect.TypeOf((*VersionRepository)(nil).DeleteByKey), arg0, arg1)
+}
+
+// FindByKey mocks base method.
+func (m *VersionRepository) FindByKey(arg0 context.Context, arg1 string) (*version.History, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByKey", arg0, arg1)
+	ret0, _ := ret[0].(*version.History)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByKey indicates an expected call of FindByKey.
+func (mr *VersionRepositoryMockRecorder) FindByKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByKey", reflect.TypeOf((*VersionRepository)(nil).FindByKey), arg0, arg1)
+}
@@ -11,6 +11,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	version "github.com/xescugc/rebost/version"
 )
 
 // Volume is a mock of Volume interface.
@@ -36,18 +37,32 @@ func (m *Volume) EXPECT() *VolumeMockRecorder {
 	return m.recorder
 }
 
+// CopyFile mocks base method.
+func (m *Volume) CopyFile(arg0 context.Context, arg1, arg2 string, arg3 bool, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFile", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyFile indicates an expected call of CopyFile.
+func (mr *VolumeMockRecorder) CopyFile(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFile", reflect.TypeOf((*Volume)(nil).CopyFile), arg0, arg1, arg2, arg3, arg4)
+}
+
 // CreateFile mocks base method.
-func (m *Volume) CreateFile(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 int, arg4 time.Duration, arg5 time.Time) error {
+func (m *Volume) CreateFile(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 int, arg4 time.Duration, arg5 time.Time, arg6 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateFile indicates an expected call of CreateFile.
-func (mr *VolumeMockRecorder) CreateFile(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+func (mr *VolumeMockRecorder) CreateFile(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*Volume)(nil).CreateFile), arg0, arg1, arg2, arg3, arg4, arg5)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*Volume)(nil).CreateFile), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 }
 
 // DeleteFile mocks base method.
@@ -95,6 +110,22 @@ func (mr *VolumeMockRecorder) HasFile(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFile", reflect.TypeOf((*Volume)(nil).HasFile), arg0, arg1)
 }
 
+// HasVersions mocks base method.
+func (m *Volume) HasVersions(arg0 context.Context, arg1 string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasVersions", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HasVersions indicates an expected call of HasVersions.
+func (mr *VolumeMockRecorder) HasVersions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasVersions", reflect.TypeOf((*Volume)(nil).HasVersions), arg0, arg1)
+}
+
 // UpdateFileReplica mocks base method.
 func (m *Volume) UpdateFileReplica(arg0 context.Context, arg1 string, arg2 []string, arg3 int) error {
 	m.ctrl.T.Helper()
@@ -108,3 +139,32 @@ func (mr *VolumeMockRecorder) UpdateFileReplica(arg0, arg1, arg2, arg3 interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileReplica", reflect.TypeOf((*Volume)(nil).UpdateFileReplica), arg0, arg1, arg2, arg3)
 }
+
+// UpdateFileTTL mocks base method.
+func (m *Volume) UpdateFileTTL(arg0 context.Context, arg1 string, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileTTL", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFileTTL indicates an expected call of UpdateFileTTL.
+func (mr *VolumeMockRecorder) UpdateFileTTL(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileTTL", reflect.TypeOf((*Volume)(nil).UpdateFileTTL), arg0, arg1, arg2)
+}
+
+// Versions mocks base method.
+func (m *Volume) Versions(arg0 context.Context, arg1 string) ([]*version.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions", arg0, arg1)
+	ret0, _ := ret[0].([]*version.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *VolumeMockRecorder) Versions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*Volume)(nil).Versions), arg0, arg1)
+}
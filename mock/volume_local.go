@@ -11,8 +11,13 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	apikey "github.com/xescugc/rebost/apikey"
+	bloom "github.com/xescugc/rebost/bloom"
+	bucket "github.com/xescugc/rebost/bucket"
 	replica "github.com/xescugc/rebost/replica"
 	state "github.com/xescugc/rebost/state"
+	trash "github.com/xescugc/rebost/trash"
+	version "github.com/xescugc/rebost/version"
 )
 
 // VolumeLocal is a mock of Local interface.
@@ -38,6 +43,36 @@ func (m *VolumeLocal) EXPECT() *VolumeLocalMockRecorder {
 	return m.recorder
 }
 
+// APIKeys mocks base method.
+func (m *VolumeLocal) APIKeys(arg0 context.Context) ([]*apikey.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKeys", arg0)
+	ret0, _ := ret[0].([]*apikey.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// APIKeys indicates an expected call of APIKeys.
+func (mr *VolumeLocalMockRecorder) APIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKeys", reflect.TypeOf((*VolumeLocal)(nil).APIKeys), arg0)
+}
+
+// Buckets mocks base method.
+func (m *VolumeLocal) Buckets(arg0 context.Context) ([]*bucket.Bucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Buckets", arg0)
+	ret0, _ := ret[0].([]*bucket.Bucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Buckets indicates an expected call of Buckets.
+func (mr *VolumeLocalMockRecorder) Buckets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Buckets", reflect.TypeOf((*VolumeLocal)(nil).Buckets), arg0)
+}
+
 // Close mocks base method.
 func (m *VolumeLocal) Close() error {
 	m.ctrl.T.Helper()
@@ -52,18 +87,32 @@ func (mr *VolumeLocalMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*VolumeLocal)(nil).Close))
 }
 
+// CopyFile mocks base method.
+func (m *VolumeLocal) CopyFile(arg0 context.Context, arg1, arg2 string, arg3 bool, arg4 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyFile", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CopyFile indicates an expected call of CopyFile.
+func (mr *VolumeLocalMockRecorder) CopyFile(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyFile", reflect.TypeOf((*VolumeLocal)(nil).CopyFile), arg0, arg1, arg2, arg3, arg4)
+}
+
 // CreateFile mocks base method.
-func (m *VolumeLocal) CreateFile(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 int, arg4 time.Duration, arg5 time.Time) error {
+func (m *VolumeLocal) CreateFile(arg0 context.Context, arg1 string, arg2 io.ReadCloser, arg3 int, arg4 time.Duration, arg5 time.Time, arg6 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret := m.ctrl.Call(m, "CreateFile", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateFile indicates an expected call of CreateFile.
-func (mr *VolumeLocalMockRecorder) CreateFile(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+func (mr *VolumeLocalMockRecorder) CreateFile(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*VolumeLocal)(nil).CreateFile), arg0, arg1, arg2, arg3, arg4, arg5)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*VolumeLocal)(nil).CreateFile), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
 }
 
 // DeleteFile mocks base method.
@@ -80,6 +129,21 @@ func (mr *VolumeLocalMockRecorder) DeleteFile(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*VolumeLocal)(nil).DeleteFile), arg0, arg1)
 }
 
+// GetBucket mocks base method.
+func (m *VolumeLocal) GetBucket(arg0 context.Context, arg1 string) (*bucket.Bucket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBucket", arg0, arg1)
+	ret0, _ := ret[0].(*bucket.Bucket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBucket indicates an expected call of GetBucket.
+func (mr *VolumeLocalMockRecorder) GetBucket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBucket", reflect.TypeOf((*VolumeLocal)(nil).GetBucket), arg0, arg1)
+}
+
 // GetFile mocks base method.
 func (m *VolumeLocal) GetFile(arg0 context.Context, arg1 string) (io.ReadCloser, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +159,21 @@ func (mr *VolumeLocalMockRecorder) GetFile(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*VolumeLocal)(nil).GetFile), arg0, arg1)
 }
 
+// GetFileSignature mocks base method.
+func (m *VolumeLocal) GetFileSignature(arg0 context.Context, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileSignature", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileSignature indicates an expected call of GetFileSignature.
+func (mr *VolumeLocalMockRecorder) GetFileSignature(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileSignature", reflect.TypeOf((*VolumeLocal)(nil).GetFileSignature), arg0, arg1)
+}
+
 // GetState mocks base method.
 func (m *VolumeLocal) GetState(arg0 context.Context) (*state.State, error) {
 	m.ctrl.T.Helper()
@@ -126,6 +205,22 @@ func (mr *VolumeLocalMockRecorder) HasFile(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFile", reflect.TypeOf((*VolumeLocal)(nil).HasFile), arg0, arg1)
 }
 
+// HasVersions mocks base method.
+func (m *VolumeLocal) HasVersions(arg0 context.Context, arg1 string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasVersions", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HasVersions indicates an expected call of HasVersions.
+func (mr *VolumeLocalMockRecorder) HasVersions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasVersions", reflect.TypeOf((*VolumeLocal)(nil).HasVersions), arg0, arg1)
+}
+
 // ID mocks base method.
 func (m *VolumeLocal) ID() string {
 	m.ctrl.T.Helper()
@@ -140,6 +235,36 @@ func (mr *VolumeLocalMockRecorder) ID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ID", reflect.TypeOf((*VolumeLocal)(nil).ID))
 }
 
+// KeysFilter mocks base method.
+func (m *VolumeLocal) KeysFilter(arg0 context.Context) (*bloom.Filter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeysFilter", arg0)
+	ret0, _ := ret[0].(*bloom.Filter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// KeysFilter indicates an expected call of KeysFilter.
+func (mr *VolumeLocalMockRecorder) KeysFilter(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeysFilter", reflect.TypeOf((*VolumeLocal)(nil).KeysFilter), arg0)
+}
+
+// MoveReplicas mocks base method.
+func (m *VolumeLocal) MoveReplicas(arg0 context.Context, arg1 string, arg2 int) ([]*replica.Replica, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveReplicas", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*replica.Replica)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveReplicas indicates an expected call of MoveReplicas.
+func (mr *VolumeLocalMockRecorder) MoveReplicas(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveReplicas", reflect.TypeOf((*VolumeLocal)(nil).MoveReplicas), arg0, arg1, arg2)
+}
+
 // NextReplica mocks base method.
 func (m *VolumeLocal) NextReplica(arg0 context.Context) (*replica.Replica, error) {
 	m.ctrl.T.Helper()
@@ -155,6 +280,34 @@ func (mr *VolumeLocalMockRecorder) NextReplica(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextReplica", reflect.TypeOf((*VolumeLocal)(nil).NextReplica), arg0)
 }
 
+// PutAPIKey mocks base method.
+func (m *VolumeLocal) PutAPIKey(arg0 context.Context, arg1 *apikey.Key) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutAPIKey indicates an expected call of PutAPIKey.
+func (mr *VolumeLocalMockRecorder) PutAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutAPIKey", reflect.TypeOf((*VolumeLocal)(nil).PutAPIKey), arg0, arg1)
+}
+
+// PutBucket mocks base method.
+func (m *VolumeLocal) PutBucket(arg0 context.Context, arg1 *bucket.Bucket) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutBucket", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutBucket indicates an expected call of PutBucket.
+func (mr *VolumeLocalMockRecorder) PutBucket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutBucket", reflect.TypeOf((*VolumeLocal)(nil).PutBucket), arg0, arg1)
+}
+
 // Reset mocks base method.
 func (m *VolumeLocal) Reset(arg0 context.Context) error {
 	m.ctrl.T.Helper()
@@ -169,6 +322,20 @@ func (mr *VolumeLocalMockRecorder) Reset(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*VolumeLocal)(nil).Reset), arg0)
 }
 
+// RestoreTrash mocks base method.
+func (m *VolumeLocal) RestoreTrash(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTrash", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreTrash indicates an expected call of RestoreTrash.
+func (mr *VolumeLocalMockRecorder) RestoreTrash(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTrash", reflect.TypeOf((*VolumeLocal)(nil).RestoreTrash), arg0, arg1, arg2)
+}
+
 // SynchronizeReplicas mocks base method.
 func (m *VolumeLocal) SynchronizeReplicas(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -183,6 +350,35 @@ func (mr *VolumeLocalMockRecorder) SynchronizeReplicas(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SynchronizeReplicas", reflect.TypeOf((*VolumeLocal)(nil).SynchronizeReplicas), arg0, arg1)
 }
 
+// Trash mocks base method.
+func (m *VolumeLocal) Trash(arg0 context.Context) ([]*trash.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Trash", arg0)
+	ret0, _ := ret[0].([]*trash.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Trash indicates an expected call of Trash.
+func (mr *VolumeLocalMockRecorder) Trash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Trash", reflect.TypeOf((*VolumeLocal)(nil).Trash), arg0)
+}
+
+// TrashFile mocks base method.
+func (m *VolumeLocal) TrashFile(arg0 context.Context, arg1 string, arg2, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashFile", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrashFile indicates an expected call of TrashFile.
+func (mr *VolumeLocalMockRecorder) TrashFile(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashFile", reflect.TypeOf((*VolumeLocal)(nil).TrashFile), arg0, arg1, arg2, arg3)
+}
+
 // UpdateFileReplica mocks base method.
 func (m *VolumeLocal) UpdateFileReplica(arg0 context.Context, arg1 string, arg2 []string, arg3 int) error {
 	m.ctrl.T.Helper()
@@ -197,6 +393,20 @@ func (mr *VolumeLocalMockRecorder) UpdateFileReplica(arg0, arg1, arg2, arg3 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileReplica", reflect.TypeOf((*VolumeLocal)(nil).UpdateFileReplica), arg0, arg1, arg2, arg3)
 }
 
+// UpdateFileTTL mocks base method.
+func (m *VolumeLocal) UpdateFileTTL(arg0 context.Context, arg1 string, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFileTTL", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateFileTTL indicates an expected call of UpdateFileTTL.
+func (mr *VolumeLocalMockRecorder) UpdateFileTTL(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFileTTL", reflect.TypeOf((*VolumeLocal)(nil).UpdateFileTTL), arg0, arg1, arg2)
+}
+
 // UpdateReplica mocks base method.
 func (m *VolumeLocal) UpdateReplica(arg0 context.Context, arg1 *replica.Replica, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -210,3 +420,18 @@ func (mr *VolumeLocalMockRecorder) UpdateReplica(arg0, arg1, arg2 interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReplica", reflect.TypeOf((*VolumeLocal)(nil).UpdateReplica), arg0, arg1, arg2)
 }
+
+// Versions mocks base method.
+func (m *VolumeLocal) Versions(arg0 context.Context, arg1 string) ([]*version.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Versions", arg0, arg1)
+	ret0, _ := ret[0].([]*version.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Versions indicates an expected call of Versions.
+func (mr *VolumeLocalMockRecorder) Versions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Versions", reflect.TypeOf((*VolumeLocal)(nil).Versions), arg0, arg1)
+}
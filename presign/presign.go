@@ -0,0 +1,203 @@
+// Package presign has the logic to sign and verify URLs that give
+// temporary access to a file without any other credential. The URLs
+// are signed with HMAC-SHA256 using a key derived from the cluster
+// secret, so they are valid on any Node of the cluster. The signature
+// covers the whole query so no parameter can be added to the URL
+package presign
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// List of the query parameters of the presigned URLs
+const (
+	ExpiresParam     = "X-Rebost-Expires"
+	ContentTypeParam = "X-Rebost-Content-Type"
+	MaxSizeParam     = "X-Rebost-Max-Size"
+	SignatureParam   = "X-Rebost-Signature"
+)
+
+// MaxExpiration is the maximum time a presigned URL can be valid
+const MaxExpiration = 7 * 24 * time.Hour
+
+// keyLabel is the info used to derive the key that signs the
+// URLs from the secret, so it's not used directly for them
+const keyLabel = "presign"
+
+var (
+	// ErrExpired is returned when the URL has expired
+	ErrExpired = errors.New("the presigned URL has expired")
+
+	// ErrInvalidSignature is returned when the URL
+	// has not been signed with the secret
+	ErrInvalidSignature = errors.New("the presigned URL has an invalid signature")
+
+	// ErrTooLarge is returned when the content is
+	// larger than the MaxSize of the URL
+	ErrTooLarge = errors.New("the content is larger than the allowed by the presigned URL")
+)
+
+// URL is the information signed
+type URL struct {
+	// Method is the HTTP method allowed, GET or PUT
+	Method string
+
+	// Key is the key of the file
+	Key string
+
+	// Expires is when the URL stops being valid
+	Expires time.Time
+
+	// ContentType is the required 'Content-Type'
+	// of the PUT, it's optional
+	ContentType string
+
+	// MaxSize is the maximum size of the content
+	// of the PUT, it's optional
+	MaxSize int64
+}
+
+// Validate checks if the URL can be signed
+func (u URL) Validate(now time.Time) error {
+	if u.Method != http.MethodGet && u.Method != http.MethodPut {
+		return fmt.Errorf("invalid method %q, only %s and %s can be presigned", u.Method, http.MethodGet, http.MethodPut)
+	}
+	if u.Key == "" {
+		return errors.New("the key is required")
+	}
+	if !u.Expires.After(now) {
+		return errors.New("the expiration has to be in the future")
+	}
+	if u.Expires.Sub(now) > MaxExpiration {
+		return fmt.Errorf("the expiration can not be longer than %s", MaxExpiration)
+	}
+	if u.Method == http.MethodGet && (u.ContentType != "" || u.MaxSize != 0) {
+		return errors.New("the content type and the max size can only be used with PUT")
+	}
+	if u.MaxSize < 0 {
+		return errors.New("the max size can not be negative")
+	}
+	return nil
+}
+
+// Sign returns the path, with the query, of the
+// URL signed with the secret
+func Sign(secret []byte, u URL) string {
+	q := url.Values{}
+	q.Set(ExpiresParam, strconv.FormatInt(u.Expires.Unix(), 10))
+	if u.ContentType != "" {
+		q.Set(ContentTypeParam, u.ContentType)
+	}
+	if u.MaxSize != 0 {
+		q.Set(MaxSizeParam, strconv.FormatInt(u.MaxSize, 10))
+	}
+	q.Set(SignatureParam, signature(secret, u.Method, u.Key, q))
+
+	p := url.URL{Path: "/files/" + u.Key, RawQuery: q.Encode()}
+
+	return p.String()
+}
+
+// IsPresigned checks if the r has been made
+// with a presigned URL
+func IsPresigned(r *http.Request) bool {
+	return r.URL.Query().Get(SignatureParam) != ""
+}
+
+// Verify checks if the r for the file key has been made with a valid
+// presigned URL signed with the secret. If the URL has a MaxSize the
+// body of the r is limited to it
+func Verify(secret []byte, r *http.Request, key string, now time.Time) error {
+	q := r.URL.Query()
+
+	sig := q.Get(SignatureParam)
+	q.Del(SignatureParam)
+
+	// The HEAD is allowed with the GET URLs
+	m := r.Method
+	if m == http.MethodHead {
+		m = http.MethodGet
+	}
+
+	if !hmac.Equal([]byte(signature(secret, m, key, q)), []byte(sig)) {
+		return ErrInvalidSignature
+	}
+
+	exp, err := strconv.ParseInt(q.Get(ExpiresParam), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	u := URL{
+		Method:      m,
+		Key:         key,
+		Expires:     time.Unix(exp, 0),
+		ContentType: q.Get(ContentTypeParam),
+	}
+
+	if ms := q.Get(MaxSizeParam); ms != "" {
+		u.MaxSize, err = strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+	}
+
+	if !now.Before(u.Expires) {
+		return ErrExpired
+	}
+
+	if u.ContentType != "" && !strings.EqualFold(r.Header.Get("Content-Type"), u.ContentType) {
+		return fmt.Errorf("the presigned URL requires the Content-Type %q", u.ContentType)
+	}
+
+	if u.MaxSize != 0 {
+		if r.ContentLength > u.MaxSize {
+			return ErrTooLarge
+		}
+		r.Body = &limitedReader{rc: r.Body, n: u.MaxSize}
+	}
+
+	return nil
+}
+
+// signature returns the hex HMAC-SHA256 of the method, key and
+// the whole q (sorted by the name of the parameters) with the
+// key derived from the secret
+func signature(secret []byte, method, key string, q url.Values) string {
+	// It can only fail if the length is longer than 255 times the
+	// size of the hash, so it's safe to ignore the error
+	sk, _ := hkdf.Key(sha256.New, secret, nil, keyLabel, sha256.Size)
+
+	m := hmac.New(sha256.New, sk)
+	fmt.Fprintf(m, "%s\n%s\n%s", method, key, q.Encode())
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// limitedReader returns ErrTooLarge if
+// more than n bytes are read
+type limitedReader struct {
+	rc io.ReadCloser
+	n  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.rc.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+func (l *limitedReader) Close() error { return l.rc.Close() }
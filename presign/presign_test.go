@@ -0,0 +1,136 @@
+package presign_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/presign"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		Name string
+		URL  presign.URL
+		Err  string
+	}{
+		{
+			Name: "Success",
+			URL:  presign.URL{Method: http.MethodPut, Key: "key", Expires: now.Add(time.Minute), ContentType: "text/plain", MaxSize: 10},
+		},
+		{
+			Name: "InvalidMethod",
+			URL:  presign.URL{Method: http.MethodDelete, Key: "key", Expires: now.Add(time.Minute)},
+			Err:  `invalid method "DELETE", only GET and PUT can be presigned`,
+		},
+		{
+			Name: "NoKey",
+			URL:  presign.URL{Method: http.MethodGet, Expires: now.Add(time.Minute)},
+			Err:  "the key is required",
+		},
+		{
+			Name: "Expired",
+			URL:  presign.URL{Method: http.MethodGet, Key: "key", Expires: now},
+			Err:  "the expiration has to be in the future",
+		},
+		{
+			Name: "TooLong",
+			URL:  presign.URL{Method: http.MethodGet, Key: "key", Expires: now.Add(presign.MaxExpiration + time.Minute)},
+			Err:  "the expiration can not be longer than 168h0m0s",
+		},
+		{
+			Name: "GetWithMaxSize",
+			URL:  presign.URL{Method: http.MethodGet, Key: "key", Expires: now.Add(time.Minute), MaxSize: 10},
+			Err:  "the content type and the max size can only be used with PUT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := tt.URL.Validate(now)
+			if tt.Err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.Err)
+			}
+		})
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	var (
+		secret = []byte("secret")
+		now    = time.Now()
+	)
+
+	t.Run("Success", func(t *testing.T) {
+		p := presign.Sign(secret, presign.URL{Method: http.MethodGet, Key: "dir/key", Expires: now.Add(time.Minute)})
+
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+		assert.True(t, presign.IsPresigned(r))
+		assert.Equal(t, "/files/dir/key", r.URL.Path)
+
+		err := presign.Verify(secret, r, "dir/key", now)
+		require.NoError(t, err)
+
+		// The HEAD is also valid
+		r = httptest.NewRequest(http.MethodHead, p, nil)
+		err = presign.Verify(secret, r, "dir/key", now)
+		require.NoError(t, err)
+	})
+	t.Run("NotPresigned", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/files/key", nil)
+		assert.False(t, presign.IsPresigned(r))
+	})
+	t.Run("Expired", func(t *testing.T) {
+		p := presign.Sign(secret, presign.URL{Method: http.MethodGet, Key: "key", Expires: now.Add(time.Minute)})
+
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+		err := presign.Verify(secret, r, "key", now.Add(time.Hour))
+		assert.Equal(t, presign.ErrExpired, err)
+	})
+	t.Run("ModifiedExpiration", func(t *testing.T) {
+		p := presign.Sign(secret, presign.URL{Method: http.MethodGet, Key: "key", Expires: now.Add(time.Minute)})
+
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+		q := r.URL.Query()
+		q.Set(presign.ExpiresParam, "99999999999")
+		r.URL.RawQuery = q.Encode()
+
+		err := presign.Verify(secret, r, "key", now)
+		assert.Equal(t, presign.ErrInvalidSignature, err)
+	})
+	t.Run("AddedParameter", func(t *testing.T) {
+		p := presign.Sign(secret, presign.URL{Method: http.MethodPut, Key: "key", Expires: now.Add(time.Minute)})
+
+		r := httptest.NewRequest(http.MethodPut, p+"&ttl=1h", nil)
+
+		err := presign.Verify(secret, r, "key", now)
+		assert.Equal(t, presign.ErrInvalidSignature, err)
+	})
+	t.Run("OtherKey", func(t *testing.T) {
+		p := presign.Sign(secret, presign.URL{Method: http.MethodGet, Key: "key", Expires: now.Add(time.Minute)})
+
+		r := httptest.NewRequest(http.MethodGet, p, nil)
+
+		err := presign.Verify(secret, r, "other", now)
+		assert.Equal(t, presign.ErrInvalidSignature, err)
+	})
+	t.Run("MaxSizeWithUnknownLength", func(t *testing.T) {
+		p := presign.Sign(secret, presign.URL{Method: http.MethodPut, Key: "key", Expires: now.Add(time.Minute), MaxSize: 3})
+
+		r := httptest.NewRequest(http.MethodPut, p, io.NopCloser(bytes.NewBufferString("content")))
+		r.ContentLength = -1
+
+		err := presign.Verify(secret, r, "key", now)
+		require.NoError(t, err)
+
+		_, err = io.ReadAll(r.Body)
+		assert.Equal(t, presign.ErrTooLarge, err)
+	})
+}
@@ -0,0 +1,58 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Limits are the Quotas that limit a change of the Usage with
+// the Usage of each one of the volumes of the cluster, so the
+// volume that does the change can check them with its own
+type Limits struct {
+	Quotas []Quota
+
+	// Usages is the Usage by prefix of each volume by its ID
+	Usages map[string]map[string]Usage
+}
+
+// Check checks that the Quotas of any of the prefixes ps are not exceeded
+// once the volume vid has the Usage us, which replaces the one it had
+func (ls *Limits) Check(vid string, us map[string]Usage, ps ...string) error {
+	for _, q := range ls.Quotas {
+		if !slices.Contains(ps, q.Prefix) {
+			continue
+		}
+
+		u := us[q.Prefix]
+		for id, vus := range ls.Usages {
+			if id == vid {
+				continue
+			}
+			u = u.Add(vus[q.Prefix])
+		}
+
+		if q.Count != 0 && u.Count > q.Count {
+			return fmt.Errorf("%w: the prefix %q would have %d of %d files", ErrCountExceeded, q.Prefix, u.Count, q.Count)
+		}
+		if q.Size != 0 && u.Size > q.Size {
+			return fmt.Errorf("%w: the prefix %q would use %d of %d bytes", ErrSizeExceeded, q.Prefix, u.Size, q.Size)
+		}
+	}
+	return nil
+}
+
+// limitsContextKey is the key of the context with the Limits
+type limitsContextKey struct{}
+
+// NewContext returns a ctx with the ls to check
+// by the volume that does the change
+func NewContext(ctx context.Context, ls *Limits) context.Context {
+	return context.WithValue(ctx, limitsContextKey{}, ls)
+}
+
+// FromContext returns the Limits of the ctx
+func FromContext(ctx context.Context) (*Limits, bool) {
+	ls, ok := ctx.Value(limitsContextKey{}).(*Limits)
+	return ls, ok
+}
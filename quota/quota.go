@@ -0,0 +1,79 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrSizeExceeded is the error returned when the
+	// Size of a Quota has been exceeded
+	ErrSizeExceeded = errors.New("quota size exceeded")
+
+	// ErrCountExceeded is the error returned when the
+	// Count of a Quota has been exceeded
+	ErrCountExceeded = errors.New("quota count exceeded")
+)
+
+// Quota is the limit of the Files which key has the Prefix
+type Quota struct {
+	Prefix string
+
+	// Size is the maximum number of bytes,
+	// 0 means no limit
+	Size int64
+
+	// Count is the maximum number of Files,
+	// 0 means no limit
+	Count int
+}
+
+// Usage is the logical usage (without replicas) of the
+// Files which key has a prefix
+type Usage struct {
+	Size  int64
+	Count int
+}
+
+// Add returns the sum of u and ou
+func (u Usage) Add(ou Usage) Usage {
+	return Usage{
+		Size:  u.Size + ou.Size,
+		Count: u.Count + ou.Count,
+	}
+}
+
+// Status is a Quota with its current Usage
+type Status struct {
+	Quota
+	Usage Usage
+}
+
+// Matches checks if the key k is limited by the Quota
+func (q Quota) Matches(k string) bool {
+	return strings.HasPrefix(k, q.Prefix)
+}
+
+// Check checks if a new File can be added with the current Usage u
+func (q Quota) Check(u Usage) error {
+	if q.Count != 0 && u.Count >= q.Count {
+		return fmt.Errorf("%w: the prefix %q has %d of %d files", ErrCountExceeded, q.Prefix, u.Count, q.Count)
+	}
+	if q.Size != 0 && u.Size >= q.Size {
+		return fmt.Errorf("%w: the prefix %q uses %d of %d bytes", ErrSizeExceeded, q.Prefix, u.Size, q.Size)
+	}
+	return nil
+}
+
+// Left returns the number of bytes that can still be
+// used with the current Usage u, -1 means no limit
+func (q Quota) Left(u Usage) int64 {
+	if q.Size == 0 {
+		return -1
+	}
+	if l := q.Size - u.Size; l > 0 {
+		return l
+	}
+	return 0
+}
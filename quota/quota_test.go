@@ -0,0 +1,53 @@
+package quota_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/quota"
+)
+
+func TestCheck(t *testing.T) {
+	q := quota.Quota{Prefix: "logs/", Size: 10, Count: 2}
+
+	assert.NoError(t, q.Check(quota.Usage{Size: 9, Count: 1}))
+	assert.True(t, errors.Is(q.Check(quota.Usage{Size: 10, Count: 1}), quota.ErrSizeExceeded))
+	assert.True(t, errors.Is(q.Check(quota.Usage{Size: 1, Count: 2}), quota.ErrCountExceeded))
+	assert.NoError(t, quota.Quota{Prefix: "logs/"}.Check(quota.Usage{Size: 100, Count: 100}))
+}
+
+func TestLeft(t *testing.T) {
+	assert.Equal(t, int64(-1), quota.Quota{}.Left(quota.Usage{Size: 10}))
+	assert.Equal(t, int64(4), quota.Quota{Size: 10}.Left(quota.Usage{Size: 6}))
+	assert.Equal(t, int64(0), quota.Quota{Size: 10}.Left(quota.Usage{Size: 12}))
+}
+
+func TestMatches(t *testing.T) {
+	q := quota.Quota{Prefix: "logs/"}
+
+	assert.True(t, q.Matches("logs/a"))
+	assert.False(t, q.Matches("images/a"))
+}
+
+func TestAdd(t *testing.T) {
+	assert.Equal(t, quota.Usage{Size: 3, Count: 2}, quota.Usage{Size: 1, Count: 1}.Add(quota.Usage{Size: 2, Count: 1}))
+}
+
+func TestLimitsCheck(t *testing.T) {
+	ls := &quota.Limits{
+		Quotas: []quota.Quota{{Prefix: "logs/", Size: 10, Count: 2}},
+		Usages: map[string]map[string]quota.Usage{
+			"v1": {"logs/": {Size: 4, Count: 1}},
+			"v2": {"logs/": {Size: 2, Count: 1}},
+		},
+	}
+
+	// The Usage of the v1 is replaced with the new one
+	assert.NoError(t, ls.Check("v1", map[string]quota.Usage{"logs/": {Size: 8, Count: 1}}, "logs/"))
+	assert.True(t, errors.Is(ls.Check("v1", map[string]quota.Usage{"logs/": {Size: 9, Count: 1}}, "logs/"), quota.ErrSizeExceeded))
+	assert.True(t, errors.Is(ls.Check("v1", map[string]quota.Usage{"logs/": {Size: 5, Count: 2}}, "logs/"), quota.ErrCountExceeded))
+
+	// Only the Quotas of the prefixes changed are checked
+	assert.NoError(t, ls.Check("v1", map[string]quota.Usage{"logs/": {Size: 9, Count: 2}}, "images/"))
+}
@@ -0,0 +1,118 @@
+// Package rendezvous implements the rendezvous (highest random weight)
+// hashing used to know which volumes are the preferred ones for a key.
+// Each volume has a weight for each key and the ones with the highest
+// weight are the preferred, so when a volume is added or removed only
+// the keys that have it as preferred change of volume
+package rendezvous
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Weight returns the weight of the volume with the id for the key k
+func Weight(id, k string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	h.Write([]byte{0})
+	h.Write([]byte(k))
+
+	return mix(h.Sum64())
+}
+
+// Rank returns the ids ordered by preference for the key k, from the one
+// with the highest Weight. It's the same for any order of the ids
+func Rank(ids []string, k string) []string {
+	res := make([]string, len(ids))
+	copy(res, ids)
+
+	ws := make(map[string]uint64, len(res))
+	for _, id := range res {
+		ws[id] = Weight(id, k)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if ws[res[i]] != ws[res[j]] {
+			return ws[res[i]] > ws[res[j]]
+		}
+		return res[i] < res[j]
+	})
+
+	return res
+}
+
+// WeightedRank returns the ids ordered by preference for the key k like Rank
+// but with the Weight of each one scaled by its capacity on cs, so each id is
+// the preferred one for a share of the keys proportional to its capacity. The
+// ids that are not on cs have the average capacity of the ones that are, and
+// if all of them have the same capacity it's the same as Rank
+func WeightedRank(ids []string, k string, cs map[string]uint64) []string {
+	res := make([]string, len(ids))
+	copy(res, ids)
+
+	var (
+		avg   float64
+		known int
+	)
+	for _, id := range res {
+		if c, ok := cs[id]; ok {
+			avg += float64(c)
+			known++
+		}
+	}
+	if known != 0 {
+		avg /= float64(known)
+	}
+
+	var (
+		ws     = make(map[string]uint64, len(res))
+		scores = make(map[string]float64, len(res))
+	)
+	for _, id := range res {
+		c, ok := cs[id]
+		fc := float64(c)
+		if !ok {
+			fc = avg
+		}
+		ws[id] = Weight(id, k)
+		scores[id] = score(ws[id], fc)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if scores[res[i]] != scores[res[j]] {
+			return scores[res[i]] > scores[res[j]]
+		}
+		if ws[res[i]] != ws[res[j]] {
+			return ws[res[i]] > ws[res[j]]
+		}
+		return res[i] < res[j]
+	})
+
+	return res
+}
+
+// score is the weighted rendezvous score of the w with
+// the capacity c, the w as a (0, 1] uniform value u
+// scaled as c / -ln(u)
+func score(w uint64, c float64) float64 {
+	if c == 0 {
+		return 0
+	}
+	u := (float64(w>>11) + 1) / (1 << 53)
+	if u == 1 {
+		return math.Inf(1)
+	}
+	return c / -math.Log(u)
+}
+
+// mix is the finalizer of the SplitMix64 so
+// the Weights are evenly distributed
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,76 @@
+package rendezvous_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/rendezvous"
+)
+
+func TestRank(t *testing.T) {
+	ids := []string{"a", "b", "c", "d"}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		r := rendezvous.Rank(ids, "key")
+		assert.ElementsMatch(t, ids, r)
+		assert.Equal(t, r, rendezvous.Rank([]string{"d", "c", "b", "a"}, "key"))
+		assert.Equal(t, []string{"a", "b", "c", "d"}, ids, "the ids are not modified")
+	})
+	t.Run("Empty", func(t *testing.T) {
+		assert.Empty(t, rendezvous.Rank(nil, "key"))
+	})
+	t.Run("Distribution", func(t *testing.T) {
+		counts := make(map[string]int)
+		for i := 0; i < 4000; i++ {
+			counts[rendezvous.Rank(ids, fmt.Sprintf("key-%d", i))[0]]++
+		}
+		for _, id := range ids {
+			assert.InDelta(t, 1000, counts[id], 150, id)
+		}
+	})
+	t.Run("AddVolume", func(t *testing.T) {
+		// Only the keys that have the new
+		// volume as preferred one are moved
+		nids := append([]string{"e"}, ids...)
+		for i := 0; i < 1000; i++ {
+			k := fmt.Sprintf("key-%d", i)
+			b, a := rendezvous.Rank(ids, k)[0], rendezvous.Rank(nids, k)[0]
+			if a != "e" {
+				assert.Equal(t, b, a, k)
+			}
+		}
+	})
+}
+
+func TestWeightedRank(t *testing.T) {
+	ids := []string{"a", "b", "c", "d"}
+
+	t.Run("SameCapacity", func(t *testing.T) {
+		cs := map[string]uint64{"a": 10, "b": 10, "c": 10, "d": 10}
+		for i := 0; i < 100; i++ {
+			k := fmt.Sprintf("key-%d", i)
+			assert.Equal(t, rendezvous.Rank(ids, k), rendezvous.WeightedRank(ids, k, cs), k)
+			assert.Equal(t, rendezvous.Rank(ids, k), rendezvous.WeightedRank(ids, k, nil), k)
+		}
+	})
+	t.Run("Distribution", func(t *testing.T) {
+		cs := map[string]uint64{"a": 1, "b": 1, "c": 1, "d": 5}
+		counts := make(map[string]int)
+		for i := 0; i < 8000; i++ {
+			counts[rendezvous.WeightedRank(ids, fmt.Sprintf("key-%d", i), cs)[0]]++
+		}
+		for _, id := range []string{"a", "b", "c"} {
+			assert.InDelta(t, 1000, counts[id], 150, id)
+		}
+		assert.InDelta(t, 5000, counts["d"], 300, "d")
+	})
+	t.Run("WithoutCapacity", func(t *testing.T) {
+		// The ones without capacity are the last ones
+		cs := map[string]uint64{"a": 0, "b": 10, "c": 10, "d": 10}
+		for i := 0; i < 100; i++ {
+			r := rendezvous.WeightedRank(ids, fmt.Sprintf("key-%d", i), cs)
+			assert.Equal(t, "a", r[3])
+		}
+	})
+}
@@ -36,4 +36,11 @@ type Replica struct {
 
 	// CreatedAt is the time of creation of the original file
 	CreatedAt time.Time
+
+	// Class is the storage class of the original file
+	Class string
+
+	// Move means that once it's created on another volume
+	// the file is removed from the one of the VolumeID
+	Move bool
 }
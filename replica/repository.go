@@ -17,4 +17,7 @@ type Repository interface {
 	Delete(ctx context.Context, r *Replica) error
 
 	DeleteAll(ctx context.Context) error
+
+	// All returns all the Replicas in order
+	All(ctx context.Context) ([]*Replica, error)
 }
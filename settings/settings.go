@@ -0,0 +1,72 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Redirect is how the requests for the
+// Files on other Nodes are answered
+type Redirect string
+
+const (
+	// RedirectNode uses the --redirect of each Node
+	RedirectNode Redirect = ""
+
+	// RedirectAlways redirects them to the Node with the File
+	RedirectAlways Redirect = "always"
+
+	// RedirectNever proxies the content of the File
+	RedirectNever Redirect = "never"
+)
+
+var (
+	// ErrInvalid is the error returned when
+	// the Settings have invalid values
+	ErrInvalid = errors.New("invalid settings")
+)
+
+// Settings are the ones of all the cluster, decided by the leader
+// of the coordinator, that are used instead of the ones of the
+// configuration of each Node. The zero values keep the ones of
+// each Node
+type Settings struct {
+	// Replica is the default number of replicas of the Files,
+	// the Buckets and the requests with their own still use them
+	Replica int
+
+	// Redirect is how the requests for the Files on other Nodes
+	// are answered if the requests do not have the X-Rebost-Redirect
+	Redirect Redirect
+
+	// MaxRepairs is the maximum number of Nodes that create
+	// the pending replicas at the same time, the leader gives
+	// turns to them so the repairs do not overload the cluster.
+	// If 0 all of them do it at any time
+	MaxRepairs int
+
+	// Rebalance is the maximum difference, in points of the
+	// percentage of used space, between the fullest volume and
+	// the emptiest one of another Node, over it the leader moves
+	// Files between them. If 0 they are not rebalanced
+	Rebalance int
+}
+
+// Validate checks that the Settings are valid
+func (s Settings) Validate() error {
+	if s.Replica < 0 {
+		return fmt.Errorf("%w: the replica can not be negative", ErrInvalid)
+	}
+	if s.MaxRepairs < 0 {
+		return fmt.Errorf("%w: the max repairs can not be negative", ErrInvalid)
+	}
+	if s.Rebalance < 0 || s.Rebalance > 100 {
+		return fmt.Errorf("%w: the rebalance has to be between 0 and 100", ErrInvalid)
+	}
+	switch s.Redirect {
+	case RedirectNode, RedirectAlways, RedirectNever:
+	default:
+		return fmt.Errorf("%w: unknown redirect %q", ErrInvalid, s.Redirect)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package settings_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/settings"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		s := settings.Settings{Replica: 2, Redirect: settings.RedirectAlways, MaxRepairs: 1, Rebalance: 10}
+		assert.NoError(t, s.Validate())
+	})
+	t.Run("SuccessEmpty", func(t *testing.T) {
+		assert.NoError(t, settings.Settings{}.Validate())
+	})
+	t.Run("Fails", func(t *testing.T) {
+		tests := []struct {
+			Name     string
+			Settings settings.Settings
+		}{
+			{Name: "NegativeReplica", Settings: settings.Settings{Replica: -1}},
+			{Name: "NegativeMaxRepairs", Settings: settings.Settings{MaxRepairs: -1}},
+			{Name: "NegativeRebalance", Settings: settings.Settings{Rebalance: -1}},
+			{Name: "OverRebalance", Settings: settings.Settings{Rebalance: 101}},
+			{Name: "UnknownRedirect", Settings: settings.Settings{Redirect: "sometimes"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.Name, func(t *testing.T) {
+				err := tt.Settings.Validate()
+				assert.True(t, errors.Is(err, settings.ErrInvalid))
+			})
+		}
+	})
+}
@@ -0,0 +1,87 @@
+// Package signature has the logic to calculate and parse the Signatures
+// of the files with the different supported hash algorithms.
+// A Signature has the format '<algorithm>:<hex digest>' but the legacy
+// format, just the SHA1 hex digest with no algorithm, is also supported
+package signature
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/zeebo/blake3"
+)
+
+// Algorithm is a hash algorithm used to calculate the Signatures
+type Algorithm string
+
+// List of all the supported Algorithms
+const (
+	SHA1   Algorithm = "sha1"
+	SHA256 Algorithm = "sha256"
+	BLAKE3 Algorithm = "blake3"
+
+	// Default is the Algorithm used if none is defined
+	Default = SHA1
+
+	separator = ":"
+)
+
+// Algorithms is the list of all the supported algorithms
+var Algorithms = []Algorithm{SHA1, SHA256, BLAKE3}
+
+// Parse returns the Algorithm with the name s, if
+// s is empty the Default Algorithm is returned
+func Parse(s string) (Algorithm, error) {
+	if s == "" {
+		return Default, nil
+	}
+	for _, a := range Algorithms {
+		if string(a) == strings.ToLower(s) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("invalid hash algorithm %q, the supported ones are %v", s, Algorithms)
+}
+
+// New returns a new hash.Hash for the Algorithm
+func (a Algorithm) New() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New()
+	case BLAKE3:
+		return blake3.New()
+	default:
+		return sha1.New()
+	}
+}
+
+// Sum returns the Signature of the h calculated with the Algorithm
+func (a Algorithm) Sum(h hash.Hash) string {
+	return Join(a, fmt.Sprintf("%x", h.Sum(nil)))
+}
+
+// Join returns the Signature of the hex digest d calculated
+// with the Algorithm a
+func Join(a Algorithm, d string) string {
+	return string(a) + separator + d
+}
+
+// Split returns the Algorithm and the hex digest of the sig,
+// if sig has the legacy format (no algorithm) it's assumed
+// to be SHA1
+func Split(sig string) (Algorithm, string) {
+	alg, d, ok := strings.Cut(sig, separator)
+	if !ok {
+		return SHA1, sig
+	}
+	return Algorithm(alg), d
+}
+
+// IsLegacy checks if the sig is on the legacy format
+// which has no Algorithm on it
+func IsLegacy(sig string) bool {
+	return !strings.Contains(sig, separator)
+}
@@ -0,0 +1,65 @@
+package signature_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/signature"
+)
+
+func TestParse(t *testing.T) {
+	a, err := signature.Parse("")
+	require.NoError(t, err)
+	assert.Equal(t, signature.Default, a)
+
+	a, err = signature.Parse("SHA256")
+	require.NoError(t, err)
+	assert.Equal(t, signature.SHA256, a)
+
+	_, err = signature.Parse("md5")
+	assert.EqualError(t, err, `invalid hash algorithm "md5", the supported ones are [sha1 sha256 blake3]`)
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		alg signature.Algorithm
+		sig string
+	}{
+		{
+			alg: signature.SHA1,
+			sig: "sha1:da39a3ee5e6b4b0d3255bfef95601890afd80709",
+		},
+		{
+			alg: signature.SHA256,
+			sig: "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			alg: signature.BLAKE3,
+			sig: "blake3:af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.alg), func(t *testing.T) {
+			// The Signatures are from an empty content
+			h := tt.alg.New()
+			assert.Equal(t, tt.sig, tt.alg.Sum(h))
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	alg, d := signature.Split("sha256:1234")
+	assert.Equal(t, signature.SHA256, alg)
+	assert.Equal(t, "1234", d)
+
+	alg, d = signature.Split("1234")
+	assert.Equal(t, signature.SHA1, alg)
+	assert.Equal(t, "1234", d)
+}
+
+func TestIsLegacy(t *testing.T) {
+	assert.True(t, signature.IsLegacy("1234"))
+	assert.False(t, signature.IsLegacy("sha1:1234"))
+}
@@ -2,6 +2,8 @@ package state
 
 import (
 	"time"
+
+	"github.com/xescugc/rebost/quota"
 )
 
 // State is the current state in which the volume is
@@ -22,12 +24,36 @@ type State struct {
 	// UsedSize is the total used size of the volume objects
 	VolumeUsedSize int
 
+	// Usage is the logical usage, by key prefix, of the Files
+	// owned by the volume, which are the ones created on
+	// it and not the replicas of other volumes
+	Usage map[string]quota.Usage
+
+	// Expiry is the state of the
+	// expiration of the Files with TTL
+	Expiry Expiry
+
 	// UpdatedAt is useful to be able to know on restart
 	// how long has it been since the last check, it's like
 	// a heartbeat
 	UpdatedAt time.Time
 }
 
+// Expiry is the state of the expiration of the Files with TTL
+type Expiry struct {
+	// Lag is how late the last expirations
+	// were processed after they expired
+	Lag time.Duration
+
+	// Expired is the number of keys
+	// expired by the volume
+	Expired int
+
+	// ExpiredAt is the last time
+	// an expiration was processed
+	ExpiredAt time.Time
+}
+
 // CanStore will check if the b bytes fit into the defined sizes
 // to prevent over sizing
 func (s *State) CanStore(b int) bool {
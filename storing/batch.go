@@ -0,0 +1,232 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/volume"
+)
+
+const (
+	// batchWorkers is the number of Operations of a
+	// batch that are done in parallel on the Node
+	batchWorkers = 10
+)
+
+func (s *service) Batch(ctx context.Context, ops []*batch.Operation, local bool) ([]*batch.Result, error) {
+	rs := make([]*batch.Result, len(ops))
+	for i, op := range ops {
+		rs[i] = &batch.Result{Operation: *op}
+	}
+
+	// The owner of each key is found first so each
+	// Operation is only done once on the volume that has it
+	var (
+		owners = make([]volume.Volume, len(ops))
+		vids   = make([]string, len(ops))
+		lvs    = localVolumesToVolumes(s.members.LocalVolumes())
+	)
+	parallel(len(ops), func(i int) {
+		rs[i].Err = ops[i].Validate()
+		if rs[i].Err != nil {
+			return
+		}
+		vids[i], owners[i], rs[i].Err = s.findVolume(ctx, lvs, ops[i].Key)
+		if rs[i].Err != nil && rs[i].Err.Error() == "not found" {
+			rs[i].Err = nil
+		}
+	})
+
+	if !local {
+		s.findBatchOwners(ctx, rs, owners, vids)
+	}
+
+	// The Operations of each Node are sent
+	// together and done there in parallel
+	var (
+		wg    sync.WaitGroup
+		nodes = make(map[*client.Client][]int)
+	)
+	for i, v := range owners {
+		if rs[i].Err != nil {
+			continue
+		}
+		if v == nil {
+			rs[i].Err = errors.New("not found")
+			continue
+		}
+		// The Head is done when the
+		// volume with the key is found
+		if ops[i].Op == batch.Head && vids[i] != "" {
+			rs[i].VolumeID = vids[i]
+			continue
+		}
+		if n, ok := v.(*client.Client); ok {
+			nodes[n] = append(nodes[n], i)
+		}
+	}
+
+	for n, idxs := range nodes {
+		wg.Add(1)
+		go func(n *client.Client, idxs []int) {
+			defer wg.Done()
+			s.nodeBatch(ctx, n, ops, rs, idxs)
+		}(n, idxs)
+	}
+
+	parallel(len(ops), func(i int) {
+		if rs[i].Err != nil {
+			return
+		}
+		if _, ok := owners[i].(volume.Local); !ok {
+			return
+		}
+		switch ops[i].Op {
+		case batch.Delete:
+			rs[i].Err = s.deleteFile(ctx, owners[i], ops[i].Key)
+		case batch.Copy, batch.Move:
+			rs[i].Err = s.copyFile(ctx, owners[i], ops[i].Key, ops[i].Destination, ops[i].Op == batch.Move, time.Now())
+		case batch.Update:
+			rs[i].Err = s.updateFileTTL(ctx, owners[i], ops[i].Key, *ops[i].TTL)
+		}
+	})
+
+	wg.Wait()
+
+	return rs, nil
+}
+
+// findBatchOwners finds the Nodes that have the keys of the rs that
+// are not on the local volumes, first with the cache and then, like
+// getVolume, asking the Nodes with the preferred volumes for each key
+// and the rest that may have it by the filters of the keys of their
+// volumes. All the keys of each Node are asked at once
+func (s *service) findBatchOwners(ctx context.Context, rs []*batch.Result, owners []volume.Volume, vids []string) {
+	var (
+		ns    = s.members.Nodes()
+		nodes = make(map[*client.Client][]int)
+		asked []int
+	)
+	for i, v := range owners {
+		if rs[i].Err != nil || v != nil {
+			continue
+		}
+		k := rs[i].Key
+		if vid, ok := s.cache.Get(k); ok {
+			if n, err := s.members.GetNodeWithVolumeByID(vid); err == nil {
+				owners[i] = n
+				continue
+			}
+			s.cache.Remove(k)
+		}
+		if s.isMissing(k) {
+			continue
+		}
+
+		rns := s.rankNodes(k, ns)
+		pr := min(s.preferredReplicas(), len(rns))
+		for _, n := range append(rns[:pr:pr], s.nodesWithKey(k, rns[pr:])...) {
+			nodes[n] = append(nodes[n], i)
+		}
+		asked = append(asked, i)
+	}
+
+	var (
+		wg sync.WaitGroup
+		mx sync.Mutex
+	)
+	for n, idxs := range nodes {
+		wg.Add(1)
+		go func(n *client.Client, idxs []int) {
+			defer wg.Done()
+			heads := make([]*batch.Operation, 0, len(idxs))
+			for _, i := range idxs {
+				heads = append(heads, &batch.Operation{Op: batch.Head, Key: rs[i].Key})
+			}
+
+			nrs, err := n.Batch(ctx, heads, true)
+			if err == nil && len(nrs) != len(heads) {
+				err = errors.New("invalid number of results")
+			}
+			if err != nil {
+				s.logger.Log("msg", err.Error())
+				return
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+			for j, nr := range nrs {
+				i := idxs[j]
+				if nr.Err != nil || owners[i] != nil {
+					continue
+				}
+				owners[i] = n
+				vids[i] = nr.VolumeID
+				s.cache.Add(nr.Key, nr.VolumeID)
+			}
+		}(n, idxs)
+	}
+	wg.Wait()
+
+	// Like on getVolume they are not
+	// asked again for a while
+	for _, i := range asked {
+		if owners[i] == nil {
+			s.addMissing(rs[i].Key)
+		}
+	}
+}
+
+// nodeBatch does the ops of the idxs on the Node n,
+// which has them, and sets the results to the rs
+func (s *service) nodeBatch(ctx context.Context, n *client.Client, ops []*batch.Operation, rs []*batch.Result, idxs []int) {
+	nops := make([]*batch.Operation, 0, len(idxs))
+	for _, i := range idxs {
+		nops = append(nops, ops[i])
+	}
+
+	nrs, err := n.Batch(ctx, nops, true)
+	if err == nil && len(nrs) != len(nops) {
+		err = errors.New("invalid number of results")
+	}
+
+	for j, i := range idxs {
+		if err != nil {
+			rs[i].Err = err
+			continue
+		}
+		rs[i].VolumeID = nrs[j].VolumeID
+		rs[i].Err = nrs[j].Err
+
+		// The cache is no longer valid
+		if (rs[i].Err != nil && rs[i].Err.Error() == "not found") || (rs[i].Err == nil && ops[i].Op == batch.Delete) {
+			s.cache.Remove(rs[i].Key)
+		}
+	}
+}
+
+// parallel calls the fn with all the indexes
+// until n, batchWorkers at the same time
+func parallel(n int, fn func(i int)) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, batchWorkers)
+	)
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
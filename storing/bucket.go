@@ -0,0 +1,148 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/client"
+)
+
+func (s *service) CreateBucket(ctx context.Context, b *bucket.Bucket) error {
+	if b.Policy == "" {
+		b.Policy = bucket.Private
+	}
+
+	err := b.Validate()
+	if err != nil {
+		return err
+	}
+
+	b.Deleted = false
+	b.UpdatedAt = time.Now().UTC()
+
+	return s.putBucket(ctx, b)
+}
+
+func (s *service) GetBucket(ctx context.Context, name string) (*bucket.Bucket, error) {
+	vls := s.members.LocalVolumes()
+	if len(vls) == 0 {
+		return s.getRemoteBucket(ctx, name)
+	}
+
+	// All the local volumes have the same
+	// Buckets so any of them works
+	b, err := vls[0].GetBucket(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Deleted {
+		return nil, errors.New("not found")
+	}
+
+	return b, nil
+}
+
+func (s *service) Buckets(ctx context.Context) ([]*bucket.Bucket, error) {
+	vls := s.members.LocalVolumes()
+	if len(vls) == 0 {
+		return s.remoteBuckets(ctx)
+	}
+
+	bks, err := vls[0].Buckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rbks := make([]*bucket.Bucket, 0, len(bks))
+	for _, b := range bks {
+		if !b.Deleted {
+			rbks = append(rbks, b)
+		}
+	}
+
+	return rbks, nil
+}
+
+func (s *service) DeleteBucket(ctx context.Context, name string) error {
+	b, err := s.GetBucket(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	b.Deleted = true
+	b.UpdatedAt = time.Now().UTC()
+
+	return s.putBucket(ctx, b)
+}
+
+// putBucket stores the b on all the local volumes
+// and notifies it to the rest of the cluster
+func (s *service) putBucket(ctx context.Context, b *bucket.Bucket) error {
+	for _, v := range s.members.LocalVolumes() {
+		err := v.PutBucket(ctx, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.members.BroadcastBucket(b)
+
+	return nil
+}
+
+// getRemoteBucket returns the Bucket with the name from the
+// Nodes with volumes, used when there are no local ones
+func (s *service) getRemoteBucket(ctx context.Context, name string) (*bucket.Bucket, error) {
+	for _, n := range s.storageNodes() {
+		b, err := n.GetBucket(ctx, name)
+		if err != nil {
+			if err.Error() == "not found" {
+				return nil, err
+			}
+			s.logger.Log("msg", err.Error())
+			continue
+		}
+		return b, nil
+	}
+
+	return nil, errors.New("not found")
+}
+
+// remoteBuckets returns the Buckets from the Nodes
+// with volumes, used when there are no local ones
+func (s *service) remoteBuckets(ctx context.Context) ([]*bucket.Bucket, error) {
+	for _, n := range s.storageNodes() {
+		bks, err := n.Buckets(ctx)
+		if err != nil {
+			s.logger.Log("msg", err.Error())
+			continue
+		}
+		return bks, nil
+	}
+
+	return []*bucket.Bucket{}, nil
+}
+
+// storageNodes returns the Nodes of the cluster that have volumes,
+// as all of them have the same Buckets any of them works
+func (s *service) storageNodes() []*client.Client {
+	var (
+		res  []*client.Client
+		seen = make(map[*client.Client]struct{})
+	)
+	for _, vid := range s.members.VolumeIDs() {
+		n, err := s.members.GetNodeWithVolumeByID(vid)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		res = append(res, n)
+	}
+	return res
+}
@@ -0,0 +1,230 @@
+package storing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/xescugc/rebost/coordinator"
+)
+
+// repairsTurn is how long the Nodes scheduled by the
+// leader create the pending replicas before the next ones
+const repairsTurn = time.Minute
+
+// loopCoordinator keeps the Nodes that take part on the election
+// up to date with the cluster and, if the current Node is the
+// leader, decides to synchronize the volumes that left it,
+// schedules the Nodes that create the pending replicas and
+// rebalances the used space of the volumes
+func (s *service) loopCoordinator() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			goto end
+		default:
+			err := s.coordinator.Reconcile(s.members.CoordinatorNodes())
+			if err != nil {
+				s.logger.Log("msg", err.Error())
+			}
+
+			// All the Nodes keep the volumes that left so
+			// if the leader changes the new one can decide
+			// the ones the previous could not. The ones the
+			// leader already decided, before this Node knew
+			// they left, are not decided again
+			rvids := s.members.RemovedVolumeIDs()
+			decided := s.decidedVolumeIDs(time.Now().Add(-s.cfg.VolumeDowntime))
+			s.pendingVolumeIDsLock.Lock()
+			for _, vid := range rvids {
+				if _, ok := decided[vid]; !ok {
+					s.pendingVolumeIDs[vid] = struct{}{}
+				}
+			}
+			vids := make([]string, 0, len(s.pendingVolumeIDs))
+			for vid := range s.pendingVolumeIDs {
+				vids = append(vids, vid)
+			}
+			s.pendingVolumeIDsLock.Unlock()
+
+			if s.coordinator.IsLeader() {
+				for _, vid := range vids {
+					err := s.coordinator.Decide(coordinator.Decision{
+						Type:     coordinator.SynchronizeReplicas,
+						VolumeID: vid,
+					})
+					if err != nil {
+						s.logger.Log("msg", err.Error())
+						break
+					}
+
+					// It's no longer pending even if the
+					// Decision is not applied yet
+					s.pendingVolumeIDsLock.Lock()
+					delete(s.pendingVolumeIDs, vid)
+					s.pendingVolumeIDsLock.Unlock()
+				}
+
+				err = s.scheduleRepairs()
+				if err != nil {
+					s.logger.Log("msg", err.Error())
+				}
+
+				err = s.rebalanceVolumes()
+				if err != nil {
+					s.logger.Log("msg", err.Error())
+				}
+			}
+
+			time.Sleep(time.Second)
+		}
+	}
+end:
+	return
+}
+
+// decidedVolumeIDs returns the volumes the leader
+// decided to synchronize after the t
+func (s *service) decidedVolumeIDs(t time.Time) map[string]struct{} {
+	res := make(map[string]struct{})
+	for _, d := range s.coordinator.Decisions() {
+		if d.Type == coordinator.SynchronizeReplicas && !d.DecidedAt.Before(t) {
+			res[d.VolumeID] = struct{}{}
+		}
+	}
+	return res
+}
+
+// scheduleRepairs gives the turn to create the pending replicas to the
+// next Nodes once the one of the previous ones is over, only if the
+// Settings limit them. The last turn is replicated so a new leader
+// continues from it
+func (s *service) scheduleRepairs() error {
+	mr := s.currentSettings().MaxRepairs
+	if mr == 0 {
+		return nil
+	}
+
+	cns := s.members.CoordinatorNodes()
+	nns := make([]string, 0, len(cns))
+	for nn := range cns {
+		nns = append(nns, nn)
+	}
+	sort.Strings(nns)
+
+	d, ok := s.coordinator.Last(coordinator.ScheduleRepairs)
+	if ok && time.Since(d.DecidedAt) < repairsTurn && len(d.Nodes) == min(mr, len(nns)) {
+		return nil
+	}
+
+	return s.coordinator.Decide(coordinator.Decision{
+		Type:  coordinator.ScheduleRepairs,
+		Nodes: nextRepairs(nns, d.Nodes, mr),
+	})
+}
+
+// nextRepairs returns the mr Nodes of the nns, sorted, that go
+// after the ones of the last turn, starting again from the first
+// ones once all had it
+func nextRepairs(nns, last []string, mr int) []string {
+	if mr >= len(nns) {
+		return nns
+	}
+
+	var i int
+	if len(last) != 0 {
+		ln := last[len(last)-1]
+		i = sort.SearchStrings(nns, ln)
+		if i < len(nns) && nns[i] == ln {
+			i++
+		}
+	}
+
+	res := make([]string, 0, mr)
+	for j := 0; j < mr; j++ {
+		res = append(res, nns[(i+j)%len(nns)])
+	}
+
+	return res
+}
+
+// canRepair checks if it's the turn of the current Node to
+// create the pending replicas, the Nodes that do not take
+// part on the election do it at any time
+func (s *service) canRepair() bool {
+	if s.coordinator == nil || s.currentSettings().MaxRepairs == 0 {
+		return true
+	}
+
+	d, ok := s.coordinator.Last(coordinator.ScheduleRepairs)
+	if !ok {
+		return false
+	}
+	for _, nn := range d.Nodes {
+		if nn == s.cfg.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyDecision applies the d taken by the leader on the current Node
+func (s *service) applyDecision(d coordinator.Decision) {
+	switch d.Type {
+	case coordinator.SynchronizeReplicas:
+		s.pendingVolumeIDsLock.Lock()
+		delete(s.pendingVolumeIDs, d.VolumeID)
+		s.pendingVolumeIDsLock.Unlock()
+
+		// The Nodes that do not store
+		// replicas have nothing to synchronize
+		if s.cfg.Replica == -1 || s.cfg.Gateway {
+			return
+		}
+
+		// The volume may be back from when it was decided,
+		// which happens when the Decisions are applied
+		// again after a restart of the Node
+		if _, err := s.members.GetNodeWithVolumeByID(d.VolumeID); err == nil {
+			return
+		}
+		for _, lv := range s.members.LocalVolumes() {
+			if lv.ID() == d.VolumeID {
+				return
+			}
+		}
+
+		// It can take a while so it does not
+		// delay the next Decisions
+		go func() {
+			for _, lv := range s.members.LocalVolumes() {
+				err := lv.SynchronizeReplicas(s.ctx, d.VolumeID)
+				if err != nil {
+					s.logger.Log("msg", err.Error())
+					continue
+				}
+			}
+		}()
+	case coordinator.RebalanceVolume:
+		// The ones applied again after a restart of the Node
+		// are not done as the volumes changed since then
+		if time.Since(d.DecidedAt) > rebalanceInterval {
+			return
+		}
+
+		for _, lv := range s.members.LocalVolumes() {
+			if lv.ID() == d.VolumeID {
+				// It can take a while so it does
+				// not delay the next Decisions
+				go s.moveReplicas(lv, d.ToVolumeID, d.Size)
+				return
+			}
+		}
+	case coordinator.UpdateSettings, coordinator.ScheduleRepairs:
+		// They are read from the last one
+		// each time they are used
+		s.logger.Log("action", d.Type, "leader", d.Leader)
+	default:
+		s.logger.Log("msg", "unknown decision", "type", d.Type)
+	}
+}
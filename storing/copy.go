@@ -0,0 +1,122 @@
+package storing
+
+import (
+	"context"
+	"time"
+
+	"github.com/xescugc/rebost/volume"
+)
+
+func (s *service) CopyFile(ctx context.Context, src, dst string, move bool, ca time.Time) error {
+	if ca.IsZero() {
+		ca = time.Now()
+	}
+
+	return s.withVolume(ctx, src, func(v volume.Volume) error {
+		return s.copyFile(ctx, v, src, dst, move, ca)
+	})
+}
+
+// copyFile copies the src to the dst from the v
+func (s *service) copyFile(ctx context.Context, v volume.Volume, src, dst string, move bool, ca time.Time) error {
+	// The Node that has it copies it on the
+	// rest of the replicas of the cluster
+	lv, ok := v.(volume.Local)
+	if !ok {
+		err := v.CopyFile(ctx, src, dst, move, ca)
+		if err != nil {
+			return err
+		}
+		s.cache.Remove(dst)
+		if move {
+			s.cache.Remove(src)
+		}
+		return nil
+	}
+
+	ctx, err := s.checkCopyQuotas(ctx, src, dst, move)
+	if err != nil {
+		return err
+	}
+
+	// The rest of the Nodes are the first ones so the previous
+	// dst is deleted from all the cluster before it's placed
+	// on the volume of the src
+	for _, n := range s.members.Nodes() {
+		err = n.CopyReplica(ctx, src, dst, move, ca)
+		if err != nil {
+			s.logger.Log("msg", err.Error())
+		}
+	}
+
+	err = s.CopyReplica(ctx, src, dst, move, ca)
+	if err != nil {
+		return err
+	}
+
+	// The dst is on the volume of the src that may not be a
+	// preferred one for it and the src no longer exists if
+	// it was moved, so the cluster is notified of both
+	s.invalidateKey(lv, dst)
+	if move {
+		s.invalidateKey(lv, src)
+	}
+
+	return nil
+}
+
+func (s *service) CopyReplica(ctx context.Context, src, dst string, move bool, ca time.Time) error {
+	// The cache could point to the Node
+	// that had the dst before or not have it
+	s.cache.Remove(dst)
+	s.missing.Remove(dst)
+	if move {
+		s.cache.Remove(src)
+	}
+
+	// The volumes without the src will not have the dst
+	// after the copy, so if they have it it's the previous
+	// one and it's deleted before copying it on the others
+	var vls []volume.Local
+	for _, v := range s.members.LocalVolumes() {
+		_, ok, err := v.HasFile(ctx, src)
+		if err != nil {
+			return err
+		}
+		if ok {
+			vls = append(vls, v)
+			continue
+		}
+
+		_, ok, err = v.HasFile(ctx, dst)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		err = v.DeleteFile(ctx, dst)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+	}
+
+	var copied bool
+	for _, v := range vls {
+		err := v.CopyFile(ctx, src, dst, move, ca)
+		if err != nil {
+			if err.Error() == "not found" {
+				continue
+			}
+			return err
+		}
+		copied = true
+	}
+
+	if copied {
+		s.members.BroadcastKey(dst)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package storing
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/xescugc/rebost/storing/model"
+)
+
+// digestError is returned when the content received does not
+// match the integrity information sent by the client
+type digestError struct {
+	msg string
+}
+
+func (e *digestError) Error() string { return e.msg }
+
+// digestAlgorithms are all the supported algorithms with the name
+// used on the RFC 3230 'Digest' header
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha":     sha1.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// digestReader wraps the body of a request and calculates the digests
+// of it while it's read, once the EOF is reached it validates them
+// against the ones sent on the Headers or Trailers of the request
+// and also the Content-Length.
+// If any of them does not match, instead of the io.EOF a *digestError
+// is returned so whoever is reading it knows the content is not valid
+type digestReader struct {
+	body io.ReadCloser
+	req  *http.Request
+
+	hashes map[string]hash.Hash
+	n      int64
+}
+
+// newDigestReader returns a digestReader for the r.Body
+func newDigestReader(r *http.Request) *digestReader {
+	dr := &digestReader{
+		body:   r.Body,
+		req:    r,
+		hashes: make(map[string]hash.Hash),
+	}
+
+	// If the Digest is sent as a Trailer we do not know which
+	// algorithms will be used until the end so we calculate all of them
+	if _, ok := r.Trailer[http.CanonicalHeaderKey(model.DigestHeader)]; ok {
+		for alg, fn := range digestAlgorithms {
+			dr.hashes[alg] = fn()
+		}
+	} else if d := r.Header.Get(model.DigestHeader); d != "" {
+		for alg := range parseDigest(d) {
+			if fn, ok := digestAlgorithms[alg]; ok {
+				dr.hashes[alg] = fn()
+			}
+		}
+	}
+
+	if dr.isSent(model.ContentMD5Header) {
+		dr.hashes["md5"] = md5.New()
+	}
+
+	if dr.isSent(model.SHA1Header) {
+		dr.hashes["sha"] = sha1.New()
+	}
+
+	return dr
+}
+
+// isSent checks if the h is on the Header or declared on the Trailer
+func (dr *digestReader) isSent(h string) bool {
+	if dr.req.Header.Get(h) != "" {
+		return true
+	}
+	_, ok := dr.req.Trailer[http.CanonicalHeaderKey(h)]
+	return ok
+}
+
+// get returns the value of h from the Header or the Trailer
+func (dr *digestReader) get(h string) string {
+	if v := dr.req.Header.Get(h); v != "" {
+		return v
+	}
+	return dr.req.Trailer.Get(h)
+}
+
+func (dr *digestReader) Read(p []byte) (int, error) {
+	n, err := dr.body.Read(p)
+	if n > 0 {
+		dr.n += int64(n)
+		for _, h := range dr.hashes {
+			h.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		if verr := dr.verify(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+func (dr *digestReader) Close() error {
+	return dr.body.Close()
+}
+
+// verify checks that all the information sent matches with
+// the content that has been read
+func (dr *digestReader) verify() error {
+	if dr.req.ContentLength > 0 && dr.req.ContentLength != dr.n {
+		return &digestError{msg: fmt.Sprintf("the Content-Length %d does not match the received %d bytes", dr.req.ContentLength, dr.n)}
+	}
+
+	if v := dr.get(model.ContentMD5Header); v != "" {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return &digestError{msg: fmt.Sprintf("invalid %s: %s", model.ContentMD5Header, err)}
+		}
+		if !bytes.Equal(b, dr.hashes["md5"].Sum(nil)) {
+			return &digestError{msg: fmt.Sprintf("the %s does not match the content", model.ContentMD5Header)}
+		}
+	}
+
+	if v := dr.get(model.SHA1Header); v != "" {
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return &digestError{msg: fmt.Sprintf("invalid %s: %s", model.SHA1Header, err)}
+		}
+		if !bytes.Equal(b, dr.hashes["sha"].Sum(nil)) {
+			return &digestError{msg: fmt.Sprintf("the %s does not match the content", model.SHA1Header)}
+		}
+	}
+
+	if v := dr.get(model.DigestHeader); v != "" {
+		for alg, d := range parseDigest(v) {
+			h, ok := dr.hashes[alg]
+			if !ok {
+				// As the RFC 3230 says, the unsupported
+				// algorithms are ignored
+				continue
+			}
+			b, err := base64.StdEncoding.DecodeString(d)
+			if err != nil {
+				return &digestError{msg: fmt.Sprintf("invalid %s %q: %s", model.DigestHeader, alg, err)}
+			}
+			if !bytes.Equal(b, h.Sum(nil)) {
+				return &digestError{msg: fmt.Sprintf("the %s %q does not match the content", model.DigestHeader, alg)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDigest parses the value of a Digest header like 'sha=<base64>,md5=<base64>'
+// to a map of algorithm (lowercase) and digest
+func parseDigest(v string) map[string]string {
+	ds := make(map[string]string)
+	for _, d := range strings.Split(v, ",") {
+		alg, value, ok := strings.Cut(strings.TrimSpace(d), "=")
+		if !ok {
+			continue
+		}
+		ds[strings.ToLower(alg)] = value
+	}
+	return ds
+}
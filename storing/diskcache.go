@@ -0,0 +1,51 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/volume"
+)
+
+// getFile returns the content of the k from the v, the one of the other
+// Nodes is served from the contents cache if it has the same Signature
+// that the Node has and if not it's added to it while it's read
+func (s *service) getFile(ctx context.Context, v volume.Volume, k string) (io.ReadCloser, error) {
+	n, ok := v.(*client.Client)
+	if !ok || s.contents == nil {
+		return v.GetFile(ctx, k)
+	}
+
+	sig, err := n.GetFileSignatureReplica(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, ok := s.contents.Get(sig); ok {
+		return r, nil
+	}
+
+	r, err := n.GetFile(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.contents.Add(sig, r), nil
+}
+
+func (s *service) GetFileSignatureReplica(ctx context.Context, k string) (string, error) {
+	for _, v := range s.members.LocalVolumes() {
+		sig, err := v.GetFileSignature(ctx, k)
+		if err != nil {
+			if err.Error() == "not found" {
+				continue
+			}
+			return "", err
+		}
+		return sig, nil
+	}
+
+	return "", errors.New("not found")
+}
@@ -2,19 +2,25 @@ package storing
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/settings"
 	"github.com/xescugc/rebost/storing/model"
 )
 
 type createFileRequest struct {
 	Key       string
+	Bucket    string
 	Body      io.ReadCloser
 	Replica   int
 	TTL       time.Duration
 	CreatedAt time.Time
+	Class     string
 }
 
 type createFileResponse struct {
@@ -26,18 +32,45 @@ func (r createFileResponse) error() error { return r.Err }
 func makeCreateFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createFileRequest)
-		err := s.CreateFile(ctx, req.Key, req.Body, req.Replica, req.TTL, req.CreatedAt)
+		if req.Bucket != "" {
+			b, err := s.GetBucket(ctx, req.Bucket)
+			if err != nil {
+				req.Body.Close()
+				return createFileResponse{Err: err}, nil
+			}
+			// The Bucket settings are the default
+			// ones if none is specified
+			if req.Replica == 0 {
+				req.Replica = b.Replica
+			}
+			if req.TTL == 0 {
+				req.TTL = b.TTL
+			}
+			if req.Class == "" {
+				req.Class = b.Class
+			}
+		}
+		err := s.CreateFile(ctx, req.Key, req.Body, req.Replica, req.TTL, req.CreatedAt, req.Class)
 		return createFileResponse{Err: err}, nil
 	}
 }
 
 type getFileRequest struct {
-	Key string
+	Key            string
+	Bucket         string
+	AcceptEncoding string
+	Redirect       redirectMode
+
+	// URI is the one of the request, used
+	// to redirect it to another Node
+	URI string
 }
 
 type getFileResponse struct {
-	IORC io.ReadCloser
-	Err  error
+	IORC           io.ReadCloser
+	AcceptEncoding string
+	Location       string
+	Err            error
 }
 
 func (r getFileResponse) error() error { return r.Err }
@@ -45,13 +78,44 @@ func (r getFileResponse) error() error { return r.Err }
 func makeGetFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(getFileRequest)
-		iorc, err := s.GetFile(ctx, req.Key)
-		return getFileResponse{IORC: iorc, Err: err}, nil
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return getFileResponse{Err: err}, nil
+			}
+		}
+		iorc, err := s.GetFile(withRedirect(ctx, req.Redirect), req.Key)
+		var rerr *redirectError
+		if errors.As(err, &rerr) {
+			return getFileResponse{Location: rerr.URL + req.URI}, nil
+		}
+		return getFileResponse{IORC: iorc, AcceptEncoding: req.AcceptEncoding, Err: err}, nil
+	}
+}
+
+type getFileNodesRequest struct {
+	Key    string
+	Bucket string
+}
+
+func makeGetFileNodesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getFileNodesRequest)
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return response{Err: err}, nil
+			}
+		}
+		urls, err := s.GetFileNodes(ctx, req.Key)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		return response{Data: urls}, nil
 	}
 }
 
 type deleteFileRequest struct {
-	Key string
+	Key    string
+	Bucket string
 }
 
 type deleteFileResponse struct {
@@ -63,13 +127,19 @@ func (r deleteFileResponse) error() error { return r.Err }
 func makeDeleteFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(deleteFileRequest)
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return deleteFileResponse{Err: err}, nil
+			}
+		}
 		err := s.DeleteFile(ctx, req.Key)
 		return deleteFileResponse{Err: err}, nil
 	}
 }
 
 type hasFileRequest struct {
-	Key string
+	Key    string
+	Bucket string
 }
 
 type hasFileResponse struct {
@@ -80,6 +150,11 @@ type hasFileResponse struct {
 func makeHasFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(hasFileRequest)
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return hasFileResponse{}, nil
+			}
+		}
 		vid, ok, err := s.HasFile(ctx, req.Key)
 		if err != nil {
 			return nil, err
@@ -110,12 +185,13 @@ type createReplicaRequest struct {
 	Body      io.ReadCloser
 	TTL       time.Duration
 	CreatedAt time.Time
+	Class     string
 }
 
 func makeCreateReplicaEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createReplicaRequest)
-		volID, err := s.CreateReplica(ctx, req.Key, req.Body, req.TTL, req.CreatedAt)
+		volID, err := s.CreateReplica(ctx, req.Key, req.Body, req.TTL, req.CreatedAt, req.Class)
 		if err != nil {
 			return response{Err: err}, nil
 		}
@@ -142,3 +218,337 @@ func makeUpdateFileReplicaEndpoint(s Service) endpoint.Endpoint {
 		return updateFileReplicaResponse{Err: err}, nil
 	}
 }
+
+type createBucketRequest struct {
+	Bucket *bucket.Bucket
+}
+
+func makeCreateBucketEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createBucketRequest)
+		err := s.CreateBucket(ctx, req.Bucket)
+		return response{Err: err}, nil
+	}
+}
+
+type getBucketRequest struct {
+	Name string
+}
+
+func makeGetBucketEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getBucketRequest)
+		b, err := s.GetBucket(ctx, req.Name)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		return response{Data: model.BucketToModel(b)}, nil
+	}
+}
+
+func makeBucketsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		bks, err := s.Buckets(ctx)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		mbks := make([]model.Bucket, 0, len(bks))
+		for _, b := range bks {
+			mbks = append(mbks, model.BucketToModel(b))
+		}
+		return response{Data: mbks}, nil
+	}
+}
+
+type deleteBucketRequest struct {
+	Name string
+}
+
+func makeDeleteBucketEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteBucketRequest)
+		err := s.DeleteBucket(ctx, req.Name)
+		return response{Err: err}, nil
+	}
+}
+
+func makeSettingsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		st, err := s.Settings(ctx)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		return response{Data: model.SettingsToModel(st)}, nil
+	}
+}
+
+type updateSettingsRequest struct {
+	Settings *settings.Settings
+}
+
+func makeUpdateSettingsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateSettingsRequest)
+		err := s.UpdateSettings(ctx, req.Settings)
+		return response{Err: err}, nil
+	}
+}
+
+func makeQuotasEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		sts, err := s.Quotas(ctx)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		mqs := make([]model.Quota, 0, len(sts))
+		for _, st := range sts {
+			mqs = append(mqs, model.QuotaToModel(st))
+		}
+		return response{Data: mqs}, nil
+	}
+}
+
+type versionsRequest struct {
+	Key    string
+	Bucket string
+}
+
+func makeVersionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(versionsRequest)
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return response{Err: err}, nil
+			}
+		}
+		vs, err := s.Versions(ctx, req.Key)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		mvs := make([]model.Version, 0, len(vs))
+		for _, v := range vs {
+			mvs = append(mvs, model.VersionToModel(v))
+		}
+		return response{Data: mvs}, nil
+	}
+}
+
+func makeHasVersionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(versionsRequest)
+		vid, ok, err := s.HasVersions(ctx, req.Key)
+		if err != nil {
+			return nil, err
+		}
+		return hasFileResponse{VolumeID: vid, Ok: ok}, nil
+	}
+}
+
+type restoreVersionRequest struct {
+	Key     string
+	Bucket  string
+	Version string
+}
+
+func makeRestoreVersionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(restoreVersionRequest)
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return createFileResponse{Err: err}, nil
+			}
+		}
+		err := s.RestoreVersion(ctx, req.Key, req.Version)
+		return createFileResponse{Err: err}, nil
+	}
+}
+
+type trashRequest struct {
+	Local bool
+}
+
+func makeTrashEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(trashRequest)
+		its, err := s.Trash(ctx, req.Local)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		mits := make([]model.TrashItem, 0, len(its))
+		for _, it := range its {
+			mits = append(mits, model.TrashItemToModel(it))
+		}
+		return response{Data: mits}, nil
+	}
+}
+
+type restoreTrashRequest struct {
+	Key   string
+	ID    string
+	Local bool
+}
+
+func makeRestoreTrashEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(restoreTrashRequest)
+		err := s.RestoreTrash(ctx, req.Key, req.ID, req.Local)
+		return response{Err: err}, nil
+	}
+}
+
+type trashReplicaRequest struct {
+	Key       string
+	DeletedAt time.Time
+	PurgeAt   time.Time
+}
+
+func makeTrashReplicaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(trashReplicaRequest)
+		err := s.TrashReplica(ctx, req.Key, req.DeletedAt, req.PurgeAt)
+		return response{Err: err}, nil
+	}
+}
+
+type copyFileRequest struct {
+	Source            string
+	SourceBucket      string
+	Destination       string
+	DestinationBucket string
+	Move              bool
+	CopiedAt          time.Time
+}
+
+func makeCopyFileEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(copyFileRequest)
+		for _, bn := range []string{req.SourceBucket, req.DestinationBucket} {
+			if bn == "" {
+				continue
+			}
+			if _, err := s.GetBucket(ctx, bn); err != nil {
+				return createFileResponse{Err: err}, nil
+			}
+		}
+		err := s.CopyFile(ctx, req.Source, req.Destination, req.Move, req.CopiedAt)
+		return createFileResponse{Err: err}, nil
+	}
+}
+
+type getFileSignatureReplicaRequest struct {
+	Key string
+}
+
+func makeGetFileSignatureReplicaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getFileSignatureReplicaRequest)
+		sig, err := s.GetFileSignatureReplica(ctx, req.Key)
+		if err != nil {
+			return response{Err: err}, nil
+		}
+		return response{Data: sig}, nil
+	}
+}
+
+func makeCopyReplicaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(copyFileRequest)
+		err := s.CopyReplica(ctx, req.Source, req.Destination, req.Move, req.CopiedAt)
+		return response{Err: err}, nil
+	}
+}
+
+type updateFileTTLRequest struct {
+	Key    string
+	Bucket string
+	TTL    time.Duration
+}
+
+func makeUpdateFileTTLEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateFileTTLRequest)
+		if req.Bucket != "" {
+			if _, err := s.GetBucket(ctx, req.Bucket); err != nil {
+				return response{Err: err}, nil
+			}
+		}
+		err := s.UpdateFileTTL(ctx, req.Key, req.TTL)
+		return response{Err: err}, nil
+	}
+}
+
+func makeUpdateFileTTLReplicaEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateFileTTLRequest)
+		err := s.UpdateFileTTLReplica(ctx, req.Key, req.TTL)
+		return response{Err: err}, nil
+	}
+}
+
+type batchRequest struct {
+	Operations []model.BatchOperation
+	Local      bool
+	NDJSON     bool
+}
+
+type batchResponse struct {
+	Results []model.BatchResult
+	NDJSON  bool
+	Err     error
+}
+
+func (r batchResponse) error() error { return r.Err }
+
+func makeBatchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchRequest)
+
+		// The Operations of Buckets that do not
+		// exist fail without being done
+		var (
+			ops  = make([]*batch.Operation, 0, len(req.Operations))
+			idxs = make([]int, 0, len(req.Operations))
+			errs = make(map[int]error)
+			bkts = make(map[string]error)
+		)
+		for i, mop := range req.Operations {
+			if mop.Bucket != "" {
+				err, ok := bkts[mop.Bucket]
+				if !ok {
+					_, err = s.GetBucket(ctx, mop.Bucket)
+					bkts[mop.Bucket] = err
+				}
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+			}
+			op, err := model.ToBatchOperation(mop)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			ops = append(ops, op)
+			idxs = append(idxs, i)
+		}
+
+		rs, err := s.Batch(ctx, ops, req.Local)
+		if err != nil {
+			return batchResponse{Err: err}, nil
+		}
+
+		mrs := make([]model.BatchResult, len(req.Operations))
+		for i, err := range errs {
+			mrs[i] = model.BatchResult{BatchOperation: req.Operations[i], Status: errorStatusCode(err), Error: err.Error()}
+		}
+		for j, r := range rs {
+			i := idxs[j]
+			mrs[i] = model.BatchResult{BatchOperation: req.Operations[i], VolumeID: r.VolumeID, Status: batchStatusCode(r)}
+			if r.Err != nil {
+				mrs[i].Error = r.Err.Error()
+			}
+		}
+
+		return batchResponse{Results: mrs, NDJSON: req.NDJSON}, nil
+	}
+}
@@ -0,0 +1,127 @@
+package storing
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/rebost/auth"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/storing/model"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/version"
+)
+
+// isReservedKey checks if the k belongs to one of the namespaces
+// used internally, which can only be used from the cluster
+func isReservedKey(k string) bool {
+	return bucket.IsKey(k) || version.IsKey(k) || trash.IsKey(k)
+}
+
+// isInternal checks if the r was made by a Node of the cluster. If
+// the auth is disabled it's only known by the model.InternalHeader
+func isInternal(s Service, r *http.Request) (bool, error) {
+	if auth.IsCluster(r.Context()) {
+		return true, nil
+	}
+	if r.Header.Get(model.InternalHeader) == "" {
+		return false, nil
+	}
+
+	cfg, err := s.Config(r.Context())
+	if err != nil {
+		return false, err
+	}
+
+	return cfg.Auth.ClusterSecret == "", nil
+}
+
+// verifyKey wraps the next to reject the requests that use a reserved
+// key, on the path or on the ones of the copies and the batches,
+// unless they are made by a Node of the cluster
+func verifyKey(s Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys, err := requestKeys(r)
+		if err != nil {
+			encodeError(r.Context(), err, w)
+			return
+		}
+
+		for _, k := range keys {
+			if !isReservedKey(k) {
+				continue
+			}
+
+			ok, err := isInternal(s, r)
+			if err != nil {
+				encodeError(r.Context(), err, w)
+				return
+			}
+			if !ok {
+				encodeError(r.Context(), model.ErrReservedKey, w)
+				return
+			}
+			break
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestKeys returns the keys of the r as they were sent, before
+// the 'version' query parameter is applied, the ones of a Bucket
+// are not included as they can not be reserved. The body
+// of a batch is read and set again to the r
+func requestKeys(r *http.Request) ([]string, error) {
+	var keys []string
+	if _, ok := mux.Vars(r)["bucket"]; !ok {
+		keys = append(keys, mux.Vars(r)["key"])
+	}
+	for _, h := range []string{model.CopySourceHeader, model.DestinationHeader} {
+		if k, ok := filePathKey(r.Header.Get(h)); ok {
+			keys = append(keys, k)
+		}
+	}
+
+	if r.URL.Path != "/batch" {
+		return keys, nil
+	}
+
+	b, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	mops, err := model.DecodeBatch(bytes.NewReader(b), r.Header.Get("Content-Type"))
+	if err != nil {
+		// It fails later on when decoding it
+		return keys, nil
+	}
+	for _, mop := range mops {
+		if mop.Bucket == "" {
+			keys = append(keys, mop.Key, mop.Destination)
+		}
+	}
+
+	return keys, nil
+}
+
+// filePathKey returns the key of the p if it's the path, or
+// the URL, of a File without Bucket like '/files/{key}'
+func filePathKey(p string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+	u, err := url.Parse(p)
+	if err != nil {
+		return "", false
+	}
+	path := "/" + strings.TrimPrefix(u.Path, "/")
+	k := strings.TrimPrefix(path, "/files/")
+	return k, k != path
+}
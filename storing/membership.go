@@ -1,6 +1,7 @@
 package storing
 
 import (
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/client"
 	"github.com/xescugc/rebost/membership"
 	"github.com/xescugc/rebost/volume"
@@ -19,6 +20,14 @@ type Membership interface {
 	// do not have any of the provided vids
 	NodesWithoutVolumeIDs(vids []string) []*client.Client
 
+	// VolumeIDs returns the IDs of the volumes of all
+	// the Nodes of the cluster except the current one
+	VolumeIDs() []string
+
+	// NodesWithKey returns all the Nodes of the cluster except the current
+	// one that may have the key k by the filters of the keys of their volumes
+	NodesWithKey(k string) []*client.Client
+
 	// LocalVolumes returns only the local volumes
 	LocalVolumes() []volume.Local
 
@@ -26,13 +35,47 @@ type Membership interface {
 	// vid in his volumes
 	GetNodeWithVolumeByID(vid string) (*client.Client, error)
 
+	// GetNodeByName returns the Node with the name nn
+	GetNodeByName(nn string) (*client.Client, error)
+
+	// NodeURL returns the public URL of the Node n
+	NodeURL(n *client.Client) (string, error)
+
+	// LocalURL returns the public URL of the current Node
+	LocalURL() string
+
+	// CoordinatorNodes returns the host:port used to elect the leader
+	// by the name of the Nodes that take part on the election
+	CoordinatorNodes() map[string]string
+
 	// GetNodeState returns the Staet of the Node
 	GetNodeState(nn string) (*membership.State, error)
 
+	// NodeStates returns the State of all the Nodes
+	// except the current one
+	NodeStates() []*membership.State
+
 	// RemovedVolumeIDs returns a list of are the volumeIDs
 	// that left the cluster
 	RemovedVolumeIDs() []string
 
+	// BroadcastBucket notifies the b to all the Nodes of the cluster
+	BroadcastBucket(b *bucket.Bucket)
+
+	// BroadcastKey notifies the k, created on the current Node out of
+	// its preferred volumes, to all the Nodes of the cluster
+	BroadcastKey(k string)
+
+	// BroadcastInvalidation notifies the cluster that the locations
+	// of the k they know are no longer valid
+	BroadcastInvalidation(k string)
+
 	// Leave makes it leave the cluster
 	Leave()
 }
+
+// keyInvalidations is implemented by the Memberships that notify
+// the keys which locations are invalidated by the other Nodes
+type keyInvalidations interface {
+	OnKeyInvalidated(fn func(k string))
+}
@@ -0,0 +1,26 @@
+package storing
+
+import "time"
+
+// isMissing checks if the k was not found on the
+// cluster less than the cfg.Cache.NegativeTTL ago
+func (s *service) isMissing(k string) bool {
+	ea, ok := s.missing.Get(k)
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(ea) {
+		s.missing.Remove(k)
+		return false
+	}
+	return true
+}
+
+// addMissing caches the k as not found on the cluster
+// for the cfg.Cache.NegativeTTL, if it's set
+func (s *service) addMissing(k string) {
+	if s.cfg.Cache.NegativeTTL == 0 {
+		return
+	}
+	s.missing.Add(k, time.Now().Add(s.cfg.Cache.NegativeTTL))
+}
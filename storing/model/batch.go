@@ -0,0 +1,145 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
+)
+
+const (
+	// NDJSONContentType is the Content-Type of a Batch sent
+	// as NDJSON, with one BatchOperation per line, the
+	// BatchResults are returned the same way
+	NDJSONContentType = "application/x-ndjson"
+
+	// MaxBatchOperations is the maximum number
+	// of Operations on a Batch
+	MaxBatchOperations = 1000
+)
+
+// Batch is the body of a batch request as JSON
+type Batch struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is the transport representation of a batch.Operation,
+// if it has the Bucket the Key and the Destination are of that Bucket
+type BatchOperation struct {
+	Op          string `json:"op"`
+	Key         string `json:"key"`
+	Bucket      string `json:"bucket,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	TTL         string `json:"ttl,omitempty"`
+}
+
+// BatchResult is the transport representation of a batch.Result,
+// the Status is the HTTP status code the Operation would have
+// had as a single request
+type BatchResult struct {
+	BatchOperation
+
+	VolumeID string `json:"volume_id,omitempty"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DecodeBatch decodes the BatchOperations from the r, which is
+// a Batch or NDJSON if the contentType is the NDJSONContentType
+func DecodeBatch(r io.Reader, contentType string) ([]BatchOperation, error) {
+	var (
+		mops []BatchOperation
+		err  error
+	)
+
+	if mt, _, _ := mime.ParseMediaType(contentType); mt == NDJSONContentType {
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			l := bytes.TrimSpace(s.Bytes())
+			if len(l) == 0 {
+				continue
+			}
+			var mop BatchOperation
+			err = json.Unmarshal(l, &mop)
+			if err != nil {
+				break
+			}
+			mops = append(mops, mop)
+		}
+		if err == nil {
+			err = s.Err()
+		}
+	} else {
+		var mb Batch
+		err = json.NewDecoder(r).Decode(&mb)
+		mops = mb.Operations
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", batch.ErrInvalid, err)
+	}
+
+	if len(mops) > MaxBatchOperations {
+		return nil, fmt.Errorf("%w: the maximum is %d operations", batch.ErrInvalid, MaxBatchOperations)
+	}
+
+	return mops, nil
+}
+
+// ToBatchOperation converts the BatchOperation to a batch.Operation
+func ToBatchOperation(o BatchOperation) (*batch.Operation, error) {
+	op := &batch.Operation{
+		Op:          batch.Op(o.Op),
+		Key:         o.Key,
+		Destination: o.Destination,
+	}
+	if o.Bucket != "" {
+		op.Key = bucket.Key(o.Bucket, o.Key)
+		if o.Destination != "" {
+			op.Destination = bucket.Key(o.Bucket, o.Destination)
+		}
+	}
+	if o.TTL != "" {
+		ttl, err := ParseTTL(o.TTL)
+		if err != nil {
+			return nil, err
+		}
+		op.TTL = &ttl
+	}
+	return op, nil
+}
+
+// BatchOperationToModel converts the batch.Operation to a BatchOperation
+func BatchOperationToModel(o *batch.Operation) BatchOperation {
+	mo := BatchOperation{
+		Op:          string(o.Op),
+		Key:         o.Key,
+		Destination: o.Destination,
+	}
+	if o.TTL != nil {
+		mo.TTL = o.TTL.String()
+	}
+	return mo
+}
+
+// ToBatchResult converts the BatchResult of a BatchOperation
+// without Bucket to a batch.Result
+func ToBatchResult(r BatchResult) (*batch.Result, error) {
+	op, err := ToBatchOperation(r.BatchOperation)
+	if err != nil {
+		return nil, err
+	}
+	br := &batch.Result{
+		Operation: *op,
+		VolumeID:  r.VolumeID,
+	}
+	if r.Error != "" {
+		br.Err = errors.New(r.Error)
+	}
+	return br, nil
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+
+	"github.com/xescugc/rebost/bucket"
+)
+
+// Bucket is the transport representation of the bucket.Bucket
+type Bucket struct {
+	Name    string      `json:"name"`
+	Replica int         `json:"replica,omitempty"`
+	Class   string      `json:"class,omitempty"`
+	TTL     string      `json:"ttl,omitempty"`
+	Quota   BucketQuota `json:"quota"`
+	Policy  string      `json:"policy,omitempty"`
+
+	Versioning BucketVersioning `json:"versioning"`
+
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// BucketQuota is the transport representation of the bucket.Quota
+type BucketQuota struct {
+	Size  int64 `json:"size,omitempty"`
+	Count int   `json:"count,omitempty"`
+}
+
+// BucketVersioning is the transport representation of the bucket.Versioning
+type BucketVersioning struct {
+	Enabled     bool   `json:"enabled,omitempty"`
+	MaxVersions int    `json:"max_versions,omitempty"`
+	MaxAge      string `json:"max_age,omitempty"`
+}
+
+// ToBucket converts a model.Bucket to a bucket.Bucket
+func ToBucket(b Bucket) (*bucket.Bucket, error) {
+	var ttl time.Duration
+	if b.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(b.TTL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var maxAge time.Duration
+	if b.Versioning.MaxAge != "" {
+		var err error
+		maxAge, err = time.ParseDuration(b.Versioning.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &bucket.Bucket{
+		Name:    b.Name,
+		Replica: b.Replica,
+		Class:   b.Class,
+		TTL:     ttl,
+		Quota: bucket.Quota{
+			Size:  b.Quota.Size,
+			Count: b.Quota.Count,
+		},
+		Policy: bucket.Policy(b.Policy),
+		Versioning: bucket.Versioning{
+			Enabled:     b.Versioning.Enabled,
+			MaxVersions: b.Versioning.MaxVersions,
+			MaxAge:      maxAge,
+		},
+		UpdatedAt: b.UpdatedAt,
+	}, nil
+}
+
+// BucketToModel converts a bucket.Bucket to a model.Bucket
+func BucketToModel(b *bucket.Bucket) Bucket {
+	mb := Bucket{
+		Name:    b.Name,
+		Replica: b.Replica,
+		Class:   b.Class,
+		Quota: BucketQuota{
+			Size:  b.Quota.Size,
+			Count: b.Quota.Count,
+		},
+		Policy: string(b.Policy),
+		Versioning: BucketVersioning{
+			Enabled:     b.Versioning.Enabled,
+			MaxVersions: b.Versioning.MaxVersions,
+		},
+		UpdatedAt: b.UpdatedAt,
+	}
+	if b.TTL != 0 {
+		mb.TTL = b.TTL.String()
+	}
+	if b.Versioning.MaxAge != 0 {
+		mb.Versioning.MaxAge = b.Versioning.MaxAge.String()
+	}
+	return mb
+}
@@ -10,6 +10,7 @@ type Config struct {
 	Replica int      `json:"int"`
 
 	Name string `json:"name"`
+	Hash string `json:"hash"`
 
 	Memberlist ConfigMemberlist `json:"memberlist"`
 
@@ -35,6 +36,7 @@ func ToConfig(c Config) *config.Config {
 		Remote:  c.Remote,
 		Replica: c.Replica,
 		Name:    c.Name,
+		Hash:    c.Hash,
 		Memberlist: config.Memberlist{
 			Port: c.Memberlist.Port,
 		},
@@ -53,6 +55,7 @@ func ConfigToModel(c *config.Config) Config {
 		Remote:  c.Remote,
 		Replica: c.Replica,
 		Name:    c.Name,
+		Hash:    c.Hash,
 		Memberlist: ConfigMemberlist{
 			Port: c.Memberlist.Port,
 		},
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/version"
+)
+
+const (
+	// CopySourceHeader is the HEADER of a PUT with the path of the File
+	// to copy instead of the content, like '/files/{key}' or
+	// '/buckets/{bucket}/files/{key}'
+	CopySourceHeader = "X-Rebost-Copy-Source"
+
+	// DestinationHeader is the HEADER of a COPY or a MOVE with the path
+	// (or URL) of where the File is copied, like the CopySourceHeader
+	DestinationHeader = "Destination"
+)
+
+// ErrInvalidFilePath is returned when the path
+// is not the one of a File
+var ErrInvalidFilePath = errors.New("invalid file path")
+
+// ParseFilePath returns the key of the File of the p and the name of
+// the Bucket it belongs to, if it has one. The p can be a path
+// or an URL like the ones of the CopySourceHeader, and if it has
+// the 'version' query parameter the key is the one of that Version
+func ParseFilePath(p string) (string, string, error) {
+	u, err := url.Parse(p)
+	if err != nil {
+		return "", "", ErrInvalidFilePath
+	}
+
+	var (
+		key, bn string
+		path    = "/" + strings.TrimPrefix(u.Path, "/")
+	)
+	if k := strings.TrimPrefix(path, "/files/"); k != path {
+		key = k
+	} else if parts := strings.SplitN(strings.TrimPrefix(path, "/buckets/"), "/", 3); strings.HasPrefix(path, "/buckets/") && len(parts) == 3 && parts[0] != "" && parts[1] == "files" && parts[2] != "" {
+		key, bn = bucket.Key(parts[0], parts[2]), parts[0]
+	}
+
+	if key == "" {
+		return "", "", ErrInvalidFilePath
+	}
+
+	if id := u.Query().Get("version"); id != "" {
+		key = version.Key(key, id)
+	}
+
+	return key, bn, nil
+}
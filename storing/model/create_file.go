@@ -0,0 +1,14 @@
+package model
+
+const (
+	// ContentMD5Header is the HEADER (or TRAILER) with the base64 MD5 of the content
+	// as defined on the RFC 1864
+	ContentMD5Header = "Content-MD5"
+
+	// DigestHeader is the HEADER (or TRAILER) with the list of digests of the
+	// content as defined on the RFC 3230, like 'sha=<base64>,md5=<base64>'
+	DigestHeader = "Digest"
+
+	// SHA1Header is the HEADER (or TRAILER) with the hex SHA1 of the content
+	SHA1Header = "X-Rebost-SHA1"
+)
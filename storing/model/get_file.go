@@ -0,0 +1,8 @@
+package model
+
+const (
+	// RedirectHeader defines the HEADER used to choose if the request
+	// for a file on another Node is answered with a redirect to it (true)
+	// or proxied (false), if not set the Node configuration is used
+	RedirectHeader = "X-Rebost-Redirect"
+)
@@ -0,0 +1,12 @@
+package model
+
+import "errors"
+
+// InternalHeader is the HEADER set by the Nodes on the requests
+// between them, as they use the internal keys, like the ones
+// of the Buckets, that can not be used from outside the cluster
+const InternalHeader = "X-Rebost-Internal"
+
+// ErrReservedKey is returned when a key of one of the
+// internal namespaces is used from outside the cluster
+var ErrReservedKey = errors.New("reserved key")
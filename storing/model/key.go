@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Key is the transport representation of an API key
+type Key struct {
+	ID          string   `json:"id"`
+	Secret      string   `json:"secret,omitempty"`
+	Permissions []string `json:"permissions"`
+	Prefix      string   `json:"prefix,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	Deleted   bool      `json:"deleted,omitempty"`
+}
@@ -0,0 +1,19 @@
+package model
+
+// Presign is the body to request a presigned URL
+type Presign struct {
+	Method string `json:"method"`
+	Key    string `json:"key"`
+
+	// ExpiresIn is the number of seconds the URL will be valid
+	ExpiresIn int `json:"expires_in"`
+
+	ContentType string `json:"content_type,omitempty"`
+	MaxSize     int64  `json:"max_size,omitempty"`
+}
+
+// PresignedURL is the response with the presigned URL, it's only the
+// path and query so it can be used with any Node of the cluster
+type PresignedURL struct {
+	URL string `json:"url"`
+}
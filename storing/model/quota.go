@@ -0,0 +1,45 @@
+package model
+
+import "github.com/xescugc/rebost/quota"
+
+// Quota is the transport representation of the quota.Status
+type Quota struct {
+	Prefix string     `json:"prefix"`
+	Size   int64      `json:"size,omitempty"`
+	Count  int        `json:"count,omitempty"`
+	Usage  QuotaUsage `json:"usage"`
+}
+
+// QuotaUsage is the transport representation of the quota.Usage
+type QuotaUsage struct {
+	Size  int64 `json:"size"`
+	Count int   `json:"count"`
+}
+
+// ToQuota converts a model.Quota to a quota.Status
+func ToQuota(q Quota) *quota.Status {
+	return &quota.Status{
+		Quota: quota.Quota{
+			Prefix: q.Prefix,
+			Size:   q.Size,
+			Count:  q.Count,
+		},
+		Usage: quota.Usage{
+			Size:  q.Usage.Size,
+			Count: q.Usage.Count,
+		},
+	}
+}
+
+// QuotaToModel converts a quota.Status to a model.Quota
+func QuotaToModel(s *quota.Status) Quota {
+	return Quota{
+		Prefix: s.Prefix,
+		Size:   s.Size,
+		Count:  s.Count,
+		Usage: QuotaUsage{
+			Size:  s.Usage.Size,
+			Count: s.Usage.Count,
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import "github.com/xescugc/rebost/settings"
+
+// Settings is the transport representation of the settings.Settings
+type Settings struct {
+	Replica    int    `json:"replica,omitempty"`
+	Redirect   string `json:"redirect,omitempty"`
+	MaxRepairs int    `json:"max_repairs,omitempty"`
+	Rebalance  int    `json:"rebalance,omitempty"`
+}
+
+// ToSettings converts a model.Settings to a settings.Settings
+func ToSettings(s Settings) *settings.Settings {
+	return &settings.Settings{
+		Replica:    s.Replica,
+		Redirect:   settings.Redirect(s.Redirect),
+		MaxRepairs: s.MaxRepairs,
+		Rebalance:  s.Rebalance,
+	}
+}
+
+// SettingsToModel converts a settings.Settings to a model.Settings
+func SettingsToModel(s *settings.Settings) Settings {
+	return Settings{
+		Replica:    s.Replica,
+		Redirect:   string(s.Redirect),
+		MaxRepairs: s.MaxRepairs,
+		Rebalance:  s.Rebalance,
+	}
+}
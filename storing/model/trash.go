@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/xescugc/rebost/trash"
+)
+
+// TrashItem is the transport representation of the trash.Item
+type TrashItem struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Signature string    `json:"signature"`
+	Size      int       `json:"size"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// ToTrashItem converts a model.TrashItem to a trash.Item
+func ToTrashItem(it TrashItem) *trash.Item {
+	return &trash.Item{
+		ID:        it.ID,
+		Key:       it.Key,
+		Signature: it.Signature,
+		Size:      it.Size,
+		DeletedAt: it.DeletedAt,
+		PurgeAt:   it.PurgeAt,
+	}
+}
+
+// TrashItemToModel converts a trash.Item to a model.TrashItem
+func TrashItemToModel(it *trash.Item) TrashItem {
+	return TrashItem{
+		ID:        it.ID,
+		Key:       it.Key,
+		Signature: it.Signature,
+		Size:      it.Size,
+		DeletedAt: it.DeletedAt,
+		PurgeAt:   it.PurgeAt,
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidTTL is returned when the TTL
+// is not a valid positive duration
+var ErrInvalidTTL = errors.New("invalid ttl")
+
+// ParseTTL parses the TTL s, which is a duration
+// like '24h', empty or '0' means no TTL
+func ParseTTL(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl < 0 {
+		return 0, ErrInvalidTTL
+	}
+
+	return ttl, nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/xescugc/rebost/version"
+)
+
+// Version is the transport representation of the version.Version
+type Version struct {
+	ID           string    `json:"id"`
+	Signature    string    `json:"signature,omitempty"`
+	Size         int       `json:"size"`
+	Class        string    `json:"class,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	DeleteMarker bool      `json:"delete_marker,omitempty"`
+}
+
+// ToVersion converts a model.Version to a version.Version
+func ToVersion(v Version) *version.Version {
+	return &version.Version{
+		ID:           v.ID,
+		Signature:    v.Signature,
+		Size:         v.Size,
+		Class:        v.Class,
+		CreatedAt:    v.CreatedAt,
+		DeleteMarker: v.DeleteMarker,
+	}
+}
+
+// VersionToModel converts a version.Version to a model.Version
+func VersionToModel(v *version.Version) Version {
+	return Version{
+		ID:           v.ID,
+		Signature:    v.Signature,
+		Size:         v.Size,
+		Class:        v.Class,
+		CreatedAt:    v.CreatedAt,
+		DeleteMarker: v.DeleteMarker,
+	}
+}
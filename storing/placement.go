@@ -0,0 +1,173 @@
+package storing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/rendezvous"
+	"github.com/xescugc/rebost/state"
+	"github.com/xescugc/rebost/volume"
+)
+
+// getOwnerVolume returns the volume in which the key k has to be created, the
+// preferred one of the cluster for it that may be from another Node. The
+// volumes are ranked by their free capacity so the ones with more space
+// left get more keys. The Versions of a key are on the volume that has its
+// History so if one of the local volumes has it, it's the one returned
+func (s *service) getOwnerVolume(ctx context.Context, k string) (volume.Volume, error) {
+	vls := s.members.LocalVolumes()
+
+	if _, ok := bucket.Name(k); ok && len(vls) != 0 {
+		_, v, err := s.findVolumeWith(ctx, localVolumesToVolumes(vls), k, hasVersions)
+		if err == nil {
+			return v, nil
+		}
+	}
+
+	var (
+		vids = s.members.VolumeIDs()
+		lvs  = make(map[string]volume.Local, len(vls))
+	)
+	for _, v := range vls {
+		id := v.ID()
+		vids = append(vids, id)
+		lvs[id] = v
+	}
+
+	// With only one there is nothing to rank
+	if len(vids) == 1 {
+		if v, ok := lvs[vids[0]]; ok {
+			return v, nil
+		}
+	}
+
+	var (
+		cs = s.freeCapacities(ctx, lvs)
+
+		// fallback is the preferred full volume
+		// used if all the others are full too
+		fallback volume.Volume
+	)
+	for _, vid := range rendezvous.WeightedRank(vids, k, cs) {
+		var v volume.Volume
+		if lv, ok := lvs[vid]; ok {
+			v = lv
+		} else {
+			n, err := s.members.GetNodeWithVolumeByID(vid)
+			if err != nil {
+				// The Node could have left
+				continue
+			}
+			v = n
+		}
+		if c, ok := cs[vid]; ok && c == 0 {
+			if fallback == nil {
+				fallback = v
+			}
+			continue
+		}
+		return v, nil
+	}
+
+	if fallback != nil {
+		return fallback, nil
+	}
+
+	return nil, errors.New("no volumes to store the file")
+}
+
+// freeCapacities returns the free space of the lvs and the volumes of the
+// other Nodes by their gossiped State. The ones without a known size are
+// not on it
+func (s *service) freeCapacities(ctx context.Context, lvs map[string]volume.Local) map[string]uint64 {
+	res := make(map[string]uint64)
+	for _, ns := range s.members.NodeStates() {
+		for vid, st := range ns.Volumes {
+			if c, ok := freeCapacity(&st); ok {
+				res[vid] = c
+			}
+		}
+	}
+	for id, v := range lvs {
+		st, err := v.GetState(ctx)
+		if err != nil {
+			s.logger.Log("msg", err.Error(), "volume", id)
+			continue
+		}
+		if c, ok := freeCapacity(st); ok {
+			res[id] = c
+		}
+	}
+	return res
+}
+
+// freeCapacity returns the space left on the st
+// and if it has a known size
+func freeCapacity(st *state.State) (uint64, bool) {
+	if st.TotalSize() <= 0 {
+		return 0, false
+	}
+	if !st.CanStore(1) {
+		return 0, true
+	}
+	return uint64(st.TotalSize() - st.UsedSize()), true
+}
+
+// rankNodes returns the ns ordered by preference for the key k, the
+// Nodes with the preferred volumes first. The ones without
+// known volumes are left at the end
+func (s *service) rankNodes(k string, ns []*client.Client) []*client.Client {
+	pending := make(map[*client.Client]struct{}, len(ns))
+	for _, n := range ns {
+		pending[n] = struct{}{}
+	}
+
+	res := make([]*client.Client, 0, len(ns))
+	for _, vid := range rendezvous.Rank(s.members.VolumeIDs(), k) {
+		n, err := s.members.GetNodeWithVolumeByID(vid)
+		if err != nil {
+			continue
+		}
+		if _, ok := pending[n]; ok {
+			res = append(res, n)
+			delete(pending, n)
+		}
+	}
+
+	for _, n := range ns {
+		if _, ok := pending[n]; ok {
+			res = append(res, n)
+		}
+	}
+
+	return res
+}
+
+// preferredReplicas returns how many Nodes have to be checked
+// when looking for a key before asking to all of them, the
+// ones in which the keys are created and replicated
+func (s *service) preferredReplicas() int {
+	if rep := s.replica(); rep > 1 {
+		return rep
+	}
+	return 1
+}
+
+// nodesWithKey returns the ns that may have the key k
+// by the filters of the keys of their volumes
+func (s *service) nodesWithKey(k string, ns []*client.Client) []*client.Client {
+	may := make(map[*client.Client]struct{})
+	for _, n := range s.members.NodesWithKey(k) {
+		may[n] = struct{}{}
+	}
+
+	res := make([]*client.Client, 0, len(ns))
+	for _, n := range ns {
+		if _, ok := may[n]; ok {
+			res = append(res, n)
+		}
+	}
+	return res
+}
@@ -0,0 +1,49 @@
+package storing
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/rebost/presign"
+)
+
+// presignError is returned when the request
+// has an invalid presigned URL
+type presignError struct {
+	err error
+}
+
+func (e *presignError) Error() string { return e.err.Error() }
+
+func (e *presignError) Unwrap() error { return e.err }
+
+// verifyPresigned wraps the next to verify the requests made with a
+// presigned URL with the cluster secret, the rest are not checked
+func verifyPresigned(s Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !presign.IsPresigned(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cfg, err := s.Config(r.Context())
+		if err != nil {
+			encodeError(r.Context(), err, w)
+			return
+		}
+
+		if cfg.Auth.ClusterSecret == "" {
+			err = errors.New("the presigned URLs require the auth.cluster-secret")
+		} else {
+			err = presign.Verify([]byte(cfg.Auth.ClusterSecret), r, mux.Vars(r)["key"], time.Now())
+		}
+		if err != nil {
+			encodeError(r.Context(), &presignError{err: err}, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,215 @@
+package storing
+
+import (
+	"context"
+	"io"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/quota"
+)
+
+func (s *service) Quotas(ctx context.Context) ([]*quota.Status, error) {
+	qs, err := s.quotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := s.usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sts := make([]*quota.Status, 0, len(qs))
+	for _, q := range qs {
+		sts = append(sts, &quota.Status{
+			Quota: q,
+			Usage: us[q.Prefix],
+		})
+	}
+
+	return sts, nil
+}
+
+// quotas returns the configured Quotas and the
+// ones from the Buckets that have one
+func (s *service) quotas(ctx context.Context) ([]quota.Quota, error) {
+	qs := s.cfg.PrefixQuotas()
+
+	bks, err := s.Buckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bks {
+		if b.Quota.Size == 0 && b.Quota.Count == 0 {
+			continue
+		}
+		qs = append(qs, quota.Quota{
+			Prefix: bucket.Key(b.Name, ""),
+			Size:   b.Quota.Size,
+			Count:  b.Quota.Count,
+		})
+	}
+
+	return qs, nil
+}
+
+// usage returns the Usage of all the cluster, which is
+// the sum of the Usage of each one of the volumes
+func (s *service) usage(ctx context.Context) (map[string]quota.Usage, error) {
+	vus, err := s.volumesUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sumUsage(vus), nil
+}
+
+// volumesUsage returns the Usage of each volume of the cluster by
+// its ID, the one of the local volumes and the one gossiped
+// by the other Nodes
+func (s *service) volumesUsage(ctx context.Context) (map[string]map[string]quota.Usage, error) {
+	vus := make(map[string]map[string]quota.Usage)
+
+	for _, v := range s.members.LocalVolumes() {
+		st, err := v.GetState(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vus[v.ID()] = st.Usage
+	}
+
+	for _, ns := range s.members.NodeStates() {
+		for vid, st := range ns.Volumes {
+			vus[vid] = st.Usage
+		}
+	}
+
+	return vus, nil
+}
+
+// sumUsage returns the sum of the Usage of the vus
+func sumUsage(vus map[string]map[string]quota.Usage) map[string]quota.Usage {
+	us := make(map[string]quota.Usage)
+	for _, vu := range vus {
+		for p, u := range vu {
+			us[p] = us[p].Add(u)
+		}
+	}
+	return us
+}
+
+// checkQuotas checks that the Quotas that limit the key k allow one
+// more File and wraps the r so it fails if it's read over the Size
+// left of any of them. The returned ctx has the quota.Limits so
+// the volume that stores it checks them again with its Usage
+func (s *service) checkQuotas(ctx context.Context, k string, r io.ReadCloser) (context.Context, io.ReadCloser, error) {
+	qs, err := s.quotas(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		ls   *quota.Limits
+		us   map[string]quota.Usage
+		left int64 = -1
+	)
+	for _, q := range qs {
+		if !q.Matches(k) {
+			continue
+		}
+
+		// The usage is only calculated if
+		// any of the Quotas is needed
+		if ls == nil {
+			vus, err := s.volumesUsage(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			ls = &quota.Limits{Usages: vus}
+			us = sumUsage(vus)
+		}
+		ls.Quotas = append(ls.Quotas, q)
+
+		u := us[q.Prefix]
+		err = q.Check(u)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if l := q.Left(u); l != -1 && (left == -1 || l < left) {
+			left = l
+		}
+	}
+
+	if ls == nil {
+		return ctx, r, nil
+	}
+	ctx = quota.NewContext(ctx, ls)
+
+	if left == -1 {
+		return ctx, r, nil
+	}
+
+	return ctx, &quotaReader{rc: r, n: left}, nil
+}
+
+// checkCopyQuotas checks that the Quotas that limit the key dst
+// allow one more File, on a move the ones that also limit the src
+// are ignored as the File is only renamed within them. The returned
+// ctx has the quota.Limits like on checkQuotas
+func (s *service) checkCopyQuotas(ctx context.Context, src, dst string, move bool) (context.Context, error) {
+	qs, err := s.quotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ls *quota.Limits
+		us map[string]quota.Usage
+	)
+	for _, q := range qs {
+		if !q.Matches(dst) || (move && q.Matches(src)) {
+			continue
+		}
+
+		if ls == nil {
+			vus, err := s.volumesUsage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ls = &quota.Limits{Usages: vus}
+			us = sumUsage(vus)
+		}
+		ls.Quotas = append(ls.Quotas, q)
+
+		err = q.Check(us[q.Prefix])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ls == nil {
+		return ctx, nil
+	}
+
+	return quota.NewContext(ctx, ls), nil
+}
+
+// quotaReader returns quota.ErrSizeExceeded
+// if more than n bytes are read
+type quotaReader struct {
+	rc io.ReadCloser
+	n  int64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	n, err := q.rc.Read(p)
+	q.n -= int64(n)
+	if q.n < 0 {
+		return n, quota.ErrSizeExceeded
+	}
+	return n, err
+}
+
+func (q *quotaReader) Close() error { return q.rc.Close() }
@@ -0,0 +1,198 @@
+package storing
+
+import (
+	"time"
+
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/coordinator"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/volume"
+)
+
+const (
+	// rebalanceInterval is how often the leader
+	// checks if the volumes have to be rebalanced
+	rebalanceInterval = time.Minute
+
+	// rebalanceStep is the maximum size of
+	// the Files moved on each rebalance
+	rebalanceStep = 1 << 30
+)
+
+// volumeUsage is the used space of a volume of a Node
+type volumeUsage struct {
+	id    string
+	node  string
+	used  int
+	total int
+}
+
+// percentage returns the percentage of used space
+func (vu volumeUsage) percentage() float64 {
+	return float64(vu.used) * 100 / float64(vu.total)
+}
+
+// rebalanceVolumes decides to move Files from the fullest volume to the
+// emptiest one of another Node when the difference of their percentage
+// of used space is over the one of the Settings. The size moved is the
+// one that leaves both with the same percentage
+func (s *service) rebalanceVolumes() error {
+	th := s.currentSettings().Rebalance
+	if th == 0 {
+		return nil
+	}
+
+	// The previous one has to have time to be done
+	// before the states of the volumes are checked
+	d, ok := s.coordinator.Last(coordinator.RebalanceVolume)
+	if ok && time.Since(d.DecidedAt) < rebalanceInterval {
+		return nil
+	}
+
+	vus := s.volumeUsages()
+
+	var from, to *volumeUsage
+	for i, vu := range vus {
+		if from == nil || vu.percentage() > from.percentage() {
+			from = &vus[i]
+		}
+	}
+	if from == nil {
+		return nil
+	}
+	for i, vu := range vus {
+		if vu.node == from.node {
+			continue
+		}
+		if to == nil || vu.percentage() < to.percentage() {
+			to = &vus[i]
+		}
+	}
+	if to == nil || from.percentage()-to.percentage() <= float64(th) {
+		return nil
+	}
+
+	size := int((float64(from.used)*float64(to.total) - float64(to.used)*float64(from.total)) / float64(from.total+to.total))
+	size = min(size, rebalanceStep, to.total-to.used)
+	if size <= 0 {
+		return nil
+	}
+
+	return s.coordinator.Decide(coordinator.Decision{
+		Type:       coordinator.RebalanceVolume,
+		VolumeID:   from.id,
+		ToVolumeID: to.id,
+		Size:       size,
+	})
+}
+
+// volumeUsages returns the used space of the volumes of the cluster, the
+// ones of the other Nodes by their gossiped State. The ones without a
+// known size are not on it
+func (s *service) volumeUsages() []volumeUsage {
+	var res []volumeUsage
+	for _, ns := range s.members.NodeStates() {
+		for vid, st := range ns.Volumes {
+			if st.TotalSize() <= 0 {
+				continue
+			}
+			res = append(res, volumeUsage{id: vid, node: ns.Node, used: st.UsedSize(), total: st.TotalSize()})
+		}
+	}
+	for _, lv := range s.members.LocalVolumes() {
+		st, err := lv.GetState(s.ctx)
+		if err != nil {
+			s.logger.Log("msg", err.Error(), "volume", lv.ID())
+			continue
+		}
+		if st.TotalSize() <= 0 {
+			continue
+		}
+		res = append(res, volumeUsage{id: lv.ID(), node: s.cfg.Name, used: st.UsedSize(), total: st.TotalSize()})
+	}
+	return res
+}
+
+// moveReplicas moves Files of up to the size from the
+// lv to the volume to, which is on another Node
+func (s *service) moveReplicas(lv volume.Local, to string, size int) {
+	n, err := s.members.GetNodeWithVolumeByID(to)
+	if err != nil {
+		s.logger.Log("msg", err.Error(), "volume", to)
+		return
+	}
+
+	rps, err := lv.MoveReplicas(s.ctx, to, size)
+	if err != nil {
+		s.logger.Log("msg", err.Error(), "volume", lv.ID())
+		return
+	}
+
+	var moved int
+	for _, rp := range rps {
+		err := s.moveReplica(lv, n, rp)
+		if err != nil {
+			s.logger.Log("msg", err.Error(), "key", rp.Key)
+			continue
+		}
+		moved++
+	}
+
+	s.logger.Log("action", coordinator.RebalanceVolume, "volume", lv.ID(), "to", to, "moved", moved)
+}
+
+// moveReplica creates the rp on the Node n and, once all the volumes
+// that have it know it, removes it from the v. If any of them
+// can not be updated the File is kept on the v
+func (s *service) moveReplica(v volume.Local, n *client.Client, rp *replica.Replica) error {
+	_, ok, err := n.HasFile(s.ctx, rp.Key)
+	if err != nil {
+		return err
+	}
+	// The Node already has a File with
+	// this key so it can not be moved
+	if ok {
+		return nil
+	}
+
+	iorc, err := v.GetFile(s.ctx, rp.Key)
+	if err != nil {
+		return err
+	}
+	vID, err := n.CreateReplica(s.ctx, rp.Key, iorc, rp.TTL, rp.CreatedAt, rp.Class)
+	if err != nil {
+		return err
+	}
+
+	// The new volume takes the position of the current
+	// one so if it was the owner master the new one is
+	vids := make([]string, 0, len(rp.VolumeIDs))
+	for _, vid := range rp.VolumeIDs {
+		if vid == v.ID() {
+			vid = vID
+		}
+		vids = append(vids, vid)
+	}
+
+	for _, vid := range vids {
+		n, err := s.members.GetNodeWithVolumeByID(vid)
+		if err != nil {
+			return err
+		}
+		err = n.UpdateFileReplica(s.ctx, rp.Key, vids, rp.OriginalCount)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = v.UpdateReplica(s.ctx, rp, vID)
+	if err != nil {
+		return err
+	}
+
+	// The Nodes that know where it was have to
+	// look for it again on the next request
+	s.members.BroadcastInvalidation(rp.Key)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xescugc/rebost/client"
+)
+
+// redirectMode is how the request for a
+// file on another Node has to be answered
+type redirectMode int
+
+const (
+	// redirectConfig uses the Settings.Redirect
+	// of the cluster or the Config.Redirect
+	redirectConfig redirectMode = iota
+	redirectAlways
+	redirectNever
+)
+
+// redirectContextKey is the key of the context with the redirectMode
+// of the requests for files, the other reads do not have it as they
+// always need the content
+type redirectContextKey struct{}
+
+// withRedirect returns a ctx for a request
+// for a file with the redirectMode m
+func withRedirect(ctx context.Context, m redirectMode) context.Context {
+	return context.WithValue(ctx, redirectContextKey{}, m)
+}
+
+// canRedirect checks if the file requested with
+// the ctx can be redirected to another Node
+func (s *service) canRedirect(ctx context.Context) bool {
+	m, ok := ctx.Value(redirectContextKey{}).(redirectMode)
+	if !ok {
+		return false
+	}
+
+	switch m {
+	case redirectAlways:
+		return true
+	case redirectNever:
+		return false
+	default:
+		return s.redirect()
+	}
+}
+
+// redirectError is returned when the file has to
+// be requested to the Node with the URL
+type redirectError struct {
+	URL string
+}
+
+func (e *redirectError) Error() string {
+	return fmt.Sprintf("the file is on %q", e.URL)
+}
+
+// redirectTo returns the redirectError to the Node n
+func (s *service) redirectTo(n *client.Client) error {
+	u, err := s.members.NodeURL(n)
+	if err != nil {
+		return err
+	}
+	return &redirectError{URL: u}
+}
+
+func (s *service) GetFileNodes(ctx context.Context, k string) ([]string, error) {
+	var urls []string
+
+	_, v, err := s.findVolume(ctx, localVolumesToVolumes(s.members.LocalVolumes()), k)
+	if err != nil && err.Error() != "not found" {
+		return nil, err
+	}
+	if v != nil {
+		urls = append(urls, s.members.LocalURL())
+	}
+
+	// The Nodes with the preferred volumes for the key are
+	// asked and of the rest only the ones that may have it
+	// by the filters of the keys of their volumes
+	ns := s.rankNodes(k, s.members.Nodes())
+	pr := min(s.preferredReplicas(), len(ns))
+	ns = append(ns[:pr:pr], s.nodesWithKey(k, ns[pr:])...)
+
+	for _, n := range ns {
+		_, ok, err := n.HasFile(ctx, k)
+		if err != nil {
+			s.logger.Log("msg", err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		u, err := s.members.NodeURL(n)
+		if err != nil {
+			// The Node could have left
+			continue
+		}
+		urls = append(urls, u)
+	}
+
+	if len(urls) == 0 {
+		return nil, errors.New("not found")
+	}
+
+	return urls, nil
+}
@@ -14,6 +14,11 @@ func (s *service) loopVolumesReplicas() {
 		case <-s.ctx.Done():
 			goto end
 		default:
+			// The leader may limit the Nodes that
+			// create them at the same time
+			if !s.canRepair() {
+				break
+			}
 			for _, v := range s.members.LocalVolumes() {
 				rp, err := v.NextReplica(s.ctx)
 				if err != nil {
@@ -22,7 +27,9 @@ func (s *service) loopVolumesReplicas() {
 					}
 					continue
 				}
-				for _, n := range s.members.NodesWithoutVolumeIDs(rp.VolumeIDs) {
+				// The replicas are created on the Nodes with
+				// the preferred volumes for the key first
+				for _, n := range s.rankNodes(rp.Key, s.members.NodesWithoutVolumeIDs(rp.VolumeIDs)) {
 					_, ok, err := n.HasFile(s.ctx, rp.Key)
 					if err != nil {
 						s.logger.Log("msg", err.Error())
@@ -40,7 +47,7 @@ func (s *service) loopVolumesReplicas() {
 						s.logger.Log("msg", err.Error())
 						continue
 					}
-					vID, err := n.CreateReplica(s.ctx, rp.Key, iorc, rp.TTL, rp.CreatedAt)
+					vID, err := n.CreateReplica(s.ctx, rp.Key, iorc, rp.TTL, rp.CreatedAt, rp.Class)
 					if err != nil {
 						s.logger.Log("msg", err.Error())
 						continue
@@ -4,14 +4,23 @@ import (
 	"context"
 	"errors"
 	"io"
-	"math/rand"
 	"sync"
 	"time"
 
 	kitlog "github.com/go-kit/kit/log"
 	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/spf13/afero"
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/client"
 	"github.com/xescugc/rebost/config"
+	"github.com/xescugc/rebost/coordinator"
+	"github.com/xescugc/rebost/diskcache"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/rendezvous"
+	"github.com/xescugc/rebost/settings"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/version"
 	"github.com/xescugc/rebost/volume"
 )
 
@@ -31,7 +40,68 @@ type Service interface {
 	Config(context.Context) (*config.Config, error)
 
 	// CreateReplica creates a new File replica
-	CreateReplica(ctx context.Context, key string, reader io.ReadCloser, ttl time.Duration, ca time.Time) (vID string, err error)
+	CreateReplica(ctx context.Context, key string, reader io.ReadCloser, ttl time.Duration, ca time.Time, class string) (vID string, err error)
+
+	// CreateBucket creates or replaces the Bucket b on
+	// all the cluster
+	CreateBucket(ctx context.Context, b *bucket.Bucket) error
+
+	// GetBucket returns the Bucket with the name
+	GetBucket(ctx context.Context, name string) (*bucket.Bucket, error)
+
+	// Buckets returns all the Buckets of the cluster
+	Buckets(ctx context.Context) ([]*bucket.Bucket, error)
+
+	// DeleteBucket deletes the Bucket with the name from all
+	// the cluster, the Files of it are not deleted
+	DeleteBucket(ctx context.Context, name string) error
+
+	// Quotas returns the Status of all the Quotas, the
+	// configured ones and the ones from the Buckets
+	Quotas(ctx context.Context) ([]*quota.Status, error)
+
+	// RestoreVersion stores the Version with the id
+	// of the key k as the newest one
+	RestoreVersion(ctx context.Context, k, id string) error
+
+	// Trash returns the Items of the trash of all the cluster,
+	// or only the ones of the local volumes if local
+	Trash(ctx context.Context, local bool) ([]*trash.Item, error)
+
+	// RestoreTrash restores the Item with the id of the key k on
+	// all the cluster, or only on the local volumes if local
+	RestoreTrash(ctx context.Context, k, id string, local bool) error
+
+	// TrashReplica moves the key k to the trash, as deleted at da
+	// until the pa, on the local volumes that have it
+	TrashReplica(ctx context.Context, k string, da, pa time.Time) error
+
+	// CopyReplica copies (or moves if move) the key src to the
+	// dst, as copied at ca, on the local volumes that have it
+	CopyReplica(ctx context.Context, src, dst string, move bool, ca time.Time) error
+
+	// UpdateFileTTLReplica updates the TTL of the
+	// key k on the local volumes that have it
+	UpdateFileTTLReplica(ctx context.Context, k string, ttl time.Duration) error
+
+	// GetFileSignatureReplica returns the Signature of
+	// the key k on the local volumes that have it
+	GetFileSignatureReplica(ctx context.Context, k string) (string, error)
+
+	// GetFileNodes returns the URLs of the Nodes of the
+	// cluster that have the key k, the current one first
+	GetFileNodes(ctx context.Context, k string) ([]string, error)
+
+	// Batch does all the ops on the Nodes that have the keys of them, or
+	// only on the local volumes if local, and returns the Result of each one
+	Batch(ctx context.Context, ops []*batch.Operation, local bool) ([]*batch.Result, error)
+
+	// Settings returns the Settings of the cluster
+	Settings(ctx context.Context) (*settings.Settings, error)
+
+	// UpdateSettings replaces the Settings of the cluster with
+	// the st, they are decided by the leader of the coordinator
+	UpdateSettings(ctx context.Context, st *settings.Settings) error
 }
 
 type service struct {
@@ -40,6 +110,30 @@ type service struct {
 
 	cache *lru.ARCCache[string, string]
 
+	// missing are the keys not found on the
+	// cluster with when they expire
+	missing *lru.Cache[string, time.Time]
+
+	// contents is the cache of the content of the files
+	// of the other Nodes, nil if it's not enabled
+	contents *diskcache.Cache
+
+	// coordinator elects the leader that decides the repairs,
+	// the Settings and the rebalancing of the cluster, nil if
+	// the Node does not take part on it
+	coordinator *coordinator.Coordinator
+
+	// pendingVolumeIDs are the volumes that left the cluster
+	// with no Decision yet from the leader to synchronize them
+	pendingVolumeIDs     map[string]struct{}
+	pendingVolumeIDsLock sync.Mutex
+
+	// clusterSettings are the Settings of the cluster for
+	// the Nodes without coordinator, the rest use the
+	// last ones decided by the leader
+	clusterSettings     settings.Settings
+	clusterSettingsLock sync.RWMutex
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
@@ -55,11 +149,28 @@ func New(cfg *config.Config, m Membership, logger kitlog.Logger) (Service, error
 		cancel()
 		return nil, err
 	}
+	missing, err := lru.New[string, time.Time](cfg.Cache.Size)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	var contents *diskcache.Cache
+	if cfg.Cache.Disk.Enabled() {
+		contents, err = diskcache.New(afero.NewOsFs(), cfg.Cache.Disk.Dir, cfg.Cache.Disk.Bytes())
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
 	s := &service{
 		members: m,
 		cfg:     cfg,
 
-		cache: cache,
+		cache:    cache,
+		missing:  missing,
+		contents: contents,
+
+		pendingVolumeIDs: make(map[string]struct{}),
 
 		ctx:    ctx,
 		cancel: cancel,
@@ -67,10 +178,57 @@ func New(cfg *config.Config, m Membership, logger kitlog.Logger) (Service, error
 		logger: kitlog.With(logger, "src", "storing", "name", cfg.Name),
 	}
 
-	if s.cfg.Replica != -1 {
+	if cfg.Coordinator.Enabled() {
+		// The Node that does not join any other is the one
+		// that starts the election (bootstrap), only if it has
+		// nothing on the Dir. Without Dir, once restarted,
+		// it has to join another one or it starts a new one
+		stc, err := cfg.TLS.ServerConfig()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		ctc, err := cfg.TLS.ClientConfig()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		s.coordinator, err = coordinator.New(cfg.Name, cfg.Coordinator.Port, m.CoordinatorNodes()[cfg.Name], cfg.Coordinator.Dir, cfg.Remote == "", stc, ctc, s.applyDecision, logger)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	// The locations of the keys invalidated by the
+	// other Nodes of the cluster are no longer cached
+	if ki, ok := m.(keyInvalidations); ok {
+		ki.OnKeyInvalidated(func(k string) {
+			s.cache.Remove(k)
+			s.missing.Remove(k)
+		})
+	}
+
+	// The gateways do not have volumes to replicate
+	if s.cfg.Replica != -1 && !s.cfg.Gateway {
 		go s.loopVolumesReplicas()
+	}
+
+	// With a coordinator the leader is the one
+	// that decides to synchronize the replicas
+	if s.coordinator != nil {
+		go s.loopCoordinator()
+	} else if s.cfg.Replica != -1 && !s.cfg.Gateway {
 		go s.loopRemovedVolumeDIs()
 	}
+
+	// The Nodes that do not take part on the election get the
+	// Settings from the ones that do, which is only possible
+	// if it joined a cluster as the Node started without
+	// remote is the one that starts the election
+	if s.coordinator == nil && s.cfg.Remote != "" {
+		go s.loopSettings()
+	}
 	//go s.loopTLL()
 
 	return s, nil
@@ -80,25 +238,46 @@ func (s *service) Config(_ context.Context) (*config.Config, error) {
 	return s.cfg, nil
 }
 
-func (s *service) CreateFile(ctx context.Context, k string, r io.ReadCloser, rep int, ttl time.Duration, ca time.Time) error {
+func (s *service) CreateFile(ctx context.Context, k string, r io.ReadCloser, rep int, ttl time.Duration, ca time.Time, class string) error {
 	if rep == 0 {
-		rep = s.cfg.Replica
+		rep = s.replica()
 	}
-	err := s.getLocalVolume(ctx, k).CreateFile(ctx, k, r, rep, ttl, ca)
+	ctx, qr, err := s.checkQuotas(ctx, k, r)
 	if err != nil {
+		r.Close()
 		return err
 	}
+	v, err := s.getOwnerVolume(ctx, k)
+	if err != nil {
+		qr.Close()
+		return err
+	}
+	err = v.CreateFile(ctx, k, qr, rep, ttl, ca, class)
+	if err != nil {
+		return err
+	}
+
+	// The owner notifies the overwrite to the cluster
+	// as the key may have been on other volumes
+	s.invalidateKey(v, k)
+
+	// And it's no longer missing for this Node even
+	// if it was created on the volume of another one
+	s.missing.Remove(k)
 
 	return nil
 }
 
 func (s *service) GetFile(ctx context.Context, k string) (io.ReadCloser, error) {
-	_, v, err := s.getVolume(ctx, k)
-	if err != nil {
-		return nil, err
-	}
-
-	r, err := v.GetFile(ctx, k)
+	var r io.ReadCloser
+	err := s.withVolume(ctx, k, func(v volume.Volume) error {
+		if n, ok := v.(*client.Client); ok && s.canRedirect(ctx) {
+			return s.redirectTo(n)
+		}
+		var err error
+		r, err = s.getFile(ctx, v, k)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -107,17 +286,41 @@ func (s *service) GetFile(ctx context.Context, k string) (io.ReadCloser, error)
 }
 
 func (s *service) DeleteFile(ctx context.Context, k string) error {
-	_, v, err := s.getVolume(ctx, k)
-	if err != nil {
-		return err
+	return s.withVolume(ctx, k, func(v volume.Volume) error {
+		return s.deleteFile(ctx, v, k)
+	})
+}
+
+// deleteFile deletes the k from the v
+func (s *service) deleteFile(ctx context.Context, v volume.Volume, k string) error {
+	// The hidden keys are deleted directly and the remote
+	// volumes move it to the trash on their own Node
+	var err error
+	if lv, ok := v.(volume.Local); ok && s.cfg.Trash.Retention != 0 && !trash.IsKey(k) && !version.IsKey(k) {
+		err = s.trashFile(ctx, lv, k)
+	} else {
+		err = v.DeleteFile(ctx, k)
 	}
-	err = v.DeleteFile(ctx, k)
 	if err != nil {
 		return err
 	}
+
+	s.invalidateKey(v, k)
+
 	return nil
 }
 
+// invalidateKey invalidates the locations of the k after it changed on
+// the v, if it's local the cluster is notified and if not it's the Node
+// of the v the one that notifies it so only the cache is invalidated
+func (s *service) invalidateKey(v volume.Volume, k string) {
+	if _, ok := v.(volume.Local); ok {
+		s.members.BroadcastInvalidation(k)
+		return
+	}
+	s.cache.Remove(k)
+}
+
 func (s *service) HasFile(ctx context.Context, k string) (string, bool, error) {
 	vid, v, err := s.findVolume(ctx, localVolumesToVolumes(s.members.LocalVolumes()), k)
 	if err != nil && err.Error() != "not found" {
@@ -138,12 +341,12 @@ func (s *service) HasFile(ctx context.Context, k string) (string, bool, error) {
 	return "", false, nil
 }
 
-func (s *service) CreateReplica(ctx context.Context, key string, reader io.ReadCloser, ttl time.Duration, ca time.Time) (string, error) {
-	if s.cfg.Replica == -1 {
+func (s *service) CreateReplica(ctx context.Context, key string, reader io.ReadCloser, ttl time.Duration, ca time.Time, class string) (string, error) {
+	if s.cfg.Replica == -1 || s.cfg.Gateway {
 		return "", errors.New("can not store replicas")
 	}
 	v := s.getLocalVolume(ctx, key)
-	err := v.CreateFile(ctx, key, reader, noReplica, ttl, ca)
+	err := v.CreateFile(ctx, key, reader, noReplica, ttl, ca, class)
 	if err != nil {
 		return "", err
 	}
@@ -152,7 +355,7 @@ func (s *service) CreateReplica(ctx context.Context, key string, reader io.ReadC
 }
 
 func (s *service) UpdateFileReplica(ctx context.Context, key string, volumeIDs []string, replica int) error {
-	if s.cfg.Replica == -1 {
+	if s.cfg.Replica == -1 || s.cfg.Gateway {
 		return errors.New("can not store replicas")
 	}
 
@@ -172,19 +375,40 @@ func (s *service) UpdateFileReplica(ctx context.Context, key string, volumeIDs [
 func (s *service) getLocalVolume(ctx context.Context, k string) volume.Local {
 	vls := s.members.LocalVolumes()
 
-	rand.Seed(time.Now().UTC().UnixNano())
-	return vls[rand.Intn(len(vls))]
+	// The Versions of a key are on the volume that has its
+	// History so the new ones have to be created there too
+	if _, ok := bucket.Name(k); ok && len(vls) > 1 {
+		_, v, err := s.findVolumeWith(ctx, localVolumesToVolumes(vls), k, hasVersions)
+		if err == nil {
+			return v.(volume.Local)
+		}
+	}
+
+	// Otherwise it's the preferred local volume for the key
+	var (
+		vids = make([]string, 0, len(vls))
+		lvs  = make(map[string]volume.Local, len(vls))
+	)
+	for _, v := range vls {
+		id := v.ID()
+		vids = append(vids, id)
+		lvs[id] = v
+	}
+	return lvs[rendezvous.Rank(vids, k)[0]]
 }
 
 // getVolume returns a volume and the volumeID that may have k in his index. It tries first with
-// the LocalVolumes and then with the Nodes
+// the LocalVolumes, then with the Nodes with the preferred volumes for k and then with the rest
+// that may have it
 func (s *service) getVolume(ctx context.Context, k string) (string, volume.Volume, error) {
 	if vid, ok := s.cache.Get(k); ok {
 		n, err := s.members.GetNodeWithVolumeByID(vid)
-		if err != nil {
-			return "", nil, err
+		if err == nil {
+			return vid, n, nil
 		}
-		return vid, n, nil
+		// The volume left the cluster
+		// so it's looked up again
+		s.cache.Remove(k)
 	}
 
 	vid, v, err := s.findVolume(ctx, localVolumesToVolumes(s.members.LocalVolumes()), k)
@@ -196,11 +420,31 @@ func (s *service) getVolume(ctx context.Context, k string) (string, volume.Volum
 		return vid, v, nil
 	}
 
-	vid, v, err = s.findVolume(ctx, clientsToVolumes(s.members.Nodes()), k)
+	// It was not found on the cluster recently so
+	// all the Nodes are not asked for it again
+	if s.isMissing(k) {
+		return "", nil, errors.New("not found")
+	}
+
+	// The Nodes with the preferred volumes for the key are asked
+	// first and only if they do not have it all the others are
+	ns := s.rankNodes(k, s.members.Nodes())
+	pr := min(s.preferredReplicas(), len(ns))
+
+	vid, v, err = s.findVolume(ctx, clientsToVolumes(ns[:pr]), k)
 	if err != nil && err.Error() != "not found" {
 		return "", nil, err
 	}
 
+	// Of the rest only the ones that may have it by the
+	// filters of the keys of their volumes are asked
+	if v == nil && len(ns) > pr {
+		vid, v, err = s.findVolume(ctx, clientsToVolumes(s.nodesWithKey(k, ns[pr:])), k)
+		if err != nil && err.Error() != "not found" {
+			return "", nil, err
+		}
+	}
+
 	if v != nil {
 		// We only cache the remove ones because the local ones are faster and easier to access
 		// but also because the list of nodes does not include the current node so if where
@@ -211,16 +455,60 @@ func (s *service) getVolume(ctx context.Context, k string) (string, volume.Volum
 		return vid, v, nil
 	}
 
+	s.addMissing(k)
+
 	return "", nil, errors.New("not found")
 }
 
+// withVolume calls the fn with the volume that has the key k, if the volume
+// was cached and the fn fails with not found the cache is no longer valid
+// so the fn is called again with the volume of a fresh lookup
+func (s *service) withVolume(ctx context.Context, k string, fn func(v volume.Volume) error) error {
+	_, cached := s.cache.Peek(k)
+
+	_, v, err := s.getVolume(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	err = fn(v)
+	if err == nil || err.Error() != "not found" || !cached {
+		return err
+	}
+
+	s.cache.Remove(k)
+	_, v, err = s.getVolume(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	return fn(v)
+}
+
 type msg struct {
 	v   volume.Volume
 	vid string
 }
 
+// hasFn checks if the v has the key k
+type hasFn func(ctx context.Context, v volume.Volume, k string) (string, bool, error)
+
+func hasFile(ctx context.Context, v volume.Volume, k string) (string, bool, error) {
+	return v.HasFile(ctx, k)
+}
+
+func hasVersions(ctx context.Context, v volume.Volume, k string) (string, bool, error) {
+	return v.HasVersions(ctx, k)
+}
+
 // findVolume finds the volume and the ID that has the key k within the volumes vls in parallel
 func (s *service) findVolume(ctx context.Context, vls []volume.Volume, k string) (string, volume.Volume, error) {
+	return s.findVolumeWith(ctx, vls, k, hasFile)
+}
+
+// findVolumeWith finds the volume and the ID that has the key k, checked with the has,
+// within the volumes vls in parallel
+func (s *service) findVolumeWith(ctx context.Context, vls []volume.Volume, k string, has hasFn) (string, volume.Volume, error) {
 	var wg sync.WaitGroup
 	cctx, cfn := context.WithCancel(ctx)
 
@@ -232,7 +520,7 @@ func (s *service) findVolume(ctx context.Context, vls []volume.Volume, k string)
 	for _, v := range vls {
 		go func(v volume.Volume) {
 			defer wg.Done()
-			vid, ok, err := v.HasFile(cctx, k)
+			vid, ok, err := has(cctx, v, k)
 			if err != nil {
 				// TODO: Log the error?
 				// remember that when the ctx is canceled, it
@@ -3,9 +3,23 @@ package storing_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
+	"fmt"
 	"io"
+	"math/big"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,10 +27,23 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/client"
 	"github.com/xescugc/rebost/config"
+	"github.com/xescugc/rebost/membership"
 	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/rendezvous"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/settings"
+	"github.com/xescugc/rebost/signature"
+	"github.com/xescugc/rebost/state"
 	"github.com/xescugc/rebost/storing"
+	"github.com/xescugc/rebost/storing/model"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/util"
+	"github.com/xescugc/rebost/version"
 	"github.com/xescugc/rebost/volume"
 )
 
@@ -24,6 +51,17 @@ var (
 	noRep int
 )
 
+// preferredKey returns a key which preferred
+// volume of the vids is the vid
+func preferredKey(vids []string, vid string) string {
+	for i := 0; ; i++ {
+		k := fmt.Sprintf("key%d", i)
+		if rendezvous.Rank(vids, k)[0] == vid {
+			return k
+		}
+	}
+}
+
 func TestCreateFile(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		var (
@@ -40,14 +78,18 @@ func TestCreateFile(t *testing.T) {
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		v.EXPECT().CreateFile(gomock.Any(), key, buff, rep, ttl, ca).Return(nil)
+		v.EXPECT().CreateFile(gomock.Any(), key, buff, rep, ttl, ca, "").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
+		v.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
 
-		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		m.EXPECT().VolumeIDs().Return(nil)
+		v.EXPECT().ID().Return("vid").AnyTimes()
 
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		err = s.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err = s.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		require.NoError(t, err)
 	})
 	t.Run("SuccessWithConfigReplica", func(t *testing.T) {
@@ -65,11 +107,15 @@ func TestCreateFile(t *testing.T) {
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		v.EXPECT().CreateFile(gomock.Any(), key, buff, rep, ttl, ca).Return(nil)
+		v.EXPECT().CreateFile(gomock.Any(), key, buff, rep, ttl, ca, "").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
+		v.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
 
 		// It's AnyTimes as we have the config witha number of replicas
 		// which activates the goroutines that also calls this
 		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		m.EXPECT().VolumeIDs().Return(nil)
+		v.EXPECT().ID().Return("vid").AnyTimes()
 
 		// This is also because of the goroutine, it may call it or not
 		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
@@ -78,49 +124,50 @@ func TestCreateFile(t *testing.T) {
 		s, err := storing.New(&config.Config{Replica: rep, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		err = s.CreateFile(ctx, key, buff, noRep, ttl, ca)
+		err = s.CreateFile(ctx, key, buff, noRep, ttl, ca, "")
 		require.NoError(t, err)
 	})
 	t.Run("SuccessMultiVolume", func(t *testing.T) {
-		t.Skip("Not yet thought")
-	})
-}
-
-func TestGetFile(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
 		var (
-			key  = "expectedkey"
+			key  = preferredKey([]string{"a", "b"}, "b")
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
 			ctrl = gomock.NewController(t)
 			ctx  = context.Background()
-			vid  = "vid"
+			ca   = time.Now()
 		)
 
-		v := mock.NewVolumeLocal(ctrl)
+		v1 := mock.NewVolumeLocal(ctrl)
+		v2 := mock.NewVolumeLocal(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v1, v2}).Times(2)
+		m.EXPECT().VolumeIDs().Return(nil)
+		m.EXPECT().NodeStates().Return(nil)
+		v1.EXPECT().ID().Return("a")
+		v2.EXPECT().ID().Return("b")
+		v1.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+		v1.EXPECT().GetState(gomock.Any()).Return(&state.State{VolumeTotalSize: 10}, nil)
+		v2.EXPECT().GetState(gomock.Any()).Return(&state.State{VolumeTotalSize: 10}, nil)
 
-		v.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
-		v.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+		// It's created on the preferred volume
+		v2.EXPECT().CreateFile(gomock.Any(), key, buff, 2, time.Duration(0), ca, "").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
 
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		ior, err := s.GetFile(ctx, key)
-
+		err = s.CreateFile(ctx, key, buff, 2, 0, ca, "")
 		require.NoError(t, err)
-
-		b, err := io.ReadAll(ior)
-		assert.Equal(t, "expectedcontent", string(b))
 	})
-	t.Run("SuccessMultiVolume", func(t *testing.T) {
+	t.Run("SuccessRemoteOwner", func(t *testing.T) {
 		var (
-			key  = "expectedkey"
+			key  = preferredKey([]string{"a", "b"}, "b")
 			ctrl = gomock.NewController(t)
 			ctx  = context.Background()
-			vid  = "vid"
+			ca   = time.Now().Truncate(time.Second)
 		)
+
 		v := mock.NewVolumeLocal(ctrl)
 		s2 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
@@ -131,32 +178,201 @@ func TestGetFile(t *testing.T) {
 		c, err := client.New(server.URL)
 		require.NoError(t, err)
 
-		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
-		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		m.EXPECT().VolumeIDs().Return([]string{"b"})
+		m.EXPECT().GetNodeWithVolumeByID("b").Return(c, nil)
+		m.EXPECT().NodeStates().Return(nil)
+		v.EXPECT().ID().Return("a")
+		v.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+		v.EXPECT().GetState(gomock.Any()).Return(&state.State{VolumeTotalSize: 10}, nil)
+
+		// The preferred volume is from another Node
+		// so it's created there
+		s2.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), 2, time.Duration(0), gomock.Any(), "").DoAndReturn(func(_ context.Context, _ string, r io.ReadCloser, _ int, _ time.Duration, rca time.Time, _ string) error {
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "expectedcontent", string(b))
+			assert.True(t, ca.Equal(rca))
+			return nil
+		})
 
-		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
-		s2.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
-		s2.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CreateFile(ctx, key, io.NopCloser(bytes.NewBufferString("expectedcontent")), 2, 0, ca, "")
+		require.NoError(t, err)
+	})
+	t.Run("SuccessGatewaySkipsFullVolume", func(t *testing.T) {
+		var (
+			key  = preferredKey([]string{"b", "c"}, "b")
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ca   = time.Now().Truncate(time.Second)
+		)
+
+		s2 := mock.NewStoring(ctrl)
+		s3 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		server2 := httptest.NewServer(storing.MakeHandler(s2))
+		defer server2.Close()
+		c2, err := client.New(server2.URL)
+		require.NoError(t, err)
+
+		server3 := httptest.NewServer(storing.MakeHandler(s3))
+		defer server3.Close()
+		c3, err := client.New(server3.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return(nil).Times(2)
+		m.EXPECT().VolumeIDs().Return([]string{"b", "c"}).Times(2)
+		m.EXPECT().GetNodeWithVolumeByID("b").Return(c2, nil)
+		m.EXPECT().GetNodeWithVolumeByID("c").Return(c3, nil).Times(2)
+		m.EXPECT().NodeStates().Return([]*membership.State{
+			&membership.State{
+				Node: "n2",
+				Volumes: map[string]state.State{
+					"b": state.State{VolumeTotalSize: 10, VolumeUsedSize: 10},
+				},
+			},
+			&membership.State{
+				Node: "n3",
+				Volumes: map[string]state.State{
+					"c": state.State{VolumeTotalSize: 10, VolumeUsedSize: 2},
+				},
+			},
+		})
+
+		// The Buckets are read from the Nodes with volumes
+		s2.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+
+		// The preferred volume is full so
+		// it's created on the next one
+		s3.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), config.DefaultReplica, time.Duration(0), gomock.Any(), "").DoAndReturn(func(_ context.Context, _ string, r io.ReadCloser, _ int, _ time.Duration, _ time.Time, _ string) error {
+			b, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "expectedcontent", string(b))
+			return nil
+		})
+
+		s, err := storing.New(&config.Config{Gateway: true, Replica: config.DefaultReplica, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CreateFile(ctx, key, io.NopCloser(bytes.NewBufferString("expectedcontent")), 0, 0, ca, "")
+		require.NoError(t, err)
+	})
+	t.Run("SuccessMoreFreeCapacity", func(t *testing.T) {
+		var (
+			key  = preferredKey([]string{"a", "b"}, "a")
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ca   = time.Now()
+		)
+
+		v1 := mock.NewVolumeLocal(ctrl)
+		v2 := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v1, v2}).Times(2)
+		m.EXPECT().VolumeIDs().Return(nil)
+		m.EXPECT().NodeStates().Return(nil)
+		v1.EXPECT().ID().Return("a")
+		v2.EXPECT().ID().Return("b")
+		v1.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+
+		// The preferred volume has almost no space
+		// left so the other one is preferred
+		v1.EXPECT().GetState(gomock.Any()).Return(&state.State{VolumeTotalSize: 1000000, VolumeUsedSize: 999999}, nil)
+		v2.EXPECT().GetState(gomock.Any()).Return(&state.State{VolumeTotalSize: 1000000}, nil)
+
+		v2.EXPECT().CreateFile(gomock.Any(), key, buff, 2, time.Duration(0), ca, "").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
 
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		ior, err := s.GetFile(ctx, key)
+		err = s.CreateFile(ctx, key, buff, 2, 0, ca, "")
 		require.NoError(t, err)
+	})
+	t.Run("FailsQuotaCount", func(t *testing.T) {
+		var (
+			key  = bucket.Key("logs", "a")
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
 
-		b, err := io.ReadAll(ior)
-		assert.Equal(t, "expectedcontent", string(b))
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		v.EXPECT().ID().Return("lvid").AnyTimes()
+		v.EXPECT().Buckets(gomock.Any()).Return([]*bucket.Bucket{{Name: "logs", Quota: bucket.Quota{Count: 2}}}, nil)
+		v.EXPECT().GetState(gomock.Any()).Return(&state.State{Usage: map[string]quota.Usage{"@logs/": {Count: 1}}}, nil)
+		m.EXPECT().NodeStates().Return([]*membership.State{
+			{Volumes: map[string]state.State{"vid": {Usage: map[string]quota.Usage{"@logs/": {Count: 1}}}}},
+		})
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CreateFile(ctx, key, buff, 0, 0, time.Time{}, "")
+		assert.True(t, errors.Is(err, quota.ErrCountExceeded))
+	})
+	t.Run("FailsQuotaSize", func(t *testing.T) {
+		var (
+			key  = "logs/a"
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(3)
+		m.EXPECT().VolumeIDs().Return(nil)
+		v.EXPECT().ID().Return("vid").AnyTimes()
+		v.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+		v.EXPECT().GetState(gomock.Any()).Return(&state.State{Usage: map[string]quota.Usage{"logs/": {Size: 10, Count: 1}}}, nil)
+		m.EXPECT().NodeStates().Return(nil)
+		v.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), -1, time.Duration(0), time.Time{}, "").DoAndReturn(func(ctx context.Context, _ string, r io.ReadCloser, _ int, _ time.Duration, _ time.Time, _ string) error {
+			// The volume checks them again with its Usage
+			ls, ok := quota.FromContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, []quota.Quota{{Prefix: "logs/", Size: 20}}, ls.Quotas)
+
+			_, err := io.ReadAll(r)
+			return err
+		})
+
+		cfg := &config.Config{
+			Replica: -1,
+			Cache:   config.Cache{Size: config.DefaultCacheSize},
+			Quotas:  []config.Quota{{Prefix: "logs/", Size: "20B"}},
+		}
+		s, err := storing.New(cfg, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CreateFile(ctx, key, buff, 0, 0, time.Time{}, "")
+		assert.True(t, errors.Is(err, quota.ErrSizeExceeded))
 	})
 }
 
-func TestDeleteFile(t *testing.T) {
+func TestGetFile(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		var (
-			ctrl = gomock.NewController(t)
 			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
 			ctx  = context.Background()
 			vid  = "vid"
 		)
+
 		v := mock.NewVolumeLocal(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
@@ -164,13 +380,17 @@ func TestDeleteFile(t *testing.T) {
 		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
 
 		v.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
-		v.EXPECT().DeleteFile(gomock.Any(), key).Return(nil)
+		v.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
 
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		err = s.DeleteFile(ctx, key)
+		ior, err := s.GetFile(ctx, key)
+
 		require.NoError(t, err)
+
+		b, err := io.ReadAll(ior)
+		assert.Equal(t, "expectedcontent", string(b))
 	})
 	t.Run("SuccessMultiVolume", func(t *testing.T) {
 		var (
@@ -191,191 +411,541 @@ func TestDeleteFile(t *testing.T) {
 
 		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
 		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
 
 		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
 		s2.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
-		s2.EXPECT().DeleteFile(gomock.Any(), key).Return(nil)
+		s2.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
 
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		err = s.DeleteFile(ctx, key)
+		ior, err := s.GetFile(ctx, key)
 		require.NoError(t, err)
-	})
-}
 
-func TestHasFile(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+		b, err := io.ReadAll(ior)
+		assert.Equal(t, "expectedcontent", string(b))
+	})
+	t.Run("SuccessRedirect", func(t *testing.T) {
 		var (
-			ctrl = gomock.NewController(t)
 			key  = "expectedkey"
-			ctx  = context.Background()
-			evid = "vid"
+			ctrl = gomock.NewController(t)
+			vid  = "vid"
 		)
 		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
+		server2 := httptest.NewServer(storing.MakeHandler(s2))
+		defer server2.Close()
+		c, err := client.New(server2.URL)
+		require.NoError(t, err)
+
 		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
+		m.EXPECT().NodeURL(c).Return("http://n2:3805", nil)
 
-		v.EXPECT().HasFile(gomock.Any(), key).Return(evid, true, nil)
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+		s2.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
 
-		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		s, err := storing.New(&config.Config{Replica: -1, Redirect: true, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		vid, ok, err := s.HasFile(ctx, key)
+		server := httptest.NewServer(storing.MakeHandler(s))
+		defer server.Close()
+
+		// The content is not requested to the
+		// other Node, the client is redirected
+		hc := &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := hc.Get(server.URL + "/files/" + key + "?version=")
 		require.NoError(t, err)
-		assert.True(t, ok)
-		assert.Equal(t, evid, vid)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+		assert.Equal(t, "http://n2:3805/files/"+key+"?version=", resp.Header.Get("Location"))
 	})
-	t.Run("SuccessMultiVolume", func(t *testing.T) {
+	t.Run("SuccessRedirectDisabledByHeader", func(t *testing.T) {
 		var (
 			key  = "expectedkey"
 			ctrl = gomock.NewController(t)
-			ctx  = context.Background()
-			evid = "vid"
+			vid  = "vid"
 		)
 		v := mock.NewVolumeLocal(ctrl)
-		v2 := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		m.EXPECT().LocalVolumes().Return([]volume.Local{v, v2})
+		server2 := httptest.NewServer(storing.MakeHandler(s2))
+		defer server2.Close()
+		c, err := client.New(server2.URL)
+		require.NoError(t, err)
 
-		// This call is execute in parallel to all the volumes
-		// so the order is "unexpected". This means that some
-		// times the first call it's not done. That's why the
-		// AnyTimes is used
-		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil).AnyTimes()
-		v2.EXPECT().HasFile(gomock.Any(), key).Return(evid, true, nil)
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
 
-		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+		s2.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
+		s2.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Redirect: true, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		vid, ok, err := s.HasFile(ctx, key)
+		server := httptest.NewServer(storing.MakeHandler(s))
+		defer server.Close()
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/files/"+key, nil)
 		require.NoError(t, err)
-		assert.True(t, ok)
-		assert.Equal(t, evid, vid)
+		req.Header.Set(model.RedirectHeader, "false")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "expectedcontent", string(b))
 	})
-	t.Run("SuccessFalse", func(t *testing.T) {
+	t.Run("SuccessPreferredNode", func(t *testing.T) {
 		var (
+			key  = preferredKey([]string{"b", "c"}, "c")
 			ctrl = gomock.NewController(t)
-			key  = "expectedkey"
 			ctx  = context.Background()
 		)
 		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		s3 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
+		c2, err := client.New(httptest.NewServer(storing.MakeHandler(s2)).URL)
+		require.NoError(t, err)
+		c3, err := client.New(httptest.NewServer(storing.MakeHandler(s3)).URL)
+		require.NoError(t, err)
+
 		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c2, c3})
+		m.EXPECT().VolumeIDs().Return([]string{"b", "c"})
+		m.EXPECT().GetNodeWithVolumeByID("b").Return(c2, nil)
+		m.EXPECT().GetNodeWithVolumeByID("c").Return(c3, nil)
 
 		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
 
+		// Only the Node with the preferred volume is asked
+		s3.EXPECT().HasFile(gomock.Any(), key).Return("c", true, nil)
+		s3.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		vid, ok, err := s.HasFile(ctx, key)
+		ior, err := s.GetFile(ctx, key)
 		require.NoError(t, err)
-		assert.False(t, ok)
-		assert.Equal(t, "", vid)
-	})
-}
 
-func TestConfig(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+		b, err := io.ReadAll(ior)
+		require.NoError(t, err)
+		assert.Equal(t, "expectedcontent", string(b))
+	})
+	t.Run("SuccessStaleCache", func(t *testing.T) {
 		var (
-			ctrl   = gomock.NewController(t)
-			ctx    = context.Background()
-			expcfg = config.Config{Name: "Pepito", Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}
+			key  = preferredKey([]string{"b", "c"}, "b")
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
 		)
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		s3 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		s, err := storing.New(&expcfg, m, kitlog.NewNopLogger())
+		c2, err := client.New(httptest.NewServer(storing.MakeHandler(s2)).URL)
 		require.NoError(t, err)
-
-		cfg, err := s.Config(ctx)
+		c3, err := client.New(httptest.NewServer(storing.MakeHandler(s3)).URL)
 		require.NoError(t, err)
-		assert.Equal(t, &expcfg, cfg)
-	})
-}
 
-func TestCreateReplica(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
-		var (
-			key            = "expectedkey"
-			buff           = io.NopCloser(bytes.NewBufferString("expectedcontent"))
-			ctrl           = gomock.NewController(t)
-			ctx            = context.Background()
-			createdToVolID = "createdToVolID"
-			ttl            = 2 * time.Minute
-			ca             = time.Now()
-		)
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		m.EXPECT().Nodes().Return([]*client.Client{c2, c3}).Times(2)
+		m.EXPECT().VolumeIDs().Return([]string{"b", "c"}).Times(2)
+		m.EXPECT().GetNodeWithVolumeByID("b").Return(c2, nil).Times(3)
+		m.EXPECT().GetNodeWithVolumeByID("c").Return(c3, nil).Times(2)
 
-		v := mock.NewVolumeLocal(ctrl)
-		m := mock.NewMembership(ctrl)
-		defer ctrl.Finish()
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil).Times(2)
 
-		v.EXPECT().CreateFile(gomock.Any(), key, buff, 1, ttl, ca).Return(nil)
+		// The first time the location is cached
+		s2.EXPECT().HasFile(gomock.Any(), key).Return("b", true, nil)
+		s2.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
 
-		// It's AnyTimes as we have the config witha number of replicas
-		// which activates the goroutines that also calls this
-		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
 
-		// This is also because of the goroutine, it may call it or not
-		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
-		m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes()
+		ior, err := s.GetFile(ctx, key)
+		require.NoError(t, err)
+		ior.Close()
 
-		v.EXPECT().ID().Return(createdToVolID)
+		// Then it no longer has it so it's
+		// looked up again on the cluster
+		s2.EXPECT().GetFile(gomock.Any(), key).Return(nil, errors.New("not found"))
+		s2.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+		m.EXPECT().NodesWithKey(key).Return([]*client.Client{c3})
+		s3.EXPECT().HasFile(gomock.Any(), key).Return("c", true, nil)
+		s3.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
 
-		s, err := storing.New(&config.Config{Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		ior, err = s.GetFile(ctx, key)
 		require.NoError(t, err)
 
-		volID, err := s.CreateReplica(ctx, key, buff, ttl, ca)
+		b, err := io.ReadAll(ior)
 		require.NoError(t, err)
-		assert.Equal(t, createdToVolID, volID)
+		assert.Equal(t, "expectedcontent", string(b))
 	})
-	t.Run("ErrorNoReplica", func(t *testing.T) {
+	t.Run("NotFoundNegativeCache", func(t *testing.T) {
 		var (
 			key  = "expectedkey"
-			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
 			ctrl = gomock.NewController(t)
 			ctx  = context.Background()
-			ttl  = 2 * time.Minute
-			ca   = time.Now()
 		)
-
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		c, err := client.New(httptest.NewServer(storing.MakeHandler(s2)).URL)
 		require.NoError(t, err)
 
-		volID, err := s.CreateReplica(ctx, key, buff, ttl, ca)
-		assert.EqualError(t, err, "can not store replicas")
-		assert.Equal(t, "", volID)
-	})
-}
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
 
-func TestUpdateFileReplica(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil).Times(2)
+
+		// Only the first time the Nodes are asked
+		s2.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize, NegativeTTL: time.Minute}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		_, err = s.GetFile(ctx, key)
+		assert.EqualError(t, err, "not found")
+
+		_, err = s.GetFile(ctx, key)
+		assert.EqualError(t, err, "not found")
+	})
+	t.Run("SuccessDiskCache", func(t *testing.T) {
 		var (
 			key  = "expectedkey"
 			ctrl = gomock.NewController(t)
 			ctx  = context.Background()
-			rep  = 4
-			vids = []string{"1", "2"}
 			vid  = "vid"
 		)
-
 		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
 		m := mock.NewMembership(ctrl)
 		defer ctrl.Finish()
 
-		v.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
-		v.EXPECT().UpdateFileReplica(gomock.Any(), key, vids, rep).Return(nil)
+		h := signature.SHA1.New()
+		h.Write([]byte("expectedcontent"))
+		sig := signature.SHA1.Sum(h)
 
-		// It's AnyTimes as we have the config witha number of replicas
+		c, err := client.New(httptest.NewServer(storing.MakeHandler(s2)).URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
+
+		// The second time the location is cached
+		m.EXPECT().GetNodeWithVolumeByID(vid).Return(c, nil)
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+		s2.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
+
+		// The content is only requested the first time
+		// as the Node has the same Signature the second
+		s2.EXPECT().GetFileSignatureReplica(gomock.Any(), key).Return(sig, nil).Times(2)
+		s2.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize, Disk: config.CacheDisk{Dir: t.TempDir(), Size: "1MB"}}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			ior, err := s.GetFile(ctx, key)
+			require.NoError(t, err)
+
+			b, err := io.ReadAll(ior)
+			require.NoError(t, err)
+			require.NoError(t, ior.Close())
+			assert.Equal(t, "expectedcontent", string(b))
+		}
+	})
+	t.Run("SuccessNodesWithKey", func(t *testing.T) {
+		var (
+			key  = preferredKey([]string{"b", "c", "d"}, "b")
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		s3 := mock.NewStoring(ctrl)
+		s4 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		c2, err := client.New(httptest.NewServer(storing.MakeHandler(s2)).URL)
+		require.NoError(t, err)
+		c3, err := client.New(httptest.NewServer(storing.MakeHandler(s3)).URL)
+		require.NoError(t, err)
+		c4, err := client.New(httptest.NewServer(storing.MakeHandler(s4)).URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c2, c3, c4})
+		m.EXPECT().VolumeIDs().Return([]string{"b", "c", "d"})
+		m.EXPECT().GetNodeWithVolumeByID("b").Return(c2, nil)
+		m.EXPECT().GetNodeWithVolumeByID("c").Return(c3, nil)
+		m.EXPECT().GetNodeWithVolumeByID("d").Return(c4, nil)
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+
+		// The preferred one does not have it
+		s2.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+
+		// And of the rest only the one that
+		// may have it by the filters is asked
+		m.EXPECT().NodesWithKey(key).Return([]*client.Client{c2, c4})
+		s4.EXPECT().HasFile(gomock.Any(), key).Return("d", true, nil)
+		s4.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		ior, err := s.GetFile(ctx, key)
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(ior)
+		require.NoError(t, err)
+		assert.Equal(t, "expectedcontent", string(b))
+	})
+}
+
+func TestDeleteFile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			key  = "expectedkey"
+			ctx  = context.Background()
+			vid  = "vid"
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
+		v.EXPECT().DeleteFile(gomock.Any(), key).Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.DeleteFile(ctx, key)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessMultiVolume", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			vid  = "vid"
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+		s2.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
+		s2.EXPECT().DeleteFile(gomock.Any(), key).Return(nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.DeleteFile(ctx, key)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithTrash", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			vid  = "vid"
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
+		v.EXPECT().TrashFile(gomock.Any(), key, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, da, pa time.Time) error {
+			assert.Equal(t, time.Hour, pa.Sub(da))
+			return nil
+		})
+		// The other local volumes are checked too
+		// but this one no longer has the key
+		v.EXPECT().TrashFile(gomock.Any(), key, gomock.Any(), gomock.Any()).Return(errors.New("not found"))
+		m.EXPECT().BroadcastInvalidation(key)
+
+		// The replicas are moved to the trash with the same time
+		s2.EXPECT().TrashReplica(gomock.Any(), key, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, da, pa time.Time) error {
+			assert.Equal(t, time.Hour, pa.Sub(da))
+			return nil
+		})
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}, Trash: config.Trash{Retention: time.Hour}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.DeleteFile(ctx, key)
+		require.NoError(t, err)
+	})
+}
+
+func TestHasFile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			key  = "expectedkey"
+			ctx  = context.Background()
+			evid = "vid"
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return(evid, true, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		vid, ok, err := s.HasFile(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, evid, vid)
+	})
+	t.Run("SuccessMultiVolume", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			evid = "vid"
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		v2 := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v, v2})
+
+		// This call is execute in parallel to all the volumes
+		// so the order is "unexpected". This means that some
+		// times the first call it's not done. That's why the
+		// AnyTimes is used
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil).AnyTimes()
+		v2.EXPECT().HasFile(gomock.Any(), key).Return(evid, true, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		vid, ok, err := s.HasFile(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, evid, vid)
+	})
+	t.Run("SuccessFalse", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			key  = "expectedkey"
+			ctx  = context.Background()
+		)
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		vid, ok, err := s.HasFile(ctx, key)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "", vid)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl   = gomock.NewController(t)
+			ctx    = context.Background()
+			expcfg = config.Config{Name: "Pepito", Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}
+		)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		s, err := storing.New(&expcfg, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		cfg, err := s.Config(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &expcfg, cfg)
+	})
+}
+
+func TestCreateReplica(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			key            = "expectedkey"
+			buff           = io.NopCloser(bytes.NewBufferString("expectedcontent"))
+			ctrl           = gomock.NewController(t)
+			ctx            = context.Background()
+			createdToVolID = "createdToVolID"
+			ttl            = 2 * time.Minute
+			ca             = time.Now()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		v.EXPECT().CreateFile(gomock.Any(), key, buff, 1, ttl, ca, "").Return(nil)
+
+		// It's AnyTimes as we have the config witha number of replicas
 		// which activates the goroutines that also calls this
 		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
 
@@ -383,19 +953,24 @@ func TestUpdateFileReplica(t *testing.T) {
 		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
 		m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes()
 
+		// It's also used to know the preferred volume
+		v.EXPECT().ID().Return(createdToVolID).Times(2)
+
 		s, err := storing.New(&config.Config{Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		err = s.UpdateFileReplica(ctx, key, vids, rep)
+		volID, err := s.CreateReplica(ctx, key, buff, ttl, ca, "")
 		require.NoError(t, err)
+		assert.Equal(t, createdToVolID, volID)
 	})
 	t.Run("ErrorNoReplica", func(t *testing.T) {
 		var (
 			key  = "expectedkey"
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
 			ctrl = gomock.NewController(t)
 			ctx  = context.Background()
-			rep  = 4
-			vids = []string{"1", "2"}
+			ttl  = 2 * time.Minute
+			ca   = time.Now()
 		)
 
 		m := mock.NewMembership(ctrl)
@@ -404,7 +979,1287 @@ func TestUpdateFileReplica(t *testing.T) {
 		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
 		require.NoError(t, err)
 
-		err = s.UpdateFileReplica(ctx, key, vids, rep)
+		volID, err := s.CreateReplica(ctx, key, buff, ttl, ca, "")
 		assert.EqualError(t, err, "can not store replicas")
+		assert.Equal(t, "", volID)
+	})
+}
+
+func TestGetFileNodes(t *testing.T) {
+	var (
+		key  = "expectedkey"
+		ctrl = gomock.NewController(t)
+		ctx  = context.Background()
+	)
+	v := mock.NewVolumeLocal(ctrl)
+	s2 := mock.NewStoring(ctrl)
+	s3 := mock.NewStoring(ctrl)
+	m := mock.NewMembership(ctrl)
+	defer ctrl.Finish()
+
+	server2 := httptest.NewServer(storing.MakeHandler(s2))
+	defer server2.Close()
+	c2, err := client.New(server2.URL)
+	require.NoError(t, err)
+
+	server3 := httptest.NewServer(storing.MakeHandler(s3))
+	defer server3.Close()
+	c3, err := client.New(server3.URL)
+	require.NoError(t, err)
+
+	m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+	m.EXPECT().LocalURL().Return("http://n1:3805")
+	m.EXPECT().Nodes().Return([]*client.Client{c2, c3})
+	m.EXPECT().NodesWithKey(key).Return([]*client.Client{c2, c3})
+	m.EXPECT().VolumeIDs().Return(nil)
+	m.EXPECT().NodeURL(c2).Return("http://n2:3805", nil)
+
+	v.EXPECT().HasFile(gomock.Any(), key).Return("vid", true, nil)
+	s2.EXPECT().HasFile(gomock.Any(), key).Return("vid2", true, nil)
+	s3.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+
+	s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+	require.NoError(t, err)
+
+	urls, err := s.GetFileNodes(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://n1:3805", "http://n2:3805"}, urls)
+}
+
+func TestGetFileSignatureReplica(t *testing.T) {
+	var (
+		key  = "expectedkey"
+		sig  = "sha1:123"
+		ctrl = gomock.NewController(t)
+		ctx  = context.Background()
+	)
+
+	v := mock.NewVolumeLocal(ctrl)
+	v2 := mock.NewVolumeLocal(ctrl)
+	m := mock.NewMembership(ctrl)
+	defer ctrl.Finish()
+
+	m.EXPECT().LocalVolumes().Return([]volume.Local{v, v2})
+
+	v.EXPECT().GetFileSignature(ctx, key).Return("", errors.New("not found"))
+	v2.EXPECT().GetFileSignature(ctx, key).Return(sig, nil)
+
+	s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+	require.NoError(t, err)
+
+	rsig, err := s.GetFileSignatureReplica(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, sig, rsig)
+}
+
+func TestUpdateFileReplica(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			rep  = 4
+			vids = []string{"1", "2"}
+			vid  = "vid"
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		v.EXPECT().HasFile(gomock.Any(), key).Return(vid, true, nil)
+		v.EXPECT().UpdateFileReplica(gomock.Any(), key, vids, rep).Return(nil)
+
+		// It's AnyTimes as we have the config witha number of replicas
+		// which activates the goroutines that also calls this
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+
+		// This is also because of the goroutine, it may call it or not
+		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
+		m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes()
+
+		s, err := storing.New(&config.Config{Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.UpdateFileReplica(ctx, key, vids, rep)
+		require.NoError(t, err)
+	})
+	t.Run("ErrorNoReplica", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			rep  = 4
+			vids = []string{"1", "2"}
+		)
+
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.UpdateFileReplica(ctx, key, vids, rep)
+		assert.EqualError(t, err, "can not store replicas")
+	})
+}
+
+func TestCreateBucket(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			b    = &bucket.Bucket{Name: "logs", Replica: 2}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		v2 := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v, v2})
+		v.EXPECT().PutBucket(ctx, b).Return(nil)
+		v2.EXPECT().PutBucket(ctx, b).Return(nil)
+		m.EXPECT().BroadcastBucket(b)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CreateBucket(ctx, b)
+		require.NoError(t, err)
+
+		assert.Equal(t, bucket.Private, b.Policy)
+		assert.False(t, b.UpdatedAt.IsZero())
+	})
+	t.Run("FailsInvalid", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CreateBucket(ctx, &bucket.Bucket{Name: "Logs"})
+		assert.True(t, errors.Is(err, bucket.ErrInvalid))
+	})
+}
+
+func TestGetBucket(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			b    = &bucket.Bucket{Name: "logs"}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		v.EXPECT().GetBucket(ctx, b.Name).Return(b, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		rb, err := s.GetBucket(ctx, b.Name)
+		require.NoError(t, err)
+		assert.Equal(t, b, rb)
+	})
+	t.Run("SuccessGateway", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			b    = &bucket.Bucket{Name: "logs", Policy: bucket.Private}
+		)
+
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		server := httptest.NewServer(storing.MakeHandler(s2))
+		defer server.Close()
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		// Without local volumes the
+		// Bucket is read from the others
+		m.EXPECT().LocalVolumes().Return(nil)
+		m.EXPECT().VolumeIDs().Return([]string{"b", "c"})
+		m.EXPECT().GetNodeWithVolumeByID("b").Return(c, nil)
+		m.EXPECT().GetNodeWithVolumeByID("c").Return(c, nil)
+		s2.EXPECT().GetBucket(gomock.Any(), b.Name).Return(b, nil)
+
+		s, err := storing.New(&config.Config{Gateway: true, Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		rb, err := s.GetBucket(ctx, b.Name)
+		require.NoError(t, err)
+		assert.Equal(t, b.Name, rb.Name)
+		assert.Equal(t, b.Policy, rb.Policy)
+	})
+	t.Run("NotFoundDeleted", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			b    = &bucket.Bucket{Name: "logs", Deleted: true}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		v.EXPECT().GetBucket(ctx, b.Name).Return(b, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		_, err = s.GetBucket(ctx, b.Name)
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestBuckets(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		ctx  = context.Background()
+		b    = &bucket.Bucket{Name: "logs"}
+	)
+
+	v := mock.NewVolumeLocal(ctrl)
+	m := mock.NewMembership(ctrl)
+	defer ctrl.Finish()
+
+	m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+	v.EXPECT().Buckets(ctx).Return([]*bucket.Bucket{b, {Name: "images", Deleted: true}}, nil)
+
+	s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+	require.NoError(t, err)
+
+	bks, err := s.Buckets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*bucket.Bucket{b}, bks)
+}
+
+func TestDeleteBucket(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		ctx  = context.Background()
+		b    = &bucket.Bucket{Name: "logs"}
+	)
+
+	v := mock.NewVolumeLocal(ctrl)
+	m := mock.NewMembership(ctrl)
+	defer ctrl.Finish()
+
+	m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+	v.EXPECT().GetBucket(ctx, b.Name).Return(b, nil)
+	v.EXPECT().PutBucket(ctx, b).Return(nil)
+	m.EXPECT().BroadcastBucket(b)
+
+	s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+	require.NoError(t, err)
+
+	err = s.DeleteBucket(ctx, b.Name)
+	require.NoError(t, err)
+	assert.True(t, b.Deleted)
+}
+
+func TestQuotas(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		v.EXPECT().ID().Return("lvid").AnyTimes()
+		v.EXPECT().Buckets(ctx).Return([]*bucket.Bucket{
+			{Name: "logs", Quota: bucket.Quota{Size: 100}},
+			{Name: "raw"},
+		}, nil)
+		v.EXPECT().GetState(ctx).Return(&state.State{Usage: map[string]quota.Usage{"@logs/": {Size: 10, Count: 1}, "tmp/": {Size: 1, Count: 1}}}, nil)
+		m.EXPECT().NodeStates().Return([]*membership.State{
+			{Volumes: map[string]state.State{"vid": {Usage: map[string]quota.Usage{"@logs/": {Size: 5, Count: 1}}}}},
+		})
+
+		cfg := &config.Config{
+			Replica: -1,
+			Cache:   config.Cache{Size: config.DefaultCacheSize},
+			Quotas:  []config.Quota{{Prefix: "tmp/", Count: 10}},
+		}
+		s, err := storing.New(cfg, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		sts, err := s.Quotas(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*quota.Status{
+			{Quota: quota.Quota{Prefix: "tmp/", Count: 10}, Usage: quota.Usage{Size: 1, Count: 1}},
+			{Quota: quota.Quota{Prefix: "@logs/", Size: 100}, Usage: quota.Usage{Size: 15, Count: 2}},
+		}, sts)
+	})
+}
+
+func TestVersions(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			key  = "expectedkey"
+			evs  = []*version.Version{{ID: "1-sig"}, {ID: "2-delete", DeleteMarker: true}}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		v.EXPECT().HasVersions(gomock.Any(), key).Return("vid", true, nil)
+		v.EXPECT().Versions(ctx, key).Return(evs, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		vs, err := s.Versions(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, evs, vs)
+	})
+}
+
+func TestRestoreVersion(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			key  = "expectedkey"
+			vk   = version.Key(key, "1-sig")
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		m.EXPECT().VolumeIDs().Return(nil)
+		v.EXPECT().ID().Return("vid").AnyTimes()
+		v.EXPECT().HasVersions(gomock.Any(), key).Return("vid", true, nil)
+		v.EXPECT().Versions(ctx, key).Return([]*version.Version{{ID: "1-sig", Class: "cold"}, {ID: "2-delete", DeleteMarker: true}}, nil)
+		v.EXPECT().HasFile(gomock.Any(), vk).Return("vid", true, nil)
+		v.EXPECT().GetFile(gomock.Any(), vk).Return(buff, nil)
+		v.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+		v.EXPECT().CreateFile(gomock.Any(), key, buff, -1, time.Duration(0), time.Time{}, "cold").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.RestoreVersion(ctx, key, "1-sig")
+		require.NoError(t, err)
+	})
+	t.Run("NotFoundDeleteMarker", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			key  = "expectedkey"
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		v.EXPECT().HasVersions(gomock.Any(), key).Return("vid", true, nil)
+		v.EXPECT().Versions(ctx, key).Return([]*version.Version{{ID: "2-delete", DeleteMarker: true}}, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.RestoreVersion(ctx, key, "2-delete")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestTrash(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			now  = time.Now().UTC().Truncate(time.Second)
+			it1  = &trash.Item{ID: "1", Key: "a", DeletedAt: now}
+			it2  = &trash.Item{ID: "2", Key: "b", DeletedAt: now.Add(-time.Minute)}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+
+		v.EXPECT().Trash(ctx).Return([]*trash.Item{it1}, nil)
+		// The replica of the it1 is also
+		// on the other Node
+		s2.EXPECT().Trash(gomock.Any(), true).Return([]*trash.Item{it1, it2}, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		its, err := s.Trash(ctx, false)
+		require.NoError(t, err)
+		assert.Equal(t, []*trash.Item{it2, it1}, its)
+	})
+	t.Run("SuccessLocal", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			it   = &trash.Item{ID: "1", Key: "a"}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+
+		v.EXPECT().Trash(ctx).Return([]*trash.Item{it}, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		its, err := s.Trash(ctx, true)
+		require.NoError(t, err)
+		assert.Equal(t, []*trash.Item{it}, its)
+	})
+}
+
+func TestRestoreTrash(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			key  = "expectedkey"
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+
+		v.EXPECT().RestoreTrash(ctx, key, "1").Return(errors.New("not found"))
+		s2.EXPECT().RestoreTrash(gomock.Any(), key, "1", true).Return(nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.RestoreTrash(ctx, key, "1", false)
+		require.NoError(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			key  = "expectedkey"
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+
+		v.EXPECT().RestoreTrash(ctx, key, "1").Return(errors.New("not found"))
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.RestoreTrash(ctx, key, "1", true)
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestCopyFile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ca   = time.Now()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		v2 := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v, v2}).Times(3)
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("vid", true, nil).Times(2)
+		v.EXPECT().Buckets(ctx).Return(nil, nil)
+		v.EXPECT().CopyFile(ctx, "a", "b", true, ca).Return(nil)
+
+		// The other volume does not have the src but it
+		// has the previous dst so it's deleted from it
+		v2.EXPECT().HasFile(gomock.Any(), "a").Return("", false, nil).AnyTimes()
+		v2.EXPECT().HasFile(gomock.Any(), "b").Return("vid2", true, nil)
+		v2.EXPECT().DeleteFile(ctx, "b").Return(nil)
+
+		// The cluster is notified of the new key
+		// and of the changes of both of them
+		m.EXPECT().BroadcastKey("b")
+		m.EXPECT().BroadcastInvalidation("b")
+		m.EXPECT().BroadcastInvalidation("a")
+
+		// The replicas are copied with the same time
+		s2.EXPECT().CopyReplica(gomock.Any(), "a", "b", true, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, _ bool, rca time.Time) error {
+			assert.True(t, ca.Equal(rca))
+			return nil
+		})
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CopyFile(ctx, "a", "b", true, ca)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessRemote", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ca   = time.Now()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("", false, nil)
+		s2.EXPECT().HasFile(gomock.Any(), "a").Return("vid", true, nil)
+
+		// The Node that has it is the one copying it
+		s2.EXPECT().CopyFile(gomock.Any(), "a", "b", false, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ string, _ bool, rca time.Time) error {
+			assert.True(t, ca.Equal(rca))
+			return nil
+		})
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CopyFile(ctx, "a", "b", false, ca)
+		require.NoError(t, err)
+	})
+	t.Run("QuotaExceeded", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(3)
+		m.EXPECT().NodeStates().Return(nil)
+		v.EXPECT().ID().Return("vid").AnyTimes()
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("vid", true, nil)
+		v.EXPECT().Buckets(ctx).Return(nil, nil)
+		v.EXPECT().GetState(ctx).Return(&state.State{Usage: map[string]quota.Usage{"tmp/": {Size: 1, Count: 1}}}, nil)
+
+		cfg := &config.Config{
+			Replica: -1,
+			Cache:   config.Cache{Size: config.DefaultCacheSize},
+			Quotas:  []config.Quota{{Prefix: "tmp/", Count: 1}},
+		}
+		s, err := storing.New(cfg, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.CopyFile(ctx, "a", "tmp/a", false, time.Now())
+		assert.ErrorIs(t, err, quota.ErrCountExceeded)
+	})
+}
+
+func TestUpdateFileTTL(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).Times(2)
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("vid", true, nil)
+		v.EXPECT().UpdateFileTTL(ctx, "a", time.Hour).Return(nil).Times(2)
+
+		// The replicas are updated too
+		s2.EXPECT().UpdateFileTTLReplica(gomock.Any(), "a", time.Hour).Return(nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.UpdateFileTTL(ctx, "a", time.Hour)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessRemote", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil)
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("", false, nil)
+		s2.EXPECT().HasFile(gomock.Any(), "a").Return("vid", true, nil)
+
+		// The Node that has it is the one updating it
+		s2.EXPECT().UpdateFileTTL(gomock.Any(), "a", time.Duration(0)).Return(nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.UpdateFileTTL(ctx, "a", 0)
+		require.NoError(t, err)
+	})
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("SuccessLocal", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ops  = []*batch.Operation{
+				{Op: batch.Head, Key: "a"},
+				{Op: batch.Delete, Key: "b"},
+				{Op: batch.Head, Key: "c"},
+				{Op: batch.Copy, Key: "a"},
+			}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("vid", true, nil)
+		v.EXPECT().HasFile(gomock.Any(), "b").Return("vid", true, nil)
+		v.EXPECT().HasFile(gomock.Any(), "c").Return("", false, nil)
+		v.EXPECT().DeleteFile(ctx, "b").Return(nil)
+		m.EXPECT().BroadcastInvalidation("b")
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		rs, err := s.Batch(ctx, ops, true)
+		require.NoError(t, err)
+		require.Len(t, rs, 4)
+
+		assert.Equal(t, &batch.Result{Operation: *ops[0], VolumeID: "vid"}, rs[0])
+		assert.Equal(t, &batch.Result{Operation: *ops[1]}, rs[1])
+		assert.EqualError(t, rs[2].Err, "not found")
+		assert.ErrorIs(t, rs[3].Err, batch.ErrInvalid)
+	})
+	t.Run("SuccessRemote", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ttl  = time.Hour
+			ops  = []*batch.Operation{
+				{Op: batch.Head, Key: "a"},
+				{Op: batch.Delete, Key: "b"},
+				{Op: batch.Delete, Key: "c"},
+				{Op: batch.Update, Key: "d", TTL: &ttl},
+			}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		h := storing.MakeHandler(s2)
+		server := httptest.NewServer(h)
+		c, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c})
+		m.EXPECT().VolumeIDs().Return(nil).AnyTimes()
+		m.EXPECT().NodesWithKey(gomock.Any()).Return(nil).AnyTimes()
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("", false, nil)
+		v.EXPECT().HasFile(gomock.Any(), "b").Return("", false, nil)
+		v.EXPECT().HasFile(gomock.Any(), "c").Return("", false, nil)
+		v.EXPECT().HasFile(gomock.Any(), "d").Return("", false, nil)
+
+		// All the keys of the Node are asked at once to find who has them
+		s2.EXPECT().Batch(gomock.Any(), []*batch.Operation{
+			{Op: batch.Head, Key: "a"},
+			{Op: batch.Head, Key: "b"},
+			{Op: batch.Head, Key: "c"},
+			{Op: batch.Head, Key: "d"},
+		}, true).Return([]*batch.Result{
+			{Operation: batch.Operation{Op: batch.Head, Key: "a"}, VolumeID: "vid2"},
+			{Operation: batch.Operation{Op: batch.Head, Key: "b"}, VolumeID: "vid2"},
+			{Operation: batch.Operation{Op: batch.Head, Key: "c"}, Err: errors.New("not found")},
+			{Operation: batch.Operation{Op: batch.Head, Key: "d"}, VolumeID: "vid2"},
+		}, nil)
+
+		// And then the Operations are sent to the one that has them
+		s2.EXPECT().Batch(gomock.Any(), []*batch.Operation{ops[1], ops[3]}, true).Return([]*batch.Result{
+			{Operation: *ops[1]},
+			{Operation: *ops[3]},
+		}, nil)
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		rs, err := s.Batch(ctx, ops, false)
+		require.NoError(t, err)
+		require.Len(t, rs, 4)
+
+		assert.Equal(t, &batch.Result{Operation: *ops[0], VolumeID: "vid2"}, rs[0])
+		assert.Equal(t, &batch.Result{Operation: *ops[1]}, rs[1])
+		assert.EqualError(t, rs[2].Err, "not found")
+		assert.Equal(t, &batch.Result{Operation: *ops[3]}, rs[3])
+	})
+	t.Run("SuccessRemoteOwners", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ops  = []*batch.Operation{
+				{Op: batch.Head, Key: "a"},
+				{Op: batch.Head, Key: "b"},
+			}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		s3 := mock.NewStoring(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		server2 := httptest.NewServer(storing.MakeHandler(s2))
+		c2, err := client.New(server2.URL)
+		require.NoError(t, err)
+		server3 := httptest.NewServer(storing.MakeHandler(s3))
+		c3, err := client.New(server3.URL)
+		require.NoError(t, err)
+
+		nodes := map[string]*client.Client{"vid2": c2, "vid3": c3}
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v})
+		m.EXPECT().Nodes().Return([]*client.Client{c2, c3})
+		m.EXPECT().VolumeIDs().Return([]string{"vid2", "vid3"}).AnyTimes()
+		m.EXPECT().GetNodeWithVolumeByID(gomock.Any()).DoAndReturn(func(vid string) (*client.Client, error) {
+			return nodes[vid], nil
+		}).AnyTimes()
+
+		// The b may be on the other one too
+		m.EXPECT().NodesWithKey("a").Return(nil)
+		m.EXPECT().NodesWithKey("b").Return([]*client.Client{c2, c3})
+
+		v.EXPECT().HasFile(gomock.Any(), "a").Return("", false, nil)
+		v.EXPECT().HasFile(gomock.Any(), "b").Return("", false, nil)
+
+		// Each key is only asked to the Node with the
+		// preferred volume for it and to the ones
+		// that may have it by their filters
+		heads := map[*mock.Storing][]*batch.Operation{}
+		for _, k := range []string{"a", "b"} {
+			st := s2
+			if rendezvous.Rank([]string{"vid2", "vid3"}, k)[0] == "vid3" {
+				st = s3
+			}
+			heads[st] = append(heads[st], &batch.Operation{Op: batch.Head, Key: k})
+			if k == "b" {
+				ost := s3
+				if st == s3 {
+					ost = s2
+				}
+				heads[ost] = append(heads[ost], &batch.Operation{Op: batch.Head, Key: k})
+			}
+		}
+		for st, hs := range heads {
+			nrs := make([]*batch.Result, 0, len(hs))
+			for _, h := range hs {
+				nrs = append(nrs, &batch.Result{Operation: *h, VolumeID: "vid"})
+			}
+			st.EXPECT().Batch(gomock.Any(), hs, true).Return(nrs, nil)
+		}
+
+		s, err := storing.New(&config.Config{Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		rs, err := s.Batch(ctx, ops, false)
+		require.NoError(t, err)
+		require.Len(t, rs, 2)
+
+		assert.Equal(t, &batch.Result{Operation: *ops[0], VolumeID: "vid"}, rs[0])
+		assert.Equal(t, &batch.Result{Operation: *ops[1], VolumeID: "vid"}, rs[1])
+	})
+}
+
+// generateTLS generates on a temporary dir a CA and the certificate
+// of a Node signed by it and returns the config.TLS with them
+func generateTLS(t *testing.T) config.TLS {
+	t.Helper()
+
+	var (
+		dir  = t.TempDir()
+		tc   = config.TLS{CAFile: filepath.Join(dir, "ca.pem"), CertFile: filepath.Join(dir, "node.pem"), KeyFile: filepath.Join(dir, "node-key.pem")}
+		tmpl = func(sn int64) *x509.Certificate {
+			return &x509.Certificate{
+				SerialNumber: big.NewInt(sn),
+				Subject:      pkix.Name{CommonName: "rebost"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+		}
+		writePEM = func(p, typ string, b []byte) {
+			err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600)
+			require.NoError(t, err)
+		}
+	)
+
+	cak, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ca := tmpl(1)
+	ca.IsCA = true
+	ca.KeyUsage = x509.KeyUsageCertSign
+	ca.BasicConstraintsValid = true
+	cab, err := x509.CreateCertificate(rand.Reader, ca, ca, &cak.PublicKey, cak)
+	require.NoError(t, err)
+	ca, err = x509.ParseCertificate(cab)
+	require.NoError(t, err)
+	writePEM(tc.CAFile, "CERTIFICATE", cab)
+
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// The Node uses the same one as
+	// the server and the client
+	n := tmpl(2)
+	n.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	n.KeyUsage = x509.KeyUsageDigitalSignature
+	n.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	nb, err := x509.CreateCertificate(rand.Reader, n, ca, &k.PublicKey, cak)
+	require.NoError(t, err)
+	writePEM(tc.CertFile, "CERTIFICATE", nb)
+
+	kb, err := x509.MarshalECPrivateKey(k)
+	require.NoError(t, err)
+	writePEM(tc.KeyFile, "EC PRIVATE KEY", kb)
+
+	return tc
+}
+
+func TestCoordinator(t *testing.T) {
+	t.Run("SuccessSynchronizeReplicas", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			done = make(chan struct{})
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		p, err := util.FreePort()
+		require.NoError(t, err)
+
+		m.EXPECT().CoordinatorNodes().Return(map[string]string{"n1": fmt.Sprintf("127.0.0.1:%d", p)}).AnyTimes()
+
+		// The goroutines of the replicas
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
+		v.EXPECT().ID().Return("vid1").AnyTimes()
+
+		// Once the leader decides it all the
+		// Nodes synchronize the replicas
+		gomock.InOrder(
+			m.EXPECT().RemovedVolumeIDs().Return([]string{"vid2"}),
+			m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes(),
+		)
+		m.EXPECT().GetNodeWithVolumeByID("vid2").Return(nil, errors.New("not found"))
+		v.EXPECT().SynchronizeReplicas(gomock.Any(), "vid2").Do(func(_ context.Context, _ string) { close(done) }).Return(nil)
+
+		_, err = storing.New(&config.Config{Name: "n1", Coordinator: config.Coordinator{Port: p}, TLS: generateTLS(t), Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("the replicas were not synchronized")
+		}
+	})
+	t.Run("SuccessDecidedOnce", func(t *testing.T) {
+		var (
+			ctrl   = gomock.NewController(t)
+			done   = make(chan struct{})
+			looped = make(chan struct{})
+			once   sync.Once
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		p, err := util.FreePort()
+		require.NoError(t, err)
+
+		m.EXPECT().CoordinatorNodes().Return(map[string]string{"n1": fmt.Sprintf("127.0.0.1:%d", p)}).AnyTimes()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
+		v.EXPECT().ID().Return("vid1").AnyTimes()
+
+		// The vid2 is removed again once synchronized,
+		// as if this Node noticed it after, and it's
+		// not decided again
+		var calls int
+		m.EXPECT().RemovedVolumeIDs().DoAndReturn(func() []string {
+			calls++
+			if calls == 1 {
+				return []string{"vid2"}
+			}
+			select {
+			case <-done:
+			default:
+				return nil
+			}
+			var rvids []string
+			once.Do(func() {
+				rvids = []string{"vid2"}
+				close(looped)
+			})
+			return rvids
+		}).AnyTimes()
+		m.EXPECT().GetNodeWithVolumeByID("vid2").Return(nil, errors.New("not found"))
+		v.EXPECT().SynchronizeReplicas(gomock.Any(), "vid2").Do(func(_ context.Context, _ string) { close(done) }).Return(nil)
+
+		_, err = storing.New(&config.Config{Name: "n1", VolumeDowntime: time.Minute, Coordinator: config.Coordinator{Port: p}, TLS: generateTLS(t), Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		for _, c := range []chan struct{}{done, looped} {
+			select {
+			case <-c:
+			case <-time.After(10 * time.Second):
+				t.Fatal("the replicas were not synchronized")
+			}
+		}
+
+		// The second one would be applied before the
+		// next loop and fail with the unexpected calls
+		time.Sleep(time.Second)
+	})
+	t.Run("SuccessScheduleRepairs", func(t *testing.T) {
+		tests := []struct {
+			Name   string
+			Nodes  func(addr string) map[string]string
+			Repair bool
+		}{
+			{
+				Name:   "Turn",
+				Nodes:  func(addr string) map[string]string { return map[string]string{"n1": addr, "n2": "127.0.0.1:1"} },
+				Repair: true,
+			},
+			{
+				Name:   "NotTurn",
+				Nodes:  func(addr string) map[string]string { return map[string]string{"n0": "127.0.0.1:1", "n1": addr} },
+				Repair: false,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.Name, func(t *testing.T) {
+				var (
+					ctrl  = gomock.NewController(t)
+					ctx   = context.Background()
+					calls atomic.Int64
+				)
+
+				v := mock.NewVolumeLocal(ctrl)
+				m := mock.NewMembership(ctrl)
+				defer ctrl.Finish()
+
+				p, err := util.FreePort()
+				require.NoError(t, err)
+
+				// The other Node can not be reached so it's
+				// not added but it's still given the turn
+				m.EXPECT().CoordinatorNodes().Return(tt.Nodes(fmt.Sprintf("127.0.0.1:%d", p))).AnyTimes()
+				m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes()
+
+				m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+				v.EXPECT().ID().Return("vid1").AnyTimes()
+				v.EXPECT().NextReplica(gomock.Any()).DoAndReturn(func(_ context.Context) (*replica.Replica, error) {
+					calls.Add(1)
+					return nil, errors.New("not found")
+				}).AnyTimes()
+
+				s, err := storing.New(&config.Config{Name: "n1", Replica: 2, Coordinator: config.Coordinator{Port: p}, TLS: generateTLS(t), Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+				require.NoError(t, err)
+
+				// Only one Node at a time, the first
+				// turn is for the first one by name
+				require.Eventually(t, func() bool {
+					return s.UpdateSettings(ctx, &settings.Settings{MaxRepairs: 1}) == nil
+				}, 10*time.Second, 50*time.Millisecond)
+
+				// Once scheduled the loop of the replicas
+				// only asks for them if it's its turn
+				time.Sleep(2 * time.Second)
+				n := calls.Load()
+				time.Sleep(2 * time.Second)
+				if tt.Repair {
+					assert.Greater(t, calls.Load(), n)
+				} else {
+					assert.Equal(t, n, calls.Load())
+				}
+			})
+		}
+	})
+	t.Run("SuccessRebalanceVolume", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			done = make(chan struct{})
+			rp   = &replica.Replica{ID: "id", OriginalCount: 2, Count: 1, Key: key, Signature: "sig", VolumeID: "vid1", VolumeIDs: []string{"vid1", "vid3"}, Move: true}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		server := httptest.NewServer(storing.MakeHandler(s2))
+		defer server.Close()
+		n2, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		p, err := util.FreePort()
+		require.NoError(t, err)
+
+		m.EXPECT().CoordinatorNodes().Return(map[string]string{"n1": fmt.Sprintf("127.0.0.1:%d", p)}).AnyTimes()
+		m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
+		v.EXPECT().ID().Return("vid1").AnyTimes()
+
+		// The local volume is at 90% and the one of the
+		// other Node at 10% so 40 are moved to leave
+		// both at 50%
+		v.EXPECT().GetState(gomock.Any()).Return(&state.State{VolumeTotalSize: 100, VolumeUsedSize: 90}, nil).AnyTimes()
+		m.EXPECT().NodeStates().Return([]*membership.State{
+			{Node: "n2", Volumes: map[string]state.State{"vid2": {VolumeTotalSize: 100, VolumeUsedSize: 10}}},
+		}).AnyTimes()
+
+		// The new volume takes the place of the current one
+		// and all the ones with the File are updated before
+		// it's removed from the current one
+		m.EXPECT().GetNodeWithVolumeByID("vid2").Return(n2, nil).Times(2)
+		m.EXPECT().GetNodeWithVolumeByID("vid3").Return(n2, nil)
+		v.EXPECT().MoveReplicas(gomock.Any(), "vid2", 40).Return([]*replica.Replica{rp}, nil)
+		s2.EXPECT().HasFile(gomock.Any(), key).Return("", false, nil)
+		v.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBufferString("expectedcontent")), nil)
+		s2.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), time.Duration(0), gomock.Any(), "").Return("vid2", nil)
+		s2.EXPECT().UpdateFileReplica(gomock.Any(), key, []string{"vid2", "vid3"}, 2).Return(nil).Times(2)
+		v.EXPECT().UpdateReplica(gomock.Any(), rp, "vid2").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key).Do(func(_ string) { close(done) })
+
+		s, err := storing.New(&config.Config{Name: "n1", Replica: 2, Coordinator: config.Coordinator{Port: p}, TLS: generateTLS(t), Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return s.UpdateSettings(ctx, &settings.Settings{Rebalance: 10}) == nil
+		}, 10*time.Second, 50*time.Millisecond)
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("the volumes were not rebalanced")
+		}
+	})
+}
+
+func TestSettings(t *testing.T) {
+	t.Run("SuccessDecided", func(t *testing.T) {
+		var (
+			key  = "expectedkey"
+			buff = io.NopCloser(bytes.NewBufferString("expectedcontent"))
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			ca   = time.Now()
+			st   = &settings.Settings{Replica: 3, Redirect: settings.RedirectAlways}
+		)
+
+		v := mock.NewVolumeLocal(ctrl)
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		p, err := util.FreePort()
+		require.NoError(t, err)
+
+		m.EXPECT().CoordinatorNodes().Return(map[string]string{"n1": fmt.Sprintf("127.0.0.1:%d", p)}).AnyTimes()
+		m.EXPECT().RemovedVolumeIDs().Return(nil).AnyTimes()
+
+		m.EXPECT().LocalVolumes().Return([]volume.Local{v}).AnyTimes()
+		v.EXPECT().NextReplica(gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
+		v.EXPECT().ID().Return("vid1").AnyTimes()
+
+		// The Files without replica use the
+		// ones of the Settings of the cluster
+		m.EXPECT().VolumeIDs().Return(nil)
+		v.EXPECT().Buckets(gomock.Any()).Return(nil, nil)
+		v.EXPECT().CreateFile(gomock.Any(), key, buff, 3, time.Duration(0), ca, "").Return(nil)
+		m.EXPECT().BroadcastInvalidation(key)
+
+		s, err := storing.New(&config.Config{Name: "n1", Replica: 2, Coordinator: config.Coordinator{Port: p}, TLS: generateTLS(t), Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		// Until it's elected as the leader
+		// there is no one to decide them
+		require.Eventually(t, func() bool {
+			return s.UpdateSettings(ctx, st) == nil
+		}, 10*time.Second, 50*time.Millisecond)
+
+		rst, err := s.Settings(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, st, rst)
+
+		err = s.CreateFile(ctx, key, buff, noRep, 0, ca, "")
+		require.NoError(t, err)
+	})
+	t.Run("SuccessForwarded", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+			st   = &settings.Settings{Replica: 3}
+		)
+
+		m := mock.NewMembership(ctrl)
+		s2 := mock.NewStoring(ctrl)
+		defer ctrl.Finish()
+
+		server := httptest.NewServer(storing.MakeHandler(s2))
+		defer server.Close()
+		n2, err := client.New(server.URL)
+		require.NoError(t, err)
+
+		// The Node without coordinator asks
+		// to the ones that take part on it
+		m.EXPECT().CoordinatorNodes().Return(map[string]string{"n2": "127.0.0.1:1"}).Times(2)
+		m.EXPECT().GetNodeByName("n2").Return(n2, nil).Times(2)
+		s2.EXPECT().UpdateSettings(gomock.Any(), st).Return(nil)
+		s2.EXPECT().Settings(gomock.Any()).Return(st, nil)
+
+		s, err := storing.New(&config.Config{Name: "n1", Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.UpdateSettings(ctx, st)
+		require.NoError(t, err)
+
+		rst, err := s.Settings(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, st, rst)
+	})
+	t.Run("SuccessWithoutCoordinator", func(t *testing.T) {
+		var (
+			ctrl = gomock.NewController(t)
+			ctx  = context.Background()
+		)
+
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		m.EXPECT().CoordinatorNodes().Return(map[string]string{}).Times(2)
+
+		s, err := storing.New(&config.Config{Name: "n1", Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		// Without any Node with the coordinator
+		// the ones of each Node are used
+		rst, err := s.Settings(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, &settings.Settings{}, rst)
+
+		err = s.UpdateSettings(ctx, &settings.Settings{Replica: 3})
+		assert.EqualError(t, err, "there is no leader on the cluster")
+	})
+	t.Run("ErrorInvalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+
+		m := mock.NewMembership(ctrl)
+		defer ctrl.Finish()
+
+		s, err := storing.New(&config.Config{Name: "n1", Replica: -1, Cache: config.Cache{Size: config.DefaultCacheSize}}, m, kitlog.NewNopLogger())
+		require.NoError(t, err)
+
+		err = s.UpdateSettings(context.Background(), &settings.Settings{Replica: -1})
+		assert.True(t, errors.Is(err, settings.ErrInvalid))
 	})
 }
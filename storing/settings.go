@@ -0,0 +1,161 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/xescugc/rebost/client"
+	"github.com/xescugc/rebost/coordinator"
+	"github.com/xescugc/rebost/settings"
+)
+
+// settingsInterval is how often the Nodes that do not take
+// part on the election ask for the Settings of the cluster
+const settingsInterval = 10 * time.Second
+
+var (
+	// errNoLeader is returned when the Settings have to
+	// be decided and there is no leader on the cluster
+	errNoLeader = errors.New("there is no leader on the cluster")
+)
+
+func (s *service) Settings(ctx context.Context) (*settings.Settings, error) {
+	if s.coordinator != nil {
+		st := s.currentSettings()
+		return &st, nil
+	}
+
+	n, err := s.coordinatorNode()
+	if err != nil {
+		// Without any Node with the coordinator
+		// the ones of each Node are used
+		if err == errNoLeader {
+			return &settings.Settings{}, nil
+		}
+		return nil, err
+	}
+
+	return n.Settings(ctx)
+}
+
+func (s *service) UpdateSettings(ctx context.Context, st *settings.Settings) error {
+	err := st.Validate()
+	if err != nil {
+		return err
+	}
+
+	if s.coordinator != nil && s.coordinator.IsLeader() {
+		return s.coordinator.Decide(coordinator.Decision{
+			Type:     coordinator.UpdateSettings,
+			Settings: st,
+		})
+	}
+
+	// Only the leader can decide them so it's
+	// asked to it or to a Node that knows it
+	n, err := s.coordinatorNode()
+	if err != nil {
+		return err
+	}
+
+	return n.UpdateSettings(ctx, st)
+}
+
+// coordinatorNode returns the Node that knows the Settings of the
+// cluster, the leader if the current Node takes part on the
+// election or any of the ones that take part if not
+func (s *service) coordinatorNode() (*client.Client, error) {
+	if s.coordinator != nil {
+		l := s.coordinator.Leader()
+		if l == "" || l == s.cfg.Name {
+			return nil, errNoLeader
+		}
+		return s.members.GetNodeByName(l)
+	}
+
+	cns := s.members.CoordinatorNodes()
+	nns := make([]string, 0, len(cns))
+	for nn := range cns {
+		nns = append(nns, nn)
+	}
+	sort.Strings(nns)
+
+	for _, nn := range nns {
+		n, err := s.members.GetNodeByName(nn)
+		if err != nil {
+			continue
+		}
+		return n, nil
+	}
+
+	return nil, errNoLeader
+}
+
+// currentSettings returns the Settings of the cluster, the
+// last ones decided by the leader, that are used instead
+// of the ones of the configuration of the Node
+func (s *service) currentSettings() settings.Settings {
+	if s.coordinator != nil {
+		d, ok := s.coordinator.Last(coordinator.UpdateSettings)
+		if !ok || d.Settings == nil {
+			return settings.Settings{}
+		}
+		return *d.Settings
+	}
+
+	s.clusterSettingsLock.RLock()
+	defer s.clusterSettingsLock.RUnlock()
+
+	return s.clusterSettings
+}
+
+// replica returns the default number of replicas of the Files,
+// the Nodes that do not replicate (-1) keep not doing it
+func (s *service) replica() int {
+	if s.cfg.Replica == -1 {
+		return s.cfg.Replica
+	}
+	if st := s.currentSettings(); st.Replica != 0 {
+		return st.Replica
+	}
+	return s.cfg.Replica
+}
+
+// redirect returns if the requests for the Files on other
+// Nodes are redirected to them when it's not on the request
+func (s *service) redirect() bool {
+	switch s.currentSettings().Redirect {
+	case settings.RedirectAlways:
+		return true
+	case settings.RedirectNever:
+		return false
+	default:
+		return s.cfg.Redirect
+	}
+}
+
+// loopSettings keeps the Settings of the cluster up to date on the Nodes
+// that do not take part on the election, as they do not get the Decisions
+func (s *service) loopSettings() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			goto end
+		default:
+			st, err := s.Settings(s.ctx)
+			if err != nil {
+				s.logger.Log("msg", err.Error())
+			} else {
+				s.clusterSettingsLock.Lock()
+				s.clusterSettings = *st
+				s.clusterSettingsLock.Unlock()
+			}
+
+			time.Sleep(settingsInterval)
+		}
+	}
+end:
+	return
+}
@@ -3,16 +3,24 @@ package storing
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
 
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/presign"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/settings"
 	"github.com/xescugc/rebost/storing/model"
+	"github.com/xescugc/rebost/version"
 )
 
 // MakeHandler returns a http.Handler that uses the storing.Service
@@ -31,6 +39,13 @@ func MakeHandler(s Service) http.Handler {
 		encodeGetFileResponse,
 	)
 
+	getFileNodesHandler := kithttp.NewServer(
+		makeGetFileNodesEndpoint(s),
+		decodeGetFileNodesRequest,
+		encodeJSONResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
 	deleteFileHandler := kithttp.NewServer(
 		makeDeleteFileEndpoint(s),
 		decodeDeleteFileRequest,
@@ -61,18 +76,180 @@ func MakeHandler(s Service) http.Handler {
 		encodeJSONResponse,
 	)
 
+	createBucketHandler := kithttp.NewServer(
+		makeCreateBucketEndpoint(s),
+		decodeCreateBucketRequest,
+		encodeNoContentResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	getBucketHandler := kithttp.NewServer(
+		makeGetBucketEndpoint(s),
+		decodeGetBucketRequest,
+		encodeJSONResponse,
+	)
+
+	bucketsHandler := kithttp.NewServer(
+		makeBucketsEndpoint(s),
+		decodeBucketsRequest,
+		encodeJSONResponse,
+	)
+
+	deleteBucketHandler := kithttp.NewServer(
+		makeDeleteBucketEndpoint(s),
+		decodeDeleteBucketRequest,
+		encodeNoContentResponse,
+	)
+
+	versionsHandler := kithttp.NewServer(
+		makeVersionsEndpoint(s),
+		decodeVersionsRequest,
+		encodeJSONResponse,
+	)
+
+	hasVersionsHandler := kithttp.NewServer(
+		makeHasVersionsEndpoint(s),
+		decodeVersionsRequest,
+		encodeHasFileResponse,
+	)
+
+	restoreVersionHandler := kithttp.NewServer(
+		makeRestoreVersionEndpoint(s),
+		decodeRestoreVersionRequest,
+		encodeCreateFileResponse,
+	)
+
+	trashHandler := kithttp.NewServer(
+		makeTrashEndpoint(s),
+		decodeTrashRequest,
+		encodeJSONResponse,
+	)
+
+	restoreTrashHandler := kithttp.NewServer(
+		makeRestoreTrashEndpoint(s),
+		decodeRestoreTrashRequest,
+		encodeNoContentResponse,
+	)
+
+	trashReplicaHandler := kithttp.NewServer(
+		makeTrashReplicaEndpoint(s),
+		decodeTrashReplicaRequest,
+		encodeNoContentResponse,
+	)
+
+	copyFileHandler := kithttp.NewServer(
+		makeCopyFileEndpoint(s),
+		decodeCopyFileRequest,
+		encodeCreateFileResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	getFileSignatureReplicaHandler := kithttp.NewServer(
+		makeGetFileSignatureReplicaEndpoint(s),
+		decodeGetFileSignatureReplicaRequest,
+		encodeJSONResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	copyReplicaHandler := kithttp.NewServer(
+		makeCopyReplicaEndpoint(s),
+		decodeCopyReplicaRequest,
+		encodeNoContentResponse,
+	)
+
+	updateFileTTLHandler := kithttp.NewServer(
+		makeUpdateFileTTLEndpoint(s),
+		decodeUpdateFileTTLRequest,
+		encodeNoContentResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	updateFileTTLReplicaHandler := kithttp.NewServer(
+		makeUpdateFileTTLReplicaEndpoint(s),
+		decodeUpdateFileTTLReplicaRequest,
+		encodeNoContentResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	batchHandler := kithttp.NewServer(
+		makeBatchEndpoint(s),
+		decodeBatchRequest,
+		encodeBatchResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	settingsHandler := kithttp.NewServer(
+		makeSettingsEndpoint(s),
+		decodeSettingsRequest,
+		encodeJSONResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	updateSettingsHandler := kithttp.NewServer(
+		makeUpdateSettingsEndpoint(s),
+		decodeUpdateSettingsRequest,
+		encodeNoContentResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+
+	quotasHandler := kithttp.NewServer(
+		makeQuotasEndpoint(s),
+		decodeQuotasRequest,
+		encodeJSONResponse,
+	)
+
 	r := mux.NewRouter()
 
-	r.Handle("/files/{key:.*}", createFileHandler).Methods("PUT")
-	r.Handle("/files/{key:.*}", getFileHandler).Methods("GET")
-	r.Handle("/files/{key:.*}", deleteFileHandler).Methods("DELETE")
-	r.Handle("/files/{key:.*}", hasFileHandler).Methods("HEAD")
+	r.Handle("/files/{key:.*}", verifyKey(s, copyFileHandler)).Methods("PUT").HeadersRegexp(model.CopySourceHeader, ".+")
+	r.Handle("/files/{key:.*}", verifyKey(s, verifyPresigned(s, createFileHandler))).Methods("PUT")
+	r.Handle("/files/{key:.*}", verifyKey(s, copyFileHandler)).Methods("COPY", "MOVE")
+	r.Handle("/files/{key:.*}", verifyKey(s, verifyPresigned(s, getFileNodesHandler))).Methods("GET").Queries("nodes", "true")
+	r.Handle("/files/{key:.*}", verifyKey(s, verifyPresigned(s, getFileHandler))).Methods("GET")
+	r.Handle("/files/{key:.*}", verifyKey(s, verifyPresigned(s, deleteFileHandler))).Methods("DELETE")
+	r.Handle("/files/{key:.*}", verifyKey(s, verifyPresigned(s, hasFileHandler))).Methods("HEAD")
+	r.Handle("/files/{key:.*}", verifyKey(s, verifyPresigned(s, updateFileTTLHandler))).Methods("PATCH")
+
+	r.Handle("/buckets", bucketsHandler).Methods("GET")
+	r.Handle("/buckets/{bucket}", createBucketHandler).Methods("PUT")
+	r.Handle("/buckets/{bucket}", getBucketHandler).Methods("GET")
+	r.Handle("/buckets/{bucket}", deleteBucketHandler).Methods("DELETE")
+
+	r.Handle("/buckets/{bucket}/files/{key:.*}", verifyKey(s, copyFileHandler)).Methods("PUT").HeadersRegexp(model.CopySourceHeader, ".+")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", createFileHandler).Methods("PUT")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", verifyKey(s, copyFileHandler)).Methods("COPY", "MOVE")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", getFileNodesHandler).Methods("GET").Queries("nodes", "true")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", getFileHandler).Methods("GET")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", deleteFileHandler).Methods("DELETE")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", hasFileHandler).Methods("HEAD")
+	r.Handle("/buckets/{bucket}/files/{key:.*}", updateFileTTLHandler).Methods("PATCH")
+
+	r.Handle("/versions/{key:.*}", verifyKey(s, versionsHandler)).Methods("GET")
+	r.Handle("/versions/{key:.*}", verifyKey(s, hasVersionsHandler)).Methods("HEAD")
+	r.Handle("/versions/{key:.*}", verifyKey(s, restoreVersionHandler)).Methods("POST")
+
+	r.Handle("/buckets/{bucket}/versions/{key:.*}", versionsHandler).Methods("GET")
+	r.Handle("/buckets/{bucket}/versions/{key:.*}", hasVersionsHandler).Methods("HEAD")
+	r.Handle("/buckets/{bucket}/versions/{key:.*}", restoreVersionHandler).Methods("POST")
+
+	r.Handle("/trash", trashHandler).Methods("GET")
+	r.Handle("/trash/{key:.*}", restoreTrashHandler).Methods("POST")
+
+	r.Handle("/quotas", quotasHandler).Methods("GET")
+
+	r.Handle("/batch", verifyKey(s, batchHandler)).Methods("POST")
 
 	r.Handle("/replicas/{key:.*}", createReplicaHandler).Methods("PUT")
+	r.Handle("/replicas/{key:.*}", updateFileTTLReplicaHandler).Methods("PATCH").Queries("ttl", "{ttl}")
 	r.Handle("/replicas/{key:.*}", updateFileReplicaHandler).Methods("PATCH")
+	r.Handle("/replicas/{key:.*}", trashReplicaHandler).Methods("DELETE")
+	r.Handle("/replicas/{key:.*}", copyReplicaHandler).Methods("COPY")
+	r.Handle("/replicas/{key:.*}", getFileSignatureReplicaHandler).Methods("GET")
 
 	r.Handle("/config", getConfigHandler).Methods("GET")
 
+	r.Handle("/settings", settingsHandler).Methods("GET")
+	r.Handle("/settings", updateSettingsHandler).Methods("PUT")
+
 	r.NotFoundHandler = http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Context-Type", "application/json; charset=utf-8")
@@ -85,30 +262,7 @@ func MakeHandler(s Service) http.Handler {
 }
 
 func decodeCreateFileRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	var iorc io.ReadCloser
-
-	if mr, _ := r.MultipartReader(); mr != nil {
-		ppr, ppw := io.Pipe()
-
-		go func() {
-			for {
-				p, err := mr.NextPart()
-				if err == io.EOF {
-					ppw.Close()
-					return
-				}
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				io.Copy(ppw, p)
-			}
-		}()
-
-		iorc = ppr
-	} else {
-		iorc = r.Body
-	}
+	iorc := decodeBody(r)
 
 	rep, err := strconv.Atoi(r.URL.Query().Get("replica"))
 	if err != nil {
@@ -131,15 +285,77 @@ func decodeCreateFileRequest(_ context.Context, r *http.Request) (interface{}, e
 		ca = time.Time{}
 	}
 
+	key, bn := decodeKey(r)
+
 	return createFileRequest{
-		Key:       mux.Vars(r)["key"],
+		Key:       key,
+		Bucket:    bn,
 		Body:      iorc,
 		Replica:   rep,
 		TTL:       ttl,
 		CreatedAt: ca,
+		Class:     r.URL.Query().Get("class"),
 	}, nil
 }
 
+// decodeKey returns the key of the File of the r and the
+// name of the Bucket it belongs to, if it has one
+func decodeKey(r *http.Request) (string, string) {
+	vars := mux.Vars(r)
+	if bn, ok := vars["bucket"]; ok {
+		return bucket.Key(bn, vars["key"]), bn
+	}
+	return vars["key"], ""
+}
+
+// decodeVersionKey returns the same as decodeKey but if the r
+// has the 'version' query parameter the key is the one
+// of that Version of the File
+func decodeVersionKey(r *http.Request) (string, string) {
+	key, bn := decodeKey(r)
+	if id := r.URL.Query().Get("version"); id != "" {
+		key = version.Key(key, id)
+	}
+	return key, bn
+}
+
+// decodeBody returns the body of the r, if it's a multipart
+// it'll return all the parts as one.
+// The body is also wrapped so the integrity information sent
+// by the client (Content-Length, Content-MD5, Digest and X-Rebost-SHA1)
+// is validated once it has been read completely
+func decodeBody(r *http.Request) io.ReadCloser {
+	r.Body = newDigestReader(r)
+
+	mr, _ := r.MultipartReader()
+	if mr == nil {
+		return r.Body
+	}
+
+	ppr, ppw := io.Pipe()
+
+	go func() {
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				ppw.Close()
+				return
+			}
+			if err != nil {
+				ppw.CloseWithError(err)
+				return
+			}
+			_, err = io.Copy(ppw, p)
+			if err != nil {
+				ppw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	return ppr
+}
+
 func encodeCreateFileResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if e, ok := response.(errorer); ok && e.error() != nil {
 		encodeError(ctx, e.error(), w)
@@ -150,8 +366,34 @@ func encodeCreateFileResponse(ctx context.Context, w http.ResponseWriter, respon
 }
 
 func decodeGetFileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	key, bn := decodeVersionKey(r)
 	return getFileRequest{
-		Key: mux.Vars(r)["key"],
+		Key:            key,
+		Bucket:         bn,
+		AcceptEncoding: r.Header.Get("Accept-Encoding"),
+		Redirect:       decodeRedirect(r),
+		URI:            r.URL.RequestURI(),
+	}, nil
+}
+
+// decodeRedirect returns the redirectMode of the r from the
+// RedirectHeader, if it's not set or invalid it's the configured one
+func decodeRedirect(r *http.Request) redirectMode {
+	rd, err := strconv.ParseBool(r.Header.Get(model.RedirectHeader))
+	if err != nil {
+		return redirectConfig
+	}
+	if rd {
+		return redirectAlways
+	}
+	return redirectNever
+}
+
+func decodeGetFileNodesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	key, bn := decodeVersionKey(r)
+	return getFileNodesRequest{
+		Key:    key,
+		Bucket: bn,
 	}, nil
 }
 
@@ -162,14 +404,31 @@ func encodeGetFileResponse(ctx context.Context, w http.ResponseWriter, response
 	}
 
 	gfr := response.(getFileResponse)
+	if gfr.Location != "" {
+		w.Header().Set("Location", gfr.Location)
+		w.WriteHeader(http.StatusTemporaryRedirect)
+		return nil
+	}
 	defer gfr.IORC.Close()
+
+	// If the file is stored compressed and the client
+	// accepts it we send it directly without decompressing it
+	if cr, ok := gfr.IORC.(*compression.Reader); ok && cr.Algorithm().IsAccepted(gfr.AcceptEncoding) {
+		w.Header().Set("Content-Encoding", string(cr.Algorithm()))
+		w.Header().Add("Vary", "Accept-Encoding")
+		_, err := io.Copy(w, cr.Raw())
+		return err
+	}
+
 	_, err := io.Copy(w, gfr.IORC)
 	return err
 }
 
 func decodeDeleteFileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	key, bn := decodeVersionKey(r)
 	return deleteFileRequest{
-		Key: mux.Vars(r)["key"],
+		Key:    key,
+		Bucket: bn,
 	}, nil
 }
 
@@ -183,8 +442,10 @@ func encodeDeleteFileResponse(ctx context.Context, w http.ResponseWriter, respon
 }
 
 func decodeHasFileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	key, bn := decodeVersionKey(r)
 	return hasFileRequest{
-		Key: mux.Vars(r)["key"],
+		Key:    key,
+		Bucket: bn,
 	}, nil
 }
 
@@ -204,30 +465,7 @@ func decodeGetConfigRequest(ctx context.Context, r *http.Request) (interface{},
 }
 
 func decodeCreateReplicaRequest(_ context.Context, r *http.Request) (interface{}, error) {
-	var iorc io.ReadCloser
-
-	if mr, _ := r.MultipartReader(); mr != nil {
-		ppr, ppw := io.Pipe()
-
-		go func() {
-			for {
-				p, err := mr.NextPart()
-				if err == io.EOF {
-					ppw.Close()
-					return
-				}
-				if err != nil {
-					log.Println(err)
-					return
-				}
-				io.Copy(ppw, p)
-			}
-		}()
-
-		iorc = ppr
-	} else {
-		iorc = r.Body
-	}
+	iorc := decodeBody(r)
 
 	ttl, err := time.ParseDuration(r.URL.Query().Get("ttl"))
 	if err != nil {
@@ -248,6 +486,7 @@ func decodeCreateReplicaRequest(_ context.Context, r *http.Request) (interface{}
 		Body:      iorc,
 		TTL:       ttl,
 		CreatedAt: ca,
+		Class:     r.URL.Query().Get("class"),
 	}, nil
 }
 
@@ -274,6 +513,248 @@ func encodeUpdateFileReplicaResponse(ctx context.Context, w http.ResponseWriter,
 	return nil
 }
 
+func decodeCreateBucketRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var mb model.Bucket
+	err := json.NewDecoder(r.Body).Decode(&mb)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", bucket.ErrInvalid, err)
+	}
+
+	// The name of the URL is the one used
+	mb.Name = mux.Vars(r)["bucket"]
+
+	b, err := model.ToBucket(mb)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", bucket.ErrInvalid, err)
+	}
+
+	return createBucketRequest{Bucket: b}, nil
+}
+
+func decodeGetBucketRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return getBucketRequest{Name: mux.Vars(r)["bucket"]}, nil
+}
+
+func decodeBucketsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeDeleteBucketRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return deleteBucketRequest{Name: mux.Vars(r)["bucket"]}, nil
+}
+
+func decodeSettingsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func decodeUpdateSettingsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var ms model.Settings
+	err := json.NewDecoder(r.Body).Decode(&ms)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", settings.ErrInvalid, err)
+	}
+
+	return updateSettingsRequest{Settings: model.ToSettings(ms)}, nil
+}
+
+func decodeVersionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	key, bn := decodeKey(r)
+	return versionsRequest{
+		Key:    key,
+		Bucket: bn,
+	}, nil
+}
+
+func decodeRestoreVersionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	key, bn := decodeKey(r)
+	return restoreVersionRequest{
+		Key:     key,
+		Bucket:  bn,
+		Version: r.URL.Query().Get("version"),
+	}, nil
+}
+
+func decodeTrashRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	local, _ := strconv.ParseBool(r.URL.Query().Get("local"))
+	return trashRequest{Local: local}, nil
+}
+
+func decodeRestoreTrashRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	local, _ := strconv.ParseBool(r.URL.Query().Get("local"))
+	return restoreTrashRequest{
+		Key:   mux.Vars(r)["key"],
+		ID:    r.URL.Query().Get("id"),
+		Local: local,
+	}, nil
+}
+
+func decodeTrashReplicaRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	da, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("deleted_at"))
+	if err != nil {
+		return nil, err
+	}
+
+	pa, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("purge_at"))
+	if err != nil {
+		return nil, err
+	}
+
+	return trashReplicaRequest{
+		Key:       mux.Vars(r)["key"],
+		DeletedAt: da,
+		PurgeAt:   pa,
+	}, nil
+}
+
+// decodeCopyFileRequest decodes the PUT with the model.CopySourceHeader,
+// where the key is the destination, and the COPY and MOVE with the
+// model.DestinationHeader, where the key is the source
+func decodeCopyFileRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ca, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("copied_at"))
+	if err != nil {
+		// If we can not transform the copied_at to a time
+		// we just set it emtpy
+		ca = time.Time{}
+	}
+
+	key, bn := decodeKey(r)
+	cfr := copyFileRequest{
+		Move:     r.Method == "MOVE",
+		CopiedAt: ca,
+	}
+
+	if r.Method == http.MethodPut {
+		cfr.Destination, cfr.DestinationBucket = key, bn
+		cfr.Source, cfr.SourceBucket, err = model.ParseFilePath(r.Header.Get(model.CopySourceHeader))
+	} else {
+		cfr.Source, cfr.SourceBucket = decodeVersionKey(r)
+		cfr.Destination, cfr.DestinationBucket, err = model.ParseFilePath(r.Header.Get(model.DestinationHeader))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cfr, nil
+}
+
+func decodeGetFileSignatureReplicaRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return getFileSignatureReplicaRequest{
+		Key: mux.Vars(r)["key"],
+	}, nil
+}
+
+func decodeCopyReplicaRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ca, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("copied_at"))
+	if err != nil {
+		return nil, err
+	}
+
+	move, _ := strconv.ParseBool(r.URL.Query().Get("move"))
+
+	return copyFileRequest{
+		Source:      mux.Vars(r)["key"],
+		Destination: r.URL.Query().Get("destination"),
+		Move:        move,
+		CopiedAt:    ca,
+	}, nil
+}
+
+func decodeUpdateFileTTLRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ttl, err := model.ParseTTL(r.URL.Query().Get("ttl"))
+	if err != nil {
+		return nil, err
+	}
+
+	key, bn := decodeKey(r)
+
+	return updateFileTTLRequest{
+		Key:    key,
+		Bucket: bn,
+		TTL:    ttl,
+	}, nil
+}
+
+func decodeUpdateFileTTLReplicaRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ttl, err := model.ParseTTL(r.URL.Query().Get("ttl"))
+	if err != nil {
+		return nil, err
+	}
+
+	return updateFileTTLRequest{
+		Key: mux.Vars(r)["key"],
+		TTL: ttl,
+	}, nil
+}
+
+func decodeBatchRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ct := r.Header.Get("Content-Type")
+	mops, err := model.DecodeBatch(r.Body, ct)
+	if err != nil {
+		return nil, err
+	}
+
+	local, _ := strconv.ParseBool(r.URL.Query().Get("local"))
+	mt, _, _ := mime.ParseMediaType(ct)
+
+	return batchRequest{
+		Operations: mops,
+		Local:      local,
+		NDJSON:     mt == model.NDJSONContentType,
+	}, nil
+}
+
+// encodeBatchResponse encodes the results as NDJSON, one per
+// line, if the request was NDJSON or as JSON if not
+func encodeBatchResponse(ctx context.Context, w http.ResponseWriter, resp interface{}) error {
+	br := resp.(batchResponse)
+	if br.Err != nil {
+		encodeError(ctx, br.Err, w)
+		return nil
+	}
+
+	if !br.NDJSON {
+		return encodeJSONResponse(ctx, w, response{Data: br.Results})
+	}
+
+	w.Header().Set("Content-Type", model.NDJSONContentType)
+	enc := json.NewEncoder(w)
+	for _, r := range br.Results {
+		err := enc.Encode(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batchStatusCode returns the HTTP status code that the
+// Operation of the r would have had as a single request
+func batchStatusCode(r *batch.Result) int {
+	if r.Err != nil {
+		return errorStatusCode(r.Err)
+	}
+	switch r.Op {
+	case batch.Copy, batch.Move:
+		return http.StatusCreated
+	default:
+		return http.StatusNoContent
+	}
+}
+
+func decodeQuotasRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func encodeNoContentResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func encodeJSONResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if e, ok := response.(errorer); ok && e.error() != nil {
 		encodeError(ctx, e.error(), w)
@@ -295,19 +776,43 @@ type errorer interface {
 
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	switch err.(type) {
+	w.WriteHeader(errorStatusCode(err))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
+
+// errorStatusCode returns the HTTP status code of the err
+func errorStatusCode(err error) int {
+	var (
+		derr *digestError
+		perr *presignError
+	)
+	switch {
+	case errors.As(err, &derr):
+		return http.StatusBadRequest
+	case errors.Is(err, presign.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &perr):
+		return http.StatusForbidden
+	case errors.Is(err, bucket.ErrInvalid), errors.Is(err, settings.ErrInvalid), errors.Is(err, model.ErrInvalidFilePath), errors.Is(err, batch.ErrInvalid), errors.Is(err, model.ErrInvalidTTL), errors.Is(err, model.ErrReservedKey):
+		return http.StatusBadRequest
+	case errors.Is(err, quota.ErrSizeExceeded):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, quota.ErrCountExceeded):
+		return http.StatusForbidden
+	case err.Error() == "not found":
+		return http.StatusNotFound
+	case err.Error() == "already exists":
+		return http.StatusConflict
 	//case errors.NotFound:
-	//w.WriteHeader(http.StatusNotFound)
+	//return http.StatusNotFound
 	//case errors.Invalid:
-	//w.WriteHeader(http.StatusBadRequest)
+	//return http.StatusBadRequest
 	//case errors.AlreadyExists:
-	//w.WriteHeader(http.StatusUnprocessableEntity)
+	//return http.StatusUnprocessableEntity
 	//case errors.Unexpected:
 	default:
-		w.WriteHeader(http.StatusInternalServerError)
+		return http.StatusInternalServerError
 	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": err.Error(),
-	})
 }
@@ -3,7 +3,13 @@ package storing_test
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,10 +21,18 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/batch"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/compression"
 	"github.com/xescugc/rebost/config"
 	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/presign"
+	"github.com/xescugc/rebost/quota"
+	"github.com/xescugc/rebost/settings"
 	"github.com/xescugc/rebost/storing"
 	"github.com/xescugc/rebost/storing/model"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/version"
 )
 
 func TestMakeHandler(t *testing.T) {
@@ -41,7 +55,7 @@ func TestMakeHandler(t *testing.T) {
 	server := httptest.NewServer(h)
 	client := server.Client()
 
-	st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), rep, ttl, timeMatcher{ca}).Do(func(_ context.Context, _ string, r io.Reader, _ int, _ time.Duration, _ time.Time) {
+	st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), rep, ttl, timeMatcher{ca}, "").Do(func(_ context.Context, _ string, r io.Reader, _ int, _ time.Duration, _ time.Time, _ string) {
 		b, err := io.ReadAll(r)
 		require.NoError(t, err)
 		assert.Equal(t, content, b)
@@ -55,7 +69,7 @@ func TestMakeHandler(t *testing.T) {
 		return "", false, nil
 	}).AnyTimes()
 	st.EXPECT().Config(gomock.Any()).Return(&cfg, nil)
-	st.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), ttl, timeMatcher{ca}).Do(func(_ context.Context, _ string, r io.Reader, _ time.Duration, _ time.Time) {
+	st.EXPECT().CreateReplica(gomock.Any(), key, gomock.Any(), ttl, timeMatcher{ca}, "").Do(func(_ context.Context, _ string, r io.Reader, _ time.Duration, _ time.Time, _ string) {
 		b, err := io.ReadAll(r)
 		require.NoError(t, err)
 		assert.Equal(t, content, b)
@@ -156,6 +170,836 @@ func TestMakeHandler(t *testing.T) {
 	}
 }
 
+func TestMakeHandlerBuckets(t *testing.T) {
+	var (
+		content = []byte("content")
+		ctrl    = gomock.NewController(t)
+		ca      = time.Now()
+		b       = &bucket.Bucket{Name: "logs", Replica: 3, TTL: time.Hour, Class: "cold", Policy: bucket.Private, UpdatedAt: ca.UTC()}
+		key     = bucket.Key(b.Name, "a/b.txt")
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().GetBucket(gomock.Any(), b.Name).Return(b, nil).AnyTimes()
+	st.EXPECT().GetBucket(gomock.Any(), "images").Return(nil, errors.New("not found")).AnyTimes()
+	st.EXPECT().Buckets(gomock.Any()).Return([]*bucket.Bucket{b}, nil)
+	st.EXPECT().CreateBucket(gomock.Any(), &bucket.Bucket{Name: "images", Replica: 2, TTL: time.Minute, Policy: bucket.PublicRead}).Return(nil)
+	st.EXPECT().CreateBucket(gomock.Any(), &bucket.Bucket{Name: "Images"}).Return(fmt.Errorf("%w: invalid name", bucket.ErrInvalid))
+	st.EXPECT().DeleteBucket(gomock.Any(), b.Name).Return(nil)
+
+	// The settings of the Bucket are used as default
+	st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), b.Replica, b.TTL, gomock.Any(), b.Class).Return(nil)
+	st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), 1, time.Minute, gomock.Any(), "hot").Return(nil)
+	st.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewBuffer(content)), nil)
+	st.EXPECT().DeleteFile(gomock.Any(), key).Return(nil)
+	st.EXPECT().HasFile(gomock.Any(), key).Return("vid", true, nil)
+
+	// The Nodes use the keys of the Buckets directly
+	st.EXPECT().Config(gomock.Any()).Return(&config.Config{}, nil)
+	st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), 0, time.Duration(0), gomock.Any(), "").Return(nil)
+
+	tests := []struct {
+		Name        string
+		URL         string
+		Method      string
+		Header      map[string]string
+		Body        []byte
+		EBody       func() []byte
+		EStatusCode int
+	}{
+		{
+			Name:        "CreateBucket",
+			URL:         "/buckets/images",
+			Method:      http.MethodPut,
+			Body:        []byte(`{"replica": 2, "ttl": "1m", "policy": "public-read"}`),
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "CreateBucketInvalid",
+			URL:         "/buckets/Images",
+			Method:      http.MethodPut,
+			Body:        []byte(`{}`),
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "CreateBucketInvalidTTL",
+			URL:         "/buckets/images",
+			Method:      http.MethodPut,
+			Body:        []byte(`{"ttl": "1 day"}`),
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "GetBucket",
+			URL:         "/buckets/logs",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				mb, _ := json.Marshal(model.BucketToModel(b))
+				return []byte(fmt.Sprintf(`{"data":%s}`, mb))
+			},
+		},
+		{
+			Name:        "GetBucketNotFound",
+			URL:         "/buckets/images",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:        "Buckets",
+			URL:         "/buckets",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				mb, _ := json.Marshal([]model.Bucket{model.BucketToModel(b)})
+				return []byte(fmt.Sprintf(`{"data":%s}`, mb))
+			},
+		},
+		{
+			Name:        "DeleteBucket",
+			URL:         "/buckets/logs",
+			Method:      http.MethodDelete,
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "CreateFile",
+			URL:         "/buckets/logs/files/a/b.txt",
+			Method:      http.MethodPut,
+			Body:        content,
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "CreateFileWithSettings",
+			URL:         "/buckets/logs/files/a/b.txt?replica=1&ttl=1m&class=hot",
+			Method:      http.MethodPut,
+			Body:        content,
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "CreateFileWithoutBucket",
+			URL:         "/buckets/images/files/a/b.txt",
+			Method:      http.MethodPut,
+			Body:        content,
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:   "GetFile",
+			URL:    "/buckets/logs/files/a/b.txt",
+			Method: http.MethodGet,
+			EBody: func() []byte {
+				return content
+			},
+			EStatusCode: http.StatusOK,
+		},
+		{
+			Name:        "DeleteFile",
+			URL:         "/buckets/logs/files/a/b.txt",
+			Method:      http.MethodDelete,
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "HasFile",
+			URL:         "/buckets/logs/files/a/b.txt",
+			Method:      http.MethodHead,
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "HasFileWithoutBucket",
+			URL:         "/buckets/images/files/a/b.txt",
+			Method:      http.MethodHead,
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:        "CreateFileWithKey",
+			URL:         "/files/@logs/a/b.txt",
+			Method:      http.MethodPut,
+			Body:        content,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "CreateFileWithKeyInternal",
+			URL:         "/files/@logs/a/b.txt",
+			Method:      http.MethodPut,
+			Header:      map[string]string{model.InternalHeader: "true"},
+			Body:        content,
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "GetFileWithKey",
+			URL:         "/files/@logs/a/b.txt",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "VersionsWithKey",
+			URL:         "/versions/@logs/a/b.txt",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "CopyFileWithKey",
+			URL:         "/buckets/logs/files/a/b.txt",
+			Method:      http.MethodPut,
+			Header:      map[string]string{model.CopySourceHeader: "/files/@images/a/b.txt"},
+			EStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, server.URL+tt.URL, bytes.NewBuffer(tt.Body))
+			require.NoError(t, err)
+			for k, v := range tt.Header {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			if tt.EBody != nil {
+				defer resp.Body.Close()
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.EBody(), b)
+			}
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerQuotas(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		sts  = []*quota.Status{
+			{Quota: quota.Quota{Prefix: "@logs/", Size: 100}, Usage: quota.Usage{Size: 10, Count: 1}},
+		}
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().Quotas(gomock.Any()).Return(sts, nil)
+	st.EXPECT().CreateFile(gomock.Any(), "size", gomock.Any(), 0, time.Duration(0), gomock.Any(), "").Return(fmt.Errorf("%w: full", quota.ErrSizeExceeded))
+	st.EXPECT().CreateFile(gomock.Any(), "count", gomock.Any(), 0, time.Duration(0), gomock.Any(), "").Return(fmt.Errorf("%w: full", quota.ErrCountExceeded))
+
+	tests := []struct {
+		Name        string
+		URL         string
+		Method      string
+		EBody       func() []byte
+		EStatusCode int
+	}{
+		{
+			Name:        "Quotas",
+			URL:         "/quotas",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				mq, _ := json.Marshal([]model.Quota{model.QuotaToModel(sts[0])})
+				return []byte(fmt.Sprintf(`{"data":%s}`, mq))
+			},
+		},
+		{
+			Name:        "CreateFileSizeExceeded",
+			URL:         "/files/size",
+			Method:      http.MethodPut,
+			EStatusCode: http.StatusInsufficientStorage,
+		},
+		{
+			Name:        "CreateFileCountExceeded",
+			URL:         "/files/count",
+			Method:      http.MethodPut,
+			EStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, server.URL+tt.URL, bytes.NewBufferString("content"))
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			if tt.EBody != nil {
+				defer resp.Body.Close()
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.EBody(), b)
+			}
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerSettings(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		st   = &settings.Settings{Replica: 2, Redirect: settings.RedirectNever}
+	)
+
+	s := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(s)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	s.EXPECT().Settings(gomock.Any()).Return(st, nil)
+	s.EXPECT().UpdateSettings(gomock.Any(), st).Return(nil)
+	s.EXPECT().UpdateSettings(gomock.Any(), &settings.Settings{Redirect: "sometimes"}).Return(fmt.Errorf("%w: unknown redirect", settings.ErrInvalid))
+
+	tests := []struct {
+		Name        string
+		Method      string
+		Body        string
+		EBody       []byte
+		EStatusCode int
+	}{
+		{
+			Name:        "Settings",
+			Method:      http.MethodGet,
+			EBody:       []byte(`{"data":{"replica":2,"redirect":"never"}}`),
+			EStatusCode: http.StatusOK,
+		},
+		{
+			Name:        "UpdateSettings",
+			Method:      http.MethodPut,
+			Body:        `{"replica":2,"redirect":"never"}`,
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "UpdateSettingsInvalid",
+			Method:      http.MethodPut,
+			Body:        `{"redirect":"sometimes"}`,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "UpdateSettingsInvalidJSON",
+			Method:      http.MethodPut,
+			Body:        `{"replica":`,
+			EStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, server.URL+"/settings", bytes.NewBufferString(tt.Body))
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			if tt.EBody != nil {
+				defer resp.Body.Close()
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.EBody, b)
+			}
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerVersions(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		vs   = []*version.Version{
+			{ID: "1-sig", Signature: "sig", Size: 7, CreatedAt: time.Now().UTC()},
+			{ID: "2-delete", DeleteMarker: true, CreatedAt: time.Now().UTC()},
+		}
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().Versions(gomock.Any(), "logs/a").Return(vs, nil)
+	st.EXPECT().HasVersions(gomock.Any(), "logs/a").Return("vid", true, nil)
+	st.EXPECT().RestoreVersion(gomock.Any(), "logs/a", "1-sig").Return(nil)
+	st.EXPECT().GetFile(gomock.Any(), version.Key("logs/a", "1-sig")).Return(io.NopCloser(bytes.NewBufferString("content")), nil)
+	st.EXPECT().GetBucket(gomock.Any(), "logs").Return(&bucket.Bucket{Name: "logs"}, nil)
+	st.EXPECT().Versions(gomock.Any(), "@logs/a").Return(vs, nil)
+
+	tests := []struct {
+		Name        string
+		URL         string
+		Method      string
+		EBody       func() []byte
+		EStatusCode int
+	}{
+		{
+			Name:        "Versions",
+			URL:         "/versions/logs/a",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				mvs, _ := json.Marshal([]model.Version{model.VersionToModel(vs[0]), model.VersionToModel(vs[1])})
+				return []byte(fmt.Sprintf(`{"data":%s}`, mvs))
+			},
+		},
+		{
+			Name:        "HasVersions",
+			URL:         "/versions/logs/a",
+			Method:      http.MethodHead,
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "RestoreVersion",
+			URL:         "/versions/logs/a?version=1-sig",
+			Method:      http.MethodPost,
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "GetFileVersion",
+			URL:         "/files/logs/a?version=1-sig",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				return []byte("content")
+			},
+		},
+		{
+			Name:        "GetFileVersionKey",
+			URL:         "/files/" + version.Key("logs/a", "1-sig"),
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "DeleteFileVersionKey",
+			URL:         "/files/" + version.Key("logs/a", "1-sig"),
+			Method:      http.MethodDelete,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "BucketVersions",
+			URL:         "/buckets/logs/versions/a",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				mvs, _ := json.Marshal([]model.Version{model.VersionToModel(vs[0]), model.VersionToModel(vs[1])})
+				return []byte(fmt.Sprintf(`{"data":%s}`, mvs))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, server.URL+tt.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			if tt.EBody != nil {
+				defer resp.Body.Close()
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.EBody(), b)
+			}
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerTrash(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		now  = time.Now().UTC()
+		its  = []*trash.Item{
+			{ID: "1", Key: "logs/a", Signature: "sig", Size: 7, DeletedAt: now, PurgeAt: now.Add(time.Hour)},
+		}
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().Trash(gomock.Any(), false).Return(its, nil)
+	st.EXPECT().Trash(gomock.Any(), true).Return(its, nil)
+	st.EXPECT().RestoreTrash(gomock.Any(), "logs/a", "1", false).Return(nil)
+	st.EXPECT().RestoreTrash(gomock.Any(), "logs/b", "1", true).Return(errors.New("already exists"))
+	st.EXPECT().TrashReplica(gomock.Any(), "logs/a", timeMatcher{t: now}, timeMatcher{t: now.Add(time.Hour)}).Return(nil)
+
+	tests := []struct {
+		Name        string
+		URL         string
+		Method      string
+		EBody       func() []byte
+		EStatusCode int
+	}{
+		{
+			Name:        "Trash",
+			URL:         "/trash",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+			EBody: func() []byte {
+				mits, _ := json.Marshal([]model.TrashItem{model.TrashItemToModel(its[0])})
+				return []byte(fmt.Sprintf(`{"data":%s}`, mits))
+			},
+		},
+		{
+			Name:        "TrashLocal",
+			URL:         "/trash?local=true",
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusOK,
+		},
+		{
+			Name:        "RestoreTrash",
+			URL:         "/trash/logs/a?id=1",
+			Method:      http.MethodPost,
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "RestoreTrashAlreadyExists",
+			URL:         "/trash/logs/b?id=1&local=true",
+			Method:      http.MethodPost,
+			EStatusCode: http.StatusConflict,
+		},
+		{
+			Name:        "GetFileTrashKey",
+			URL:         "/files/" + trash.Key("logs/a", "1"),
+			Method:      http.MethodGet,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "CreateFileTrashKey",
+			URL:         "/files/" + trash.Key("logs/a", "1"),
+			Method:      http.MethodPut,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "TrashReplica",
+			URL:         fmt.Sprintf("/replicas/logs/a?deleted_at=%s&purge_at=%s", now.Format(time.RFC3339Nano), now.Add(time.Hour).Format(time.RFC3339Nano)),
+			Method:      http.MethodDelete,
+			EStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, server.URL+tt.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+
+			if tt.EBody != nil {
+				defer resp.Body.Close()
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.EBody(), b)
+			}
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerCopy(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+		now  = time.Now().UTC()
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().GetBucket(gomock.Any(), "logs").Return(&bucket.Bucket{Name: "logs"}, nil).Times(3)
+	st.EXPECT().GetBucket(gomock.Any(), "potato").Return(nil, errors.New("not found"))
+	st.EXPECT().CopyFile(gomock.Any(), "a", "b", false, time.Time{}).Return(nil)
+	st.EXPECT().CopyFile(gomock.Any(), version.Key("a", "1"), "@logs/b", false, time.Time{}).Return(nil)
+	st.EXPECT().CopyFile(gomock.Any(), "a", "b", false, timeMatcher{t: now}).Return(nil)
+	st.EXPECT().CopyFile(gomock.Any(), "@logs/a", "@logs/b", true, time.Time{}).Return(nil)
+	st.EXPECT().CopyFile(gomock.Any(), "a", "c", true, time.Time{}).Return(errors.New("not found"))
+	st.EXPECT().CopyReplica(gomock.Any(), "a", "@logs/b", true, timeMatcher{t: now}).Return(nil)
+
+	tests := []struct {
+		Name        string
+		URL         string
+		Method      string
+		Headers     map[string]string
+		EStatusCode int
+	}{
+		{
+			Name:        "PutCopySource",
+			URL:         "/files/b",
+			Method:      http.MethodPut,
+			Headers:     map[string]string{model.CopySourceHeader: "/files/a"},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "PutCopySourceVersion",
+			URL:         "/buckets/logs/files/b",
+			Method:      http.MethodPut,
+			Headers:     map[string]string{model.CopySourceHeader: "/files/a?version=1"},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "PutCopySourceInvalid",
+			URL:         "/files/b",
+			Method:      http.MethodPut,
+			Headers:     map[string]string{model.CopySourceHeader: "/versions/a"},
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "Copy",
+			URL:         fmt.Sprintf("/files/a?copied_at=%s", now.Format(time.RFC3339Nano)),
+			Method:      "COPY",
+			Headers:     map[string]string{model.DestinationHeader: "/files/b"},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "MoveBucket",
+			URL:         "/buckets/logs/files/a",
+			Method:      "MOVE",
+			Headers:     map[string]string{model.DestinationHeader: "http://example.com/buckets/logs/files/b"},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "MoveNotFound",
+			URL:         "/files/a",
+			Method:      "MOVE",
+			Headers:     map[string]string{model.DestinationHeader: "/files/c"},
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:        "CopyBucketNotFound",
+			URL:         "/files/a",
+			Method:      "COPY",
+			Headers:     map[string]string{model.DestinationHeader: "/buckets/potato/files/a"},
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:        "CopyReplica",
+			URL:         fmt.Sprintf("/replicas/a?destination=%s&move=true&copied_at=%s", url.QueryEscape("@logs/b"), now.Format(time.RFC3339Nano)),
+			Method:      "COPY",
+			EStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, server.URL+tt.URL, nil)
+			require.NoError(t, err)
+			for k, v := range tt.Headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerTTL(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().GetBucket(gomock.Any(), "logs").Return(&bucket.Bucket{Name: "logs"}, nil)
+	st.EXPECT().GetBucket(gomock.Any(), "potato").Return(nil, errors.New("not found"))
+	st.EXPECT().UpdateFileTTL(gomock.Any(), "a", 24*time.Hour).Return(nil)
+	st.EXPECT().UpdateFileTTL(gomock.Any(), "@logs/a", time.Duration(0)).Return(nil)
+	st.EXPECT().UpdateFileTTL(gomock.Any(), "b", time.Hour).Return(errors.New("not found"))
+	st.EXPECT().UpdateFileTTLReplica(gomock.Any(), "a", time.Hour).Return(nil)
+	st.EXPECT().UpdateFileReplica(gomock.Any(), "a", []string{"vid"}, 2).Return(nil)
+	st.EXPECT().Config(gomock.Any()).Return(&config.Config{Auth: config.Auth{ClusterSecret: "secret"}}, nil)
+
+	tests := []struct {
+		Name        string
+		URL         string
+		Body        string
+		EStatusCode int
+	}{
+		{
+			Name:        "Update",
+			URL:         "/files/a?ttl=24h",
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "RemoveBucket",
+			URL:         "/buckets/logs/files/a?ttl=0",
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			Name:        "NotFound",
+			URL:         "/files/b?ttl=1h",
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:        "BucketNotFound",
+			URL:         "/buckets/potato/files/a?ttl=1h",
+			EStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:        "Invalid",
+			URL:         "/files/a?ttl=potato",
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "Negative",
+			URL:         "/files/a?ttl=-1h",
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			// The TTL can not be updated with a presigned URL
+			Name:        "Presigned",
+			URL:         "/files/a?ttl=1s&X-Rebost-Signature=x",
+			EStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:        "Replica",
+			URL:         "/replicas/a?ttl=1h",
+			EStatusCode: http.StatusNoContent,
+		},
+		{
+			// Without the ttl it's still the UpdateFileReplica
+			Name:        "UpdateFileReplica",
+			URL:         "/replicas/a",
+			Body:        `{"volume_ids":["vid"],"replica":2}`,
+			EStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPatch, server.URL+tt.URL, bytes.NewBufferString(tt.Body))
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestMakeHandlerBatch(t *testing.T) {
+	var (
+		ctrl = gomock.NewController(t)
+	)
+
+	st := mock.NewStoring(ctrl)
+	defer ctrl.Finish()
+
+	h := storing.MakeHandler(st)
+	server := httptest.NewServer(h)
+	client := server.Client()
+
+	st.EXPECT().GetBucket(gomock.Any(), "logs").Return(&bucket.Bucket{Name: "logs"}, nil)
+	st.EXPECT().GetBucket(gomock.Any(), "potato").Return(nil, errors.New("not found"))
+	st.EXPECT().Batch(gomock.Any(), []*batch.Operation{
+		{Op: batch.Head, Key: "a"},
+		{Op: batch.Move, Key: "@logs/a", Destination: "@logs/b"},
+	}, false).Return([]*batch.Result{
+		{Operation: batch.Operation{Op: batch.Head, Key: "a"}, VolumeID: "vid"},
+		{Operation: batch.Operation{Op: batch.Move, Key: "@logs/a", Destination: "@logs/b"}, Err: errors.New("not found")},
+	}, nil)
+	st.EXPECT().Batch(gomock.Any(), []*batch.Operation{
+		{Op: batch.Delete, Key: "a"},
+		{Op: batch.Copy, Key: "a", Destination: "b"},
+	}, true).Return([]*batch.Result{
+		{Operation: batch.Operation{Op: batch.Delete, Key: "a"}},
+		{Operation: batch.Operation{Op: batch.Copy, Key: "a", Destination: "b"}},
+	}, nil)
+
+	tests := []struct {
+		Name        string
+		URL         string
+		ContentType string
+		Body        string
+		EBody       string
+		EStatusCode int
+	}{
+		{
+			Name:        "JSON",
+			URL:         "/batch",
+			ContentType: "application/json",
+			Body:        `{"operations":[{"op":"head","key":"a"},{"op":"delete","key":"a","bucket":"potato"},{"op":"move","key":"a","bucket":"logs","destination":"b"}]}`,
+			EBody:       `{"data":[{"op":"head","key":"a","volume_id":"vid","status":204},{"op":"delete","key":"a","bucket":"potato","status":404,"error":"not found"},{"op":"move","key":"a","bucket":"logs","destination":"b","status":404,"error":"not found"}]}`,
+			EStatusCode: http.StatusOK,
+		},
+		{
+			Name:        "NDJSON",
+			URL:         "/batch?local=true",
+			ContentType: model.NDJSONContentType,
+			Body:        "{\"op\":\"delete\",\"key\":\"a\"}\n\n{\"op\":\"copy\",\"key\":\"a\",\"destination\":\"b\"}\n",
+			EBody:       "{\"op\":\"delete\",\"key\":\"a\",\"status\":204}\n{\"op\":\"copy\",\"key\":\"a\",\"destination\":\"b\",\"status\":201}\n",
+			EStatusCode: http.StatusOK,
+		},
+		{
+			Name:        "Invalid",
+			URL:         "/batch",
+			ContentType: "application/json",
+			Body:        `{"operations":`,
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "ReservedKey",
+			URL:         "/batch",
+			ContentType: "application/json",
+			Body:        `{"operations":[{"op":"copy","key":"a","destination":"@logs/b"}]}`,
+			EBody:       `{"error":"reserved key"}` + "\n",
+			EStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+tt.URL, bytes.NewBufferString(tt.Body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.ContentType)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			if tt.EBody != "" {
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.EBody, string(b))
+			}
+
+			require.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
 type timeMatcher struct {
 	t time.Time
 }
@@ -172,3 +1016,292 @@ func (tm timeMatcher) Matches(x interface{}) bool {
 func (tm timeMatcher) String() string {
 	return fmt.Sprintf("is equal to %s", tm.t.Format(time.RFC3339))
 }
+
+func TestCreateFileIntegrity(t *testing.T) {
+	var (
+		key     = "fileName"
+		content = []byte("content")
+		md5sum  = md5.Sum(content)
+		sha1sum = sha1.Sum(content)
+		sha256s = sha256.Sum256(content)
+		invalid = md5.Sum([]byte("invalid"))
+	)
+
+	tests := []struct {
+		Name        string
+		Header      http.Header
+		Trailer     http.Header
+		EStatusCode int
+	}{
+		{
+			Name:        "ValidContentMD5",
+			Header:      http.Header{"Content-Md5": []string{base64.StdEncoding.EncodeToString(md5sum[:])}},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "InvalidContentMD5",
+			Header:      http.Header{"Content-Md5": []string{base64.StdEncoding.EncodeToString(invalid[:])}},
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "ValidSHA1",
+			Header:      http.Header{"X-Rebost-Sha1": []string{hex.EncodeToString(sha1sum[:])}},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "InvalidSHA1",
+			Header:      http.Header{"X-Rebost-Sha1": []string{"potato"}},
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "ValidDigest",
+			Header:      http.Header{"Digest": []string{fmt.Sprintf("SHA-256=%s, unknown=1", base64.StdEncoding.EncodeToString(sha256s[:]))}},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "InvalidDigest",
+			Header:      http.Header{"Digest": []string{fmt.Sprintf("md5=%s", base64.StdEncoding.EncodeToString(invalid[:]))}},
+			EStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:        "ValidTrailer",
+			Trailer:     http.Header{"Digest": []string{fmt.Sprintf("md5=%s", base64.StdEncoding.EncodeToString(md5sum[:]))}},
+			EStatusCode: http.StatusCreated,
+		},
+		{
+			Name:        "InvalidTrailer",
+			Trailer:     http.Header{"Content-Md5": []string{base64.StdEncoding.EncodeToString(invalid[:])}},
+			EStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := mock.NewStoring(ctrl)
+			st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), 0, time.Duration(0), time.Time{}, "").DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int, _ time.Duration, _ time.Time, _ string) error {
+				_, err := io.ReadAll(r)
+				return err
+			})
+
+			server := httptest.NewServer(storing.MakeHandler(st))
+			defer server.Close()
+
+			var body io.Reader = bytes.NewBuffer(content)
+			if tt.Trailer != nil {
+				// To send Trailers the body can not have a known length
+				body = io.MultiReader(body)
+			}
+
+			req, err := http.NewRequest(http.MethodPut, server.URL+"/files/"+key, body)
+			require.NoError(t, err)
+			for k, v := range tt.Header {
+				req.Header[k] = v
+			}
+			req.Trailer = tt.Trailer
+
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.EStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestGetFileCompressed(t *testing.T) {
+	var (
+		key     = "fileName"
+		content = []byte("content")
+	)
+
+	var cb bytes.Buffer
+	w, err := compression.Gzip.NewWriter(&cb)
+	require.NoError(t, err)
+	_, err = w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	tests := []struct {
+		Name             string
+		AcceptEncoding   string
+		EContentEncoding string
+		EBody            []byte
+	}{
+		{
+			Name:  "WithoutAcceptEncoding",
+			EBody: content,
+		},
+		{
+			Name:           "WithOtherAcceptEncoding",
+			AcceptEncoding: "zstd, br",
+			EBody:          content,
+		},
+		{
+			Name:             "WithAcceptEncoding",
+			AcceptEncoding:   "br, gzip",
+			EContentEncoding: "gzip",
+			EBody:            cb.Bytes(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := mock.NewStoring(ctrl)
+			st.EXPECT().GetFile(gomock.Any(), key).Return(compression.Gzip.NewReader(io.NopCloser(bytes.NewReader(cb.Bytes()))), nil)
+
+			server := httptest.NewServer(storing.MakeHandler(st))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/files/"+key, nil)
+			require.NoError(t, err)
+			if tt.AcceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.AcceptEncoding)
+			}
+
+			// The compression is disabled so the http.Client
+			// does not decompress it automatically
+			client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			b, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.EContentEncoding, resp.Header.Get("Content-Encoding"))
+			assert.Equal(t, tt.EBody, b)
+		})
+	}
+}
+
+func TestPresigned(t *testing.T) {
+	var (
+		key     = "fileName"
+		content = []byte("content")
+		secret  = "secret"
+		cfg     = &config.Config{Auth: config.Auth{ClusterSecret: secret}}
+	)
+
+	tests := []struct {
+		Name   string
+		URL    presign.URL
+		Secret string
+		Method string
+		Key    string
+		Header http.Header
+		Body   []byte
+		Code   int
+	}{
+		{
+			Name:   "Get",
+			URL:    presign.URL{Method: http.MethodGet, Key: key, Expires: time.Now().Add(time.Minute)},
+			Secret: secret,
+			Method: http.MethodGet,
+			Key:    key,
+			Code:   http.StatusOK,
+		},
+		{
+			Name:   "Put",
+			URL:    presign.URL{Method: http.MethodPut, Key: key, Expires: time.Now().Add(time.Minute), ContentType: "text/plain", MaxSize: 10},
+			Secret: secret,
+			Method: http.MethodPut,
+			Key:    key,
+			Header: http.Header{"Content-Type": []string{"text/plain"}},
+			Body:   content,
+			Code:   http.StatusCreated,
+		},
+		{
+			Name:   "PutTooLarge",
+			URL:    presign.URL{Method: http.MethodPut, Key: key, Expires: time.Now().Add(time.Minute), MaxSize: 2},
+			Secret: secret,
+			Method: http.MethodPut,
+			Key:    key,
+			Body:   content,
+			Code:   http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:   "PutInvalidContentType",
+			URL:    presign.URL{Method: http.MethodPut, Key: key, Expires: time.Now().Add(time.Minute), ContentType: "text/plain"},
+			Secret: secret,
+			Method: http.MethodPut,
+			Key:    key,
+			Header: http.Header{"Content-Type": []string{"image/png"}},
+			Body:   content,
+			Code:   http.StatusForbidden,
+		},
+		{
+			Name:   "Expired",
+			URL:    presign.URL{Method: http.MethodGet, Key: key, Expires: time.Now().Add(-time.Minute)},
+			Secret: secret,
+			Method: http.MethodGet,
+			Key:    key,
+			Code:   http.StatusForbidden,
+		},
+		{
+			Name:   "InvalidSecret",
+			URL:    presign.URL{Method: http.MethodGet, Key: key, Expires: time.Now().Add(time.Minute)},
+			Secret: "potato",
+			Method: http.MethodGet,
+			Key:    key,
+			Code:   http.StatusForbidden,
+		},
+		{
+			Name:   "OtherMethod",
+			URL:    presign.URL{Method: http.MethodGet, Key: key, Expires: time.Now().Add(time.Minute)},
+			Secret: secret,
+			Method: http.MethodDelete,
+			Key:    key,
+			Code:   http.StatusForbidden,
+		},
+		{
+			Name:   "OtherKey",
+			URL:    presign.URL{Method: http.MethodGet, Key: key, Expires: time.Now().Add(time.Minute)},
+			Secret: secret,
+			Method: http.MethodGet,
+			Key:    "other",
+			Code:   http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			st := mock.NewStoring(ctrl)
+			st.EXPECT().Config(gomock.Any()).Return(cfg, nil)
+			st.EXPECT().GetFile(gomock.Any(), key).Return(io.NopCloser(bytes.NewReader(content)), nil).MaxTimes(1)
+			st.EXPECT().CreateFile(gomock.Any(), key, gomock.Any(), 0, time.Duration(0), gomock.Any(), "").DoAndReturn(func(_ context.Context, _ string, r io.Reader, _ int, _ time.Duration, _ time.Time, _ string) error {
+				_, err := io.ReadAll(r)
+				return err
+			}).MaxTimes(1)
+
+			server := httptest.NewServer(storing.MakeHandler(st))
+			defer server.Close()
+
+			// The URL is signed for tt.URL.Key so we replace it
+			// with the one requested
+			u, err := url.Parse(server.URL + presign.Sign([]byte(tt.Secret), tt.URL))
+			require.NoError(t, err)
+			u.Path = "/files/" + tt.Key
+
+			req, err := http.NewRequest(tt.Method, u.String(), bytes.NewReader(tt.Body))
+			require.NoError(t, err)
+			for k, v := range tt.Header {
+				req.Header[k] = v
+			}
+
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.Code, resp.StatusCode)
+		})
+	}
+}
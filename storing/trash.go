@@ -0,0 +1,129 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/volume"
+)
+
+func (s *service) Trash(ctx context.Context, local bool) ([]*trash.Item, error) {
+	its := make([]*trash.Item, 0)
+	for _, v := range s.members.LocalVolumes() {
+		vits, err := v.Trash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		its = append(its, vits...)
+	}
+
+	if !local {
+		for _, n := range s.members.Nodes() {
+			nits, err := n.Trash(ctx, true)
+			if err != nil {
+				s.logger.Log("msg", err.Error())
+				continue
+			}
+			its = append(its, nits...)
+		}
+	}
+
+	// The same Item is on all the
+	// replicas of the File
+	uits := make([]*trash.Item, 0, len(its))
+	seen := make(map[string]struct{})
+	for _, it := range its {
+		tk := trash.Key(it.Key, it.ID)
+		if _, ok := seen[tk]; ok {
+			continue
+		}
+		seen[tk] = struct{}{}
+		uits = append(uits, it)
+	}
+
+	sort.Slice(uits, func(i, j int) bool {
+		if uits[i].DeletedAt.Equal(uits[j].DeletedAt) {
+			return uits[i].Key < uits[j].Key
+		}
+		return uits[i].DeletedAt.Before(uits[j].DeletedAt)
+	})
+
+	return uits, nil
+}
+
+func (s *service) RestoreTrash(ctx context.Context, k, id string, local bool) error {
+	// All the Nodes are asked to restore it so
+	// all of them know it's no longer missing
+	s.missing.Remove(k)
+
+	var restored bool
+	for _, v := range s.members.LocalVolumes() {
+		err := v.RestoreTrash(ctx, k, id)
+		if err != nil {
+			if err.Error() == "not found" {
+				continue
+			}
+			return err
+		}
+		restored = true
+	}
+
+	if !local {
+		for _, n := range s.members.Nodes() {
+			err := n.RestoreTrash(ctx, k, id, true)
+			if err != nil {
+				if err.Error() != "not found" {
+					s.logger.Log("msg", err.Error())
+				}
+				continue
+			}
+			restored = true
+		}
+	}
+
+	if !restored {
+		return errors.New("not found")
+	}
+
+	return nil
+}
+
+func (s *service) TrashReplica(ctx context.Context, k string, da, pa time.Time) error {
+	for _, v := range s.members.LocalVolumes() {
+		err := v.TrashFile(ctx, k, da, pa)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// trashFile moves the k to the trash on the v and on all the
+// replicas of the cluster so it's purged at the same time on all of them
+func (s *service) trashFile(ctx context.Context, v volume.Local, k string) error {
+	da := time.Now()
+	pa := da.Add(s.cfg.Trash.Retention)
+
+	err := v.TrashFile(ctx, k, da, pa)
+	if err != nil {
+		return err
+	}
+
+	err = s.TrashReplica(ctx, k, da, pa)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range s.members.Nodes() {
+		err = n.TrashReplica(ctx, k, da, pa)
+		if err != nil {
+			s.logger.Log("msg", err.Error())
+		}
+	}
+
+	return nil
+}
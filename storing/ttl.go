@@ -0,0 +1,54 @@
+package storing
+
+import (
+	"context"
+	"time"
+
+	"github.com/xescugc/rebost/volume"
+)
+
+func (s *service) UpdateFileTTL(ctx context.Context, key string, ttl time.Duration) error {
+	return s.withVolume(ctx, key, func(v volume.Volume) error {
+		return s.updateFileTTL(ctx, v, key, ttl)
+	})
+}
+
+// updateFileTTL updates the TTL of the key from the v
+func (s *service) updateFileTTL(ctx context.Context, v volume.Volume, key string, ttl time.Duration) error {
+	// The Node that has it updates it on
+	// the rest of the replicas of the cluster
+	lv, ok := v.(volume.Local)
+	if !ok {
+		return v.UpdateFileTTL(ctx, key, ttl)
+	}
+
+	err := lv.UpdateFileTTL(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+
+	err = s.UpdateFileTTLReplica(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range s.members.Nodes() {
+		err = n.UpdateFileTTLReplica(ctx, key, ttl)
+		if err != nil {
+			s.logger.Log("msg", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *service) UpdateFileTTLReplica(ctx context.Context, key string, ttl time.Duration) error {
+	for _, v := range s.members.LocalVolumes() {
+		err := v.UpdateFileTTL(ctx, key, ttl)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package storing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/version"
+)
+
+func (s *service) Versions(ctx context.Context, k string) ([]*version.Version, error) {
+	_, v, err := s.findVolumeWith(ctx, localVolumesToVolumes(s.members.LocalVolumes()), k, hasVersions)
+	if err != nil && err.Error() != "not found" {
+		return nil, err
+	}
+
+	if v == nil {
+		_, v, err = s.findVolumeWith(ctx, clientsToVolumes(s.members.Nodes()), k, hasVersions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v.Versions(ctx, k)
+}
+
+func (s *service) HasVersions(ctx context.Context, k string) (string, bool, error) {
+	vid, v, err := s.findVolumeWith(ctx, localVolumesToVolumes(s.members.LocalVolumes()), k, hasVersions)
+	if err != nil && err.Error() != "not found" {
+		return "", false, err
+	}
+
+	if v != nil {
+		return vid, true, nil
+	}
+
+	return "", false, nil
+}
+
+func (s *service) RestoreVersion(ctx context.Context, k, id string) error {
+	vs, err := s.Versions(ctx, k)
+	if err != nil {
+		return err
+	}
+
+	h := version.History{Key: k, Versions: vs}
+	v, ok := h.Find(id)
+	if !ok || v.DeleteMarker {
+		return errors.New("not found")
+	}
+
+	r, err := s.GetFile(ctx, version.Key(k, id))
+	if err != nil {
+		return err
+	}
+
+	// The Version is stored again as a new one
+	// with the settings of the Bucket
+	var (
+		rep int
+		ttl time.Duration
+	)
+	if bn, ok := bucket.Name(k); ok {
+		b, err := s.GetBucket(ctx, bn)
+		if err != nil {
+			r.Close()
+			return err
+		}
+		rep = b.Replica
+		ttl = b.TTL
+	}
+
+	return s.CreateFile(ctx, k, r, rep, ttl, time.Time{}, v.Class)
+}
@@ -0,0 +1,17 @@
+package trash
+
+import "context"
+
+//go:generate mockgen -destination=../mock/trash_repository.go -mock_names=Repository=TrashRepository -package=mock github.com/xescugc/rebost/trash Repository
+
+// Repository is the interface that has to be fulfilled to interact with the Items
+type Repository interface {
+	CreateOrReplace(ctx context.Context, it *Item) error
+	Find(ctx context.Context, key, id string) (*Item, error)
+	Delete(ctx context.Context, key, id string) error
+
+	// All returns all the Items
+	All(ctx context.Context) ([]*Item, error)
+
+	DeleteAll(ctx context.Context) error
+}
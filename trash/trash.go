@@ -0,0 +1,60 @@
+package trash
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prefix is the prefix of the keys of the Items,
+// which are hidden from the normal keys
+const prefix = "~trash/"
+
+// Item is a deleted key that is kept on
+// the trash until the PurgeAt
+type Item struct {
+	ID string
+
+	// Key is the original key of the File
+	Key string
+
+	Signature string
+	Size      int
+
+	DeletedAt time.Time
+	PurgeAt   time.Time
+
+	// ExpiresAt is the expiration the key had on its
+	// own, which it gets back when it's restored
+	ExpiresAt time.Time
+}
+
+// NewID returns the ID of an Item deleted at da. It's
+// deterministic so the replicas of the same File have the same ID
+func NewID(da time.Time) string {
+	return strconv.FormatInt(da.UnixNano(), 10)
+}
+
+// Key returns the internal key in which the
+// Item id of the key k is stored
+func Key(k, id string) string {
+	return prefix + id + "/" + k
+}
+
+// IsKey checks if the k is the key of an Item
+func IsKey(k string) bool {
+	return strings.HasPrefix(k, prefix)
+}
+
+// Split returns the key and the ID of the Item
+// of the k, if it's the key of an Item
+func Split(k string) (string, string, bool) {
+	if !IsKey(k) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(k, prefix), "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[1], parts[0], true
+}
@@ -0,0 +1,29 @@
+package trash_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/trash"
+)
+
+func TestNewID(t *testing.T) {
+	assert.Equal(t, "1000000000005", trash.NewID(time.Unix(1000, 5)))
+}
+
+func TestKey(t *testing.T) {
+	tk := trash.Key("@logs/a", "1000")
+
+	assert.Equal(t, "~trash/1000/@logs/a", tk)
+	assert.True(t, trash.IsKey(tk))
+	assert.False(t, trash.IsKey("@logs/a"))
+
+	k, id, ok := trash.Split(tk)
+	assert.True(t, ok)
+	assert.Equal(t, "@logs/a", k)
+	assert.Equal(t, "1000", id)
+
+	_, _, ok = trash.Split("@logs/a")
+	assert.False(t, ok)
+}
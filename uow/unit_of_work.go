@@ -10,12 +10,16 @@ import (
 	"context"
 
 	"github.com/spf13/afero"
+	"github.com/xescugc/rebost/apikey"
+	"github.com/xescugc/rebost/bucket"
 	"github.com/xescugc/rebost/file"
 	"github.com/xescugc/rebost/idxkey"
 	"github.com/xescugc/rebost/idxttl"
 	"github.com/xescugc/rebost/idxvolume"
 	"github.com/xescugc/rebost/replica"
 	"github.com/xescugc/rebost/state"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/version"
 )
 
 //go:generate mockgen -destination=../mock/unit_of_work.go -mock_names=UnitOfWork=UnitOfWork -package mock github.com/xescugc/rebost/uow UnitOfWork
@@ -42,6 +46,10 @@ type UnitOfWork interface {
 	Fs() afero.Fs
 	Replicas() replica.Repository
 	State() state.Repository
+	Buckets() bucket.Repository
+	Versions() version.Repository
+	Trash() trash.Repository
+	APIKeys() apikey.Repository
 }
 
 // StartUnitOfWork it's the way to initialize a typed UoW, it has a uowFn
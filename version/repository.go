@@ -0,0 +1,18 @@
+package version
+
+import "context"
+
+//go:generate mockgen -destination=../mock/version_repository.go -mock_names=Repository=VersionRepository -package=mock github.com/xescugc/rebost/version Repository
+
+// Repository is the interface that has to be fulfilled to interact with the History
+// of the Versions of the keys
+type Repository interface {
+	CreateOrReplace(ctx context.Context, h *History) error
+	FindByKey(ctx context.Context, k string) (*History, error)
+	DeleteByKey(ctx context.Context, k string) error
+
+	// All returns the History of all the keys
+	All(ctx context.Context) ([]*History, error)
+
+	DeleteAll(ctx context.Context) error
+}
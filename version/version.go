@@ -0,0 +1,128 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// prefix is the prefix of the keys of the Versions,
+// which are hidden from the normal keys
+const prefix = "~versions/"
+
+// Version is one of the versions of the File
+// stored with a key
+type Version struct {
+	ID string
+
+	// Signature is the one of the File
+	// of the Version, empty if it's a DeleteMarker
+	Signature string
+
+	Size  int
+	Class string
+
+	CreatedAt time.Time
+
+	// DeleteMarker means that the key
+	// was deleted on this Version
+	DeleteMarker bool
+}
+
+// History is the list of Versions of the Key
+// ordered from the oldest to the newest
+type History struct {
+	Key      string
+	Versions []*Version
+}
+
+// NewID returns the ID of a Version created at ca with the
+// signature sig. It's deterministic so the replicas of
+// the same File have the same ID
+func NewID(ca time.Time, sig string) string {
+	if i := strings.Index(sig, ":"); i != -1 {
+		sig = sig[i+1:]
+	}
+	if len(sig) > 12 {
+		sig = sig[:12]
+	}
+	return fmt.Sprintf("%d-%s", ca.Unix(), sig)
+}
+
+// Key returns the internal key in which the
+// Version id of the key k is stored
+func Key(k, id string) string {
+	return prefix + id + "/" + k
+}
+
+// IsKey checks if the k is the key of a Version
+func IsKey(k string) bool {
+	return strings.HasPrefix(k, prefix)
+}
+
+// Split returns the key and the ID of the Version
+// of the k, if it's the key of a Version
+func Split(k string) (string, string, bool) {
+	if !IsKey(k) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(k, prefix), "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[1], parts[0], true
+}
+
+// Find returns the Version with the id
+func (h *History) Find(id string) (*Version, bool) {
+	for _, v := range h.Versions {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Add adds the v as the newest Version, if it's
+// already on the History it returns false
+func (h *History) Add(v *Version) bool {
+	if _, ok := h.Find(v.ID); ok {
+		return false
+	}
+	h.Versions = append(h.Versions, v)
+	return true
+}
+
+// Remove removes the Version with the id
+func (h *History) Remove(id string) {
+	vs := make([]*Version, 0, len(h.Versions))
+	for _, v := range h.Versions {
+		if v.ID != id {
+			vs = append(vs, v)
+		}
+	}
+	h.Versions = vs
+}
+
+// Prune returns the noncurrent Versions (all except the
+// newest) that are over the max number of Versions or that were
+// created before the maxAge from now. The 0 values mean no limit
+func (h *History) Prune(max int, maxAge time.Duration, now time.Time) []*Version {
+	if len(h.Versions) < 2 {
+		return nil
+	}
+
+	nc := h.Versions[:len(h.Versions)-1]
+	prune := make([]*Version, 0)
+	for i, v := range nc {
+		if max != 0 && len(nc)-i > max {
+			prune = append(prune, v)
+			continue
+		}
+		if maxAge != 0 && v.CreatedAt.Add(maxAge).Before(now) {
+			prune = append(prune, v)
+		}
+	}
+
+	return prune
+}
@@ -0,0 +1,80 @@
+package version_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/rebost/version"
+)
+
+func TestNewID(t *testing.T) {
+	ca := time.Unix(1000, 0)
+
+	assert.Equal(t, "1000-0123456789ab", version.NewID(ca, "sha1:0123456789abcdef"))
+	assert.Equal(t, "1000-delete", version.NewID(ca, "delete"))
+}
+
+func TestKey(t *testing.T) {
+	vk := version.Key("@logs/a", "1000-delete")
+
+	assert.Equal(t, "~versions/1000-delete/@logs/a", vk)
+	assert.True(t, version.IsKey(vk))
+	assert.False(t, version.IsKey("@logs/a"))
+
+	k, id, ok := version.Split(vk)
+	assert.True(t, ok)
+	assert.Equal(t, "@logs/a", k)
+	assert.Equal(t, "1000-delete", id)
+
+	_, _, ok = version.Split("@logs/a")
+	assert.False(t, ok)
+}
+
+func TestHistory(t *testing.T) {
+	h := &version.History{Key: "a"}
+
+	assert.True(t, h.Add(&version.Version{ID: "1"}))
+	assert.True(t, h.Add(&version.Version{ID: "2"}))
+	assert.False(t, h.Add(&version.Version{ID: "1"}))
+	assert.Len(t, h.Versions, 2)
+
+	v, ok := h.Find("2")
+	assert.True(t, ok)
+	assert.Equal(t, "2", v.ID)
+
+	h.Remove("1")
+	assert.Equal(t, []*version.Version{{ID: "2"}}, h.Versions)
+
+	_, ok = h.Find("1")
+	assert.False(t, ok)
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	h := &version.History{
+		Key: "a",
+		Versions: []*version.Version{
+			{ID: "1", CreatedAt: now.Add(-3 * time.Hour)},
+			{ID: "2", CreatedAt: now.Add(-2 * time.Hour)},
+			{ID: "3", CreatedAt: now.Add(-1 * time.Hour)},
+			{ID: "4", CreatedAt: now.Add(-4 * time.Hour)},
+		},
+	}
+
+	t.Run("NoLimits", func(t *testing.T) {
+		assert.Empty(t, h.Prune(0, 0, now))
+	})
+	t.Run("MaxVersions", func(t *testing.T) {
+		assert.Equal(t, h.Versions[:2], h.Prune(1, 0, now))
+	})
+	t.Run("MaxAge", func(t *testing.T) {
+		// The current Version is never pruned
+		// even if it's older than the MaxAge
+		assert.Equal(t, h.Versions[:2], h.Prune(0, 90*time.Minute, now))
+	})
+	t.Run("OnlyCurrent", func(t *testing.T) {
+		h := &version.History{Versions: []*version.Version{{ID: "1"}}}
+		assert.Empty(t, h.Prune(1, time.Second, now))
+	})
+}
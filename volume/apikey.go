@@ -0,0 +1,53 @@
+package volume
+
+import (
+	"context"
+
+	"github.com/xescugc/rebost/apikey"
+	"github.com/xescugc/rebost/uow"
+)
+
+func (l *local) APIKeys(ctx context.Context) ([]*apikey.Key, error) {
+	var (
+		keys []*apikey.Key
+		err  error
+	)
+
+	err = l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		keys, err = uw.APIKeys().All(ctx)
+		if err != nil {
+			return err
+		}
+		return nil
+	}, l.apikeys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (l *local) PutAPIKey(ctx context.Context, k *apikey.Key) error {
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		ok, err := uw.APIKeys().FindByID(ctx, k.ID)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+
+		// As the Keys are propagated through the cluster
+		// the same (or an older) version can be received
+		// more than once
+		if !k.IsNewer(ok) {
+			return nil
+		}
+
+		return uw.APIKeys().CreateOrReplace(ctx, k)
+	}, l.apikeys)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
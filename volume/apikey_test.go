@@ -0,0 +1,74 @@
+package volume_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/apikey"
+)
+
+func TestAPIKeys(t *testing.T) {
+	var (
+		mv   = newManageVolume(t, "/")
+		ctx  = context.Background()
+		keys = []*apikey.Key{{ID: "logs", Secret: "logs"}, {ID: "images", Deleted: true}}
+	)
+	defer mv.Finish()
+
+	mv.APIKeys.EXPECT().All(ctx).Return(keys, nil)
+
+	rkeys, err := mv.V.APIKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, keys, rkeys)
+}
+
+func TestPutAPIKey(t *testing.T) {
+	var (
+		now = time.Now()
+	)
+	t.Run("SuccessNew", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			k   = &apikey.Key{ID: "logs", Secret: "logs", UpdatedAt: now}
+		)
+		defer mv.Finish()
+
+		mv.APIKeys.EXPECT().FindByID(ctx, k.ID).Return(nil, errors.New("not found"))
+		mv.APIKeys.EXPECT().CreateOrReplace(ctx, k).Return(nil)
+
+		err := mv.V.PutAPIKey(ctx, k)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessNewer", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			k   = &apikey.Key{ID: "logs", Deleted: true, UpdatedAt: now}
+		)
+		defer mv.Finish()
+
+		mv.APIKeys.EXPECT().FindByID(ctx, k.ID).Return(&apikey.Key{ID: "logs", Secret: "logs", UpdatedAt: now.Add(-time.Minute)}, nil)
+		mv.APIKeys.EXPECT().CreateOrReplace(ctx, k).Return(nil)
+
+		err := mv.V.PutAPIKey(ctx, k)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessOlder", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			k   = &apikey.Key{ID: "logs", Secret: "logs", UpdatedAt: now.Add(-time.Minute)}
+		)
+		defer mv.Finish()
+
+		mv.APIKeys.EXPECT().FindByID(ctx, k.ID).Return(&apikey.Key{ID: "logs", Deleted: true, UpdatedAt: now}, nil)
+
+		err := mv.V.PutAPIKey(ctx, k)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,74 @@
+package volume
+
+import (
+	"context"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/uow"
+)
+
+func (l *local) GetBucket(ctx context.Context, name string) (*bucket.Bucket, error) {
+	var (
+		b   *bucket.Bucket
+		err error
+	)
+
+	err = l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		b, err = uw.Buckets().FindByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		return nil
+	}, l.buckets)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (l *local) Buckets(ctx context.Context) ([]*bucket.Bucket, error) {
+	var (
+		bks []*bucket.Bucket
+		err error
+	)
+
+	err = l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		bks, err = uw.Buckets().All(ctx)
+		if err != nil {
+			return err
+		}
+		return nil
+	}, l.buckets)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bks, nil
+}
+
+func (l *local) PutBucket(ctx context.Context, b *bucket.Bucket) error {
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		ob, err := uw.Buckets().FindByName(ctx, b.Name)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+
+		// As the Buckets are propagated through the cluster
+		// the same (or an older) version can be received
+		// more than once
+		if !b.IsNewer(ob) {
+			return nil
+		}
+
+		return uw.Buckets().CreateOrReplace(ctx, b)
+	}, l.buckets)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package volume_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/bucket"
+)
+
+func TestGetBucket(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			b   = &bucket.Bucket{Name: "logs", Policy: bucket.Private}
+		)
+		defer mv.Finish()
+
+		mv.Buckets.EXPECT().FindByName(ctx, b.Name).Return(b, nil)
+
+		rb, err := mv.V.GetBucket(ctx, b.Name)
+		require.NoError(t, err)
+		assert.Equal(t, b, rb)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.Buckets.EXPECT().FindByName(ctx, "logs").Return(nil, errors.New("not found"))
+
+		_, err := mv.V.GetBucket(ctx, "logs")
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestBuckets(t *testing.T) {
+	var (
+		mv  = newManageVolume(t, "/")
+		ctx = context.Background()
+		bks = []*bucket.Bucket{{Name: "logs"}, {Name: "images", Deleted: true}}
+	)
+	defer mv.Finish()
+
+	mv.Buckets.EXPECT().All(ctx).Return(bks, nil)
+
+	rbks, err := mv.V.Buckets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, bks, rbks)
+}
+
+func TestPutBucket(t *testing.T) {
+	var (
+		now = time.Now()
+	)
+	t.Run("SuccessNew", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			b   = &bucket.Bucket{Name: "logs", UpdatedAt: now}
+		)
+		defer mv.Finish()
+
+		mv.Buckets.EXPECT().FindByName(ctx, b.Name).Return(nil, errors.New("not found"))
+		mv.Buckets.EXPECT().CreateOrReplace(ctx, b).Return(nil)
+
+		err := mv.V.PutBucket(ctx, b)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessNewer", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			b   = &bucket.Bucket{Name: "logs", Replica: 3, UpdatedAt: now}
+		)
+		defer mv.Finish()
+
+		mv.Buckets.EXPECT().FindByName(ctx, b.Name).Return(&bucket.Bucket{Name: "logs", UpdatedAt: now.Add(-time.Minute)}, nil)
+		mv.Buckets.EXPECT().CreateOrReplace(ctx, b).Return(nil)
+
+		err := mv.V.PutBucket(ctx, b)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessOlder", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			b   = &bucket.Bucket{Name: "logs", Replica: 3, UpdatedAt: now.Add(-time.Minute)}
+		)
+		defer mv.Finish()
+
+		mv.Buckets.EXPECT().FindByName(ctx, b.Name).Return(&bucket.Bucket{Name: "logs", UpdatedAt: now}, nil)
+
+		err := mv.V.PutBucket(ctx, b)
+		require.NoError(t, err)
+	})
+}
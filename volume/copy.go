@@ -0,0 +1,122 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/uow"
+)
+
+func (l *local) CopyFile(ctx context.Context, src, dst string, move bool, ca time.Time) error {
+	if ca.IsZero() {
+		ca = time.Now()
+	}
+
+	return l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		ik, err := uw.IDXKeys().FindByKey(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		dbf, err := uw.Files().FindBySignature(ctx, ik.Value)
+		if err != nil {
+			return err
+		}
+
+		// It's not yet removed by the TTL but
+		// it can no longer be read so it's not copied
+		now := time.Now()
+		if dbf.IsExpired(now) || ik.IsExpired(now) {
+			return errors.New("not found")
+		}
+
+		if src == dst {
+			return nil
+		}
+
+		dik, err := uw.IDXKeys().FindByKey(ctx, dst)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+
+		// If the dst already points to the same File it has
+		// already been copied, it happens when the same
+		// copy is done again on the replicas
+		if dik == nil || dik.Value != ik.Value {
+			// The dst is replaced so it's removed
+			// from the File it had before
+			if dik != nil {
+				err = l.deleteFile(ctx, uw, dst)
+				if err != nil {
+					return err
+				}
+			}
+
+			// The moved key keeps the expiration of the src,
+			// the one of the File or its own if it's earlier
+			ea := ik.ExpiresAt
+			if dbf.TTL != noTTL && (ea.IsZero() || dbf.ExpiresAt().Before(ea)) {
+				ea = dbf.ExpiresAt()
+			}
+
+			// The TTL of the File would also expire the
+			// dst so it's moved to the keys it already has
+			err = l.moveFileTTLToKeys(ctx, uw, dbf, "")
+			if err != nil {
+				return err
+			}
+
+			dbf.Keys = append(dbf.Keys, dst)
+
+			err = uw.Files().CreateOrReplace(ctx, dbf)
+			if err != nil {
+				return err
+			}
+
+			// The copy is a new key but the moved
+			// one keeps its age and its expiration
+			nik := idxkey.New(dst, dbf.Signature)
+			nik.CreatedAt = ca
+			if move {
+				nik.CreatedAt = ik.CreatedAt
+			}
+			err = uw.IDXKeys().CreateOrReplace(ctx, nik)
+			if err != nil {
+				return err
+			}
+			l.keysFilter.add(dst)
+
+			err = l.updateUsage(ctx, uw, dbf, 1, dst)
+			if err != nil {
+				return err
+			}
+
+			if move {
+				err = l.setKeyTTL(ctx, uw, nik, ea)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = l.putVersion(ctx, uw, dst, dbf, ca)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !move {
+			return nil
+		}
+
+		// The File still has the dst
+		// so only the key is removed
+		err = l.deleteFile(ctx, uw, src)
+		if err != nil {
+			return err
+		}
+
+		return l.addDeleteMarker(ctx, uw, src, ca)
+	}, l.idxkeys, l.files, l.idxttls, l.fs, l.state, l.buckets, l.versions, l.trash)
+}
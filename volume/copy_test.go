@@ -0,0 +1,163 @@
+package volume_test
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxttl"
+)
+
+func TestCopyFile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+			ca        = time.Now()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(nil, errors.New("not found"))
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature}, nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: signature}).Return(nil)
+		// The copy is a new key with its own age
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: signature, CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CopyFile(ctx, "a", "b", false, ca)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessMove", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+			ca        = time.Now()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(&idxkey.IDXKey{Key: "a", Value: signature, CreatedAt: ca.Add(-time.Hour)}, nil).Times(2)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(nil, errors.New("not found"))
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature}, nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a", "b"}, Signature: signature}, nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: signature}).Return(nil)
+		// The moved key keeps the age of the src
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: signature, CreatedAt: ca.Add(-time.Hour)}).Return(nil)
+
+		// Only the key is removed as the
+		// content is still used by the dst
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"b"}, Signature: signature}).Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, "a").Return(nil)
+
+		err := mv.V.CopyFile(ctx, "a", "b", true, ca)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessReplace", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+			ca        = time.Now()
+			osig      = "321321321"
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(idxkey.New("b", osig), nil).Times(2)
+
+		// The old content of the dst is removed
+		mv.Files.EXPECT().FindBySignature(ctx, osig).Return(&file.File{Keys: []string{"b"}, Signature: osig, Size: 5}, nil)
+		mv.Files.EXPECT().DeleteBySignature(ctx, osig).Return(nil)
+		mv.Fs.EXPECT().Remove(file.Path(path.Join("/", "file"), osig)).Return(nil)
+		expectUpdateState(t, mv, ctx, -5)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, "b").Return(nil)
+
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature}, nil)
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: signature}).Return(nil)
+		// The copy is a new key with its own age
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: signature, CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CopyFile(ctx, "a", "b", false, ca)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessAlreadyCopied", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a", "b"}, Signature: signature}, nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(idxkey.New("b", signature), nil)
+
+		err := mv.V.CopyFile(ctx, "a", "b", false, time.Now())
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithFileTTL", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+			ca        = time.Now()
+			fca       = ca.Add(-time.Minute)
+			ea        = fca.Add(time.Hour)
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil).Times(2)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(nil, errors.New("not found"))
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature, TTL: time.Hour, CreatedAt: fca}, nil)
+
+		// The TTL of the File is moved to the src
+		// so the dst does not expire with it
+		mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(idxttl.New(ea, signature), nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, ea).Return(nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ea, Keys: []string{"a"}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "a", Value: signature, ExpiresAt: ea}).Return(nil)
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a"}, Signature: signature, CreatedAt: fca}).Return(nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: signature, CreatedAt: fca}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: signature, CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CopyFile(ctx, "a", "b", false, ca)
+		require.NoError(t, err)
+	})
+	t.Run("Expired", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(&idxkey.IDXKey{Key: "a", Value: signature, ExpiresAt: time.Now().Add(-time.Second)}, nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature}, nil)
+
+		err := mv.V.CopyFile(ctx, "a", "b", false, time.Now())
+		assert.EqualError(t, err, "not found")
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(nil, errors.New("not found"))
+
+		err := mv.V.CopyFile(ctx, "a", "b", false, time.Now())
+		assert.EqualError(t, err, "not found")
+	})
+}
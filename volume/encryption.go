@@ -0,0 +1,153 @@
+package volume
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/encryption"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/uow"
+)
+
+// contentWriter is the writer of the content
+// of a file as it's stored on the disk
+type contentWriter struct {
+	cw io.WriteCloser
+	ew io.WriteCloser
+}
+
+func (w contentWriter) Write(p []byte) (int, error) { return w.cw.Write(p) }
+
+// Close closes first the compression
+// and then the encryption
+func (w contentWriter) Close() error {
+	err := w.cw.Close()
+	if err != nil {
+		return err
+	}
+	return w.ew.Close()
+}
+
+// newContentWriter returns a writer that stores the content on the w
+// compressed with the comp and, if there is a keyring, encrypted with
+// a new data key which is returned wrapped with the ID of the master key.
+// The content is first compressed and then encrypted as the encrypted
+// content can not be compressed
+func (l *local) newContentWriter(w io.Writer, comp compression.Algorithm) (io.WriteCloser, string, []byte, error) {
+	var (
+		ekid         string
+		encryptedKey []byte
+	)
+	// With no compression the content is written as it is
+	ew, err := compression.None.NewWriter(w)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if l.keyring != nil {
+		var dk []byte
+		ekid, dk, encryptedKey, err = l.keyring.GenerateDataKey()
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		ew, err = encryption.NewWriter(w, dk)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	cw, err := comp.NewWriter(ew)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return contentWriter{cw: cw, ew: ew}, ekid, encryptedKey, nil
+}
+
+// loopRewrapKeys wraps again the data keys each
+// time the master key of the keyring is rotated
+func (l *local) loopRewrapKeys() {
+	var rewrapped string
+
+	tk := time.NewTicker(TickerDuration)
+	defer tk.Stop()
+	for {
+		if c := l.keyring.Current(); c != rewrapped {
+			err := l.rewrapKeys()
+			if err != nil {
+				l.logger.Log("msg", err.Error())
+			} else {
+				rewrapped = c
+			}
+		}
+
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-tk.C:
+		}
+	}
+}
+
+// rewrapKeys wraps again with the current master key all the data keys of
+// the files that were wrapped with a different one, which means the master
+// key has been rotated. The content of the files is not changed
+func (l *local) rewrapKeys() error {
+	var n int
+	err := l.startUnitOfWork(l.ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		fs, err := uw.Files().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range fs {
+			ok, err := l.rewrapKey(f)
+			if err != nil {
+				l.logger.Log("msg", err.Error(), "signature", f.Signature)
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			err = uw.Files().CreateOrReplace(ctx, f)
+			if err != nil {
+				return err
+			}
+			n++
+		}
+
+		return nil
+	}, l.files)
+	if err != nil {
+		return err
+	}
+
+	if n != 0 {
+		l.logger.Log("msg", "rewrapped the data keys with the current master key", "files", n, "key", l.keyring.Current())
+	}
+
+	return nil
+}
+
+// rewrapKey wraps again the data key of the f with the current master key
+// if it was wrapped with a different one and returns if it was. The master
+// key can also be rotated while the content of a file is written, so it's
+// checked before storing it
+func (l *local) rewrapKey(f *file.File) (bool, error) {
+	if l.keyring == nil || !f.IsEncrypted() || f.EncryptionKeyID == l.keyring.Current() {
+		return false, nil
+	}
+
+	id, ek, err := l.keyring.Rewrap(f.EncryptionKeyID, f.EncryptedKey)
+	if err != nil {
+		return false, err
+	}
+
+	f.EncryptionKeyID = id
+	f.EncryptedKey = ek
+
+	return true, nil
+}
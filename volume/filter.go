@@ -0,0 +1,118 @@
+package volume
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xescugc/rebost/bloom"
+	"github.com/xescugc/rebost/uow"
+)
+
+const (
+	// keysFilterFalsePositive is the false positive
+	// probability of the KeysFilter
+	keysFilterFalsePositive = 0.01
+
+	// keysFilterMargin is the minimum number of keys that
+	// can be added to the KeysFilter before it has
+	// to be calculated again with a bigger size
+	keysFilterMargin = 1024
+)
+
+// keysFilter is the bloom.Filter of the keys of the volume, which is
+// updated with each key added. The removed keys can not be removed
+// from it so they are counted and once there are too many, or more
+// keys than the ones it was sized for, it's calculated again
+type keysFilter struct {
+	mx sync.Mutex
+
+	filter *bloom.Filter
+
+	// size is the number of keys the filter was sized for, keys
+	// the ones added to it and removed the ones removed after
+	size    int
+	keys    int
+	removed int
+}
+
+// add adds the k to the filter, it's called on the same
+// transaction that adds the key to the volume so when the
+// filter is calculated again it's not lost
+func (kf *keysFilter) add(k string) {
+	kf.mx.Lock()
+	defer kf.mx.Unlock()
+
+	if kf.filter == nil {
+		return
+	}
+	kf.filter.Add(k)
+	kf.keys++
+}
+
+// remove counts one more key removed from the volume
+func (kf *keysFilter) remove() {
+	kf.mx.Lock()
+	defer kf.mx.Unlock()
+
+	kf.removed++
+}
+
+// reset removes the filter so it's calculated again
+func (kf *keysFilter) reset() {
+	kf.mx.Lock()
+	defer kf.mx.Unlock()
+
+	kf.filter = nil
+}
+
+// get returns a copy of the filter, or nil if it
+// has to be calculated again
+func (kf *keysFilter) get() *bloom.Filter {
+	kf.mx.Lock()
+	defer kf.mx.Unlock()
+
+	if kf.filter == nil || kf.keys > kf.size || kf.removed > kf.keys/4 {
+		return nil
+	}
+
+	f := *kf.filter
+	f.Bits = append([]byte(nil), kf.filter.Bits...)
+
+	return &f
+}
+
+func (l *local) KeysFilter(ctx context.Context) (*bloom.Filter, error) {
+	if f := l.keysFilter.get(); f != nil {
+		return f, nil
+	}
+
+	// It's calculated on a Write UnitOfWork so no other key
+	// is added, or removed, while the keys are read and
+	// all of them end up on the filter or counted
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		iks, err := uw.IDXKeys().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		n := len(iks) + len(iks)/4 + keysFilterMargin
+		f := bloom.New(n, keysFilterFalsePositive)
+		for _, ik := range iks {
+			f.Add(ik.Key)
+		}
+
+		l.keysFilter.mx.Lock()
+		l.keysFilter.filter = f
+		l.keysFilter.size = n
+		l.keysFilter.keys = len(iks)
+		l.keysFilter.removed = 0
+		l.keysFilter.mx.Unlock()
+
+		return nil
+	}, l.idxkeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.keysFilter.get(), nil
+}
@@ -0,0 +1,67 @@
+package volume_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+)
+
+func TestKeysFilter(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().All(ctx).Return([]*idxkey.IDXKey{idxkey.New("a", "123123123")}, nil)
+
+		f, err := mv.V.KeysFilter(ctx)
+		require.NoError(t, err)
+		assert.True(t, f.Test("a"))
+		assert.False(t, f.Test("b"))
+
+		// It's not calculated again and
+		// the copy returned can be changed
+		f.Add("b")
+		f, err = mv.V.KeysFilter(ctx)
+		require.NoError(t, err)
+		assert.True(t, f.Test("a"))
+		assert.False(t, f.Test("b"))
+	})
+	t.Run("SuccessWithNewKeys", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			signature = "123123123"
+			ca        = time.Now()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().All(ctx).Return([]*idxkey.IDXKey{idxkey.New("a", signature)}, nil)
+
+		_, err := mv.V.KeysFilter(ctx)
+		require.NoError(t, err)
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(nil, errors.New("not found"))
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature}, nil)
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: signature}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: signature, CreatedAt: ca}).Return(nil)
+
+		err = mv.V.CopyFile(ctx, "a", "b", false, ca)
+		require.NoError(t, err)
+
+		// The copy is added without reading all the keys again
+		f, err := mv.V.KeysFilter(ctx)
+		require.NoError(t, err)
+		assert.True(t, f.Test("a"))
+		assert.True(t, f.Test("b"))
+	})
+}
@@ -5,10 +5,14 @@ import (
 	"os"
 	"testing"
 
+	kitlog "github.com/go-kit/log"
 	"github.com/golang/mock/gomock"
 	"github.com/spf13/afero/mem"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/encryption"
 	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/state"
 	"github.com/xescugc/rebost/uow"
 	"github.com/xescugc/rebost/volume"
@@ -24,6 +28,10 @@ type manageVolume struct {
 	Fs         *mock.Fs
 	Replicas   *mock.ReplicaRepository
 	State      *mock.StateRepository
+	Buckets    *mock.BucketRepository
+	Versions   *mock.VersionRepository
+	Trash      *mock.TrashRepository
+	APIKeys    *mock.APIKeyRepository
 
 	V volume.Local
 
@@ -33,6 +41,12 @@ type manageVolume struct {
 // newManageVolume returns the initialization of the ManageVolume
 // with all the mocks
 func newManageVolume(t *testing.T, root string) manageVolume {
+	return newManageVolumeWithKeyring(t, root, nil)
+}
+
+// newManageVolumeWithKeyring returns the initialization of the ManageVolume
+// with all the mocks and the kr to encrypt the files
+func newManageVolumeWithKeyring(t *testing.T, root string, kr *encryption.Keyring) manageVolume {
 	ctrl := gomock.NewController(t)
 
 	files := mock.NewFileRepository(ctrl)
@@ -42,6 +56,10 @@ func newManageVolume(t *testing.T, root string) manageVolume {
 	fs := mock.NewFs(ctrl)
 	rp := mock.NewReplicaRepository(ctrl)
 	sr := mock.NewStateRepository(ctrl)
+	bkts := mock.NewBucketRepository(ctrl)
+	vrs := mock.NewVersionRepository(ctrl)
+	trs := mock.NewTrashRepository(ctrl)
+	aks := mock.NewAPIKeyRepository(ctrl)
 
 	uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
 		uw := mock.NewUnitOfWork(ctrl)
@@ -52,6 +70,10 @@ func newManageVolume(t *testing.T, root string) manageVolume {
 		uw.EXPECT().Fs().Return(fs).AnyTimes()
 		uw.EXPECT().Replicas().Return(rp).AnyTimes()
 		uw.EXPECT().State().Return(sr).AnyTimes()
+		uw.EXPECT().Buckets().Return(bkts).AnyTimes()
+		uw.EXPECT().Versions().Return(vrs).AnyTimes()
+		uw.EXPECT().Trash().Return(trs).AnyTimes()
+		uw.EXPECT().APIKeys().Return(aks).AnyTimes()
 		return uowFn(ctx, uw)
 	}
 
@@ -59,12 +81,37 @@ func newManageVolume(t *testing.T, root string) manageVolume {
 	// so we do not need it
 	fs.EXPECT().MkdirAll(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 	fs.EXPECT().Stat(gomock.Any()).Return(nil, os.ErrNotExist)
-	fs.EXPECT().Create(gomock.Any()).Return(mem.NewFileHandle(mem.CreateFile("")), nil)
-
-	sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil)
-	sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+	fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(name string) (*mem.File, error) {
+		return mem.NewFileHandle(mem.CreateFile(name)), nil
+	}).Times(2)
+
+	// The size and the Usage
+	sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+	idxkeys.EXPECT().All(gomock.Any()).Return(nil, nil)
+	sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	if kr != nil {
+		// The rewrap of the keys on the background
+		// has no files to rewrap
+		files.EXPECT().All(gomock.Any()).Return(nil, nil).AnyTimes()
+	}
 
-	v, err := volume.New(root, files, idxkeys, idxttls, idxvolumes, rp, sr, fs, nil, uowFn)
+	v, err := volume.New(root, volume.Repositories{
+		Files:      files,
+		IDXKeys:    idxkeys,
+		IDXTTLs:    idxttls,
+		IDXVolumes: idxvolumes,
+		Replicas:   rp,
+		State:      sr,
+		Buckets:    bkts,
+		Versions:   vrs,
+		Trash:      trs,
+		APIKeys:    aks,
+	}, fs, uowFn, kitlog.NewNopLogger(), volume.Options{
+		Hash:         signature.SHA1,
+		Compressions: map[string]compression.Algorithm{"compressed": compression.Gzip},
+		Keyring:      kr,
+	})
 	require.NoError(t, err)
 
 	return manageVolume{
@@ -75,6 +122,10 @@ func newManageVolume(t *testing.T, root string) manageVolume {
 		Fs:         fs,
 		Replicas:   rp,
 		State:      sr,
+		Buckets:    bkts,
+		Versions:   vrs,
+		Trash:      trs,
+		APIKeys:    aks,
 
 		V: v,
 
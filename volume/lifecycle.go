@@ -0,0 +1,428 @@
+package volume
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/lifecycle"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
+)
+
+// lifecycleDuration is how often the lifecycle Rules are applied, as
+// all the files are checked each time and the Rules are by days
+// it's not done on each TickerDuration
+const lifecycleDuration = time.Hour
+
+// loopLifecycle applies the lifecycle Rules
+// to all the files of the volume
+func (l *local) loopLifecycle() {
+	tk := time.NewTicker(lifecycleDuration)
+	for {
+		select {
+		case <-l.ctx.Done():
+			tk.Stop()
+			return
+		case <-tk.C:
+			l.applyLifecycle(l.ctx, time.Now())
+		}
+	}
+}
+
+// lifecycleBatch is the number of files read at once
+// when the lifecycle Rules are applied
+const lifecycleBatch = 1000
+
+// applyLifecycle applies the lifecycle Rules to all the files at the now.
+// Each volume applies them to its own files, and as all the replicas
+// have the same keys, CreatedAt and VolumeIDs they all do the same.
+// The files are read in batches of lifecycleBatch, each one on
+// its own UnitOfWork, so the volume is not locked while all of
+// them are read
+func (l *local) applyLifecycle(ctx context.Context, now time.Time) {
+	var (
+		expired, reduced, moved int
+		after                   string
+	)
+	for {
+		fs, keys, err := l.readLifecycleBatch(ctx, after)
+		if err != nil {
+			l.logger.Log("msg", err.Error())
+			return
+		}
+		if len(fs) == 0 {
+			break
+		}
+		after = fs[len(fs)-1].Signature
+
+		for _, f := range fs {
+			if len(keys[f.Signature]) == 0 {
+				continue
+			}
+
+			p := lifecycle.Evaluate(l.rules, keys[f.Signature], now)
+
+			if len(p.Expired) != 0 {
+				err = l.expireKeys(ctx, p.Expired)
+				if err != nil {
+					l.logger.Log("msg", err.Error(), "signature", f.Signature)
+					continue
+				}
+				expired += len(p.Expired)
+			}
+
+			if p.Replica != 0 && p.Replica < f.Replica {
+				deleted, err := l.reduceReplica(ctx, f.Signature, p.Replica)
+				if err != nil {
+					l.logger.Log("msg", err.Error(), "signature", f.Signature)
+					continue
+				}
+				reduced++
+				if deleted {
+					continue
+				}
+			}
+
+			if p.Class != "" && p.Class != f.Class {
+				err = l.moveClass(ctx, f, p.Class)
+				if err != nil {
+					l.logger.Log("msg", err.Error(), "signature", f.Signature)
+					continue
+				}
+				moved++
+			}
+		}
+
+		if len(fs) < lifecycleBatch {
+			break
+		}
+	}
+
+	if expired+reduced+moved != 0 {
+		l.logger.Log("msg", "applied the lifecycle rules", "expired", expired, "reduced", reduced, "moved", moved)
+	}
+}
+
+// readLifecycleBatch returns the next lifecycleBatch files after the
+// Signature after with the time each one of their keys was created
+func (l *local) readLifecycleBatch(ctx context.Context, after string) ([]*file.File, map[string]map[string]time.Time, error) {
+	var (
+		fs   []*file.File
+		keys = make(map[string]map[string]time.Time)
+	)
+	err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		var err error
+		fs, err = uw.Files().After(ctx, after, lifecycleBatch)
+		if err != nil {
+			return err
+		}
+
+		// The age of each key is since it was
+		// created, not since the File was
+		for _, f := range fs {
+			cas := make(map[string]time.Time)
+			for _, k := range f.Keys {
+				// The Versions and the Items of the
+				// trash have their own retention
+				if version.IsKey(k) || trash.IsKey(k) {
+					continue
+				}
+
+				ik, err := uw.IDXKeys().FindByKey(ctx, k)
+				if err != nil {
+					if err.Error() == "not found" {
+						continue
+					}
+					return err
+				}
+
+				cas[k] = ik.CreatedAt
+				if ik.CreatedAt.IsZero() {
+					cas[k] = f.CreatedAt
+				}
+			}
+			keys[f.Signature] = cas
+		}
+		return nil
+	}, l.files, l.idxkeys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fs, keys, nil
+}
+
+// expireKeys deletes the keys, if they have Versioning a DeleteMarker
+// is added at the time they expired so it's the same on all the replicas
+func (l *local) expireKeys(ctx context.Context, keys map[string]time.Time) error {
+	return l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		for k, ea := range keys {
+			err := l.deleteFile(ctx, uw, k)
+			if err != nil {
+				if err.Error() == "not found" {
+					continue
+				}
+				return err
+			}
+
+			err = l.addDeleteMarker(ctx, uw, k, ea)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}, l.idxkeys, l.files, l.fs, l.state, l.buckets, l.versions, l.trash, l.idxttls)
+}
+
+// reduceReplica reduces the replicas of the file with the sig to rep. The
+// first rep VolumeIDs keep it and the rest delete it, in which case it
+// returns true. The pending Replicas of the file are also reduced
+func (l *local) reduceReplica(ctx context.Context, sig string, rep int) (bool, error) {
+	var deleted bool
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		f, err := uw.Files().FindBySignature(ctx, sig)
+		if err != nil {
+			return err
+		}
+
+		keep := f.VolumeIDs
+		if len(keep) > rep {
+			keep = keep[:rep]
+		}
+
+		deleted = true
+		for _, vid := range keep {
+			if vid == l.id {
+				deleted = false
+				break
+			}
+		}
+
+		// The volumes that no longer have the file
+		// are removed from the IDXVolumes so it's
+		// not replicated again when they are gone
+		for _, vid := range f.VolumeIDs[len(keep):] {
+			if vid == l.id {
+				continue
+			}
+			err = l.deleteIDXVolumeSignature(ctx, uw, vid, sig)
+			if err != nil {
+				return err
+			}
+		}
+
+		if deleted {
+			for _, vid := range keep {
+				err = l.deleteIDXVolumeSignature(ctx, uw, vid, sig)
+				if err != nil {
+					return err
+				}
+			}
+			for _, k := range f.Keys {
+				err = l.deleteFile(ctx, uw, k)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		f.VolumeIDs = keep
+		f.Replica = rep
+
+		err = uw.Files().CreateOrReplace(ctx, f)
+		if err != nil {
+			return err
+		}
+
+		rps, err := uw.Replicas().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, rp := range rps {
+			if rp.Signature != sig {
+				continue
+			}
+
+			err = uw.Replicas().Delete(ctx, rp)
+			if err != nil {
+				return err
+			}
+
+			rp.Count = rep - len(keep)
+			rp.OriginalCount = rep
+			rp.VolumeIDs = keep
+
+			if rp.Count > 0 {
+				err = uw.Replicas().Create(ctx, rp)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}, l.files, l.idxkeys, l.idxvolumes, l.replicas, l.fs, l.state, l.buckets, l.versions, l.trash, l.idxttls)
+	if err != nil {
+		return false, err
+	}
+
+	return deleted, nil
+}
+
+// deleteIDXVolumeSignature removes the sig from the IDXVolume of the vid
+func (l *local) deleteIDXVolumeSignature(ctx context.Context, uw uow.UnitOfWork, vid, sig string) error {
+	idxv, err := uw.IDXVolumes().FindByVolumeID(ctx, vid)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	idxv.DeleteSignature(sig)
+
+	return uw.IDXVolumes().CreateOrReplace(ctx, idxv)
+}
+
+// moveClass moves the f to the class, if the compression of the class
+// is different the content is stored again with it. If the f has
+// changed while it was being stored nothing is done as it'll be
+// moved again on the next run
+func (l *local) moveClass(ctx context.Context, f *file.File, class string) error {
+	comp, ok := l.compressions[class]
+	if !ok {
+		return fmt.Errorf("invalid class %q", class)
+	}
+
+	var (
+		tmp    string
+		stored *file.File
+	)
+	if comp != f.Compression {
+		tmp = path.Join(l.tempDir, uuid.NewV4().String())
+
+		var err error
+		stored, err = l.storeContent(f, tmp, comp)
+		if err != nil {
+			l.fs.Remove(tmp)
+			return err
+		}
+	}
+
+	// swap is set when the content on the disk has
+	// to be replaced with the tmp once it's committed
+	var (
+		swap bool
+		p    string
+	)
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		dbf, err := uw.Files().FindBySignature(ctx, f.Signature)
+		if err != nil {
+			return err
+		}
+
+		if stored != nil {
+			if dbf.Compression != f.Compression || dbf.EncryptionKeyID != f.EncryptionKeyID || !bytes.Equal(dbf.EncryptedKey, f.EncryptedKey) {
+				return nil
+			}
+
+			if d := stored.DiskSize() - dbf.DiskSize(); d != 0 {
+				st, err := uw.State().Find(ctx)
+				if err != nil {
+					return err
+				}
+				if !st.Use(d) {
+					return errors.New("file is too large for the dedicated space left")
+				}
+
+				err = uw.State().Update(ctx, st)
+				if err != nil {
+					return err
+				}
+			}
+
+			swap = true
+			p = dbf.Path(l.fileDir)
+
+			_, err = l.rewrapKey(stored)
+			if err != nil {
+				return err
+			}
+
+			dbf.StoredSize = stored.StoredSize
+			dbf.Compression = stored.Compression
+			dbf.EncryptionKeyID = stored.EncryptionKeyID
+			dbf.EncryptedKey = stored.EncryptedKey
+		}
+
+		dbf.Class = class
+
+		return uw.Files().CreateOrReplace(ctx, dbf)
+	}, l.files, l.fs, l.state)
+
+	// The content is only replaced once the new way it's
+	// stored is committed, if not the File could not be read
+	if err == nil && swap {
+		return l.fs.Rename(tmp, p)
+	}
+
+	// If it's not replaced it's no longer needed
+	if tmp != "" {
+		l.fs.Remove(tmp)
+	}
+
+	return err
+}
+
+// storeContent stores the content of the f on the p compressed with the
+// comp and returns how it's stored: the StoredSize, the Compression and
+// the encryption data key if it's encrypted
+func (l *local) storeContent(f *file.File, p string, comp compression.Algorithm) (*file.File, error) {
+	rc, err := l.openContent(l.fs, f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	fh, err := l.fs.Create(p)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	cw, ekid, encryptedKey, err := l.newContentWriter(fh, comp)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(cw, rc)
+	if err == nil {
+		err = cw.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return &file.File{
+		StoredSize:      int(fi.Size()),
+		Compression:     comp,
+		EncryptionKeyID: ekid,
+		EncryptedKey:    encryptedKey,
+	}, nil
+}
@@ -0,0 +1,216 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/spf13/afero"
+	"github.com/xescugc/rebost/encryption"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxttl"
+	"github.com/xescugc/rebost/idxvolume"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/signature"
+	"github.com/xescugc/rebost/uow"
+)
+
+// Migrate migrates all the Signatures of the volume on root to the alg.
+// The legacy Signatures (the ones without algorithm) are SHA1 so if the
+// alg is also SHA1 they are only prefixed and the files are not moved,
+// for any other case the content is hashed again and moved to the new Path.
+// All the indexes (IDXKeys, IDXTTLs, IDXVolumes and Replicas) are also updated.
+// The kr is required to read the content of the encrypted files.
+// The volume must not be in use while it's being migrated
+func Migrate(ctx context.Context, root string, files file.Repository, idxkeys idxkey.Repository, idxttls idxttl.Repository, idxvolumes idxvolume.Repository, rp replica.Repository, fileSystem afero.Fs, logger kitlog.Logger, suow uow.StartUnitOfWork, alg signature.Algorithm, kr *encryption.Keyring) error {
+	root = strings.Split(root, ":")[0]
+	fileDir := path.Join(root, "file")
+	logger = kitlog.With(logger, "src", "volume", "root", root)
+
+	err := suow(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		fs, err := uw.Files().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		// sigs has the old Signature as key
+		// and the new one as value
+		sigs := make(map[string]string)
+		for _, f := range fs {
+			if a, _ := signature.Split(f.Signature); a == alg && !signature.IsLegacy(f.Signature) {
+				continue
+			}
+
+			osig := f.Signature
+			nsig, err := migrateSignature(uw.Fs(), fileDir, f, alg, kr)
+			if err != nil {
+				return err
+			}
+
+			op, np := file.Path(fileDir, osig), file.Path(fileDir, nsig)
+			if op != np {
+				dir, _ := path.Split(np)
+				err = uw.Fs().MkdirAll(dir, os.ModePerm)
+				if err != nil {
+					return err
+				}
+
+				err = uw.Fs().Rename(op, np)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = uw.Files().DeleteBySignature(ctx, osig)
+			if err != nil {
+				return err
+			}
+
+			f.Signature = nsig
+			err = uw.Files().CreateOrReplace(ctx, f)
+			if err != nil {
+				return err
+			}
+
+			for _, k := range f.Keys {
+				// It keeps its own expiration, if it has one
+				ik, err := uw.IDXKeys().FindByKey(ctx, k)
+				if err != nil {
+					if err.Error() != "not found" {
+						return err
+					}
+					ik = idxkey.New(k, nsig)
+				}
+				ik.Value = nsig
+
+				err = uw.IDXKeys().CreateOrReplace(ctx, ik)
+				if err != nil {
+					return err
+				}
+			}
+
+			if f.TTL != noTTL {
+				ittl, err := uw.IDXTTLs().Find(ctx, f.ExpiresAt())
+				if err != nil && err.Error() != "not found" {
+					return err
+				}
+				if ittl != nil {
+					for i, s := range ittl.Signatures {
+						if s == osig {
+							ittl.Signatures[i] = nsig
+						}
+					}
+					ittl.ExpiresAt = f.ExpiresAt()
+
+					err = uw.IDXTTLs().CreateOrReplace(ctx, ittl)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			sigs[osig] = nsig
+		}
+
+		idxvs, err := uw.IDXVolumes().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, idxv := range idxvs {
+			for i, s := range idxv.Signatures {
+				if ns, ok := sigs[s]; ok {
+					idxv.Signatures[i] = ns
+				}
+			}
+
+			err = uw.IDXVolumes().CreateOrReplace(ctx, idxv)
+			if err != nil {
+				return err
+			}
+		}
+
+		rps, err := uw.Replicas().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rps {
+			ns, ok := sigs[r.Signature]
+			if !ok {
+				continue
+			}
+
+			err = uw.Replicas().Delete(ctx, r)
+			if err != nil {
+				return err
+			}
+
+			r.Signature = ns
+			err = uw.Replicas().Create(ctx, r)
+			if err != nil {
+				return err
+			}
+		}
+
+		logger.Log("msg", "migrated", "files", len(sigs), "hash", alg)
+
+		return nil
+	}, files, idxkeys, idxttls, idxvolumes, rp, fileSystem)
+	if err != nil {
+		return err
+	}
+
+	return writeAlgorithm(fileSystem, path.Join(root, "hash"), alg)
+}
+
+// migrateSignature returns the Signature of the f
+// calculated with the alg
+func migrateSignature(fs afero.Fs, fileDir string, f *file.File, alg signature.Algorithm, kr *encryption.Keyring) (string, error) {
+	if alg == signature.SHA1 && signature.IsLegacy(f.Signature) {
+		_, hs := signature.Split(f.Signature)
+		return signature.Join(alg, hs), nil
+	}
+
+	var rc io.ReadCloser
+	rc, err := fs.Open(f.Path(fileDir))
+	if err != nil {
+		return "", err
+	}
+
+	if f.IsEncrypted() {
+		if kr == nil {
+			rc.Close()
+			return "", fmt.Errorf("the file %q is encrypted and there is no keyring", f.Signature)
+		}
+
+		dk, err := kr.Unwrap(f.EncryptionKeyID, f.EncryptedKey)
+		if err != nil {
+			rc.Close()
+			return "", err
+		}
+
+		rc, err = encryption.NewReader(rc, dk)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// The Signature is calculated with
+	// the content decrypted and decompressed
+	r := f.Compression.NewReader(rc)
+	defer r.Close()
+
+	h := alg.New()
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+
+	return alg.Sum(h), nil
+}
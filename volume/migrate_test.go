@@ -0,0 +1,145 @@
+package volume_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero/mem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxvolume"
+	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/signature"
+	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/volume"
+)
+
+func TestMigrate(t *testing.T) {
+	var (
+		rootDir  = "/"
+		fileDir  = path.Join(rootDir, "file")
+		hashPath = path.Join(rootDir, "hash")
+		content  = "content"
+	)
+
+	newMocks := func(t *testing.T) (*gomock.Controller, *mock.FileRepository, *mock.IDXKeyRepository, *mock.IDXTTLRepository, *mock.IDXVolumeRepository, *mock.ReplicaRepository, *mock.Fs, uow.StartUnitOfWork) {
+		ctrl := gomock.NewController(t)
+
+		files := mock.NewFileRepository(ctrl)
+		idxkeys := mock.NewIDXKeyRepository(ctrl)
+		idxttls := mock.NewIDXTTLRepository(ctrl)
+		idxvolumes := mock.NewIDXVolumeRepository(ctrl)
+		rp := mock.NewReplicaRepository(ctrl)
+		fs := mock.NewFs(ctrl)
+
+		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
+			uw := mock.NewUnitOfWork(ctrl)
+			uw.EXPECT().Files().Return(files).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
+			uw.EXPECT().IDXTTLs().Return(idxttls).AnyTimes()
+			uw.EXPECT().IDXVolumes().Return(idxvolumes).AnyTimes()
+			uw.EXPECT().Replicas().Return(rp).AnyTimes()
+			uw.EXPECT().Fs().Return(fs).AnyTimes()
+			return uowFn(ctx, uw)
+		}
+
+		return ctrl, files, idxkeys, idxttls, idxvolumes, rp, fs, uowFn
+	}
+
+	t.Run("SuccessLegacyToSHA1", func(t *testing.T) {
+		var (
+			ctx  = context.Background()
+			osig = "040f06fd774092478d450774f5ba30c5da78acc8"
+			nsig = "sha1:040f06fd774092478d450774f5ba30c5da78acc8"
+			hfh  = mem.NewFileHandle(mem.CreateFile(hashPath))
+		)
+
+		ctrl, files, idxkeys, idxttls, idxvolumes, rp, fs, uowFn := newMocks(t)
+		defer ctrl.Finish()
+
+		files.EXPECT().All(ctx).Return([]*file.File{
+			{Keys: []string{"a", "b"}, Signature: osig},
+			{Keys: []string{"c"}, Signature: nsig},
+		}, nil)
+		files.EXPECT().DeleteBySignature(ctx, osig).Return(nil)
+		files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: nsig}).Return(nil)
+
+		idxkeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", osig), nil)
+		idxkeys.EXPECT().FindByKey(ctx, "b").Return(idxkey.New("b", osig), nil)
+		idxkeys.EXPECT().CreateOrReplace(ctx, idxkey.New("a", nsig)).Return(nil)
+		idxkeys.EXPECT().CreateOrReplace(ctx, idxkey.New("b", nsig)).Return(nil)
+
+		idxvolumes.EXPECT().All(ctx).Return([]*idxvolume.IDXVolume{idxvolume.New("vid", []string{osig, "other"})}, nil)
+		idxvolumes.EXPECT().CreateOrReplace(ctx, idxvolume.New("vid", []string{nsig, "other"})).Return(nil)
+
+		orp := &replica.Replica{ID: "1", Signature: osig}
+		rp.EXPECT().All(ctx).Return([]*replica.Replica{orp, {ID: "2", Signature: "other"}}, nil)
+		rp.EXPECT().Delete(ctx, orp).Return(nil)
+		rp.EXPECT().Create(ctx, &replica.Replica{ID: "1", Signature: nsig}).Return(nil)
+
+		fs.EXPECT().Create(hashPath).Return(hfh, nil)
+
+		err := volume.Migrate(ctx, rootDir, files, idxkeys, idxttls, idxvolumes, rp, fs, kitlog.NewNopLogger(), uowFn, signature.SHA1, nil)
+		require.NoError(t, err)
+
+		err = hfh.Open()
+		require.NoError(t, err)
+
+		alg, err := io.ReadAll(hfh)
+		require.NoError(t, err)
+		assert.Equal(t, "sha1", string(alg))
+	})
+	t.Run("SuccessLegacyToSHA256", func(t *testing.T) {
+		var (
+			ctx  = context.Background()
+			osig = "040f06fd774092478d450774f5ba30c5da78acc8"
+			nsig = "sha256:ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+			ofh  = mem.NewFileHandle(mem.CreateFile(file.Path(fileDir, osig)))
+			hfh  = mem.NewFileHandle(mem.CreateFile(hashPath))
+		)
+
+		_, err := io.Copy(ofh, strings.NewReader(content))
+		require.NoError(t, err)
+
+		_, err = ofh.Seek(0, 0)
+		require.NoError(t, err)
+
+		ctrl, files, idxkeys, idxttls, idxvolumes, rp, fs, uowFn := newMocks(t)
+		defer ctrl.Finish()
+
+		files.EXPECT().All(ctx).Return([]*file.File{{Keys: []string{"a"}, Signature: osig}}, nil)
+		files.EXPECT().DeleteBySignature(ctx, osig).Return(nil)
+		files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a"}, Signature: nsig}).Return(nil)
+
+		// The expiration of the key is kept
+		ik := idxkey.New("a", osig)
+		ik.ExpiresAt = time.Now()
+		eik := *ik
+		eik.Value = nsig
+		idxkeys.EXPECT().FindByKey(ctx, "a").Return(ik, nil)
+		idxkeys.EXPECT().CreateOrReplace(ctx, &eik).Return(nil)
+
+		idxvolumes.EXPECT().All(ctx).Return([]*idxvolume.IDXVolume{}, nil)
+		rp.EXPECT().All(ctx).Return([]*replica.Replica{}, nil)
+
+		np := file.Path(fileDir, nsig)
+		dir, _ := path.Split(np)
+		fs.EXPECT().Open(file.Path(fileDir, osig)).Return(ofh, nil)
+		fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
+		fs.EXPECT().Rename(file.Path(fileDir, osig), np).Return(nil)
+		fs.EXPECT().Create(hashPath).Return(hfh, nil)
+
+		err = volume.Migrate(ctx, rootDir, files, idxkeys, idxttls, idxvolumes, rp, fs, kitlog.NewNopLogger(), uowFn, signature.SHA256, nil)
+		require.NoError(t, err)
+	})
+}
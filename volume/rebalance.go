@@ -0,0 +1,139 @@
+package volume
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
+)
+
+func (l *local) MoveReplicas(ctx context.Context, to string, size int) ([]*replica.Replica, error) {
+	var (
+		rps   []*replica.Replica
+		after string
+		done  bool
+		moved int
+	)
+	for !done && moved < size {
+		err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+			fs, err := uw.Files().After(ctx, after, lifecycleBatch)
+			if err != nil {
+				return err
+			}
+			if len(fs) < lifecycleBatch {
+				done = true
+			}
+			if len(fs) == 0 {
+				return nil
+			}
+			after = fs[len(fs)-1].Signature
+
+			// The Files with pending replicas are not moved
+			// as their VolumeIDs are going to change
+			prps, err := uw.Replicas().All(ctx)
+			if err != nil {
+				return err
+			}
+			pending := make(map[string]struct{}, len(prps))
+			for _, rp := range prps {
+				pending[rp.Signature] = struct{}{}
+			}
+
+			for _, f := range fs {
+				if moved >= size {
+					break
+				}
+				if _, ok := pending[f.Signature]; ok {
+					continue
+				}
+				ok, err := l.canMove(ctx, uw, f, to)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+
+				rps = append(rps, &replica.Replica{
+					ID:            uuid.NewV4().String(),
+					OriginalCount: f.Replica,
+					Count:         1,
+					Key:           f.Keys[0],
+					Signature:     f.Signature,
+					VolumeID:      l.id,
+					VolumeIDs:     f.VolumeIDs,
+					TTL:           f.TTL,
+					CreatedAt:     f.CreatedAt,
+					Class:         f.Class,
+					Move:          true,
+				})
+				moved += f.DiskSize()
+			}
+			return nil
+		}, l.files, l.idxkeys, l.replicas)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rps, nil
+}
+
+// canMove checks if the f can be moved to the volume to. Only the Files with
+// one key are moved, as the replicas only have one, which has to have the
+// same TTL of the File. The Versions and the Items of the trash are not
+// moved as they are only on the volumes of their keys
+func (l *local) canMove(ctx context.Context, uw uow.UnitOfWork, f *file.File, to string) (bool, error) {
+	if len(f.Keys) != 1 || version.IsKey(f.Keys[0]) || trash.IsKey(f.Keys[0]) {
+		return false, nil
+	}
+
+	now := time.Now()
+	if f.IsExpired(now) {
+		return false, nil
+	}
+
+	var local bool
+	for _, vid := range f.VolumeIDs {
+		if vid == to {
+			return false, nil
+		}
+		if vid == l.id {
+			local = true
+		}
+	}
+	if !local {
+		return false, nil
+	}
+
+	ik, err := uw.IDXKeys().FindByKey(ctx, f.Keys[0])
+	if err != nil {
+		if err.Error() == "not found" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return ik.ExpiresAt.IsZero(), nil
+}
+
+// moveReplica removes the f, moved to another volume, from the
+// volume and from the IDXVolumes of the ones that have it
+func (l *local) moveReplica(ctx context.Context, uw uow.UnitOfWork, f *file.File) error {
+	for _, vid := range f.VolumeIDs {
+		if vid == l.id {
+			continue
+		}
+		err := l.deleteIDXVolumeSignature(ctx, uw, vid, f.Signature)
+		if err != nil {
+			return err
+		}
+	}
+
+	return l.deleteFile(ctx, uw, f.Keys[0])
+}
@@ -0,0 +1,77 @@
+package volume_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/version"
+)
+
+func TestMoveReplicas(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			ca  = time.Now()
+		)
+		defer mv.Finish()
+
+		var (
+			vids = []string{mv.V.ID(), "b"}
+			fs   = []*file.File{
+				{Keys: []string{"a"}, Signature: "sig1", Replica: 2, VolumeIDs: vids, Size: 10, TTL: time.Hour, CreatedAt: ca, Class: "compressed"},
+				{Keys: []string{"b", "c"}, Signature: "sig2", Replica: 2, VolumeIDs: vids, Size: 10},
+				{Keys: []string{"d"}, Signature: "sig3", Replica: 2, VolumeIDs: []string{mv.V.ID(), "to"}, Size: 10},
+				{Keys: []string{"e"}, Signature: "sig4", Replica: 2, VolumeIDs: vids, Size: 10},
+				{Keys: []string{version.Key("f", "1-sig5")}, Signature: "sig5", Replica: 2, VolumeIDs: vids, Size: 10},
+				{Keys: []string{"g"}, Signature: "sig6", Replica: 2, VolumeIDs: vids, Size: 10, TTL: time.Hour, CreatedAt: ca.Add(-2 * time.Hour)},
+				{Keys: []string{"h"}, Signature: "sig7", Replica: 2, VolumeIDs: vids, Size: 10},
+				{Keys: []string{"i"}, Signature: "sig8", Replica: 2, VolumeIDs: vids, Size: 10, CreatedAt: ca},
+				{Keys: []string{"j"}, Signature: "sig9", Replica: 2, VolumeIDs: vids, Size: 10},
+			}
+		)
+
+		mv.Files.EXPECT().After(ctx, "", 1000).Return(fs, nil)
+		mv.Replicas.EXPECT().All(ctx).Return([]*replica.Replica{{Signature: "sig4"}}, nil)
+
+		// The ones with more keys, already on the volume, with pending
+		// replicas, Versions or expired are not moved. Neither the
+		// ones which key has its own TTL
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", "sig1"), nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "h").Return(&idxkey.IDXKey{Key: "h", Value: "sig7", ExpiresAt: ca.Add(time.Hour)}, nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "i").Return(idxkey.New("i", "sig8"), nil)
+
+		// Once the size is reached the
+		// rest of the Files are not moved
+		rps, err := mv.V.MoveReplicas(ctx, "to", 15)
+		require.NoError(t, err)
+		require.Len(t, rps, 2)
+		for _, rp := range rps {
+			assert.NotEmpty(t, rp.ID)
+			rp.ID = ""
+		}
+		assert.Equal(t, []*replica.Replica{
+			{OriginalCount: 2, Count: 1, Key: "a", Signature: "sig1", VolumeID: mv.V.ID(), VolumeIDs: vids, TTL: time.Hour, CreatedAt: ca, Class: "compressed", Move: true},
+			{OriginalCount: 2, Count: 1, Key: "i", Signature: "sig8", VolumeID: mv.V.ID(), VolumeIDs: vids, CreatedAt: ca, Move: true},
+		}, rps)
+	})
+	t.Run("SuccessEmpty", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.Files.EXPECT().After(ctx, "", 1000).Return(nil, nil)
+
+		rps, err := mv.V.MoveReplicas(ctx, "to", 15)
+		require.NoError(t, err)
+		assert.Empty(t, rps)
+	})
+}
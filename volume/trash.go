@@ -0,0 +1,184 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxttl"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
+)
+
+func (l *local) TrashFile(ctx context.Context, key string, da, pa time.Time) error {
+	return l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		ik, err := uw.IDXKeys().FindByKey(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		dbf, err := uw.Files().FindBySignature(ctx, ik.Value)
+		if err != nil {
+			return err
+		}
+
+		it := &trash.Item{
+			ID:        trash.NewID(da),
+			Key:       key,
+			Signature: dbf.Signature,
+			Size:      dbf.Size,
+			DeletedAt: da,
+			PurgeAt:   pa,
+			ExpiresAt: ik.ExpiresAt,
+		}
+
+		tik, err := l.moveKey(ctx, uw, dbf, ik, trash.Key(key, it.ID))
+		if err != nil {
+			return err
+		}
+
+		// The key of the Item is the only one purged, once
+		// the retention expires, as the File could have
+		// other keys. Its own expiration is kept on the Item
+		err = l.setKeyTTL(ctx, uw, tik, pa)
+		if err != nil {
+			return err
+		}
+
+		err = uw.Trash().CreateOrReplace(ctx, it)
+		if err != nil {
+			return err
+		}
+
+		return l.addDeleteMarker(ctx, uw, key, da)
+	}, l.idxkeys, l.files, l.idxttls, l.trash, l.buckets, l.versions, l.state)
+}
+
+func (l *local) Trash(ctx context.Context) ([]*trash.Item, error) {
+	var its []*trash.Item
+	err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		var err error
+		its, err = uw.Trash().All(ctx)
+		if err != nil {
+			return err
+		}
+		return nil
+	}, l.trash)
+	if err != nil {
+		return nil, err
+	}
+
+	return its, nil
+}
+
+func (l *local) RestoreTrash(ctx context.Context, key, id string) error {
+	return l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		it, err := uw.Trash().Find(ctx, key, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = uw.IDXKeys().FindByKey(ctx, key)
+		if err == nil {
+			return errors.New("already exists")
+		} else if err.Error() != "not found" {
+			return err
+		}
+
+		tik, err := uw.IDXKeys().FindByKey(ctx, trash.Key(key, id))
+		if err != nil {
+			return err
+		}
+
+		dbf, err := uw.Files().FindBySignature(ctx, it.Signature)
+		if err != nil {
+			return err
+		}
+
+		ik, err := l.moveKey(ctx, uw, dbf, tik, key)
+		if err != nil {
+			return err
+		}
+
+		// The key is no longer purged and
+		// gets back the expiration it had
+		err = l.setKeyTTL(ctx, uw, ik, it.ExpiresAt)
+		if err != nil {
+			return err
+		}
+
+		err = uw.Trash().Delete(ctx, key, id)
+		if err != nil {
+			return err
+		}
+
+		// If the key has Versioning the DeleteMarker
+		// of the deletion is no longer true
+		return l.removeVersion(ctx, uw, key, version.NewID(it.DeletedAt, deleteMarker))
+	}, l.idxkeys, l.files, l.idxttls, l.trash, l.versions, l.state)
+}
+
+// moveKey replaces the key of the ik of the f with the to, which
+// keeps the rest of the fields of the ik, and returns the IDXKey
+// of the to. If the ik expires on its own the to expires with it
+func (l *local) moveKey(ctx context.Context, uw uow.UnitOfWork, f *file.File, ik *idxkey.IDXKey, to string) (*idxkey.IDXKey, error) {
+	from := ik.Key
+	for i, k := range f.Keys {
+		if k == from {
+			f.Keys[i] = to
+		}
+	}
+
+	err := uw.Files().CreateOrReplace(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	// The keys of the trash are accounted on the
+	// key they belong to so the Usage does not change
+	err = l.updateUsage(ctx, uw, f, -1, from)
+	if err != nil {
+		return nil, err
+	}
+	err = l.updateUsage(ctx, uw, f, 1, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ik.ExpiresAt.IsZero() {
+		dbidxttl, err := uw.IDXTTLs().Find(ctx, ik.ExpiresAt)
+		if err != nil && err.Error() != "not found" {
+			return nil, err
+		}
+		if dbidxttl == nil {
+			dbidxttl = idxttl.New(ik.ExpiresAt)
+		}
+		dbidxttl.DeleteKeys(from)
+		dbidxttl.AddKeys(to)
+
+		err = uw.IDXTTLs().CreateOrReplace(ctx, dbidxttl)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nik := *ik
+	nik.Key = to
+
+	err = uw.IDXKeys().CreateOrReplace(ctx, &nik)
+	if err != nil {
+		return nil, err
+	}
+	l.keysFilter.add(to)
+
+	err = uw.IDXKeys().DeleteByKey(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	l.keysFilter.remove()
+
+	return &nik, nil
+}
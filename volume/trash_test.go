@@ -0,0 +1,228 @@
+package volume_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxttl"
+	"github.com/xescugc/rebost/trash"
+	"github.com/xescugc/rebost/version"
+)
+
+func TestTrashFile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			key       = "expectedkey"
+			signature = "123123123"
+			da        = time.Now()
+			pa        = da.Add(time.Hour)
+			id        = trash.NewID(da)
+			tk        = trash.Key(key, id)
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key, "b"}, Signature: signature, Size: 7}, nil)
+
+		// The File is kept with the key of the Item
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{tk, "b"}, Signature: signature, Size: 7}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, idxkey.New(tk, signature)).Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, key).Return(nil)
+
+		mv.IDXTTLs.EXPECT().Find(ctx, pa).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: pa, Keys: []string{tk}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: tk, Value: signature, ExpiresAt: pa}).Return(nil)
+
+		mv.Trash.EXPECT().CreateOrReplace(ctx, &trash.Item{
+			ID:        id,
+			Key:       key,
+			Signature: signature,
+			Size:      7,
+			DeletedAt: da,
+			PurgeAt:   pa,
+		}).Return(nil)
+
+		err := mv.V.TrashFile(ctx, key, da, pa)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithKeyTTL", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			key       = "expectedkey"
+			signature = "123123123"
+			ca        = time.Now().Add(-time.Hour)
+			da        = time.Now()
+			ea        = da.Add(time.Minute)
+			pa        = da.Add(time.Hour)
+			id        = trash.NewID(da)
+			tk        = trash.Key(key, id)
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(&idxkey.IDXKey{Key: key, Value: signature, ExpiresAt: ea, CreatedAt: ca}, nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key, "b"}, Signature: signature, Size: 7}, nil)
+
+		// The key of the Item is moved with the
+		// rest of the fields and its IDXTTL
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{tk, "b"}, Signature: signature, Size: 7}).Return(nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Keys: []string{key}}, nil)
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ea, Keys: []string{tk}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: tk, Value: signature, ExpiresAt: ea, CreatedAt: ca}).Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, key).Return(nil)
+
+		// It's only purged once the retention expires
+		mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Keys: []string{tk}}, nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, ea).Return(nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, pa).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: pa, Keys: []string{tk}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: tk, Value: signature, ExpiresAt: pa, CreatedAt: ca}).Return(nil)
+
+		mv.Trash.EXPECT().CreateOrReplace(ctx, &trash.Item{
+			ID:        id,
+			Key:       key,
+			Signature: signature,
+			Size:      7,
+			DeletedAt: da,
+			PurgeAt:   pa,
+			ExpiresAt: ea,
+		}).Return(nil)
+
+		err := mv.V.TrashFile(ctx, key, da, pa)
+		require.NoError(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "expectedkey").Return(nil, errors.New("not found"))
+
+		err := mv.V.TrashFile(ctx, "expectedkey", time.Now(), time.Now())
+		assert.EqualError(t, err, "not found")
+	})
+}
+
+func TestTrash(t *testing.T) {
+	var (
+		mv  = newManageVolume(t, "/")
+		ctx = context.Background()
+		its = []*trash.Item{{ID: "1", Key: "a"}, {ID: "2", Key: "b"}}
+	)
+	defer mv.Finish()
+
+	mv.Trash.EXPECT().All(ctx).Return(its, nil)
+
+	rits, err := mv.V.Trash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, its, rits)
+}
+
+func TestRestoreTrash(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			key       = "expectedkey"
+			signature = "123123123"
+			ca        = time.Now().Add(-time.Hour)
+			da        = time.Now()
+			ea        = da.Add(time.Minute)
+			pa        = da.Add(time.Hour)
+			id        = trash.NewID(da)
+			tk        = trash.Key(key, id)
+		)
+		defer mv.Finish()
+
+		mv.Trash.EXPECT().Find(ctx, key, id).Return(&trash.Item{ID: id, Key: key, Signature: signature, DeletedAt: da, PurgeAt: pa, ExpiresAt: ea}, nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+		mv.IDXKeys.EXPECT().FindByKey(ctx, tk).Return(&idxkey.IDXKey{Key: tk, Value: signature, ExpiresAt: pa, CreatedAt: ca}, nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{tk}, Signature: signature}, nil)
+
+		// The key is moved with the rest of the fields and its IDXTTL
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{key}, Signature: signature}).Return(nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, pa).Return(&idxttl.IDXTTL{ExpiresAt: pa, Keys: []string{tk}}, nil)
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: pa, Keys: []string{key}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: signature, ExpiresAt: pa, CreatedAt: ca}).Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, tk).Return(nil)
+
+		// It's no longer purged and gets back its expiration
+		mv.IDXTTLs.EXPECT().Find(ctx, pa).Return(&idxttl.IDXTTL{ExpiresAt: pa, Keys: []string{key}}, nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, pa).Return(nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ea, Keys: []string{key}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: signature, ExpiresAt: ea, CreatedAt: ca}).Return(nil)
+
+		mv.Trash.EXPECT().Delete(ctx, key, id).Return(nil)
+
+		// The key has no Versions
+		mv.Versions.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+
+		err := mv.V.RestoreTrash(ctx, key, id)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithVersioning", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			ctx       = context.Background()
+			key       = "expectedkey"
+			signature = "123123123"
+			da        = time.Now()
+			id        = trash.NewID(da)
+			tk        = trash.Key(key, id)
+			h         = &version.History{
+				Key: key,
+				Versions: []*version.Version{
+					{ID: "1-123123123", Signature: signature},
+					{ID: version.NewID(da, "delete"), DeleteMarker: true},
+				},
+			}
+		)
+		defer mv.Finish()
+
+		mv.Trash.EXPECT().Find(ctx, key, id).Return(&trash.Item{ID: id, Key: key, Signature: signature, DeletedAt: da}, nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+		mv.IDXKeys.EXPECT().FindByKey(ctx, tk).Return(idxkey.New(tk, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{tk}, Signature: signature}, nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{key}, Signature: signature}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, idxkey.New(key, signature)).Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, tk).Return(nil)
+
+		mv.Trash.EXPECT().Delete(ctx, key, id).Return(nil)
+
+		// The DeleteMarker of the deletion is removed
+		mv.Versions.EXPECT().FindByKey(ctx, key).Return(h, nil)
+		mv.Versions.EXPECT().CreateOrReplace(ctx, &version.History{
+			Key:      key,
+			Versions: []*version.Version{{ID: "1-123123123", Signature: signature}},
+		}).Return(nil)
+
+		err := mv.V.RestoreTrash(ctx, key, id)
+		require.NoError(t, err)
+	})
+	t.Run("AlreadyExists", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			key = "expectedkey"
+		)
+		defer mv.Finish()
+
+		mv.Trash.EXPECT().Find(ctx, key, "1").Return(&trash.Item{ID: "1", Key: key, Signature: "123"}, nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, "321"), nil)
+
+		err := mv.V.RestoreTrash(ctx, key, "1")
+		assert.EqualError(t, err, "already exists")
+	})
+}
@@ -2,53 +2,430 @@ package volume
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxttl"
 	"github.com/xescugc/rebost/uow"
 )
 
-// loopTTL will every second check if there is any file on the TTL table to be deleted
-// if so it'll trigger a delete to the file
+const (
+	// ttlBatchSize is the maximum number of
+	// IDXTTLs expired on the same iteration
+	ttlBatchSize = 100
+
+	// lagWarning is the lag from which the
+	// expirations are logged as overdue
+	lagWarning = time.Minute
+)
+
+// loopTTL will every second expire the files of the IDXTTLs that are
+// due, from the oldest one, so after a downtime all the overdue ones
+// are expired. Each IDXTTL is deleted once all it has is expired
 func (l *local) loopTTL() {
 	tk := time.NewTicker(time.Second)
 	for {
 		select {
 		case <-l.ctx.Done():
-			goto end
+			tk.Stop()
+			return
 		case <-tk.C:
-			err := l.startUnitOfWork(l.ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
-				ttls, err := uw.IDXTTLs().Filter(l.ctx, time.Now())
+			now := time.Now()
+			for {
+				n, err := l.expireTTLs(l.ctx, now)
 				if err != nil {
-					return err
+					l.logger.Log("msg", err.Error())
+					break
 				}
-				if len(ttls) == 0 {
-					return nil
+				// All the due ones have been expired
+				if n < ttlBatchSize {
+					break
 				}
+			}
+		}
+	}
+}
+
+// expireTTLs expires up to ttlBatchSize IDXTTLs that are due at the now and
+// updates the state.Expiry with how late they were. Each Signature and key
+// is expired on its own unit of work and removed from the IDXTTL with it, so
+// the ones that fail are kept on it and expired again on the next tick. It
+// returns the number of IDXTTLs fully expired
+func (l *local) expireTTLs(ctx context.Context, now time.Time) (int, error) {
+	var ttls []*idxttl.IDXTTL
+	err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		var err error
+		ttls, err = uw.IDXTTLs().Filter(ctx, now, ttlBatchSize)
+		return err
+	}, l.idxttls)
+	if err != nil {
+		return 0, err
+	}
+	if len(ttls) == 0 {
+		return 0, nil
+	}
 
-				for _, ttl := range ttls {
-					for _, sig := range ttl.Signatures {
-						dbf, err := uw.Files().FindBySignature(ctx, sig)
-						if err != nil {
-							l.logger.Log("msg", err.Error())
-							continue
-						}
-
-						for _, k := range dbf.Keys {
-							err = l.deleteFile(l.ctx, uw, k)
-							if err != nil {
-								l.logger.Log("msg", err.Error())
-								continue
-							}
-						}
-					}
+	var n, expired int
+	for _, ttl := range ttls {
+		done := true
+		for _, sig := range ttl.Signatures {
+			c, err := l.expireSignature(ctx, ttl.ExpiresAt, sig)
+			if err != nil {
+				l.logger.Log("msg", err.Error(), "signature", sig)
+				done = false
+				continue
+			}
+			expired += c
+		}
+
+		for _, k := range ttl.Keys {
+			c, err := l.expireKey(ctx, ttl.ExpiresAt, k)
+			if err != nil {
+				l.logger.Log("msg", err.Error(), "key", k)
+				done = false
+				continue
+			}
+			expired += c
+		}
+
+		if done {
+			n++
+		}
+	}
+
+	err = l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		st, err := uw.State().Find(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The oldest one is the first
+		st.Expiry.Lag = now.Sub(ttls[0].ExpiresAt)
+		st.Expiry.Expired += expired
+		st.Expiry.ExpiredAt = now
+
+		if st.Expiry.Lag > lagWarning {
+			l.logger.Log("msg", "expiring overdue files", "lag", st.Expiry.Lag, "expired", expired)
+		}
+
+		return uw.State().Update(ctx, st)
+	}, l.state)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// expireSignature deletes all the keys of the File with the sig and
+// removes it from the IDXTTL of the ea. It returns the number of keys
+// deleted
+func (l *local) expireSignature(ctx context.Context, ea time.Time, sig string) (int, error) {
+	var expired int
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		dbf, err := uw.Files().FindBySignature(ctx, sig)
+		// The file could already be deleted
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+
+		if dbf != nil {
+			for _, k := range dbf.Keys {
+				err = l.deleteFile(ctx, uw, k)
+				if err != nil {
+					return err
 				}
-				return nil
-			}, l.idxttls, l.files, l.idxkeys, l.files, l.fs, l.state)
+				expired++
+			}
+		}
+
+		return l.deleteExpiredTTL(ctx, uw, ea, func(ittl *idxttl.IDXTTL) { ittl.DeleteSignatures(sig) })
+	}, l.idxttls, l.files, l.idxkeys, l.fs, l.state, l.versions, l.trash)
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// expireKey deletes the k and removes it from the IDXTTL of
+// the ea. It returns the number of keys deleted
+func (l *local) expireKey(ctx context.Context, ea time.Time, k string) (int, error) {
+	var expired int
+	err := l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		err := l.deleteFile(ctx, uw, k)
+		if err != nil {
+			// The key could already be deleted
+			// or restored from the trash
+			if err.Error() != "not found" {
+				return err
+			}
+		} else {
+			expired++
+		}
+
+		return l.deleteExpiredTTL(ctx, uw, ea, func(ittl *idxttl.IDXTTL) { ittl.DeleteKeys(k) })
+	}, l.idxttls, l.files, l.idxkeys, l.fs, l.state, l.versions, l.trash)
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, nil
+}
+
+// deleteExpiredTTL removes from the IDXTTL of the ea what the
+// fn removes and deletes it if it has nothing else to expire
+func (l *local) deleteExpiredTTL(ctx context.Context, uw uow.UnitOfWork, ea time.Time, fn func(*idxttl.IDXTTL)) error {
+	dbidxttl, err := uw.IDXTTLs().Find(ctx, ea)
+	if err != nil {
+		// It was already removed when deleting the file
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	fn(dbidxttl)
+	if dbidxttl.IsEmpty() {
+		return uw.IDXTTLs().Delete(ctx, dbidxttl.ExpiresAt)
+	}
+
+	return uw.IDXTTLs().CreateOrReplace(ctx, dbidxttl)
+}
+
+func (l *local) UpdateFileTTL(ctx context.Context, key string, ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("the ttl can not be negative")
+	}
+
+	return l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		ik, err := uw.IDXKeys().FindByKey(ctx, key)
+		if err != nil {
+			return err
+		}
+		f, err := uw.Files().FindBySignature(ctx, ik.Value)
+		if err != nil {
+			return err
+		}
+
+		// The File may be shared with other keys,
+		// if it is the TTL is only set to the key
+		if len(f.Keys) > 1 {
+			err = l.updateKeyTTL(ctx, uw, ik, f, ttl)
+		} else {
+			err = l.updateFileTTL(ctx, uw, ik, f, ttl)
+		}
+		if err != nil {
+			return err
+		}
+
+		// The pending Replicas have to be
+		// created with the new TTL
+		rps, err := uw.Replicas().All(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, rp := range rps {
+			if rp.Signature != f.Signature || rp.Key != key {
+				continue
+			}
+
+			err = uw.Replicas().Delete(ctx, rp)
 			if err != nil {
-				l.logger.Log("msg", err.Error())
+				return err
 			}
+
+			rp.TTL = ttl
+
+			err = uw.Replicas().Create(ctx, rp)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, l.idxkeys, l.files, l.idxttls, l.replicas)
+}
+
+// updateFileTTL updates the TTL of the f, which only has the key of the ik
+func (l *local) updateFileTTL(ctx context.Context, uw uow.UnitOfWork, ik *idxkey.IDXKey, f *file.File, ttl time.Duration) error {
+	// It could have its own from when
+	// the File had more keys
+	err := l.setKeyTTL(ctx, uw, ik, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	if f.TTL == ttl {
+		return nil
+	}
+
+	// The Signature is moved from the IDXTTL
+	// of the old expiration to the new one
+	err = l.deleteFileTTL(ctx, uw, f)
+	if err != nil {
+		return err
+	}
+
+	f.TTL = ttl
+
+	err = uw.Files().CreateOrReplace(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	if ttl != noTTL {
+		dbidxttl, err := uw.IDXTTLs().Find(ctx, f.ExpiresAt())
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+		if dbidxttl == nil {
+			dbidxttl = idxttl.New(f.ExpiresAt())
+		}
+		dbidxttl.AddSignatures(f.Signature)
+
+		err = uw.IDXTTLs().CreateOrReplace(ctx, dbidxttl)
+		if err != nil {
+			return err
 		}
 	}
-end:
-	return
+
+	return nil
+}
+
+// updateKeyTTL updates the TTL of only the key of the ik, as the
+// f is shared with other keys that keep the one they had
+func (l *local) updateKeyTTL(ctx context.Context, uw uow.UnitOfWork, ik *idxkey.IDXKey, f *file.File, ttl time.Duration) error {
+	// The TTL of the File expires all its keys so it's
+	// moved to each one of the rest of them instead
+	err := l.moveFileTTLToKeys(ctx, uw, f, ik.Key)
+	if err != nil {
+		return err
+	}
+
+	var ea time.Time
+	if ttl != noTTL {
+		ea = f.CreatedAt.Add(ttl)
+	}
+
+	return l.setKeyTTL(ctx, uw, ik, ea)
+}
+
+// moveFileTTLToKeys moves the TTL of the f to each one of its keys,
+// except the skip, so the f can have keys that do not expire with it
+func (l *local) moveFileTTLToKeys(ctx context.Context, uw uow.UnitOfWork, f *file.File, skip string) error {
+	if f.TTL == noTTL {
+		return nil
+	}
+
+	ea := f.ExpiresAt()
+	err := l.deleteFileTTL(ctx, uw, f)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range f.Keys {
+		if k == skip {
+			continue
+		}
+		ik, err := uw.IDXKeys().FindByKey(ctx, k)
+		if err != nil {
+			return err
+		}
+		// The one of the File could be
+		// later than the one it has
+		if !ik.ExpiresAt.IsZero() && ik.ExpiresAt.Before(ea) {
+			continue
+		}
+		err = l.setKeyTTL(ctx, uw, ik, ea)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.TTL = noTTL
+
+	return uw.Files().CreateOrReplace(ctx, f)
+}
+
+// deleteFileTTL removes the Signature of the
+// f from the IDXTTL of its expiration
+func (l *local) deleteFileTTL(ctx context.Context, uw uow.UnitOfWork, f *file.File) error {
+	if f.TTL == noTTL {
+		return nil
+	}
+
+	dbidxttl, err := uw.IDXTTLs().Find(ctx, f.ExpiresAt())
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	dbidxttl.DeleteSignatures(f.Signature)
+	if dbidxttl.IsEmpty() {
+		return uw.IDXTTLs().Delete(ctx, dbidxttl.ExpiresAt)
+	}
+
+	return uw.IDXTTLs().CreateOrReplace(ctx, dbidxttl)
+}
+
+// setKeyTTL sets the expiration of only the key of the ik to the
+// ea, or removes it if it's zero, and stores the ik with it
+func (l *local) setKeyTTL(ctx context.Context, uw uow.UnitOfWork, ik *idxkey.IDXKey, ea time.Time) error {
+	if ik.ExpiresAt.Equal(ea) {
+		return nil
+	}
+
+	err := l.deleteKeyTTL(ctx, uw, ik)
+	if err != nil {
+		return err
+	}
+
+	ik.ExpiresAt = ea
+
+	if !ea.IsZero() {
+		dbidxttl, err := uw.IDXTTLs().Find(ctx, ea)
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+		if dbidxttl == nil {
+			dbidxttl = idxttl.New(ea)
+		}
+		dbidxttl.AddKeys(ik.Key)
+
+		err = uw.IDXTTLs().CreateOrReplace(ctx, dbidxttl)
+		if err != nil {
+			return err
+		}
+	}
+
+	return uw.IDXKeys().CreateOrReplace(ctx, ik)
+}
+
+// deleteKeyTTL removes the key of the ik from the IDXTTL
+// of its own expiration, if it has one
+func (l *local) deleteKeyTTL(ctx context.Context, uw uow.UnitOfWork, ik *idxkey.IDXKey) error {
+	if ik.ExpiresAt.IsZero() {
+		return nil
+	}
+
+	dbidxttl, err := uw.IDXTTLs().Find(ctx, ik.ExpiresAt)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	dbidxttl.DeleteKeys(ik.Key)
+	if dbidxttl.IsEmpty() {
+		return uw.IDXTTLs().Delete(ctx, dbidxttl.ExpiresAt)
+	}
+
+	return uw.IDXTTLs().CreateOrReplace(ctx, dbidxttl)
 }
@@ -0,0 +1,222 @@
+package volume_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/file"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/idxttl"
+	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/state"
+)
+
+func TestLoopTTL(t *testing.T) {
+	t.Run("SuccessOverdue", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			signature = "123123123"
+			// It expired long before, like
+			// after a long downtime
+			ea   = time.Now().Add(-48 * time.Hour)
+			done = make(chan struct{})
+		)
+		defer mv.Finish()
+
+		mv.IDXTTLs.EXPECT().Filter(gomock.Any(), gomock.Any(), 100).Return([]*idxttl.IDXTTL{{ExpiresAt: ea, Signatures: []string{signature}, Keys: []string{"b"}}}, nil)
+		mv.IDXTTLs.EXPECT().Filter(gomock.Any(), gomock.Any(), 100).Return(nil, nil).AnyTimes()
+
+		mv.Files.EXPECT().FindBySignature(gomock.Any(), signature).Return(&file.File{Keys: []string{"a"}, Signature: signature, Size: 5}, nil).Times(2)
+		mv.IDXKeys.EXPECT().FindByKey(gomock.Any(), "a").Return(idxkey.New("a", signature), nil)
+		mv.Files.EXPECT().DeleteBySignature(gomock.Any(), signature).Return(nil)
+		mv.Fs.EXPECT().Remove(file.Path("/file", signature)).Return(nil)
+		mv.State.EXPECT().Find(gomock.Any()).Return(&state.State{VolumeTotalSize: 1000, VolumeUsedSize: 100}, nil)
+		mv.State.EXPECT().Update(gomock.Any(), &state.State{VolumeTotalSize: 1000, VolumeUsedSize: 95, SystemUsedSize: -5}).Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(gomock.Any(), "a").Return(nil)
+		mv.IDXTTLs.EXPECT().Find(gomock.Any(), ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{signature}, Keys: []string{"b"}}, nil)
+		mv.IDXTTLs.EXPECT().CreateOrReplace(gomock.Any(), &idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{}, Keys: []string{"b"}}).Return(nil)
+
+		// The key was already restored from the trash
+		mv.IDXKeys.EXPECT().FindByKey(gomock.Any(), "b").Return(nil, errors.New("not found"))
+
+		// It's only expired once
+		mv.IDXTTLs.EXPECT().Find(gomock.Any(), ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{}, Keys: []string{"b"}}, nil)
+		mv.IDXTTLs.EXPECT().Delete(gomock.Any(), ea).Return(nil)
+
+		mv.State.EXPECT().Find(gomock.Any()).Return(&state.State{Expiry: state.Expiry{Expired: 2}}, nil)
+		mv.State.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, st *state.State) error {
+			defer close(done)
+			assert.Equal(t, 3, st.Expiry.Expired)
+			assert.True(t, st.Expiry.Lag >= 48*time.Hour)
+			assert.False(t, st.Expiry.ExpiredAt.IsZero())
+			return nil
+		})
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("the TTLs were not expired")
+		}
+	})
+	t.Run("SuccessKeepFailed", func(t *testing.T) {
+		var (
+			mv        = newManageVolume(t, "/")
+			signature = "123123123"
+			ea        = time.Now().Add(-time.Minute)
+			done      = make(chan struct{})
+		)
+		defer mv.Finish()
+
+		mv.IDXTTLs.EXPECT().Filter(gomock.Any(), gomock.Any(), 100).Return([]*idxttl.IDXTTL{{ExpiresAt: ea, Signatures: []string{signature}, Keys: []string{"b"}}}, nil)
+		mv.IDXTTLs.EXPECT().Filter(gomock.Any(), gomock.Any(), 100).Return(nil, nil).AnyTimes()
+
+		// The Signature is kept to be expired on the next tick
+		mv.Files.EXPECT().FindBySignature(gomock.Any(), signature).Return(&file.File{Keys: []string{"a"}, Signature: signature, Size: 5}, nil)
+		mv.IDXKeys.EXPECT().FindByKey(gomock.Any(), "a").Return(nil, errors.New("failed"))
+
+		// And only the key is removed
+		mv.IDXKeys.EXPECT().FindByKey(gomock.Any(), "b").Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().Find(gomock.Any(), ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{signature}, Keys: []string{"b"}}, nil)
+		mv.IDXTTLs.EXPECT().CreateOrReplace(gomock.Any(), &idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{signature}, Keys: []string{}}).Return(nil)
+
+		mv.State.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil)
+		mv.State.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, st *state.State) error {
+			defer close(done)
+			assert.Equal(t, 0, st.Expiry.Expired)
+			return nil
+		})
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("the TTLs were not expired")
+		}
+	})
+}
+
+func TestUpdateFileTTL(t *testing.T) {
+	var (
+		signature = "123123123"
+		ca        = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	t.Run("Success", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+			rp  = &replica.Replica{ID: "rp", Key: "a", Signature: signature, TTL: time.Hour}
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature, TTL: time.Hour, CreatedAt: ca}, nil)
+
+		// It's removed from the old expiration
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(time.Hour)).Return(idxttl.New(ca.Add(time.Hour), signature, "other"), nil)
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, idxttl.New(ca.Add(time.Hour), "other")).Return(nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a"}, Signature: signature, TTL: 2 * time.Hour, CreatedAt: ca}).Return(nil)
+
+		// And added to the new one
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(2*time.Hour)).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, idxttl.New(ca.Add(2*time.Hour), signature)).Return(nil)
+
+		mv.Replicas.EXPECT().All(ctx).Return([]*replica.Replica{rp, {ID: "other", Signature: "other"}}, nil)
+		mv.Replicas.EXPECT().Delete(ctx, rp).Return(nil)
+		mv.Replicas.EXPECT().Create(ctx, &replica.Replica{ID: "rp", Key: "a", Signature: signature, TTL: 2 * time.Hour}).Return(nil)
+
+		err := mv.V.UpdateFileTTL(ctx, "a", 2*time.Hour)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessRemove", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature, TTL: time.Hour, CreatedAt: ca}, nil)
+
+		// As it has nothing else to expire it's deleted
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(time.Hour)).Return(idxttl.New(ca.Add(time.Hour), signature), nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, ca.Add(time.Hour)).Return(nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a"}, Signature: signature, CreatedAt: ca}).Return(nil)
+
+		mv.Replicas.EXPECT().All(ctx).Return(nil, nil)
+
+		err := mv.V.UpdateFileTTL(ctx, "a", 0)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessShared", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a", "b"}, Signature: signature, TTL: time.Hour, CreatedAt: ca}, nil)
+
+		// The TTL of the File is moved to the other key
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(time.Hour)).Return(idxttl.New(ca.Add(time.Hour), signature), nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, ca.Add(time.Hour)).Return(nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(idxkey.New("b", signature), nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(time.Hour)).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ca.Add(time.Hour), Keys: []string{"b"}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: signature, ExpiresAt: ca.Add(time.Hour)}).Return(nil)
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"a", "b"}, Signature: signature, CreatedAt: ca}).Return(nil)
+
+		// And only the key has the new one
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(2*time.Hour)).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ca.Add(2 * time.Hour), Keys: []string{"a"}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "a", Value: signature, ExpiresAt: ca.Add(2 * time.Hour)}).Return(nil)
+
+		mv.Replicas.EXPECT().All(ctx).Return(nil, nil)
+
+		err := mv.V.UpdateFileTTL(ctx, "a", 2*time.Hour)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessSameTTL", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(idxkey.New("a", signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{"a"}, Signature: signature, TTL: time.Hour, CreatedAt: ca}, nil)
+
+		mv.Replicas.EXPECT().All(ctx).Return(nil, nil)
+
+		err := mv.V.UpdateFileTTL(ctx, "a", time.Hour)
+		require.NoError(t, err)
+	})
+	t.Run("ErrorNotFound", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "a").Return(nil, errors.New("not found"))
+
+		err := mv.V.UpdateFileTTL(ctx, "a", time.Hour)
+		assert.EqualError(t, err, "not found")
+	})
+	t.Run("ErrorNegative", func(t *testing.T) {
+		var (
+			mv  = newManageVolume(t, "/")
+			ctx = context.Background()
+		)
+		defer mv.Finish()
+
+		err := mv.V.UpdateFileTTL(ctx, "a", -time.Hour)
+		assert.EqualError(t, err, "the ttl can not be negative")
+	})
+}
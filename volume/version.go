@@ -0,0 +1,268 @@
+package volume
+
+import (
+	"context"
+	"time"
+
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/idxkey"
+	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
+)
+
+// deleteMarker is used as the Signature to calculate the ID
+// of the Versions that are a DeleteMarker
+const deleteMarker = "delete"
+
+func (l *local) Versions(ctx context.Context, key string) ([]*version.Version, error) {
+	var h *version.History
+	err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		var err error
+		h, err = uw.Versions().FindByKey(ctx, key)
+		if err != nil {
+			return err
+		}
+		return nil
+	}, l.versions)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Versions, nil
+}
+
+func (l *local) HasVersions(ctx context.Context, key string) (string, bool, error) {
+	_, err := l.Versions(ctx, key)
+	if err != nil {
+		if err.Error() == "not found" {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return l.id, true, nil
+}
+
+// versioning returns the Versioning of the Bucket of the key
+// if it has it enabled, if not it returns nil
+func (l *local) versioning(ctx context.Context, uw uow.UnitOfWork, key string) (*bucket.Versioning, error) {
+	bn, ok := bucket.Name(key)
+	if !ok {
+		return nil, nil
+	}
+
+	b, err := uw.Buckets().FindByName(ctx, bn)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if b.Deleted || !b.Versioning.Enabled {
+		return nil, nil
+	}
+
+	return &b.Versioning, nil
+}
+
+// addDeleteMarker adds a DeleteMarker, deleted at da,
+// as the newest Version of the key if it has Versioning
+func (l *local) addDeleteMarker(ctx context.Context, uw uow.UnitOfWork, key string, da time.Time) error {
+	vr, err := l.versioning(ctx, uw, key)
+	if err != nil || vr == nil {
+		return err
+	}
+
+	return l.addVersion(ctx, uw, key, &version.Version{
+		ID:           version.NewID(da, deleteMarker),
+		CreatedAt:    da,
+		DeleteMarker: true,
+	}, vr)
+}
+
+// addVersion adds the v as the newest Version of the key. If it's
+// not a DeleteMarker the File of it also gets the key of the Version
+// so it's not removed when the key is replaced or deleted
+func (l *local) addVersion(ctx context.Context, uw uow.UnitOfWork, key string, v *version.Version, vr *bucket.Versioning) error {
+	h, err := uw.Versions().FindByKey(ctx, key)
+	if err != nil && err.Error() != "not found" {
+		return err
+	}
+	if h == nil {
+		h = &version.History{Key: key}
+	}
+
+	var prev *version.Version
+	if len(h.Versions) != 0 {
+		prev = h.Versions[len(h.Versions)-1]
+	}
+
+	// It's already there, it happens when
+	// the same File is replicated again
+	if !h.Add(v) {
+		return nil
+	}
+
+	if !v.DeleteMarker {
+		vk := version.Key(key, v.ID)
+		f, err := uw.Files().FindBySignature(ctx, v.Signature)
+		if err != nil {
+			return err
+		}
+		f.Keys = append(f.Keys, vk)
+
+		err = uw.Files().CreateOrReplace(ctx, f)
+		if err != nil {
+			return err
+		}
+
+		err = uw.IDXKeys().CreateOrReplace(ctx, idxkey.New(vk, v.Signature))
+		if err != nil {
+			return err
+		}
+		l.keysFilter.add(vk)
+	}
+
+	err = uw.Versions().CreateOrReplace(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	// The previous Version is now noncurrent so
+	// it's accounted on the Usage of the key
+	err = l.updateVersionUsage(ctx, uw, key, prev, 1)
+	if err != nil {
+		return err
+	}
+
+	return l.pruneVersions(ctx, uw, h, vr)
+}
+
+// updateVersionUsage adds the v of the key to the Usage, or removes
+// it if n is -1, when it changes from (or to) the current Version
+func (l *local) updateVersionUsage(ctx context.Context, uw uow.UnitOfWork, key string, v *version.Version, n int) error {
+	if v == nil || v.DeleteMarker || len(l.keyPrefixes(key)) == 0 {
+		return nil
+	}
+
+	f, err := uw.Files().FindBySignature(ctx, v.Signature)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	return l.updateUsage(ctx, uw, f, n, version.Key(key, v.ID))
+}
+
+// pruneVersions deletes the noncurrent Versions of the h that
+// are over the limits of the vr. If at the end the only Version
+// left is a DeleteMarker it's also removed as it has nothing to hide
+func (l *local) pruneVersions(ctx context.Context, uw uow.UnitOfWork, h *version.History, vr *bucket.Versioning) error {
+	for _, v := range h.Prune(vr.MaxVersions, vr.MaxAge, time.Now()) {
+		err := l.deleteVersion(ctx, uw, h.Key, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	h, err := uw.Versions().FindByKey(ctx, h.Key)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	if len(h.Versions) == 1 && h.Versions[0].DeleteMarker {
+		return uw.Versions().DeleteByKey(ctx, h.Key)
+	}
+
+	return nil
+}
+
+// deleteVersion deletes the v of the key and the File of it if
+// no other key has it
+func (l *local) deleteVersion(ctx context.Context, uw uow.UnitOfWork, key string, v *version.Version) error {
+	if !v.DeleteMarker {
+		err := l.deleteFile(ctx, uw, version.Key(key, v.ID))
+		if err != nil && err.Error() != "not found" {
+			return err
+		}
+	}
+
+	return l.removeVersion(ctx, uw, key, v.ID)
+}
+
+// removeVersion removes the Version with the id
+// from the History of the key
+func (l *local) removeVersion(ctx context.Context, uw uow.UnitOfWork, key, id string) error {
+	h, err := uw.Versions().FindByKey(ctx, key)
+	if err != nil {
+		if err.Error() == "not found" {
+			return nil
+		}
+		return err
+	}
+
+	// If the newest one is removed the previous one is the
+	// current Version so it's no longer accounted, it has to be
+	// done before the History is updated as it's still noncurrent
+	if n := len(h.Versions); n > 1 && h.Versions[n-1].ID == id {
+		err = l.updateVersionUsage(ctx, uw, key, h.Versions[n-2], -1)
+		if err != nil {
+			return err
+		}
+	}
+
+	h.Remove(id)
+	if len(h.Versions) == 0 {
+		return uw.Versions().DeleteByKey(ctx, key)
+	}
+
+	return uw.Versions().CreateOrReplace(ctx, h)
+}
+
+// loopVersions prunes periodically the Versions so the
+// ones older than the MaxAge are removed even if the
+// key has no new Versions
+func (l *local) loopVersions() {
+	tk := time.NewTicker(TickerDuration)
+	for {
+		select {
+		case <-l.ctx.Done():
+			tk.Stop()
+			return
+		case <-tk.C:
+			err := l.startUnitOfWork(l.ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+				hs, err := uw.Versions().All(ctx)
+				if err != nil {
+					return err
+				}
+
+				for _, h := range hs {
+					vr, err := l.versioning(ctx, uw, h.Key)
+					if err != nil {
+						return err
+					}
+					// If the Versioning has been disabled the
+					// Versions are kept until they are deleted
+					if vr == nil {
+						continue
+					}
+
+					err = l.pruneVersions(ctx, uw, h, vr)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}, l.versions, l.buckets, l.idxkeys, l.files, l.fs, l.state, l.idxttls)
+			if err != nil {
+				l.logger.Log("msg", err.Error())
+			}
+		}
+	}
+}
@@ -1,14 +1,15 @@
 package volume
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha1"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,13 +18,23 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/spf13/afero"
+	"github.com/xescugc/rebost/apikey"
+	"github.com/xescugc/rebost/bloom"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/encryption"
 	"github.com/xescugc/rebost/file"
 	"github.com/xescugc/rebost/idxkey"
 	"github.com/xescugc/rebost/idxttl"
 	"github.com/xescugc/rebost/idxvolume"
+	"github.com/xescugc/rebost/lifecycle"
+	"github.com/xescugc/rebost/quota"
 	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/state"
+	"github.com/xescugc/rebost/trash"
 	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
 )
 
 const (
@@ -41,15 +52,17 @@ const (
 // Volume is an interface to deal with the simples actions
 // and basic ones
 type Volume interface {
-	// CreateFile creates a new file from the reader with the key, ttl and time of creation (if empty will be set to now)
+	// CreateFile creates a new file from the reader with the key, ttl, time of creation (if empty will be set to now)
+	// and storage class (if empty no class is used)
 	// There are 4 different use cases to consider:
 	// * New key and reader
 	// * New key with already known reader
 	// * Already known key with new reader
 	// * Already known key and reader
-	CreateFile(ctx context.Context, key string, reader io.ReadCloser, replica int, ttl time.Duration, ca time.Time) error
+	CreateFile(ctx context.Context, key string, reader io.ReadCloser, replica int, ttl time.Duration, ca time.Time, class string) error
 
-	// GetFile search for the file with the key
+	// GetFile search for the file with the key, if the file
+	// is compressed it returns a *compression.Reader
 	GetFile(ctx context.Context, key string) (io.ReadCloser, error)
 
 	// HasFile checks if a file with the key exists and returns the volumeID
@@ -66,6 +79,24 @@ type Volume interface {
 	// UpdateFileReplica updates the Replica information of the file
 	// with the given one basically replacing it
 	UpdateFileReplica(ctx context.Context, key string, volumeIDs []string, replica int) error
+
+	// Versions returns the Versions of the key, from the
+	// oldest to the newest, even if the key has been deleted
+	Versions(ctx context.Context, key string) ([]*version.Version, error)
+
+	// HasVersions checks if the key has Versions and returns the volumeID
+	// of where are they, like HasFile
+	HasVersions(ctx context.Context, key string) (string, bool, error)
+
+	// CopyFile adds the dst key to the file of the src key without copying
+	// the content, if move the src key is deleted. If the dst already exists
+	// it's replaced. The ca is the time of the copy (if empty will be set to now)
+	CopyFile(ctx context.Context, src, dst string, move bool, ca time.Time) error
+
+	// UpdateFileTTL updates the TTL of the file of the key, which is
+	// since it was created, so it expires at a different time.
+	// If the ttl is 0 the file no longer expires
+	UpdateFileTTL(ctx context.Context, key string, ttl time.Duration) error
 }
 
 //go:generate mockgen -destination=../mock/volume_local.go -mock_names=Local=VolumeLocal -package=mock github.com/xescugc/rebost/volume Local
@@ -87,9 +118,16 @@ type Local interface {
 	NextReplica(ctx context.Context) (*replica.Replica, error)
 
 	// UpdateReplica updates the rp of the index and the File to include
-	// the vID as a volume with the Replica
+	// the vID as a volume with the Replica, or removes the File if the
+	// rp is a Move
 	UpdateReplica(ctx context.Context, rp *replica.Replica, vID string) error
 
+	// MoveReplicas returns the replicas of the Files, up to the size in
+	// bytes, that can be moved to the volume to. They are not queued
+	// as the ones of the NextReplica and, once created on the other
+	// volume, the UpdateReplica removes the File from this one
+	MoveReplicas(ctx context.Context, to string, size int) ([]*replica.Replica, error)
+
 	// SynchronizeReplicas checks the replicas related with vID and
 	// if this volume is the responsible (next after the removed ID on the files)
 	// will start replication of those files which have to
@@ -100,6 +138,43 @@ type Local interface {
 
 	// Reset will clean all the data of the volume and even change the ID
 	Reset(ctx context.Context) error
+
+	// GetBucket returns the Bucket with the name, even if it's deleted
+	GetBucket(ctx context.Context, name string) (*bucket.Bucket, error)
+
+	// Buckets returns all the Buckets, including the deleted ones
+	Buckets(ctx context.Context) ([]*bucket.Bucket, error)
+
+	// PutBucket stores the b only if it's newer than
+	// the one already stored with the same name
+	PutBucket(ctx context.Context, b *bucket.Bucket) error
+
+	// APIKeys returns all the API Keys, including the deleted ones
+	APIKeys(ctx context.Context) ([]*apikey.Key, error)
+
+	// PutAPIKey stores the k only if it's newer than
+	// the one already stored with the same ID
+	PutAPIKey(ctx context.Context, k *apikey.Key) error
+
+	// TrashFile deletes the key moving it to the trash, as
+	// deleted at da, until it's purged at the pa
+	TrashFile(ctx context.Context, key string, da, pa time.Time) error
+
+	// Trash returns all the Items of the trash
+	Trash(ctx context.Context) ([]*trash.Item, error)
+
+	// RestoreTrash restores the Item with the id of the key
+	// from the trash, if the key already exists it fails
+	RestoreTrash(ctx context.Context, key, id string) error
+
+	// KeysFilter returns a bloom.Filter with all the keys of the volume,
+	// it's updated with each key added and only calculated again from
+	// all the keys when too many of them were removed
+	KeysFilter(ctx context.Context) (*bloom.Filter, error)
+
+	// GetFileSignature returns the Signature of the content
+	// of the file with the key
+	GetFileSignature(ctx context.Context, key string) (string, error)
 }
 
 type local struct {
@@ -110,6 +185,12 @@ type local struct {
 	root      string
 	totalSize int
 
+	alg          signature.Algorithm
+	compressions map[string]compression.Algorithm
+	keyring      *encryption.Keyring
+	prefixes     []string
+	rules        []lifecycle.Rule
+
 	fs         afero.Fs
 	files      file.Repository
 	idxkeys    idxkey.Repository
@@ -117,6 +198,14 @@ type local struct {
 	replicas   replica.Repository
 	idxvolumes idxvolume.Repository
 	state      state.Repository
+	buckets    bucket.Repository
+	versions   version.Repository
+	trash      trash.Repository
+	apikeys    apikey.Repository
+
+	// keysFilter is the filter of the keys of the
+	// volume updated with each change of them
+	keysFilter keysFilter
 
 	startUnitOfWork uow.StartUnitOfWork
 
@@ -127,11 +216,71 @@ type local struct {
 	cancel context.CancelFunc
 }
 
+// Repositories are the Repositories where the volume stores
+// all its data, all of them are required
+type Repositories struct {
+	Files      file.Repository
+	IDXKeys    idxkey.Repository
+	IDXTTLs    idxttl.Repository
+	IDXVolumes idxvolume.Repository
+	Replicas   replica.Repository
+	State      state.Repository
+
+	// Buckets are the settings of the Buckets of the cluster,
+	// which are not removed when the volume is Reset
+	Buckets bucket.Repository
+
+	// Versions is the History of the Versions of the
+	// keys of the Buckets with Versioning
+	Versions version.Repository
+
+	// Trash has the Items of the trash
+	Trash trash.Repository
+
+	// APIKeys are the API Keys of the cluster, which
+	// are not removed when the volume is Reset
+	APIKeys apikey.Repository
+}
+
+// Options are the settings of the volume, all of them are optional
+type Options struct {
+	// Hash is the algorithm used to calculate the Signatures of
+	// the files, if empty it's the signature.Default
+	Hash signature.Algorithm
+
+	// Compressions are the compression Algorithm of each storage class
+	Compressions map[string]compression.Algorithm
+
+	// Keyring, if not nil, is used to store the files encrypted with
+	// a data key wrapped with the current master key of it, and the
+	// data keys wrapped with other master keys are wrapped again in
+	// the background
+	Keyring *encryption.Keyring
+
+	// Prefixes are the key prefixes which logical usage is
+	// calculated, with the State, apart from the one of
+	// each Bucket
+	Prefixes []string
+
+	// Rules are the lifecycle Rules applied
+	// to the files in the background
+	Rules []lifecycle.Rule
+}
+
 // New returns an implementation of the volume.Local interface using the provided parameters
 // it can return an error because when initialized it also creates the needed directories
 // if they are missing which are $root/file and $root/tmps and also the ID
 // To define a total size of the volume it has to be appended to the root like `/v1:1GB`
-func New(root string, files file.Repository, idxkeys idxkey.Repository, idxttls idxttl.Repository, idxvolumes idxvolume.Repository, rp replica.Repository, sr state.Repository, fileSystem afero.Fs, logger kitlog.Logger, suow uow.StartUnitOfWork) (Local, error) {
+// The Hash of the opts is stored on $root/hash and if an already existing volume uses
+// a different one an error is returned as it has to be migrated first with Migrate.
+// The legacy volumes, with no hash, use SHA1 so they are migrated to it on the fly
+// which only prefixes the Signatures.
+func New(root string, rs Repositories, fileSystem afero.Fs, suow uow.StartUnitOfWork, logger kitlog.Logger, opts Options) (Local, error) {
+	alg := opts.Hash
+	if alg == "" {
+		alg = signature.Default
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	sroot := strings.Split(root, ":")
 	ts := -1
@@ -150,13 +299,23 @@ func New(root string, files file.Repository, idxkeys idxkey.Repository, idxttls
 		root:      root,
 		totalSize: ts,
 
-		files:      files,
+		alg:          alg,
+		compressions: opts.Compressions,
+		keyring:      opts.Keyring,
+		prefixes:     opts.Prefixes,
+		rules:        opts.Rules,
+
+		files:      rs.Files,
 		fs:         fileSystem,
-		idxkeys:    idxkeys,
-		idxttls:    idxttls,
-		idxvolumes: idxvolumes,
-		replicas:   rp,
-		state:      sr,
+		idxkeys:    rs.IDXKeys,
+		idxttls:    rs.IDXTTLs,
+		idxvolumes: rs.IDXVolumes,
+		replicas:   rs.Replicas,
+		state:      rs.State,
+		buckets:    rs.Buckets,
+		versions:   rs.Versions,
+		trash:      rs.Trash,
+		apikeys:    rs.APIKeys,
 
 		originalLogger: logger,
 
@@ -178,6 +337,7 @@ func New(root string, files file.Repository, idxkeys idxkey.Repository, idxttls
 
 	var id string
 	idPath := path.Join(root, "id")
+	hashPath := path.Join(root, "hash")
 	// Creates or reads the id from the idPath as a Volume
 	// must have always the same ID
 	if _, err = l.fs.Stat(idPath); os.IsNotExist(err) {
@@ -185,7 +345,36 @@ func New(root string, files file.Repository, idxkeys idxkey.Repository, idxttls
 		if err != nil {
 			return nil, err
 		}
+
+		err = writeAlgorithm(l.fs, hashPath, alg)
+		if err != nil {
+			return nil, err
+		}
 	} else {
+		valg, err := readAlgorithm(l.fs, hashPath)
+		if err != nil {
+			return nil, err
+		}
+		// The legacy volumes have no hash and their Signatures
+		// are SHA1, so if it's the one used they are only
+		// prefixed with it to not mix both formats
+		if valg == "" && alg == signature.SHA1 {
+			mlogger := logger
+			if mlogger == nil {
+				mlogger = kitlog.NewNopLogger()
+			}
+			err = Migrate(ctx, root, rs.Files, rs.IDXKeys, rs.IDXTTLs, rs.IDXVolumes, rs.Replicas, l.fs, mlogger, suow, alg, opts.Keyring)
+			if err != nil {
+				return nil, fmt.Errorf("error migrating the legacy volume %q: %w", root, err)
+			}
+			valg = alg
+		} else if valg == "" {
+			valg = signature.SHA1
+		}
+		if valg != alg {
+			return nil, fmt.Errorf("the volume %q uses the hash %q and not %q, it has to be migrated with 'rebost migrate --hash %s'", root, valg, alg, alg)
+		}
+
 		fh, err := l.fs.Open(idPath)
 		if err != nil {
 			return nil, err
@@ -211,8 +400,8 @@ func New(root string, files file.Repository, idxkeys idxkey.Repository, idxttls
 		if err != nil {
 			return err
 		}
-		return nil
-	}, l.state)
+		return l.calculateUsage(ctx, uw)
+	}, l.state, l.idxkeys, l.files, l.versions)
 	if err != nil {
 		return nil, err
 	}
@@ -241,6 +430,21 @@ func New(root string, files file.Repository, idxkeys idxkey.Repository, idxttls
 	// We check if there is any TTL expiring
 	go l.loopTTL()
 
+	// We prune the Versions that are too old
+	go l.loopVersions()
+
+	// We apply the lifecycle Rules
+	// to the files that match them
+	if len(l.rules) != 0 {
+		go l.loopLifecycle()
+	}
+
+	// We wrap the data keys with the current master
+	// key each time it's rotated
+	if l.keyring != nil {
+		go l.loopRewrapKeys()
+	}
+
 	return l, nil
 }
 
@@ -251,7 +455,13 @@ func (l *local) Close() error {
 	return nil
 }
 
-func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep int, ttl time.Duration, ca time.Time) error {
+func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep int, ttl time.Duration, ca time.Time, class string) error {
+	comp, ok := l.compressions[class]
+	if !ok && class != "" {
+		r.Close()
+		return fmt.Errorf("invalid class %q", class)
+	}
+
 	tmp := path.Join(l.tempDir, uuid.NewV4().String())
 
 	fh, err := l.fs.Create(tmp)
@@ -260,10 +470,27 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 	}
 	defer fh.Close()
 
-	sh1 := sha1.New()
-	w := io.MultiWriter(fh, sh1)
-	io.Copy(w, r)
+	cw, ekid, encryptedKey, err := l.newContentWriter(fh, comp)
+	if err != nil {
+		return err
+	}
+
+	// The Signature is calculated with the content
+	// before compressing it
+	h := l.alg.New()
+	w := io.MultiWriter(cw, h)
+	n, err := io.Copy(w, r)
 	r.Close()
+	if err == nil {
+		err = cw.Close()
+	}
+	if err != nil {
+		// If the content could not be read completely
+		// (or it was invalid) we do not want to store it
+		fh.Close()
+		l.fs.Remove(tmp)
+		return err
+	}
 
 	fi, err := fh.Stat()
 	if err != nil {
@@ -274,28 +501,32 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 		ca = time.Now()
 	}
 	f := &file.File{
-		Keys:      []string{key},
-		Signature: fmt.Sprintf("%x", sh1.Sum(nil)),
-		Replica:   rep,
-		Size:      int(fi.Size()),
-		TTL:       ttl,
-		CreatedAt: ca,
+		Keys:        []string{key},
+		Signature:   l.alg.Sum(h),
+		Replica:     rep,
+		Size:        int(n),
+		StoredSize:  int(fi.Size()),
+		Class:       class,
+		Compression: comp,
+		TTL:         ttl,
+		CreatedAt:   ca,
+
+		EncryptionKeyID: ekid,
+		EncryptedKey:    encryptedKey,
 	}
 
 	p := f.Path(l.fileDir)
-	dir, _ := path.Split(p)
-
-	err = l.fs.MkdirAll(dir, os.ModePerm)
-	if err != nil {
-		return err
-	}
-
-	err = l.fs.Rename(tmp, p)
-	if err != nil {
-		return err
-	}
 
+	// swap is set when the content already on the disk
+	// has to be replaced with the tmp once it's committed
+	// and moved when the tmp is already on the p
+	var swap, moved, shared bool
 	err = l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
+		_, err := l.rewrapKey(f)
+		if err != nil {
+			return err
+		}
+
 		dbf, err := uw.Files().FindBySignature(ctx, f.Signature)
 		if err != nil && err.Error() != "not found" {
 			return err
@@ -304,6 +535,34 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 		// If the File already exists on the DB with that signature
 		// we have to add another key if it's not already there
 		if dbf != nil {
+			// The content on the disk is replaced with the new
+			// one so how it's stored has to be updated
+			stored := dbf.Compression != f.Compression || dbf.EncryptionKeyID != f.EncryptionKeyID || !bytes.Equal(dbf.EncryptedKey, f.EncryptedKey)
+			changed := stored || dbf.Class != f.Class
+			swap = stored
+			dbf.Class = f.Class
+			if stored {
+				if d := f.DiskSize() - dbf.DiskSize(); d != 0 {
+					st, err := uw.State().Find(ctx)
+					if err != nil {
+						return err
+					}
+					if !st.Use(d) {
+						return errors.New("file is too large for the dedicated space left")
+					}
+
+					err = uw.State().Update(ctx, st)
+					if err != nil {
+						return err
+					}
+				}
+
+				dbf.StoredSize = f.StoredSize
+				dbf.Compression = f.Compression
+				dbf.EncryptionKeyID = f.EncryptionKeyID
+				dbf.EncryptedKey = f.EncryptedKey
+			}
+
 			ok := false
 			for _, k := range dbf.Keys {
 				if k == key {
@@ -311,10 +570,45 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 				}
 			}
 			if ok {
-				return nil
+				if changed {
+					err = uw.Files().CreateOrReplace(ctx, dbf)
+					if err != nil {
+						return err
+					}
+				}
+
+				// The key is created again so it gets the new ttl, or
+				// none, on its own as the File could have other keys
+				ik, err := uw.IDXKeys().FindByKey(ctx, key)
+				if err != nil {
+					return err
+				}
+				err = l.moveFileTTLToKeys(ctx, uw, dbf, key)
+				if err != nil {
+					return err
+				}
+				var ea time.Time
+				if ttl != noTTL {
+					ea = ca.Add(ttl)
+				}
+				err = l.setKeyTTL(ctx, uw, ik, ea)
+				if err != nil {
+					return err
+				}
+
+				return l.putVersion(ctx, uw, key, dbf, ca)
 			}
+
+			// The TTL of the File would also expire the key, or
+			// before its own ttl, so it's moved to the keys it has
+			err = l.moveFileTTLToKeys(ctx, uw, dbf, "")
+			if err != nil {
+				return err
+			}
+
 			dbf.Keys = append(dbf.Keys, key)
 			f = dbf
+			shared = true
 		} else {
 			// Update the State with the new file
 			// created size
@@ -322,7 +616,7 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 			if err != nil {
 				return err
 			}
-			if !st.Use(f.Size) {
+			if !st.Use(f.DiskSize()) {
 				return errors.New("file is too large for the dedicated space left")
 			}
 
@@ -330,6 +624,19 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 			if err != nil {
 				return err
 			}
+
+			// It's moved back to the tmp if it fails
+			dir, _ := path.Split(p)
+			err = uw.Fs().MkdirAll(dir, os.ModePerm)
+			if err != nil {
+				return err
+			}
+
+			err = uw.Fs().Rename(tmp, p)
+			if err != nil {
+				return err
+			}
+			moved = true
 		}
 
 		f.VolumeIDs = append(f.VolumeIDs, l.ID())
@@ -352,10 +659,21 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 		// At the end the new key will replace the old one found
 		// TODO: Update the value on the idxvolumes
 		if ik != nil {
+			err = l.deleteKeyTTL(ctx, uw, ik)
+			if err != nil {
+				return err
+			}
+
 			dbf, err := uw.Files().FindBySignature(ctx, ik.Value)
 			if err != nil && err.Error() != "not found" {
 				return err
 			}
+
+			err = l.updateUsage(ctx, uw, dbf, -1, key)
+			if err != nil {
+				return err
+			}
+
 			newKeys := make([]string, 0, len(dbf.Keys)-1)
 			for _, k := range dbf.Keys {
 				if k == key {
@@ -390,14 +708,36 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 			}
 		}
 
-		err = uw.IDXKeys().CreateOrReplace(ctx, idxkey.New(key, f.Signature))
+		// It's added once the previous one is removed
+		// so the overwrites do not exceed the Quotas
+		err = l.updateUsage(ctx, uw, f, 1, key)
+		if err != nil {
+			return err
+		}
+
+		nik := idxkey.New(key, f.Signature)
+		nik.CreatedAt = ca
+		err = uw.IDXKeys().CreateOrReplace(ctx, nik)
 		if err != nil && err.Error() != "not found" {
 			return err
 		}
+		l.keysFilter.add(key)
+
+		err = l.putVersion(ctx, uw, key, f, ca)
+		if err != nil {
+			return err
+		}
 
-		// We check if there is an expiration date for the file
-		// already on the IDXTTLs
-		if ttl != noTTL {
+		// The File is shared with other keys
+		// so only the key expires
+		if ttl != noTTL && shared {
+			err = l.setKeyTTL(ctx, uw, nik, ca.Add(ttl))
+			if err != nil {
+				return err
+			}
+		} else if ttl != noTTL {
+			// We check if there is an expiration date for the file
+			// already on the IDXTTLs
 			dbidxttl, err := uw.IDXTTLs().Find(ctx, f.ExpiresAt())
 			if err != nil && err.Error() != "not found" {
 				return err
@@ -431,6 +771,7 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 				VolumeID:      l.id,
 				TTL:           ttl,
 				CreatedAt:     ca,
+				Class:         class,
 			}
 
 			err = uw.Replicas().Create(ctx, rp)
@@ -440,45 +781,140 @@ func (l *local) CreateFile(ctx context.Context, key string, r io.ReadCloser, rep
 		}
 
 		return nil
-	}, l.idxkeys, l.files, l.fs, l.replicas, l.state, l.idxttls)
+	}, l.idxkeys, l.files, l.fs, l.replicas, l.state, l.idxttls, l.buckets, l.versions, l.trash)
 
 	if err != nil {
+		l.fs.Remove(tmp)
 		return err
 	}
 
+	// The content is only replaced once the new way it's stored is
+	// committed, if not the File could not be read. If it's the same
+	// the one already on the disk is kept
+	if swap {
+		return l.fs.Rename(tmp, p)
+	} else if !moved {
+		return l.fs.Remove(tmp)
+	}
+
 	return nil
 }
 
+// putVersion adds the f as a new Version of the key
+// if the Bucket of it has Versioning
+func (l *local) putVersion(ctx context.Context, uw uow.UnitOfWork, key string, f *file.File, ca time.Time) error {
+	vr, err := l.versioning(ctx, uw, key)
+	if err != nil || vr == nil {
+		return err
+	}
+
+	return l.addVersion(ctx, uw, key, &version.Version{
+		ID:        version.NewID(ca, f.Signature),
+		Signature: f.Signature,
+		Size:      f.Size,
+		Class:     f.Class,
+		CreatedAt: ca,
+	}, vr)
+}
+
 func (l *local) GetFile(ctx context.Context, k string) (io.ReadCloser, error) {
 	var (
-		idk *idxkey.IDXKey
+		f   *file.File
 		err error
 	)
 
 	err = l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
-		idk, err = uw.IDXKeys().FindByKey(ctx, k)
+		idk, err := uw.IDXKeys().FindByKey(ctx, k)
 		if err != nil {
 			return err
 		}
+		f, err = uw.Files().FindBySignature(ctx, idk.Value)
+		if err != nil {
+			return err
+		}
+		// The expired files are not served even
+		// if they have not been deleted yet
+		if f.IsExpired(time.Now()) || idk.IsExpired(time.Now()) {
+			return errors.New("not found")
+		}
 		return nil
-	}, l.idxkeys)
+	}, l.idxkeys, l.files)
 
 	if err != nil {
 		return nil, err
 	}
 
-	fh, err := l.fs.Open(file.Path(l.fileDir, idk.Value))
+	return l.openContent(l.fs, f)
+}
+
+func (l *local) GetFileSignature(ctx context.Context, key string) (string, error) {
+	var sig string
+	err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
+		idk, err := uw.IDXKeys().FindByKey(ctx, key)
+		if err != nil {
+			return err
+		}
+		f, err := uw.Files().FindBySignature(ctx, idk.Value)
+		if err != nil {
+			return err
+		}
+		if f.IsExpired(time.Now()) || idk.IsExpired(time.Now()) {
+			return errors.New("not found")
+		}
+		sig = f.Signature
+		return nil
+	}, l.idxkeys, l.files)
+
+	if err != nil {
+		return "", err
+	}
+
+	return sig, nil
+}
+
+// openContent opens the content of the f decrypting it, if
+// it's compressed it returns a *compression.Reader
+func (l *local) openContent(fs afero.Fs, f *file.File) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	rc, err := fs.Open(f.Path(l.fileDir))
 	if err != nil {
 		return nil, err
 	}
 
-	return fh, nil
+	if f.IsEncrypted() {
+		if l.keyring == nil {
+			rc.Close()
+			return nil, errors.New("the file is encrypted and there is no keyring")
+		}
+
+		dk, err := l.keyring.Unwrap(f.EncryptionKeyID, f.EncryptedKey)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+
+		rc, err = encryption.NewReader(rc, dk)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f.Compression != compression.None {
+		return f.Compression.NewReader(rc), nil
+	}
+
+	return rc, nil
 }
 
 func (l *local) DeleteFile(ctx context.Context, key string) error {
 	return l.startUnitOfWork(ctx, uow.Write, func(ctx context.Context, uw uow.UnitOfWork) error {
-		return l.deleteFile(ctx, uw, key)
-	}, l.idxkeys, l.files, l.fs, l.state)
+		err := l.deleteFile(ctx, uw, key)
+		if err != nil {
+			return err
+		}
+
+		return l.addDeleteMarker(ctx, uw, key, time.Now())
+	}, l.idxkeys, l.files, l.fs, l.state, l.buckets, l.versions, l.trash, l.idxttls)
 }
 
 func (l *local) deleteFile(ctx context.Context, uw uow.UnitOfWork, key string) error {
@@ -490,6 +926,12 @@ func (l *local) deleteFile(ctx context.Context, uw uow.UnitOfWork, key string) e
 	if err != nil && err.Error() != "not found" {
 		return err
 	}
+
+	err = l.updateUsage(ctx, uw, dbf, -1, key)
+	if err != nil {
+		return err
+	}
+
 	newKeys := make([]string, 0, len(dbf.Keys)-1)
 	for _, k := range dbf.Keys {
 		if k == key {
@@ -514,7 +956,7 @@ func (l *local) deleteFile(ctx context.Context, uw uow.UnitOfWork, key string) e
 		if err != nil {
 			return err
 		}
-		if !st.Use(-dbf.Size) {
+		if !st.Use(-dbf.DiskSize()) {
 			return errors.New("file is too large for the dedicated space left")
 		}
 
@@ -532,17 +974,47 @@ func (l *local) deleteFile(ctx context.Context, uw uow.UnitOfWork, key string) e
 		}
 	}
 
-	return uw.IDXKeys().DeleteByKey(ctx, key)
+	err = uw.IDXKeys().DeleteByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	l.keysFilter.remove()
+
+	err = l.deleteKeyTTL(ctx, uw, ik)
+	if err != nil {
+		return err
+	}
+
+	// If it's the key of a Version it's
+	// also removed from the History
+	if k, id, ok := version.Split(key); ok {
+		return l.removeVersion(ctx, uw, k, id)
+	}
+
+	// If it's the key of an Item
+	// it's removed from the trash
+	if k, id, ok := trash.Split(key); ok {
+		return uw.Trash().Delete(ctx, k, id)
+	}
+
+	return nil
 }
 
 func (l *local) HasFile(ctx context.Context, k string) (string, bool, error) {
 	err := l.startUnitOfWork(ctx, uow.Read, func(ctx context.Context, uw uow.UnitOfWork) error {
-		_, err := uw.IDXKeys().FindByKey(ctx, k)
+		idk, err := uw.IDXKeys().FindByKey(ctx, k)
 		if err != nil {
 			return err
 		}
+		f, err := uw.Files().FindBySignature(ctx, idk.Value)
+		if err != nil {
+			return err
+		}
+		if f.IsExpired(time.Now()) || idk.IsExpired(time.Now()) {
+			return errors.New("not found")
+		}
 		return nil
-	}, l.idxkeys)
+	}, l.idxkeys, l.files)
 
 	if err != nil {
 		if err.Error() == "not found" {
@@ -588,6 +1060,12 @@ func (l *local) UpdateReplica(ctx context.Context, rp *replica.Replica, vID stri
 			return err
 		}
 
+		// The File moved to the vID is removed from the volume, unless
+		// it has other keys now, in which case it's kept as one more
+		if rp.Move && len(f.Keys) == 1 && f.Keys[0] == rp.Key {
+			return l.moveReplica(ctx, uw, f)
+		}
+
 		f.VolumeIDs = append(f.VolumeIDs, vID)
 		f.Replica = rp.OriginalCount
 
@@ -612,6 +1090,11 @@ func (l *local) UpdateReplica(ctx context.Context, rp *replica.Replica, vID stri
 			return err
 		}
 
+		// The moves are not on the queue
+		if rp.Move {
+			return nil
+		}
+
 		// Delete the replica from the  queue to reinsert it later
 		// with a different Count
 		err = uw.Replicas().Delete(ctx, rp)
@@ -629,7 +1112,7 @@ func (l *local) UpdateReplica(ctx context.Context, rp *replica.Replica, vID stri
 		}
 
 		return nil
-	}, l.replicas, l.files, l.idxkeys, l.idxvolumes)
+	}, l.replicas, l.files, l.idxkeys, l.idxvolumes, l.fs, l.state, l.buckets, l.versions, l.trash, l.idxttls)
 
 	if err != nil {
 		return err
@@ -687,17 +1170,39 @@ func (l *local) UpdateFileReplica(ctx context.Context, key string, volumeIDs []s
 			}
 		}
 
-		// TODO: Diff the VolumeIDs and update/create/delete signature from
-		// the required idxvolumes to maintain the consistency
+		// The owner of the File could change
+		// so its Usage is moved with it
+		err = l.updateUsage(ctx, uw, f, -1, f.Keys...)
+		if err != nil {
+			return err
+		}
+
+		// The volumes that no longer have the File are removed from
+		// the IDXVolumes so it's not replicated again when they are gone
+		for _, vid := range f.VolumeIDs {
+			if vid == l.ID() || slices.Contains(volumeIDs, vid) {
+				continue
+			}
+			err = l.deleteIDXVolumeSignature(ctx, uw, vid, f.Signature)
+			if err != nil {
+				return err
+			}
+		}
+
 		f.VolumeIDs = volumeIDs
 		f.Replica = replica
 
+		err = l.updateUsage(ctx, uw, f, 1, f.Keys...)
+		if err != nil {
+			return err
+		}
+
 		err = uw.Files().CreateOrReplace(ctx, f)
 		if err != nil {
 			return err
 		}
 		return nil
-	}, l.files, l.idxkeys, l.idxvolumes)
+	}, l.files, l.idxkeys, l.idxvolumes, l.state, l.versions)
 
 	if err != nil {
 		return err
@@ -742,6 +1247,7 @@ func (l *local) SynchronizeReplicas(ctx context.Context, vID string) error {
 					Signature:     f.Signature,
 					VolumeID:      l.id,
 					VolumeIDs:     f.VolumeIDs,
+					Class:         f.Class,
 				}
 
 				err = uw.Replicas().Create(ctx, rp)
@@ -818,6 +1324,16 @@ func (l *local) Reset(ctx context.Context) error {
 			return err
 		}
 
+		err = uw.Versions().DeleteAll(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = uw.Trash().DeleteAll(ctx)
+		if err != nil {
+			return err
+		}
+
 		idPath := path.Join(l.root, "id")
 		err = uw.Fs().Remove(idPath)
 		if err != nil {
@@ -829,8 +1345,13 @@ func (l *local) Reset(ctx context.Context) error {
 		l.logger = kitlog.With(l.originalLogger, "src", "volume", "id", id)
 
 		l.calculateSize(ctx, uw, l.root, l.totalSize)
+
+		// All the keys were removed so it's
+		// calculated again when needed
+		l.keysFilter.reset()
+
 		return nil
-	}, l.files, l.idxkeys, l.fs, l.replicas, l.idxvolumes, l.state)
+	}, l.files, l.idxkeys, l.fs, l.replicas, l.idxvolumes, l.state, l.versions, l.trash)
 	if err != nil {
 		return err
 	}
@@ -853,6 +1374,206 @@ func (l *local) createID(idPath string) (string, error) {
 	return id, nil
 }
 
+// writeAlgorithm writes the alg to the hashPath
+func writeAlgorithm(fs afero.Fs, hashPath string, alg signature.Algorithm) error {
+	fh, err := fs.Create(hashPath)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	_, err = io.WriteString(fh, string(alg))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// readAlgorithm reads the algorithm from the hashPath, if
+// it does not exist it returns an empty one as it means
+// the volume has legacy Signatures
+func readAlgorithm(fs afero.Fs, hashPath string) (signature.Algorithm, error) {
+	fh, err := fs.Open(hashPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer fh.Close()
+
+	b, err := io.ReadAll(fh)
+	if err != nil {
+		return "", err
+	}
+	return signature.Parse(strings.TrimSpace(string(b)))
+}
+
+// calculateUsage calculates the logical Usage of the files owned by the
+// volume for each one of the prefixes and Buckets. It's only done when
+// the volume is opened as it's updated with each change after it
+func (l *local) calculateUsage(ctx context.Context, uw uow.UnitOfWork) error {
+	iks, err := uw.IDXKeys().All(ctx)
+	if err != nil {
+		return err
+	}
+
+	usage := make(map[string]quota.Usage)
+	files := make(map[string]*file.File)
+	for _, ik := range iks {
+		ps, err := l.usagePrefixes(ctx, uw, ik.Key)
+		if err != nil {
+			return err
+		}
+		if len(ps) == 0 {
+			continue
+		}
+
+		f, ok := files[ik.Value]
+		if !ok {
+			f, err = uw.Files().FindBySignature(ctx, ik.Value)
+			if err != nil {
+				if err.Error() == "not found" {
+					continue
+				}
+				return err
+			}
+			files[ik.Value] = f
+		}
+
+		if !l.ownsFile(f) {
+			continue
+		}
+
+		u := quota.Usage{Size: int64(f.Size), Count: 1}
+		for _, p := range ps {
+			usage[p] = usage[p].Add(u)
+		}
+	}
+
+	s, err := uw.State().Find(ctx)
+	if err != nil {
+		return err
+	}
+	s.Usage = usage
+
+	return uw.State().Update(ctx, s)
+}
+
+// updateUsage adds the keys of the f to the Usage of the State, or
+// removes them if n is -1, if the volume is the owner of the f. When
+// they are added the quota.Limits of the ctx, if any, are checked
+func (l *local) updateUsage(ctx context.Context, uw uow.UnitOfWork, f *file.File, n int, keys ...string) error {
+	if !l.ownsFile(f) {
+		return nil
+	}
+
+	var ps []string
+	for _, k := range keys {
+		kps, err := l.usagePrefixes(ctx, uw, k)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, kps...)
+	}
+	if len(ps) == 0 {
+		return nil
+	}
+
+	s, err := uw.State().Find(ctx)
+	if err != nil {
+		return err
+	}
+	if s.Usage == nil {
+		s.Usage = make(map[string]quota.Usage)
+	}
+
+	u := quota.Usage{Size: int64(n * f.Size), Count: n}
+	for _, p := range ps {
+		s.Usage[p] = s.Usage[p].Add(u)
+	}
+
+	if ls, ok := quota.FromContext(ctx); ok && n > 0 {
+		err = ls.Check(l.id, s.Usage, ps...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return uw.State().Update(ctx, s)
+}
+
+// ownsFile checks if the volume is the owner of the f, the first
+// of the VolumeIDs which is the one that created it, so the
+// replicas are not accounted more than once on the cluster
+func (l *local) ownsFile(f *file.File) bool {
+	return len(f.VolumeIDs) == 0 || f.VolumeIDs[0] == l.id
+}
+
+// usagePrefixes returns the prefixes and the Bucket on which the k is
+// accounted. The Items of the trash and the noncurrent Versions are
+// accounted on the ones of the key they belong to, the current
+// Version is not as it's the same File as the key
+func (l *local) usagePrefixes(ctx context.Context, uw uow.UnitOfWork, k string) ([]string, error) {
+	key := k
+	if tk, _, ok := trash.Split(k); ok {
+		key = tk
+	} else if vk, _, ok := version.Split(k); ok {
+		key = vk
+	}
+
+	ps := l.keyPrefixes(key)
+	if len(ps) == 0 || !version.IsKey(k) {
+		return ps, nil
+	}
+
+	nc, err := l.isNoncurrentVersion(ctx, uw, k)
+	if err != nil || !nc {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// keyPrefixes returns the prefixes and the Bucket of the k
+func (l *local) keyPrefixes(k string) []string {
+	var ps []string
+	for _, p := range l.prefixes {
+		if strings.HasPrefix(k, p) {
+			ps = append(ps, p)
+		}
+	}
+	if p, ok := bucket.Prefix(k); ok {
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// isNoncurrentVersion checks if the k is the key of a
+// Version which is not the newest one of the History
+func (l *local) isNoncurrentVersion(ctx context.Context, uw uow.UnitOfWork, k string) (bool, error) {
+	key, id, ok := version.Split(k)
+	if !ok {
+		return false, nil
+	}
+
+	h, err := uw.Versions().FindByKey(ctx, key)
+	if err != nil {
+		if err.Error() == "not found" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for i, v := range h.Versions {
+		if v.ID == id {
+			return i != len(h.Versions)-1, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (l *local) calculateSize(ctx context.Context, uw uow.UnitOfWork, root string, ts int) error {
 	s, err := uw.State().Find(ctx)
 	if err != nil {
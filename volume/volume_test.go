@@ -9,22 +9,31 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
+	kitlog "github.com/go-kit/kit/log"
 	"github.com/golang/mock/gomock"
 	uuid "github.com/satori/go.uuid"
 	"github.com/spf13/afero"
 	"github.com/spf13/afero/mem"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/rebost/bucket"
+	"github.com/xescugc/rebost/compression"
+	"github.com/xescugc/rebost/encryption"
 	"github.com/xescugc/rebost/file"
 	"github.com/xescugc/rebost/idxkey"
 	"github.com/xescugc/rebost/idxttl"
 	"github.com/xescugc/rebost/idxvolume"
 	"github.com/xescugc/rebost/mock"
+	"github.com/xescugc/rebost/quota"
 	"github.com/xescugc/rebost/replica"
+	"github.com/xescugc/rebost/signature"
 	"github.com/xescugc/rebost/state"
+	"github.com/xescugc/rebost/trash"
 	"github.com/xescugc/rebost/uow"
+	"github.com/xescugc/rebost/version"
 	"github.com/xescugc/rebost/volume"
 )
 
@@ -41,12 +50,19 @@ func TestNew(t *testing.T) {
 		fs := mock.NewFs(ctrl)
 		rp := mock.NewReplicaRepository(ctrl)
 		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
 		idPath := path.Join(rootDir, "id")
 		fh := mem.NewFileHandle(mem.CreateFile(idPath))
+		hashPath := path.Join(rootDir, "hash")
+		hfh := mem.NewFileHandle(mem.CreateFile(hashPath))
 
 		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
 			uw := mock.NewUnitOfWork(ctrl)
 			uw.EXPECT().State().Return(sr).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
 			return uowFn(ctx, uw)
 		}
 
@@ -57,11 +73,26 @@ func TestNew(t *testing.T) {
 
 		fs.EXPECT().Stat(idPath).Return(nil, os.ErrNotExist)
 		fs.EXPECT().Create(idPath).Return(fh, nil)
-
-		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil)
-		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
-
-		v, err := volume.New(rootDir, files, idxkeys, idxttls, idxvolumes, rp, sr, fs, nil, uowFn)
+		fs.EXPECT().Create(hashPath).Return(hfh, nil)
+
+		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+		idxkeys.EXPECT().All(gomock.Any()).Return(nil, nil)
+		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA1,
+		})
 		require.NoError(t, err)
 		assert.NotNil(t, v)
 		defer v.Close()
@@ -76,6 +107,13 @@ func TestNew(t *testing.T) {
 
 		_, err = uuid.FromString(string(id))
 		require.NoError(t, err, "Validates that it's a UUID")
+
+		err = hfh.Open()
+		require.NoError(t, err)
+
+		alg, err := io.ReadAll(hfh)
+		require.NoError(t, err)
+		assert.Equal(t, "sha1", string(alg))
 	})
 	t.Run("SuccessWithSize", func(t *testing.T) {
 		var (
@@ -92,12 +130,19 @@ func TestNew(t *testing.T) {
 		fs := mock.NewFs(ctrl)
 		rp := mock.NewReplicaRepository(ctrl)
 		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
 		idPath := path.Join(rootDir, "id")
 		fh := mem.NewFileHandle(mem.CreateFile(idPath))
+		hashPath := path.Join(rootDir, "hash")
+		hfh := mem.NewFileHandle(mem.CreateFile(hashPath))
 
 		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
 			uw := mock.NewUnitOfWork(ctrl)
 			uw.EXPECT().State().Return(sr).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
 			return uowFn(ctx, uw)
 		}
 
@@ -108,14 +153,29 @@ func TestNew(t *testing.T) {
 
 		fs.EXPECT().Stat(idPath).Return(nil, os.ErrNotExist)
 		fs.EXPECT().Create(idPath).Return(fh, nil)
+		fs.EXPECT().Create(hashPath).Return(hfh, nil)
 
-		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil)
+		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+		idxkeys.EXPECT().All(gomock.Any()).Return(nil, nil)
 		sr.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *state.State) error {
 			assert.Equal(t, 21474836480, s.VolumeTotalSize)
 			return nil
-		})
+		}).Times(2)
 
-		v, err := volume.New(rootDirWithSize, files, idxkeys, idxttls, idxvolumes, rp, sr, fs, nil, uowFn)
+		v, err := volume.New(rootDirWithSize, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA1,
+		})
 		require.NoError(t, err)
 		assert.NotNil(t, v)
 		defer v.Close()
@@ -131,6 +191,89 @@ func TestNew(t *testing.T) {
 		_, err = uuid.FromString(string(id))
 		require.NoError(t, err, "Validates that it's a UUID")
 	})
+	t.Run("SuccessWithUsage", func(t *testing.T) {
+		var rootDir = "/"
+
+		ctrl := gomock.NewController(t)
+
+		files := mock.NewFileRepository(ctrl)
+		idxkeys := mock.NewIDXKeyRepository(ctrl)
+		idxttls := mock.NewIDXTTLRepository(ctrl)
+		idxvolumes := mock.NewIDXVolumeRepository(ctrl)
+		fs := mock.NewFs(ctrl)
+		rp := mock.NewReplicaRepository(ctrl)
+		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
+
+		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
+			uw := mock.NewUnitOfWork(ctrl)
+			uw.EXPECT().Files().Return(files).AnyTimes()
+			uw.EXPECT().State().Return(sr).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
+			uw.EXPECT().Versions().Return(vrs).AnyTimes()
+			return uowFn(ctx, uw)
+		}
+
+		defer ctrl.Finish()
+
+		fs.EXPECT().MkdirAll(gomock.Any(), os.ModePerm).Return(nil).Times(2)
+		fs.EXPECT().Stat(gomock.Any()).Return(nil, os.ErrNotExist)
+		fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(name string) (*mem.File, error) {
+			return mem.NewFileHandle(mem.CreateFile(name)), nil
+		}).Times(2)
+
+		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+		idxkeys.EXPECT().All(gomock.Any()).Return([]*idxkey.IDXKey{
+			idxkey.New("logs/a", "sa"),
+			idxkey.New("logs/b", "sb"),
+			idxkey.New("@bkt/a", "sa"),
+			idxkey.New("other", "so"),
+			idxkey.New("logs/replica", "sr"),
+			// The Items of the trash and the noncurrent
+			// Versions are accounted on their keys
+			idxkey.New(trash.Key("@bkt/b", "1"), "sb"),
+			idxkey.New(version.Key("@bkt/a", "1-sv"), "sv"),
+			idxkey.New(version.Key("@bkt/a", "2-sa"), "sa"),
+		}, nil)
+		files.EXPECT().FindBySignature(gomock.Any(), "sa").Return(&file.File{Signature: "sa", Size: 10}, nil)
+		files.EXPECT().FindBySignature(gomock.Any(), "sb").Return(&file.File{Signature: "sb", Size: 5}, nil)
+		files.EXPECT().FindBySignature(gomock.Any(), "sr").Return(&file.File{Signature: "sr", Size: 7, VolumeIDs: []string{"other"}}, nil)
+		files.EXPECT().FindBySignature(gomock.Any(), "sv").Return(&file.File{Signature: "sv", Size: 3}, nil)
+		vrs.EXPECT().FindByKey(gomock.Any(), "@bkt/a").Return(&version.History{
+			Key:      "@bkt/a",
+			Versions: []*version.Version{{ID: "1-sv", Signature: "sv"}, {ID: "2-sa", Signature: "sa"}},
+		}, nil).Times(2)
+		// The size is updated first
+		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+		sr.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s *state.State) error {
+			assert.Equal(t, map[string]quota.Usage{
+				"logs/": {Size: 15, Count: 2},
+				"@bkt/": {Size: 18, Count: 3},
+			}, s.Usage)
+			return nil
+		})
+
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash:     signature.SHA1,
+			Prefixes: []string{"logs/"},
+		})
+		require.NoError(t, err)
+		defer v.Close()
+	})
 	t.Run("SuccessWithAlreadyID", func(t *testing.T) {
 		var rootDir = "/"
 
@@ -144,8 +287,14 @@ func TestNew(t *testing.T) {
 		fs := mock.NewFs(ctrl)
 		rp := mock.NewReplicaRepository(ctrl)
 		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
 		idPath := path.Join(rootDir, "id")
 		fh := mem.NewFileHandle(mem.CreateFile(idPath))
+		hashPath := path.Join(rootDir, "hash")
+		hfh := mem.NewFileHandle(mem.CreateFile(hashPath))
 		id := uuid.NewV4().String()
 
 		_, err := io.WriteString(fh, id)
@@ -154,9 +303,16 @@ func TestNew(t *testing.T) {
 		_, err = fh.Seek(0, 0)
 		require.NoError(t, err)
 
+		_, err = io.WriteString(hfh, "sha1")
+		require.NoError(t, err)
+
+		_, err = hfh.Seek(0, 0)
+		require.NoError(t, err)
+
 		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
 			uw := mock.NewUnitOfWork(ctrl)
 			uw.EXPECT().State().Return(sr).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
 			return uowFn(ctx, uw)
 		}
 
@@ -164,17 +320,318 @@ func TestNew(t *testing.T) {
 		fs.EXPECT().MkdirAll(path.Join(rootDir, "tmps"), os.ModePerm).Return(nil)
 
 		fs.EXPECT().Stat(idPath).Return(nil, nil)
+		fs.EXPECT().Open(hashPath).Return(hfh, nil)
 		fs.EXPECT().Open(idPath).Return(fh, nil)
 
-		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil)
-		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
-
-		v, err := volume.New(rootDir, files, idxkeys, idxttls, idxvolumes, rp, sr, fs, nil, uowFn)
+		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+		idxkeys.EXPECT().All(gomock.Any()).Return(nil, nil)
+		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA1,
+		})
 		require.NoError(t, err)
 		assert.NotNil(t, v)
 		defer v.Close()
 		assert.Equal(t, id, v.ID())
 	})
+	t.Run("SuccessWithLegacyVolume", func(t *testing.T) {
+		var (
+			rootDir = "/"
+			osig    = "040f06fd774092478d450774f5ba30c5da78acc8"
+			nsig    = "sha1:040f06fd774092478d450774f5ba30c5da78acc8"
+		)
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		files := mock.NewFileRepository(ctrl)
+		idxkeys := mock.NewIDXKeyRepository(ctrl)
+		idxttls := mock.NewIDXTTLRepository(ctrl)
+		idxvolumes := mock.NewIDXVolumeRepository(ctrl)
+		fs := mock.NewFs(ctrl)
+		rp := mock.NewReplicaRepository(ctrl)
+		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
+		idPath := path.Join(rootDir, "id")
+		fh := mem.NewFileHandle(mem.CreateFile(idPath))
+		hashPath := path.Join(rootDir, "hash")
+		hfh := mem.NewFileHandle(mem.CreateFile(hashPath))
+		id := uuid.NewV4().String()
+
+		_, err := io.WriteString(fh, id)
+		require.NoError(t, err)
+
+		_, err = fh.Seek(0, 0)
+		require.NoError(t, err)
+
+		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
+			uw := mock.NewUnitOfWork(ctrl)
+			uw.EXPECT().Files().Return(files).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
+			uw.EXPECT().IDXVolumes().Return(idxvolumes).AnyTimes()
+			uw.EXPECT().Replicas().Return(rp).AnyTimes()
+			uw.EXPECT().Fs().Return(fs).AnyTimes()
+			uw.EXPECT().State().Return(sr).AnyTimes()
+			return uowFn(ctx, uw)
+		}
+
+		fs.EXPECT().MkdirAll(path.Join(rootDir, "file"), os.ModePerm).Return(nil)
+		fs.EXPECT().MkdirAll(path.Join(rootDir, "tmps"), os.ModePerm).Return(nil)
+
+		fs.EXPECT().Stat(idPath).Return(nil, nil)
+		fs.EXPECT().Open(hashPath).Return(nil, os.ErrNotExist)
+
+		// The legacy Signatures are only prefixed with SHA1
+		files.EXPECT().All(gomock.Any()).Return([]*file.File{{Keys: []string{"a"}, Signature: osig}}, nil)
+		files.EXPECT().DeleteBySignature(gomock.Any(), osig).Return(nil)
+		files.EXPECT().CreateOrReplace(gomock.Any(), &file.File{Keys: []string{"a"}, Signature: nsig}).Return(nil)
+		idxkeys.EXPECT().FindByKey(gomock.Any(), "a").Return(idxkey.New("a", osig), nil)
+		idxkeys.EXPECT().CreateOrReplace(gomock.Any(), idxkey.New("a", nsig)).Return(nil)
+		idxvolumes.EXPECT().All(gomock.Any()).Return(nil, nil)
+		rp.EXPECT().All(gomock.Any()).Return(nil, nil)
+		fs.EXPECT().Create(hashPath).Return(hfh, nil)
+
+		fs.EXPECT().Open(idPath).Return(fh, nil)
+
+		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+		idxkeys.EXPECT().All(gomock.Any()).Return(nil, nil)
+		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA1,
+		})
+		require.NoError(t, err)
+		defer v.Close()
+		assert.Equal(t, id, v.ID())
+
+		err = hfh.Open()
+		require.NoError(t, err)
+
+		alg, err := io.ReadAll(hfh)
+		require.NoError(t, err)
+		assert.Equal(t, "sha1", string(alg))
+	})
+	t.Run("FailsWithLegacyVolume", func(t *testing.T) {
+		var rootDir = "/"
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		files := mock.NewFileRepository(ctrl)
+		idxkeys := mock.NewIDXKeyRepository(ctrl)
+		idxttls := mock.NewIDXTTLRepository(ctrl)
+		idxvolumes := mock.NewIDXVolumeRepository(ctrl)
+		fs := mock.NewFs(ctrl)
+		rp := mock.NewReplicaRepository(ctrl)
+		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
+		idPath := path.Join(rootDir, "id")
+		hashPath := path.Join(rootDir, "hash")
+
+		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
+			uw := mock.NewUnitOfWork(ctrl)
+			return uowFn(ctx, uw)
+		}
+
+		fs.EXPECT().MkdirAll(path.Join(rootDir, "file"), os.ModePerm).Return(nil)
+		fs.EXPECT().MkdirAll(path.Join(rootDir, "tmps"), os.ModePerm).Return(nil)
+
+		fs.EXPECT().Stat(idPath).Return(nil, nil)
+		fs.EXPECT().Open(hashPath).Return(nil, os.ErrNotExist)
+
+		// The legacy volumes are SHA1 so any
+		// other has to be migrated to
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA256,
+		})
+		assert.EqualError(t, err, `the volume "/" uses the hash "sha1" and not "sha256", it has to be migrated with 'rebost migrate --hash sha256'`)
+		assert.Empty(t, v)
+	})
+	t.Run("FailsWithDifferentHash", func(t *testing.T) {
+		var rootDir = "/"
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		files := mock.NewFileRepository(ctrl)
+		idxkeys := mock.NewIDXKeyRepository(ctrl)
+		idxttls := mock.NewIDXTTLRepository(ctrl)
+		idxvolumes := mock.NewIDXVolumeRepository(ctrl)
+		fs := mock.NewFs(ctrl)
+		rp := mock.NewReplicaRepository(ctrl)
+		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
+		idPath := path.Join(rootDir, "id")
+		hashPath := path.Join(rootDir, "hash")
+		hfh := mem.NewFileHandle(mem.CreateFile(hashPath))
+
+		_, err := io.WriteString(hfh, "sha1")
+		require.NoError(t, err)
+
+		_, err = hfh.Seek(0, 0)
+		require.NoError(t, err)
+
+		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
+			uw := mock.NewUnitOfWork(ctrl)
+			return uowFn(ctx, uw)
+		}
+
+		fs.EXPECT().MkdirAll(path.Join(rootDir, "file"), os.ModePerm).Return(nil)
+		fs.EXPECT().MkdirAll(path.Join(rootDir, "tmps"), os.ModePerm).Return(nil)
+
+		fs.EXPECT().Stat(idPath).Return(nil, nil)
+		fs.EXPECT().Open(hashPath).Return(hfh, nil)
+
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA256,
+		})
+		assert.EqualError(t, err, `the volume "/" uses the hash "sha1" and not "sha256", it has to be migrated with 'rebost migrate --hash sha256'`)
+		assert.Empty(t, v)
+	})
+	t.Run("SuccessRewrapKeys", func(t *testing.T) {
+		var (
+			rootDir = "/"
+			okey    = bytes.Repeat([]byte("o"), encryption.KeySize)
+			nkey    = bytes.Repeat([]byte("n"), encryption.KeySize)
+			done    = make(chan *file.File)
+		)
+
+		okr, err := encryption.NewKeyring(map[string][]byte{"old": okey}, "old")
+		require.NoError(t, err)
+		kr, err := encryption.NewKeyring(map[string][]byte{"old": okey, "new": nkey}, "new")
+		require.NoError(t, err)
+
+		_, dk, ek, err := okr.GenerateDataKey()
+		require.NoError(t, err)
+
+		ctrl := gomock.NewController(t)
+
+		files := mock.NewFileRepository(ctrl)
+		idxkeys := mock.NewIDXKeyRepository(ctrl)
+		idxttls := mock.NewIDXTTLRepository(ctrl)
+		idxvolumes := mock.NewIDXVolumeRepository(ctrl)
+		fs := mock.NewFs(ctrl)
+		rp := mock.NewReplicaRepository(ctrl)
+		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
+
+		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
+			uw := mock.NewUnitOfWork(ctrl)
+			uw.EXPECT().Files().Return(files).AnyTimes()
+			uw.EXPECT().State().Return(sr).AnyTimes()
+			uw.EXPECT().IDXKeys().Return(idxkeys).AnyTimes()
+			return uowFn(ctx, uw)
+		}
+
+		defer ctrl.Finish()
+
+		fs.EXPECT().MkdirAll(gomock.Any(), os.ModePerm).Return(nil).Times(2)
+		fs.EXPECT().Stat(gomock.Any()).Return(nil, os.ErrNotExist)
+		fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(name string) (*mem.File, error) {
+			return mem.NewFileHandle(mem.CreateFile(name)), nil
+		}).Times(2)
+
+		sr.EXPECT().Find(gomock.Any()).Return(&state.State{}, nil).Times(2)
+		idxkeys.EXPECT().All(gomock.Any()).Return(nil, nil)
+		sr.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+		files.EXPECT().All(gomock.Any()).Return([]*file.File{
+			{Signature: "plain"},
+			{Signature: "current", EncryptionKeyID: "new", EncryptedKey: []byte("key")},
+			{Signature: "old", EncryptionKeyID: "old", EncryptedKey: ek},
+		}, nil)
+		files.EXPECT().CreateOrReplace(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f *file.File) error {
+			done <- f
+			return nil
+		})
+
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, kitlog.NewNopLogger(), volume.Options{
+			Hash:    signature.SHA1,
+			Keyring: kr,
+		})
+		require.NoError(t, err)
+		defer v.Close()
+
+		select {
+		case f := <-done:
+			assert.Equal(t, "old", f.Signature)
+			assert.Equal(t, "new", f.EncryptionKeyID)
+
+			ndk, err := kr.Unwrap("new", f.EncryptedKey)
+			require.NoError(t, err)
+			assert.Equal(t, dk, ndk)
+		case <-time.After(time.Second):
+			t.Fatal("the keys were not rewrapped")
+		}
+	})
 	t.Run("Invalid size", func(t *testing.T) {
 		var rootDir = "/:20potato"
 
@@ -187,6 +644,10 @@ func TestNew(t *testing.T) {
 		fs := mock.NewFs(ctrl)
 		rp := mock.NewReplicaRepository(ctrl)
 		sr := mock.NewStateRepository(ctrl)
+		bkts := mock.NewBucketRepository(ctrl)
+		vrs := mock.NewVersionRepository(ctrl)
+		trs := mock.NewTrashRepository(ctrl)
+		aks := mock.NewAPIKeyRepository(ctrl)
 
 		uowFn := func(ctx context.Context, t uow.Type, uowFn uow.UnitOfWorkFn, repositories ...interface{}) error {
 			uw := mock.NewUnitOfWork(ctrl)
@@ -195,7 +656,20 @@ func TestNew(t *testing.T) {
 
 		defer ctrl.Finish()
 
-		v, err := volume.New(rootDir, files, idxkeys, idxttls, idxvolumes, rp, sr, fs, nil, uowFn)
+		v, err := volume.New(rootDir, volume.Repositories{
+			Files:      files,
+			IDXKeys:    idxkeys,
+			IDXTTLs:    idxttls,
+			IDXVolumes: idxvolumes,
+			Replicas:   rp,
+			State:      sr,
+			Buckets:    bkts,
+			Versions:   vrs,
+			Trash:      trs,
+			APIKeys:    aks,
+		}, fs, uowFn, nil, volume.Options{
+			Hash: signature.SHA1,
+		})
 		assert.Equal(t, "byte quantity must be a positive integer with a unit of measurement like M, MB, MiB, G, GiB, or GB", err.Error())
 		assert.Empty(t, v)
 	})
@@ -215,17 +689,19 @@ func TestCreateFile(t *testing.T) {
 			key      = "expectedkey"
 			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			ef       = file.File{
-				Keys:      []string{key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
-				Replica:   2,
-				VolumeIDs: []string{mv.V.ID()},
-				Size:      19,
-				TTL:       ttl,
-				CreatedAt: ca,
+				Keys:       []string{key},
+				Signature:  "sha1:e7e8c72d1167454b76a610074fed244be0935298",
+				Replica:    2,
+				VolumeIDs:  []string{mv.V.ID()},
+				Size:       19,
+				StoredSize: 19,
+				TTL:        ttl,
+				CreatedAt:  ca,
 			}
 			eik = idxkey.IDXKey{
-				Key:   key,
-				Value: ef.Signature,
+				Key:       key,
+				Value:     ef.Signature,
+				CreatedAt: ca,
 			}
 			eittl = idxttl.New(ef.ExpiresAt(), "1", ef.Signature)
 
@@ -234,7 +710,7 @@ func TestCreateFile(t *testing.T) {
 
 		defer mv.Finish()
 
-		mv.IDXTTLs.EXPECT().Filter(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mv.IDXTTLs.EXPECT().Filter(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
 
 		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
 			assert.True(t, strings.HasPrefix(p, tmpsDir))
@@ -273,7 +749,7 @@ func TestCreateFile(t *testing.T) {
 
 		expectUpdateState(t, mv, ctx, ef.Size)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		require.NoError(t, err)
 	})
 	t.Run("SuccessUpdateFileKey", func(t *testing.T) {
@@ -282,25 +758,24 @@ func TestCreateFile(t *testing.T) {
 			rootDir  = "/"
 			mv       = newManageVolume(t, rootDir)
 			tmpsDir  = path.Join(rootDir, "tmps")
-			fileDir  = path.Join(rootDir, "file")
 			key      = "expectedkey"
 			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			rep      = 2
 			ttl      = 2 * time.Minute
 			ca       = time.Now()
+			ea       = ca.Add(ttl)
 			ef       = file.File{
 				Keys:      []string{"b", key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
+				Signature: "sha1:e7e8c72d1167454b76a610074fed244be0935298",
 				Replica:   rep,
 				VolumeIDs: []string{mv.V.ID()},
-				TTL:       ttl,
 				CreatedAt: ca,
 			}
 			eik = idxkey.IDXKey{
-				Key:   key,
-				Value: ef.Signature,
+				Key:       key,
+				Value:     ef.Signature,
+				CreatedAt: ca,
 			}
-			eittl = idxttl.New(ef.ExpiresAt(), "1", ef.Signature)
 
 			ctx = context.Background()
 		)
@@ -313,10 +788,9 @@ func TestCreateFile(t *testing.T) {
 			return mem.NewFileHandle(mem.CreateFile(p)), nil
 		})
 
-		dir, _ := path.Split(ef.Path(fileDir))
-		mv.Fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
-
-		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Do(func(p string, _ string) {
+		// The content is the same so the
+		// one already stored is kept
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
 			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
 		}).Return(nil)
 
@@ -328,14 +802,29 @@ func TestCreateFile(t *testing.T) {
 			CreatedAt: ca,
 		}, nil)
 
-		mv.Files.EXPECT().CreateOrReplace(ctx, &ef).Return(nil)
-
-		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+		// The TTL of the File is moved to the key it already had
+		// so the shared File only expires with its keys
+		gomock.InOrder(
+			mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(idxttl.New(ea, "1", ef.Signature), nil),
+			mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{"1"}}).Return(nil),
+			mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(idxttl.New(ea, "1"), nil),
+			mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{"1"}, Keys: []string{"b"}}).Return(nil),
+			mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{"1"}, Keys: []string{"b"}}, nil),
+			mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ea, Signatures: []string{"1"}, Keys: []string{"b", key}}).Return(nil),
+		)
 
-		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &eik).Return(nil)
+		gomock.InOrder(
+			mv.IDXKeys.EXPECT().FindByKey(ctx, "b").Return(&idxkey.IDXKey{Key: "b", Value: ef.Signature, CreatedAt: ca}, nil),
+			mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: "b", Value: ef.Signature, ExpiresAt: ea, CreatedAt: ca}).Return(nil),
+			mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found")),
+			mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &eik).Return(nil),
+			mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: ef.Signature, ExpiresAt: ea, CreatedAt: ca}).Return(nil),
+		)
 
-		mv.IDXTTLs.EXPECT().Find(ctx, ef.ExpiresAt()).Return(idxttl.New(ef.ExpiresAt(), eittl.Signatures[0]), nil)
-		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, eittl).Return(nil)
+		gomock.InOrder(
+			mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{"b"}, Signature: ef.Signature, Replica: rep, CreatedAt: ca}).Return(nil),
+			mv.Files.EXPECT().CreateOrReplace(ctx, &ef).Return(nil),
+		)
 
 		mv.Replicas.EXPECT().Create(ctx, gomock.Any()).Do(
 			func(_ context.Context, rp *replica.Replica) error {
@@ -348,7 +837,7 @@ func TestCreateFile(t *testing.T) {
 			},
 		).Return(nil)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		require.NoError(t, err)
 	})
 	t.Run("SuccessSame", func(t *testing.T) {
@@ -357,7 +846,6 @@ func TestCreateFile(t *testing.T) {
 			rootDir  = "/"
 			mv       = newManageVolume(t, rootDir)
 			tmpsDir  = path.Join(rootDir, "tmps")
-			fileDir  = path.Join(rootDir, "file")
 			key      = "expectedkey"
 			rep      = 2
 			ttl      = 2 * time.Minute
@@ -365,7 +853,7 @@ func TestCreateFile(t *testing.T) {
 			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			ef       = file.File{
 				Keys:      []string{key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
+				Signature: "sha1:e7e8c72d1167454b76a610074fed244be0935298",
 				Replica:   rep,
 				VolumeIDs: []string{mv.V.ID()},
 				TTL:       ttl,
@@ -383,10 +871,9 @@ func TestCreateFile(t *testing.T) {
 			return mem.NewFileHandle(mem.CreateFile(p)), nil
 		})
 
-		dir, _ := path.Split(ef.Path(fileDir))
-		mv.Fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
-
-		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Do(func(p string, _ string) {
+		// The content is the same so the
+		// one already stored is kept
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
 			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
 		}).Return(nil)
 
@@ -395,33 +882,98 @@ func TestCreateFile(t *testing.T) {
 			Signature: ef.Signature,
 		}, nil)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		// The new ttl is set on the key
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(&idxkey.IDXKey{Key: key, Value: ef.Signature, CreatedAt: ca}, nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(ttl)).Return(nil, errors.New("not found"))
+		mv.IDXTTLs.EXPECT().CreateOrReplace(ctx, &idxttl.IDXTTL{ExpiresAt: ca.Add(ttl), Keys: []string{key}}).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: ef.Signature, ExpiresAt: ca.Add(ttl), CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		require.NoError(t, err)
 	})
-	t.Run("SuccessRemoveFileKey", func(t *testing.T) {
+	t.Run("SuccessSameWithoutTTL", func(t *testing.T) {
 		var (
 			tempuuid string
 			rootDir  = "/"
 			mv       = newManageVolume(t, rootDir)
 			tmpsDir  = path.Join(rootDir, "tmps")
-			fileDir  = path.Join(rootDir, "file")
 			key      = "expectedkey"
-			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			rep      = 2
 			ttl      = 2 * time.Minute
+			ea       = time.Now().Add(time.Minute)
 			ca       = time.Now()
+			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			ef       = file.File{
 				Keys:      []string{key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
+				Signature: "sha1:e7e8c72d1167454b76a610074fed244be0935298",
 				Replica:   rep,
 				VolumeIDs: []string{mv.V.ID()},
-				Size:      19,
-				TTL:       ttl,
 				CreatedAt: ca,
 			}
+
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			assert.True(t, strings.HasPrefix(p, tmpsDir))
+			_, tempuuid = path.Split(p)
+			return mem.NewFileHandle(mem.CreateFile(p)), nil
+		})
+
+		// The content is the same so the
+		// one already stored is kept
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
+			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
+		}).Return(nil)
+
+		mv.Files.EXPECT().FindBySignature(ctx, ef.Signature).Return(&file.File{
+			Keys:      ef.Keys,
+			Signature: ef.Signature,
+			TTL:       ttl,
+			CreatedAt: ca,
+		}, nil)
+
+		// The TTL of the File and the one of
+		// the key are removed as it has none
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(&idxkey.IDXKey{Key: key, Value: ef.Signature, ExpiresAt: ea, CreatedAt: ca}, nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ca.Add(ttl)).Return(idxttl.New(ca.Add(ttl), ef.Signature), nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, ca.Add(ttl)).Return(nil)
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: ef.Keys, Signature: ef.Signature, CreatedAt: ca}).Return(nil)
+		mv.IDXTTLs.EXPECT().Find(ctx, ea).Return(&idxttl.IDXTTL{ExpiresAt: ea, Keys: []string{key}}, nil)
+		mv.IDXTTLs.EXPECT().Delete(ctx, ea).Return(nil)
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: ef.Signature, CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, rep, 0, ca, "")
+		require.NoError(t, err)
+	})
+	t.Run("SuccessRemoveFileKey", func(t *testing.T) {
+		var (
+			tempuuid string
+			rootDir  = "/"
+			mv       = newManageVolume(t, rootDir)
+			tmpsDir  = path.Join(rootDir, "tmps")
+			fileDir  = path.Join(rootDir, "file")
+			key      = "expectedkey"
+			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
+			rep      = 2
+			ttl      = 2 * time.Minute
+			ca       = time.Now()
+			ef       = file.File{
+				Keys:       []string{key},
+				Signature:  "sha1:e7e8c72d1167454b76a610074fed244be0935298",
+				Replica:    rep,
+				VolumeIDs:  []string{mv.V.ID()},
+				Size:       19,
+				StoredSize: 19,
+				TTL:        ttl,
+				CreatedAt:  ca,
+			}
 			eik = idxkey.IDXKey{
-				Key:   key,
-				Value: ef.Signature,
+				Key:       key,
+				Value:     ef.Signature,
+				CreatedAt: ca,
 			}
 			eittl     = idxttl.New(ef.ExpiresAt(), "1", ef.Signature)
 			foundFile = file.File{
@@ -488,7 +1040,7 @@ func TestCreateFile(t *testing.T) {
 
 		expectUpdateState(t, mv, ctx, ef.Size)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		require.NoError(t, err)
 	})
 	t.Run("SuccessRemoveFileKeyAndFile", func(t *testing.T) {
@@ -504,17 +1056,19 @@ func TestCreateFile(t *testing.T) {
 			ttl      = 2 * time.Minute
 			ca       = time.Now()
 			ef       = file.File{
-				Keys:      []string{key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
-				Replica:   rep,
-				VolumeIDs: []string{mv.V.ID()},
-				Size:      19,
-				TTL:       ttl,
-				CreatedAt: ca,
+				Keys:       []string{key},
+				Signature:  "sha1:e7e8c72d1167454b76a610074fed244be0935298",
+				Replica:    rep,
+				VolumeIDs:  []string{mv.V.ID()},
+				Size:       19,
+				StoredSize: 19,
+				TTL:        ttl,
+				CreatedAt:  ca,
 			}
 			eik = idxkey.IDXKey{
-				Key:   key,
-				Value: ef.Signature,
+				Key:       key,
+				Value:     ef.Signature,
+				CreatedAt: ca,
 			}
 			eittl     = idxttl.New(ef.ExpiresAt(), "1", ef.Signature)
 			foundFile = file.File{
@@ -579,7 +1133,7 @@ func TestCreateFile(t *testing.T) {
 
 		expectUpdateState(t, mv, ctx, ef.Size)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		require.NoError(t, err)
 	})
 	t.Run("SuccessWithNoReplica", func(t *testing.T) {
@@ -595,17 +1149,19 @@ func TestCreateFile(t *testing.T) {
 			key      = "expectedkey"
 			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			ef       = file.File{
-				Keys:      []string{key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
-				Replica:   1,
-				VolumeIDs: []string{mv.V.ID()},
-				Size:      19,
-				TTL:       ttl,
-				CreatedAt: ca,
+				Keys:       []string{key},
+				Signature:  "sha1:e7e8c72d1167454b76a610074fed244be0935298",
+				Replica:    1,
+				VolumeIDs:  []string{mv.V.ID()},
+				Size:       19,
+				StoredSize: 19,
+				TTL:        ttl,
+				CreatedAt:  ca,
 			}
 			eik = idxkey.IDXKey{
-				Key:   key,
-				Value: ef.Signature,
+				Key:       key,
+				Value:     ef.Signature,
+				CreatedAt: ca,
 			}
 			eittl = idxttl.New(ef.ExpiresAt(), "1", ef.Signature)
 
@@ -640,9 +1196,307 @@ func TestCreateFile(t *testing.T) {
 
 		expectUpdateState(t, mv, ctx, ef.Size)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithCompression", func(t *testing.T) {
+		var (
+			tempuuid string
+			rootDir  = "/"
+			mv       = newManageVolume(t, rootDir)
+			rep      = 1
+			ca       = time.Now()
+			tmpsDir  = path.Join(rootDir, "tmps")
+			fileDir  = path.Join(rootDir, "file")
+			key      = "expectedkey"
+			content  = strings.Repeat("content of the file", 100)
+			buff     = io.NopCloser(bytes.NewBufferString(content))
+			ef       = file.File{Keys: []string{key}}
+			usedSize int
+
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		h := signature.SHA1.New()
+		io.WriteString(h, content)
+		ef.Signature = signature.SHA1.Sum(h)
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			assert.True(t, strings.HasPrefix(p, tmpsDir))
+			_, tempuuid = path.Split(p)
+			return mem.NewFileHandle(mem.CreateFile(p)), nil
+		})
+
+		dir, _ := path.Split(ef.Path(fileDir))
+		mv.Fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
+
+		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Do(func(p string, _ string) {
+			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
+		}).Return(nil)
+
+		mv.Files.EXPECT().FindBySignature(ctx, ef.Signature).Return(nil, errors.New("not found"))
+
+		mv.State.EXPECT().Find(ctx).Return(&state.State{VolumeTotalSize: -1, SystemTotalSize: 10000}, nil)
+		mv.State.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *state.State) error {
+			usedSize = s.SystemUsedSize
+			return nil
+		})
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, f *file.File) error {
+			assert.Equal(t, ef.Signature, f.Signature)
+			assert.Equal(t, len(content), f.Size)
+			assert.Equal(t, usedSize, f.StoredSize)
+			assert.Less(t, f.StoredSize, f.Size)
+			assert.Equal(t, "compressed", f.Class)
+			assert.Equal(t, compression.Gzip, f.Compression)
+			return nil
+		})
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: ef.Signature, CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, rep, 0, ca, "compressed")
 		require.NoError(t, err)
 	})
+	t.Run("SuccessWithCompressionAlreadyStored", func(t *testing.T) {
+		var (
+			tempuuid string
+			rootDir  = "/"
+			mv       = newManageVolume(t, rootDir)
+			rep      = 1
+			ca       = time.Now()
+			tmpsDir  = path.Join(rootDir, "tmps")
+			fileDir  = path.Join(rootDir, "file")
+			key      = "expectedkey"
+			content  = strings.Repeat("content of the file", 100)
+			buff     = io.NopCloser(bytes.NewBufferString(content))
+			ef       = file.File{Keys: []string{key}}
+
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		h := signature.SHA1.New()
+		io.WriteString(h, content)
+		ef.Signature = signature.SHA1.Sum(h)
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			_, tempuuid = path.Split(p)
+			return mem.NewFileHandle(mem.CreateFile(p)), nil
+		})
+
+		mv.Files.EXPECT().FindBySignature(ctx, ef.Signature).Return(&file.File{
+			Keys:       []string{key},
+			Signature:  ef.Signature,
+			Size:       len(content),
+			StoredSize: len(content),
+		}, nil)
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(&idxkey.IDXKey{Key: key, Value: ef.Signature}, nil)
+
+		mv.State.EXPECT().Find(ctx).Return(&state.State{VolumeTotalSize: -1, SystemTotalSize: 10000, SystemUsedSize: len(content)}, nil)
+		mv.State.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+
+		update := mv.Files.EXPECT().CreateOrReplace(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, f *file.File) error {
+			assert.Equal(t, "compressed", f.Class)
+			assert.Equal(t, compression.Gzip, f.Compression)
+			return nil
+		})
+
+		// The content is replaced once it's committed
+		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Do(func(p string, _ string) {
+			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
+		}).Return(nil).After(update)
+
+		err := mv.V.CreateFile(ctx, key, buff, rep, 0, ca, "compressed")
+		require.NoError(t, err)
+	})
+	t.Run("FailsWithCompressionAlreadyStored", func(t *testing.T) {
+		var (
+			tempuuid string
+			rootDir  = "/"
+			mv       = newManageVolume(t, rootDir)
+			rep      = 1
+			ca       = time.Now()
+			tmpsDir  = path.Join(rootDir, "tmps")
+			key      = "expectedkey"
+			content  = strings.Repeat("content of the file", 100)
+			buff     = io.NopCloser(bytes.NewBufferString(content))
+			ef       = file.File{Keys: []string{key}}
+
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		h := signature.SHA1.New()
+		io.WriteString(h, content)
+		ef.Signature = signature.SHA1.Sum(h)
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			_, tempuuid = path.Split(p)
+			return mem.NewFileHandle(mem.CreateFile(p)), nil
+		})
+
+		// It's stored encrypted on a full volume
+		mv.Files.EXPECT().FindBySignature(ctx, ef.Signature).Return(&file.File{
+			Keys:            []string{key},
+			Signature:       ef.Signature,
+			Size:            len(content),
+			StoredSize:      1,
+			EncryptionKeyID: "1",
+		}, nil)
+		mv.State.EXPECT().Find(ctx).Return(&state.State{VolumeTotalSize: 1, VolumeUsedSize: 1, SystemTotalSize: 10000}, nil)
+
+		// The content already stored
+		// is not replaced
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
+			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
+		}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, rep, 0, ca, "compressed")
+		assert.EqualError(t, err, "file is too large for the dedicated space left")
+	})
+	t.Run("SuccessWithEncryption", func(t *testing.T) {
+		var (
+			fd       *mem.FileData
+			rootDir  = "/"
+			kr, _    = encryption.NewKeyring(map[string][]byte{"1": bytes.Repeat([]byte("k"), encryption.KeySize)}, "1")
+			mv       = newManageVolumeWithKeyring(t, rootDir, kr)
+			ca       = time.Now()
+			fileDir  = path.Join(rootDir, "file")
+			key      = "expectedkey"
+			content  = "content of the file"
+			buff     = io.NopCloser(bytes.NewBufferString(content))
+			ef       = file.File{Keys: []string{key}}
+			dbf      *file.File
+			usedSize int
+
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		h := signature.SHA1.New()
+		io.WriteString(h, content)
+		ef.Signature = signature.SHA1.Sum(h)
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			fd = mem.CreateFile(p)
+			return mem.NewFileHandle(fd), nil
+		})
+
+		dir, _ := path.Split(ef.Path(fileDir))
+		mv.Fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
+		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Return(nil)
+
+		mv.Files.EXPECT().FindBySignature(ctx, ef.Signature).Return(nil, errors.New("not found"))
+
+		mv.State.EXPECT().Find(ctx).Return(&state.State{VolumeTotalSize: -1, SystemTotalSize: 10000}, nil)
+		mv.State.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, s *state.State) error {
+			usedSize = s.SystemUsedSize
+			return nil
+		})
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, f *file.File) error {
+			dbf = f
+			return nil
+		})
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+		mv.IDXKeys.EXPECT().CreateOrReplace(ctx, &idxkey.IDXKey{Key: key, Value: ef.Signature, CreatedAt: ca}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, 1, 0, ca, "")
+		require.NoError(t, err)
+
+		require.NotNil(t, dbf)
+		assert.Equal(t, ef.Signature, dbf.Signature)
+		assert.Equal(t, len(content), dbf.Size)
+		assert.Equal(t, usedSize, dbf.StoredSize)
+		assert.Equal(t, "1", dbf.EncryptionKeyID)
+		require.True(t, dbf.IsEncrypted())
+
+		raw, err := io.ReadAll(mem.NewFileHandle(fd))
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), content)
+		assert.Equal(t, len(raw), dbf.StoredSize)
+
+		dk, err := kr.Unwrap(dbf.EncryptionKeyID, dbf.EncryptedKey)
+		require.NoError(t, err)
+
+		r, err := encryption.NewReader(io.NopCloser(bytes.NewReader(raw)), dk)
+		require.NoError(t, err)
+
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	})
+	t.Run("FailsForQuota", func(t *testing.T) {
+		var (
+			tempuuid string
+			rootDir  = "/"
+			mv       = newManageVolume(t, rootDir)
+			tmpsDir  = path.Join(rootDir, "tmps")
+			fileDir  = path.Join(rootDir, "file")
+			key      = "@logs/a"
+			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
+			ef       = file.File{Signature: "sha1:e7e8c72d1167454b76a610074fed244be0935298"}
+			ls       = &quota.Limits{
+				Quotas: []quota.Quota{{Prefix: "@logs/", Count: 2}},
+				Usages: map[string]map[string]quota.Usage{
+					mv.V.ID(): {"@logs/": {Size: 1, Count: 2}},
+					"other":   {"@logs/": {Size: 1, Count: 1}},
+				},
+			}
+
+			ctx = quota.NewContext(context.Background(), ls)
+		)
+
+		defer mv.Finish()
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			_, tempuuid = path.Split(p)
+			return mem.NewFileHandle(mem.CreateFile(p)), nil
+		})
+
+		dir, _ := path.Split(ef.Path(fileDir))
+		mv.Fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
+		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Return(nil)
+
+		mv.Files.EXPECT().FindBySignature(ctx, ef.Signature).Return(nil, errors.New("not found"))
+		mv.Files.EXPECT().CreateOrReplace(ctx, gomock.Any()).Return(nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
+
+		expectUpdateState(t, mv, ctx, 19)
+
+		// The Usage of the volume is the one on the State and not the
+		// one of the Limits, and with the new key and the one of the
+		// other volume it's over the Count of the Quota
+		mv.State.EXPECT().Find(ctx).Return(&state.State{Usage: map[string]quota.Usage{"@logs/": {Size: 1, Count: 1}}}, nil)
+
+		// The tmp is removed as it failed
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
+			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
+		}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, 1, 0, time.Now(), "")
+		assert.ErrorIs(t, err, quota.ErrCountExceeded)
+	})
+	t.Run("FailsForInvalidClass", func(t *testing.T) {
+		var (
+			mv   = newManageVolume(t, "/")
+			buff = io.NopCloser(bytes.NewBufferString("content of the file"))
+			ctx  = context.Background()
+		)
+
+		defer mv.Finish()
+
+		err := mv.V.CreateFile(ctx, "expectedkey", buff, 1, 0, time.Now(), "potato")
+		assert.EqualError(t, err, `invalid class "potato"`)
+	})
 	t.Run("FailsForSize", func(t *testing.T) {
 		var (
 			tempuuid string
@@ -652,15 +1506,15 @@ func TestCreateFile(t *testing.T) {
 			ttl      = 2 * time.Minute
 			ca       = time.Now()
 			tmpsDir  = path.Join(rootDir, "tmps")
-			fileDir  = path.Join(rootDir, "file")
 			key      = "expectedkey"
 			buff     = io.NopCloser(bytes.NewBufferString("content of the file"))
 			ef       = file.File{
-				Keys:      []string{key},
-				Signature: "e7e8c72d1167454b76a610074fed244be0935298",
-				Replica:   2,
-				VolumeIDs: []string{mv.V.ID()},
-				Size:      19,
+				Keys:       []string{key},
+				Signature:  "sha1:e7e8c72d1167454b76a610074fed244be0935298",
+				Replica:    2,
+				VolumeIDs:  []string{mv.V.ID()},
+				Size:       19,
+				StoredSize: 19,
 			}
 
 			ctx = context.Background()
@@ -674,10 +1528,8 @@ func TestCreateFile(t *testing.T) {
 			return mem.NewFileHandle(mem.CreateFile(p)), nil
 		})
 
-		dir, _ := path.Split(ef.Path(fileDir))
-		mv.Fs.EXPECT().MkdirAll(dir, os.ModePerm).Return(nil)
-
-		mv.Fs.EXPECT().Rename(gomock.Any(), ef.Path(fileDir)).Do(func(p string, _ string) {
+		// The content is not stored
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
 			assert.Equal(t, path.Join(tmpsDir, tempuuid), p)
 		}).Return(nil)
 
@@ -692,9 +1544,39 @@ func TestCreateFile(t *testing.T) {
 
 		mv.State.EXPECT().Find(ctx).Return(&dbs, nil)
 
-		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca)
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
 		assert.Equal(t, "file is too large for the dedicated space left", err.Error())
 	})
+	t.Run("FailsForReadError", func(t *testing.T) {
+		var (
+			tmp     string
+			rootDir = "/"
+			mv      = newManageVolume(t, rootDir)
+			rep     = 2
+			ttl     = 2 * time.Minute
+			ca      = time.Now()
+			tmpsDir = path.Join(rootDir, "tmps")
+			key     = "expectedkey"
+			buff    = io.NopCloser(io.MultiReader(bytes.NewBufferString("content of"), iotest.ErrReader(errors.New("invalid content"))))
+
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		mv.Fs.EXPECT().Create(gomock.Any()).DoAndReturn(func(p string) (afero.File, error) {
+			assert.True(t, strings.HasPrefix(p, tmpsDir))
+			tmp = p
+			return mem.NewFileHandle(mem.CreateFile(p)), nil
+		})
+
+		mv.Fs.EXPECT().Remove(gomock.Any()).Do(func(p string) {
+			assert.Equal(t, tmp, p)
+		}).Return(nil)
+
+		err := mv.V.CreateFile(ctx, key, buff, rep, ttl, ca, "")
+		assert.EqualError(t, err, "invalid content")
+	})
 }
 
 func TestGetFile(t *testing.T) {
@@ -713,6 +1595,7 @@ func TestGetFile(t *testing.T) {
 		defer mv.Finish()
 
 		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature}, nil)
 
 		mv.Fs.EXPECT().Open(file.Path(fileDir, signature)).DoAndReturn(func(p string) (afero.File, error) {
 			tf := mem.NewFileHandle(mem.CreateFile(p))
@@ -731,6 +1614,97 @@ func TestGetFile(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, content, string(b))
 	})
+	t.Run("SuccessWithCompression", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "123123123"
+			content   = "expectedcontent"
+			fileDir   = path.Join(rootDir, "file")
+
+			mv  = newManageVolume(t, rootDir)
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, Compression: compression.Gzip}, nil)
+
+		mv.Fs.EXPECT().Open(file.Path(fileDir, signature)).DoAndReturn(func(p string) (afero.File, error) {
+			tf := mem.NewFileHandle(mem.CreateFile(p))
+			w, _ := compression.Gzip.NewWriter(tf)
+			io.WriteString(w, content)
+			w.Close()
+			tf.Seek(0, 0)
+			return tf, nil
+		})
+
+		ior, err := mv.V.GetFile(ctx, key)
+		require.NoError(t, err)
+		require.IsType(t, &compression.Reader{}, ior)
+		b, err := io.ReadAll(ior)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	})
+	t.Run("SuccessWithEncryption", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "123123123"
+			content   = "expectedcontent"
+			fileDir   = path.Join(rootDir, "file")
+			kr, _     = encryption.NewKeyring(map[string][]byte{"1": bytes.Repeat([]byte("k"), encryption.KeySize)}, "1")
+
+			mv  = newManageVolumeWithKeyring(t, rootDir, kr)
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		_, dk, ek, err := kr.GenerateDataKey()
+		require.NoError(t, err)
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, Compression: compression.Gzip, EncryptionKeyID: "1", EncryptedKey: ek}, nil)
+
+		mv.Fs.EXPECT().Open(file.Path(fileDir, signature)).DoAndReturn(func(p string) (afero.File, error) {
+			tf := mem.NewFileHandle(mem.CreateFile(p))
+			ew, _ := encryption.NewWriter(tf, dk)
+			w, _ := compression.Gzip.NewWriter(ew)
+			io.WriteString(w, content)
+			w.Close()
+			ew.Close()
+			tf.Seek(0, 0)
+			return tf, nil
+		})
+
+		ior, err := mv.V.GetFile(ctx, key)
+		require.NoError(t, err)
+		b, err := io.ReadAll(ior)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	})
+	t.Run("FailsWithEncryptionAndNoKeyring", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "123123123"
+			fileDir   = path.Join(rootDir, "file")
+
+			mv  = newManageVolume(t, rootDir)
+			ctx = context.Background()
+		)
+
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, EncryptionKeyID: "1", EncryptedKey: []byte("key")}, nil)
+		mv.Fs.EXPECT().Open(file.Path(fileDir, signature)).Return(mem.NewFileHandle(mem.CreateFile(file.Path(fileDir, signature))), nil)
+
+		_, err := mv.V.GetFile(ctx, key)
+		assert.EqualError(t, err, "the file is encrypted and there is no keyring")
+	})
 	t.Run("NotFound", func(t *testing.T) {
 		var (
 			rootDir = "/"
@@ -743,6 +1717,24 @@ func TestGetFile(t *testing.T) {
 
 		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(nil, errors.New("not found"))
 
+		_, err := mv.V.GetFile(ctx, key)
+		assert.EqualError(t, err, errors.New("not found").Error())
+	})
+	t.Run("NotFoundExpired", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "123123123"
+			mv        = newManageVolume(t, rootDir)
+			ctx       = context.Background()
+		)
+
+		defer mv.Finish()
+
+		// It has expired but it has not been deleted yet
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, TTL: time.Hour, CreatedAt: time.Now().Add(-2 * time.Hour)}, nil)
+
 		_, err := mv.V.GetFile(ctx, key)
 		assert.EqualError(t, err, errors.New("not found").Error())
 	})
@@ -751,21 +1743,42 @@ func TestGetFile(t *testing.T) {
 func TestHasFile(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		var (
-			rootDir = "/"
-			key     = "expectedkey"
-			mv      = newManageVolume(t, rootDir)
-			ctx     = context.Background()
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "123123123"
+			mv        = newManageVolume(t, rootDir)
+			ctx       = context.Background()
 		)
 
 		defer mv.Finish()
 
-		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, "not needed"), nil)
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, TTL: time.Hour, CreatedAt: time.Now()}, nil)
 
 		vid, ok, err := mv.V.HasFile(ctx, key)
 		require.NoError(t, err)
 		assert.True(t, ok)
 		assert.Equal(t, mv.V.ID(), vid)
 	})
+	t.Run("NotFoundExpired", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "123123123"
+			ctx       = context.Background()
+			mv        = newManageVolume(t, rootDir)
+		)
+
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, TTL: time.Hour, CreatedAt: time.Now().Add(-2 * time.Hour)}, nil)
+
+		vid, ok, err := mv.V.HasFile(ctx, key)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "", vid)
+	})
 	t.Run("NotFound", func(t *testing.T) {
 		var (
 			rootDir = "/"
@@ -785,6 +1798,44 @@ func TestHasFile(t *testing.T) {
 	})
 }
 
+func TestGetFileSignature(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "sha1:123123123"
+			mv        = newManageVolume(t, rootDir)
+			ctx       = context.Background()
+		)
+
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature}, nil)
+
+		sig, err := mv.V.GetFileSignature(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, signature, sig)
+	})
+	t.Run("NotFoundExpired", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = "expectedkey"
+			signature = "sha1:123123123"
+			ctx       = context.Background()
+			mv        = newManageVolume(t, rootDir)
+		)
+
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{key}, Signature: signature, TTL: time.Hour, CreatedAt: time.Now().Add(-2 * time.Hour)}, nil)
+
+		_, err := mv.V.GetFileSignature(ctx, key)
+		assert.EqualError(t, err, "not found")
+	})
+}
+
 func TestDeleteFile(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		var (
@@ -849,6 +1900,60 @@ func TestDeleteFile(t *testing.T) {
 
 		mv.IDXKeys.EXPECT().DeleteByKey(ctx, key).Return(nil)
 
+		err := mv.V.DeleteFile(ctx, key)
+		require.NoError(t, err)
+	})
+	t.Run("SuccessWithVersioning", func(t *testing.T) {
+		var (
+			rootDir   = "/"
+			key       = bucket.Key("logs", "a")
+			vk        = version.Key(key, "1-123123123")
+			signature = "123123123"
+			ef        = file.File{
+				Keys:      []string{key, vk},
+				Signature: signature,
+			}
+			h = &version.History{
+				Key:      key,
+				Versions: []*version.Version{{ID: "1-123123123", Signature: signature}},
+			}
+
+			ctx = context.Background()
+			mv  = newManageVolume(t, rootDir)
+		)
+
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, key).Return(idxkey.New(key, signature), nil)
+
+		mv.Files.EXPECT().FindBySignature(ctx, signature).DoAndReturn(func(_ context.Context, sig string) (*file.File, error) {
+			aux := file.File(ef)
+			return &aux, nil
+		})
+
+		// The key is no longer accounted on the Bucket
+		mv.State.EXPECT().Find(ctx).Return(&state.State{Usage: map[string]quota.Usage{"@logs/": {Size: 10, Count: 2}}}, nil)
+		mv.State.EXPECT().Update(ctx, &state.State{Usage: map[string]quota.Usage{"@logs/": {Size: 10, Count: 1}}}).Return(nil)
+
+		// The File is kept as the Version still has it
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{Keys: []string{vk}, Signature: signature}).Return(nil)
+
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, key).Return(nil)
+
+		mv.Buckets.EXPECT().FindByName(ctx, "logs").Return(&bucket.Bucket{Name: "logs", Versioning: bucket.Versioning{Enabled: true}}, nil)
+
+		mv.Versions.EXPECT().FindByKey(ctx, key).Return(h, nil).Times(3)
+		mv.Versions.EXPECT().CreateOrReplace(ctx, h).DoAndReturn(func(_ context.Context, h *version.History) error {
+			require.Len(t, h.Versions, 2)
+			assert.True(t, h.Versions[1].DeleteMarker)
+			return nil
+		})
+
+		// The Version is now noncurrent so it's accounted on the Bucket
+		mv.Files.EXPECT().FindBySignature(ctx, signature).Return(&file.File{Keys: []string{vk}, Signature: signature}, nil)
+		mv.State.EXPECT().Find(ctx).Return(&state.State{Usage: map[string]quota.Usage{"@logs/": {Size: 10, Count: 1}}}, nil)
+		mv.State.EXPECT().Update(ctx, &state.State{Usage: map[string]quota.Usage{"@logs/": {Size: 10, Count: 2}}}).Return(nil)
+
 		err := mv.V.DeleteFile(ctx, key)
 		require.NoError(t, err)
 	})
@@ -947,6 +2052,84 @@ func TestUpdateReplica(t *testing.T) {
 		err := mv.V.UpdateReplica(ctx, rp, "1")
 		require.NoError(t, err)
 	})
+	t.Run("SuccessMove", func(t *testing.T) {
+		var (
+			rootDir  = "/"
+			ctx      = context.Background()
+			mv       = newManageVolume(t, rootDir)
+			fileDir  = path.Join(rootDir, "file")
+			findFile = &file.File{
+				Keys:      []string{"key"},
+				Signature: "sig",
+				Size:      19,
+				VolumeIDs: []string{mv.V.ID(), "2"},
+			}
+			rp = &replica.Replica{
+				ID:            "1",
+				Count:         1,
+				OriginalCount: 2,
+				Key:           "key",
+				Signature:     "sig",
+				VolumeID:      mv.V.ID(),
+				Move:          true,
+			}
+		)
+		defer mv.Finish()
+
+		mv.Files.EXPECT().FindBySignature(ctx, rp.Signature).Return(findFile, nil).Times(2)
+
+		// The other volumes no longer keep track
+		// of it as it's no longer on this one
+		mv.IDXVolumes.EXPECT().FindByVolumeID(ctx, "2").Return(idxvolume.New("2", []string{"sig"}), nil)
+		mv.IDXVolumes.EXPECT().CreateOrReplace(ctx, idxvolume.New("2", []string{})).Return(nil)
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, "key").Return(idxkey.New("key", "sig"), nil)
+		mv.Files.EXPECT().DeleteBySignature(ctx, "sig").Return(nil)
+		mv.IDXKeys.EXPECT().DeleteByKey(ctx, "key").Return(nil)
+		mv.Fs.EXPECT().Remove(file.Path(fileDir, "sig")).Return(nil)
+		expectUpdateState(t, mv, ctx, -findFile.Size)
+
+		err := mv.V.UpdateReplica(ctx, rp, "3")
+		require.NoError(t, err)
+	})
+	t.Run("SuccessMoveWithOtherKeys", func(t *testing.T) {
+		var (
+			rootDir  = "/"
+			ctx      = context.Background()
+			mv       = newManageVolume(t, rootDir)
+			findFile = &file.File{
+				Keys:      []string{"key", "other"},
+				Signature: "sig",
+				VolumeIDs: []string{mv.V.ID()},
+			}
+			rp = &replica.Replica{
+				ID:            "1",
+				Count:         1,
+				OriginalCount: 1,
+				Key:           "key",
+				Signature:     "sig",
+				VolumeID:      mv.V.ID(),
+				Move:          true,
+			}
+			updateFile = &file.File{
+				Keys:      []string{"key", "other"},
+				Signature: "sig",
+				Replica:   1,
+				VolumeIDs: []string{mv.V.ID(), "3"},
+			}
+		)
+		defer mv.Finish()
+
+		// It has another key since it was moved so it's
+		// kept as one more, and it's not on the queue
+		mv.Files.EXPECT().FindBySignature(ctx, rp.Signature).Return(findFile, nil)
+		mv.Files.EXPECT().CreateOrReplace(ctx, updateFile).Return(nil)
+		mv.IDXVolumes.EXPECT().FindByVolumeID(ctx, "3").Return(nil, errors.New("not found"))
+		mv.IDXVolumes.EXPECT().CreateOrReplace(ctx, idxvolume.New("3", []string{"sig"})).Return(nil)
+
+		err := mv.V.UpdateReplica(ctx, rp, "3")
+		require.NoError(t, err)
+	})
 	t.Run("ErrorWithNoReplica", func(t *testing.T) {
 		var (
 			rootDir = "/"
@@ -1016,6 +2199,44 @@ func TestUpdateFileReplica(t *testing.T) {
 		err := mv.V.UpdateFileReplica(ctx, findFile.Keys[0], vids, rep)
 		require.NoError(t, err)
 	})
+	t.Run("SuccessWithRemovedVolumes", func(t *testing.T) {
+		var (
+			rootDir  = "/"
+			ctx      = context.Background()
+			mv       = newManageVolume(t, rootDir)
+			findFile = &file.File{
+				Keys:      []string{"file-key"},
+				Signature: "sig",
+				Replica:   3,
+				VolumeIDs: []string{"2", mv.V.ID(), "3"},
+			}
+			kv   = idxkey.New(findFile.Keys[0], findFile.Signature)
+			vids = []string{"4", mv.V.ID(), "3"}
+		)
+		defer mv.Finish()
+
+		mv.IDXKeys.EXPECT().FindByKey(ctx, kv.Key).Return(kv, nil)
+		mv.Files.EXPECT().FindBySignature(ctx, kv.Value).Return(findFile, nil)
+		mv.IDXVolumes.EXPECT().FindByVolumeID(ctx, "4").Return(nil, errors.New("not found"))
+		mv.IDXVolumes.EXPECT().CreateOrReplace(ctx, idxvolume.New("4", []string{"sig"})).Return(nil)
+		mv.IDXVolumes.EXPECT().FindByVolumeID(ctx, "3").Return(idxvolume.New("3", []string{"sig"}), nil)
+		mv.IDXVolumes.EXPECT().CreateOrReplace(ctx, idxvolume.New("3", []string{"sig"})).Return(nil)
+
+		// The "2" no longer has it so it's not
+		// replicated again when it's gone
+		mv.IDXVolumes.EXPECT().FindByVolumeID(ctx, "2").Return(idxvolume.New("2", []string{"other", "sig"}), nil)
+		mv.IDXVolumes.EXPECT().CreateOrReplace(ctx, idxvolume.New("2", []string{"other"})).Return(nil)
+
+		mv.Files.EXPECT().CreateOrReplace(ctx, &file.File{
+			Keys:      findFile.Keys,
+			Signature: findFile.Signature,
+			Replica:   3,
+			VolumeIDs: vids,
+		}).Return(nil)
+
+		err := mv.V.UpdateFileReplica(ctx, findFile.Keys[0], vids, 3)
+		require.NoError(t, err)
+	})
 	t.Run("ErrorRequireVolumeIDOnList", func(t *testing.T) {
 		var (
 			rootDir  = "/"
@@ -1157,6 +2378,8 @@ func TestReset(t *testing.T) {
 		mv.Fs.EXPECT().RemoveAll(fileDir).Return(nil)
 		mv.Fs.EXPECT().RemoveAll(tempDir).Return(nil)
 		mv.State.EXPECT().DeleteAll(ctx).Return(nil)
+		mv.Versions.EXPECT().DeleteAll(ctx).Return(nil)
+		mv.Trash.EXPECT().DeleteAll(ctx).Return(nil)
 		mv.Fs.EXPECT().Remove(idPath).Return(nil)
 
 		mv.Fs.EXPECT().Create(idPath).Return(fh, nil)